@@ -42,10 +42,12 @@ var NewClientset NewClientsetFunc
 type Controller struct {
 	// Indirection hook for unit tests to supply fake client sets.
 	NewClientset NewClientsetFunc
-	epsInformer  cache.Controller
-	stopCh       chan struct{}
-	store        *Map
-	clientSet    kubernetes.Interface
+	// ClustersetGroup, if set, restricts the EndpointSlices to those labeled with the given clusterset group.
+	ClustersetGroup string
+	epsInformer     cache.Controller
+	stopCh          chan struct{}
+	store           *Map
+	clientSet       kubernetes.Interface
 }
 
 func NewController(endpointSliceStore *Map) *Controller {
@@ -96,10 +98,10 @@ func (c *Controller) Start(kubeConfig *rest.Config) error {
 		0,
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
-				c.store.Put(obj.(*discovery.EndpointSlice))
+				c.endpointSliceCreatedOrUpdated(obj.(*discovery.EndpointSlice))
 			},
 			UpdateFunc: func(_ interface{}, newObj interface{}) {
-				c.store.Put(newObj.(*discovery.EndpointSlice))
+				c.endpointSliceCreatedOrUpdated(newObj.(*discovery.EndpointSlice))
 			},
 			DeleteFunc: func(obj interface{}) {
 				var endpointSlice *discovery.EndpointSlice
@@ -128,6 +130,16 @@ func (c *Controller) Start(kubeConfig *rest.Config) error {
 	return nil
 }
 
+func (c *Controller) endpointSliceCreatedOrUpdated(endpointSlice *discovery.EndpointSlice) {
+	if c.ClustersetGroup != "" && endpointSlice.Labels[lhconstants.ClustersetGroupLabel] != c.ClustersetGroup {
+		// Remove in case the EndpointSlice was previously in our clusterset group.
+		c.store.Remove(endpointSlice)
+		return
+	}
+
+	c.store.Put(endpointSlice)
+}
+
 func (c *Controller) Stop() {
 	close(c.stopCh)
 
@@ -20,11 +20,14 @@ package endpointslice
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 	"sync"
 
 	"github.com/submariner-io/admiral/pkg/log"
 	"github.com/submariner-io/lighthouse/coredns/serviceimport"
 	"github.com/submariner-io/lighthouse/pkg/constants"
+	lhendpointslice "github.com/submariner-io/lighthouse/pkg/endpointslice"
 	discovery "k8s.io/api/discovery/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -44,6 +47,7 @@ type endpointInfo struct {
 type clusterInfo struct {
 	hostRecords map[string][]serviceimport.DNSRecord
 	recordList  []serviceimport.DNSRecord
+	readyCount  int
 }
 
 type Map struct {
@@ -51,6 +55,7 @@ type Map struct {
 	mutex          sync.RWMutex
 	localClusterID string
 	kubeClient     kubernetes.Interface
+	onChange       func(namespace, name string)
 }
 
 func (m *Map) GetDNSRecords(hostname, cluster, namespace, name string, checkCluster func(string) bool) ([]serviceimport.DNSRecord, bool) {
@@ -90,11 +95,72 @@ func (m *Map) GetDNSRecords(hostname, cluster, namespace, name string, checkClus
 	case clusterInfos[cluster].hostRecords == nil:
 		return nil, false
 	default:
-		records, ok := clusterInfos[cluster].hostRecords[hostname]
+		records, ok := clusterInfos[cluster].hostRecords[strings.ToLower(hostname)]
 		return records, ok
 	}
 }
 
+// GetClusterStatus returns the reachability of each cluster that contributes endpoints to the given service, as
+// determined by checkCluster.
+func (m *Map) GetClusterStatus(namespace, name string, checkCluster func(string) bool) (map[string]bool, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	epInfo, ok := m.epMap[keyFunc(name, namespace)]
+	if !ok {
+		return nil, false
+	}
+
+	status := make(map[string]bool, len(epInfo.clusterInfo))
+	for clusterID := range epInfo.clusterInfo {
+		status[clusterID] = checkCluster(clusterID)
+	}
+
+	return status, true
+}
+
+// HasReadyEndpoints returns true if any of the clusters accepted by checkCluster has a ready endpoint for the given
+// service.
+func (m *Map) HasReadyEndpoints(namespace, name string, checkCluster func(string) bool) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	epInfo, ok := m.epMap[keyFunc(name, namespace)]
+	if !ok {
+		return false
+	}
+
+	for clusterID, info := range epInfo.clusterInfo {
+		if info.readyCount > 0 && checkCluster(clusterID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetReadyEndpointCount returns the number of ready endpoints for the given service across the clusters accepted by
+// checkCluster.
+func (m *Map) GetReadyEndpointCount(namespace, name string, checkCluster func(string) bool) int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	epInfo, ok := m.epMap[keyFunc(name, namespace)]
+	if !ok {
+		return 0
+	}
+
+	count := 0
+
+	for clusterID, info := range epInfo.clusterInfo {
+		if checkCluster(clusterID) {
+			count += info.readyCount
+		}
+	}
+
+	return count
+}
+
 func NewMap(localClusterID string, kubeClient kubernetes.Interface) *Map {
 	return &Map{
 		epMap:          make(map[string]*endpointInfo),
@@ -103,6 +169,15 @@ func NewMap(localClusterID string, kubeClient kubernetes.Interface) *Map {
 	}
 }
 
+// SetChangeHandler sets the function invoked, outside of the Map's lock, with the namespace and name of the service whose
+// endpoints were added, updated or removed.
+func (m *Map) SetChangeHandler(onChange func(namespace, name string)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.onChange = onChange
+}
+
 func (m *Map) Put(es *discovery.EndpointSlice) {
 	key, ok := getKey(es)
 	if !ok {
@@ -155,6 +230,8 @@ func (m *Map) Put(es *discovery.EndpointSlice) {
 		}
 	}
 
+	defer m.notifyChanged(es)
+
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -171,19 +248,18 @@ func (m *Map) Put(es *discovery.EndpointSlice) {
 		hostRecords: make(map[string][]serviceimport.DNSRecord),
 	}
 
-	mcsPorts := make([]mcsv1a1.ServicePort, len(es.Ports))
+	mcsPorts := toMCSPorts(es.Ports)
+	portsByAddress := getEndpointPorts(es)
 
-	for i, port := range es.Ports {
-		mcsPort := mcsv1a1.ServicePort{
-			Name:        *port.Name,
-			Protocol:    *port.Protocol,
-			AppProtocol: port.AppProtocol,
-			Port:        *port.Port,
-		}
-		mcsPorts[i] = mcsPort
+	// The ready endpoints of a very large headless service may be published compacted into CIDR ranges.
+	endpoints, err := lhendpointslice.GetEndpoints(es)
+	if err != nil {
+		klog.Errorf("Error retrieving the endpoints of EndpointSlice %q - ignoring the compacted endpoints: %v", es.Name, err)
+
+		endpoints = es.Endpoints
 	}
 
-	for _, endpoint := range es.Endpoints {
+	for _, endpoint := range endpoints {
 		var records []serviceimport.DNSRecord
 
 		addresses := endpoint.Addresses
@@ -196,11 +272,18 @@ func (m *Map) Put(es *discovery.EndpointSlice) {
 			}
 		}
 
+		ports := mcsPorts
+		if len(endpoint.Addresses) > 0 {
+			if endpointPorts, found := portsByAddress[endpoint.Addresses[0]]; found {
+				ports = endpointPorts
+			}
+		}
+
 		for _, address := range addresses {
 
 			record := serviceimport.DNSRecord{
 				IP:          address,
-				Ports:       mcsPorts,
+				Ports:       ports,
 				ClusterName: cluster,
 			}
 
@@ -212,10 +295,33 @@ func (m *Map) Put(es *discovery.EndpointSlice) {
 		}
 
 		if endpoint.Hostname != nil {
-			epInfo.clusterInfo[cluster].hostRecords[*endpoint.Hostname] = records
+			// Key by the lowercase hostname since DNS lookups are case-insensitive.
+			epInfo.clusterInfo[cluster].hostRecords[strings.ToLower(*endpoint.Hostname)] = records
 		}
 
 		epInfo.clusterInfo[cluster].recordList = append(epInfo.clusterInfo[cluster].recordList, records...)
+
+		// A nil Ready condition is interpreted as ready.
+		if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+			epInfo.clusterInfo[cluster].readyCount += len(records)
+		}
+	}
+
+	// FQDN-only endpoints have no IP so they're recorded with an external name to be answered with a CNAME.
+	if fqdns := es.Annotations[constants.FQDNEndpointsAnnotation]; fqdns != "" {
+		for _, fqdn := range strings.Split(fqdns, ",") {
+			ports, found := portsByAddress[fqdn]
+			if !found {
+				ports = mcsPorts
+			}
+
+			epInfo.clusterInfo[cluster].recordList = append(epInfo.clusterInfo[cluster].recordList, serviceimport.DNSRecord{
+				Ports:        ports,
+				ClusterName:  cluster,
+				ExternalName: fqdn,
+			})
+			epInfo.clusterInfo[cluster].readyCount++
+		}
 	}
 
 	klog.V(log.DEBUG).Infof("Adding clusterInfo %#v for EndpointSlice %q in %q", epInfo.clusterInfo[cluster], es.Name, cluster)
@@ -237,6 +343,8 @@ func (m *Map) Remove(es *discovery.EndpointSlice) {
 			return
 		}
 
+		defer m.notifyChanged(es)
+
 		m.mutex.Lock()
 		defer m.mutex.Unlock()
 
@@ -250,6 +358,18 @@ func (m *Map) Remove(es *discovery.EndpointSlice) {
 	}
 }
 
+// notifyChanged invokes the change handler for the service of the given EndpointSlice. It must be called without holding
+// the lock.
+func (m *Map) notifyChanged(es *discovery.EndpointSlice) {
+	m.mutex.RLock()
+	onChange := m.onChange
+	m.mutex.RUnlock()
+
+	if onChange != nil {
+		onChange(es.Labels[constants.LabelSourceNamespace], getServiceName(es))
+	}
+}
+
 func (m *Map) get(key string) *endpointInfo {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
@@ -258,14 +378,18 @@ func (m *Map) get(key string) *endpointInfo {
 	return endpointInfo
 }
 
-func getKey(es *discovery.EndpointSlice) (string, bool) {
+func getServiceName(es *discovery.EndpointSlice) string {
 	name, ok := es.Labels[constants.MCSLabelServiceName]
-
 	if !ok {
-		name, ok = es.Labels[constants.LighthouseLabelSourceName]
+		name = es.Labels[constants.LighthouseLabelSourceName]
 	}
 
-	if !ok {
+	return name
+}
+
+func getKey(es *discovery.EndpointSlice) (string, bool) {
+	name := getServiceName(es)
+	if name == "" {
 		return "", false
 	}
 
@@ -281,3 +405,42 @@ func getKey(es *discovery.EndpointSlice) (string, bool) {
 func keyFunc(name, namespace string) string {
 	return name + "-" + namespace
 }
+
+func toMCSPorts(ports []discovery.EndpointPort) []mcsv1a1.ServicePort {
+	mcsPorts := make([]mcsv1a1.ServicePort, len(ports))
+
+	for i, port := range ports {
+		mcsPort := mcsv1a1.ServicePort{
+			Name:        *port.Name,
+			Protocol:    *port.Protocol,
+			AppProtocol: port.AppProtocol,
+			Port:        *port.Port,
+		}
+		mcsPorts[i] = mcsPort
+	}
+
+	return mcsPorts
+}
+
+// getEndpointPorts returns the ports exposed by each endpoint address if the agent recorded them because they differ, eg for
+// a multi-port headless service whose pods don't all expose all ports.
+func getEndpointPorts(es *discovery.EndpointSlice) map[string][]mcsv1a1.ServicePort {
+	annotation := es.Annotations[constants.EndpointPortsAnnotation]
+	if annotation == "" {
+		return nil
+	}
+
+	var byAddress map[string][]discovery.EndpointPort
+
+	if err := json.Unmarshal([]byte(annotation), &byAddress); err != nil {
+		klog.Errorf("Error parsing the endpoint ports of EndpointSlice %q: %v", es.Name, err)
+		return nil
+	}
+
+	portsByAddress := make(map[string][]mcsv1a1.ServicePort, len(byAddress))
+	for address, ports := range byAddress {
+		portsByAddress[address] = toMCSPorts(ports)
+	}
+
+	return portsByAddress
+}
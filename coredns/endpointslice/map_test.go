@@ -141,6 +141,95 @@ var _ = Describe("EndpointSlice Map", func() {
 			expectIPs("", "", []string{endpointIP})
 		})
 	})
+
+	When("checking for ready endpoints", func() {
+		var es1, es2 *discovery.EndpointSlice
+
+		BeforeEach(func() {
+			notReady := false
+
+			es1 = newEndpointSlice(namespace1, service1, clusterID1, []string{endpointIP})
+			es1.Endpoints[0].Conditions.Ready = &notReady
+			endpointSliceMap.Put(es1)
+
+			es2 = newEndpointSlice(namespace1, service1, clusterID2, []string{endpointIP2})
+			endpointSliceMap.Put(es2)
+		})
+
+		It("should return true if a connected cluster has a ready endpoint", func() {
+			Expect(endpointSliceMap.HasReadyEndpoints(namespace1, service1, checkCluster)).To(BeTrue())
+		})
+
+		It("should return false if only a disconnected cluster has a ready endpoint", func() {
+			clusterStatusMap[clusterID2] = false
+			Expect(endpointSliceMap.HasReadyEndpoints(namespace1, service1, checkCluster)).To(BeFalse())
+		})
+
+		It("should return false if no cluster has a ready endpoint", func() {
+			endpointSliceMap.Remove(es2)
+			Expect(endpointSliceMap.HasReadyEndpoints(namespace1, service1, checkCluster)).To(BeFalse())
+		})
+
+		It("should return false for an unknown service", func() {
+			Expect(endpointSliceMap.HasReadyEndpoints(namespace1, "unknown", checkCluster)).To(BeFalse())
+		})
+
+		It("should count the ready endpoints of the connected clusters", func() {
+			Expect(endpointSliceMap.GetReadyEndpointCount(namespace1, service1, checkCluster)).To(Equal(1))
+
+			clusterStatusMap[clusterID2] = false
+			Expect(endpointSliceMap.GetReadyEndpointCount(namespace1, service1, checkCluster)).To(BeZero())
+			Expect(endpointSliceMap.GetReadyEndpointCount(namespace1, "unknown", checkCluster)).To(BeZero())
+		})
+	})
+
+	When("a headless service has FQDN endpoints", func() {
+		BeforeEach(func() {
+			es := newEndpointSlice(namespace1, service1, clusterID1, []string{endpointIP})
+			es.Annotations = map[string]string{lhconstants.FQDNEndpointsAnnotation: "ext1.example.com,ext2.example.com"}
+			endpointSliceMap.Put(es)
+		})
+
+		It("should return records with the FQDNs as external names alongside the IPs", func() {
+			records, found := endpointSliceMap.GetDNSRecords("", "", namespace1, service1, checkCluster)
+			Expect(found).To(BeTrue())
+			Expect(records).To(HaveLen(3))
+			Expect(records[0].IP).To(Equal(endpointIP))
+			Expect(records[1].ExternalName).To(Equal("ext1.example.com"))
+			Expect(records[1].IP).To(BeEmpty())
+			Expect(records[2].ExternalName).To(Equal("ext2.example.com"))
+		})
+
+		It("should count the FQDN endpoints as ready", func() {
+			notReady := false
+
+			es := newEndpointSlice(namespace1, service1, clusterID1, []string{endpointIP})
+			es.Endpoints[0].Conditions.Ready = &notReady
+			es.Annotations = map[string]string{lhconstants.FQDNEndpointsAnnotation: "ext1.example.com"}
+			endpointSliceMap.Put(es)
+
+			Expect(endpointSliceMap.HasReadyEndpoints(namespace1, service1, checkCluster)).To(BeTrue())
+		})
+	})
+
+	When("a headless service has compacted endpoints", func() {
+		BeforeEach(func() {
+			notReady := false
+
+			es := newEndpointSlice(namespace1, service1, clusterID1, []string{endpointIP})
+			es.Endpoints[0].Conditions.Ready = &notReady
+			es.Annotations = map[string]string{lhconstants.CompactedEndpointsAnnotation: "100.96.157.102,100.96.157.104/31"}
+			endpointSliceMap.Put(es)
+		})
+
+		It("should return records with the expanded IPs alongside the other endpoints", func() {
+			expectIPs("", "", []string{endpointIP, endpointIP2, "100.96.157.104", "100.96.157.105"})
+		})
+
+		It("should count the compacted endpoints as ready", func() {
+			Expect(endpointSliceMap.GetReadyEndpointCount(namespace1, service1, checkCluster)).To(Equal(3))
+		})
+	})
 })
 
 // nolint:unparam // `namespace` always receives `namespace1`.
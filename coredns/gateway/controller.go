@@ -54,6 +54,7 @@ type Controller struct {
 	clusterStatusMap atomic.Value
 	localClusterID   atomic.Value
 	gatewayAvailable bool
+	onChange         atomic.Value
 }
 
 func NewController() *Controller {
@@ -202,9 +203,18 @@ func (c *Controller) updateClusterStatusMap(connections []interface{}) {
 	if newMap != nil {
 		klog.Infof("Updating the gateway status %#v ", newMap)
 		c.clusterStatusMap.Store(newMap)
+
+		if onChange, ok := c.onChange.Load().(func()); ok {
+			onChange()
+		}
 	}
 }
 
+// SetChangeHandler sets the function invoked when the connection status of a cluster changes.
+func (c *Controller) SetChangeHandler(onChange func()) {
+	c.onChange.Store(onChange)
+}
+
 func (c *Controller) updateLocalClusterIDIfNeeded(clusterID string) {
 	updateNeeded := clusterID != "" && clusterID != c.LocalClusterID()
 	if updateNeeded {
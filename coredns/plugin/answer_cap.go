@@ -0,0 +1,88 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lighthouse
+
+import (
+	"sort"
+
+	"github.com/submariner-io/lighthouse/coredns/serviceimport"
+)
+
+// capAnswerClusters limits the records to those of at most MaxAnswerClusters clusters, preferring the local cluster
+// followed by the clusters with the highest weight. The order of the retained records is preserved.
+func (lh *Lighthouse) capAnswerClusters(pReq *recordRequest, records []serviceimport.DNSRecord) []serviceimport.DNSRecord {
+	if lh.MaxAnswerClusters <= 0 {
+		return records
+	}
+
+	var clusters []string
+
+	seen := map[string]bool{}
+
+	for i := range records {
+		if !seen[records[i].ClusterName] {
+			seen[records[i].ClusterName] = true
+			clusters = append(clusters, records[i].ClusterName)
+		}
+	}
+
+	if len(clusters) <= lh.MaxAnswerClusters {
+		return records
+	}
+
+	weights, _ := lh.ServiceImports.GetClusterWeights(pReq.namespace, pReq.service)
+	localClusterID := lh.ClusterStatus.LocalClusterID()
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if (clusters[i] == localClusterID) != (clusters[j] == localClusterID) {
+			return clusters[i] == localClusterID
+		}
+
+		if clusterWeight(weights, clusters[i]) != clusterWeight(weights, clusters[j]) {
+			return clusterWeight(weights, clusters[i]) > clusterWeight(weights, clusters[j])
+		}
+
+		return clusters[i] < clusters[j]
+	})
+
+	selected := map[string]bool{}
+	for _, c := range clusters[:lh.MaxAnswerClusters] {
+		selected[c] = true
+	}
+
+	log.Debugf("Capping the answer for %s/%s to clusters %v", pReq.namespace, pReq.service, clusters[:lh.MaxAnswerClusters])
+
+	capped := make([]serviceimport.DNSRecord, 0, len(records))
+
+	for i := range records {
+		if selected[records[i].ClusterName] {
+			capped = append(capped, records[i])
+		}
+	}
+
+	return capped
+}
+
+func clusterWeight(weights map[string]int64, clusterID string) int64 {
+	if w, ok := weights[clusterID]; ok {
+		return w
+	}
+
+	return 1
+}
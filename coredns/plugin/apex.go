@@ -0,0 +1,87 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lighthouse
+
+import (
+	"net"
+	"strings"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/request"
+	"github.com/miekg/dns"
+)
+
+// isApexQuery checks if the query is for the apex of the matched zone and an apex answer is configured.
+func (lh *Lighthouse) isApexQuery(state *request.Request) bool {
+	return len(lh.ApexIPs) > 0 && strings.EqualFold(state.Name(), state.Zone)
+}
+
+// apexResponse answers a query for the zone apex with the configured addresses matching the query type. If none
+// match, eg an AAAA query when only IPv4 addresses are configured, an empty NOERROR response is returned.
+func (lh *Lighthouse) apexResponse(state *request.Request) (int, error) {
+	var records []dns.RR
+
+	for _, ip := range lh.ApexIPs {
+		ip4 := ip.To4()
+
+		switch {
+		case state.QType() == dns.TypeA && ip4 != nil:
+			records = append(records, &dns.A{Hdr: dns.RR_Header{
+				Name: state.QName(), Rrtype: dns.TypeA, Class: state.QClass(),
+				Ttl: lh.TTL,
+			}, A: ip4})
+		case state.QType() == dns.TypeAAAA && ip4 == nil:
+			records = append(records, &dns.AAAA{Hdr: dns.RR_Header{
+				Name: state.QName(), Rrtype: dns.TypeAAAA, Class: state.QClass(),
+				Ttl: lh.TTL,
+			}, AAAA: ip})
+		}
+	}
+
+	if len(records) == 0 {
+		log.Debugf("No apex address configured for query type %d", state.QType())
+		return lh.emptyResponse(state)
+	}
+
+	a := new(dns.Msg)
+	a.SetReply(state.Req)
+	a.Answer = append(a.Answer, records...)
+
+	return lh.writeResponse(state, a)
+}
+
+func parseApexAnswer(c *caddy.Controller) ([]net.IP, error) {
+	args := c.RemainingArgs()
+	if len(args) == 0 {
+		return nil, c.ArgErr() // nolint:wrapcheck // No need to wrap this.
+	}
+
+	ips := make([]net.IP, 0, len(args))
+
+	for _, arg := range args {
+		ip := net.ParseIP(arg)
+		if ip == nil {
+			return nil, c.Errf("invalid apex_answer address %q", arg) // nolint:wrapcheck // No need to wrap this.
+		}
+
+		ips = append(ips, ip)
+	}
+
+	return ips, nil
+}
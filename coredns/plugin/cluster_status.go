@@ -0,0 +1,112 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lighthouse
+
+import (
+	"sort"
+	"strings"
+)
+
+// ClusterReachability describes whether a cluster contributing to an imported service is currently reachable from
+// the local cluster.
+type ClusterReachability struct {
+	ClusterID string `json:"clusterID"`
+	Reachable bool   `json:"reachable"`
+}
+
+// GetClusterReachability returns the reachability of each cluster contributing to the given imported service, sorted
+// by cluster ID. Reachability is derived from the Submariner connection status and the health reported by the cluster,
+// the same sources used to gate DNS answers.
+func (lh *Lighthouse) GetClusterReachability(namespace, name string) ([]ClusterReachability, bool) {
+	status, found := lh.ServiceImports.GetClusterStatus(namespace, name, lh.ClusterStatus.IsConnected)
+	if !found || len(status) == 0 {
+		status, found = lh.EndpointSlices.GetClusterStatus(namespace, name, func(clusterID string) bool {
+			return lh.ServiceImports.IsClusterHealthy(namespace, name, clusterID) && lh.ClusterStatus.IsConnected(clusterID)
+		})
+	}
+
+	if !found {
+		return nil, false
+	}
+
+	result := make([]ClusterReachability, 0, len(status))
+	for clusterID, reachable := range status {
+		result = append(result, ClusterReachability{ClusterID: clusterID, Reachable: reachable})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ClusterID < result[j].ClusterID
+	})
+
+	return result, true
+}
+
+// UpdateClusterReachability recomputes the reachability of each cluster contributing to the given imported service and
+// publishes it via the cluster reachability metric. The series of the clusters no longer contributing to the service, or of
+// all its clusters if the service is gone, are deleted. It's invoked when the service's records or the cluster connection
+// status change, not on each query.
+func (lh *Lighthouse) UpdateClusterReachability(namespace, name string) {
+	reachability, found := lh.GetClusterReachability(namespace, name)
+	key := namespace + "/" + name
+
+	lh.reachabilityMutex.Lock()
+	defer lh.reachabilityMutex.Unlock()
+
+	current := make(map[string]bool, len(reachability))
+
+	for i := range reachability {
+		current[reachability[i].ClusterID] = true
+		setClusterReachable(reachability[i].ClusterID, name, namespace, reachability[i].Reachable)
+	}
+
+	for _, previous := range lh.reachability[key] {
+		if !current[previous.ClusterID] {
+			deleteClusterReachable(previous.ClusterID, name, namespace)
+		}
+	}
+
+	if !found || len(reachability) == 0 {
+		delete(lh.reachability, key)
+		return
+	}
+
+	if lh.reachability == nil {
+		lh.reachability = make(map[string][]ClusterReachability)
+	}
+
+	lh.reachability[key] = reachability
+}
+
+// UpdateAllClusterReachability recomputes the reachability of the clusters of all the imported services, eg when the
+// connection status of a cluster changes.
+func (lh *Lighthouse) UpdateAllClusterReachability() {
+	lh.reachabilityMutex.Lock()
+
+	keys := make([]string, 0, len(lh.reachability))
+	for key := range lh.reachability {
+		keys = append(keys, key)
+	}
+
+	lh.reachabilityMutex.Unlock()
+
+	for _, key := range keys {
+		namespace, name, _ := strings.Cut(key, "/")
+		lh.UpdateClusterReachability(namespace, name)
+	}
+}
@@ -0,0 +1,48 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lighthouse
+
+import (
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+	"github.com/coredns/coredns/request"
+	"github.com/miekg/dns"
+)
+
+// getClustersetHostnameRequest checks if the query is for a custom clusterset hostname, ie "<hostname>.<zone>",
+// declared by an exported service and, if so, returns the request for that service. If the hostname is declared by
+// more than one service, conflict is returned as true.
+func (lh *Lighthouse) getClustersetHostnameRequest(state *request.Request) (pReq *recordRequest, conflict bool) {
+	base, _ := dnsutil.TrimZone(state.Name(), state.Zone)
+
+	segs := dns.SplitDomainName(base)
+	if len(segs) != 1 || segs[0] == Svc || segs[0] == Pod {
+		return nil, false
+	}
+
+	namespace, name, found, conflict := lh.ServiceImports.GetServiceForClustersetHostname(segs[0])
+	if !found || conflict {
+		return nil, conflict
+	}
+
+	return &recordRequest{
+		service:   name,
+		namespace: namespace,
+		podOrSvc:  Svc,
+	}, false
+}
@@ -0,0 +1,137 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lighthouse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/request"
+	"github.com/miekg/dns"
+	"github.com/submariner-io/lighthouse/coredns/serviceimport"
+)
+
+// maxCNAMEChainDepth limits the number of ExternalName services that are followed when resolving a CNAME chain.
+const maxCNAMEChainDepth = 8
+
+// resolveExternalName answers a query for a service exported as an ExternalName Service with a CNAME to its external
+// name. If the external name refers to another service in one of our zones, or to the cluster-local name of a service
+// exported in the clusterset, the chain is followed and the records of the final service are appended. A chain that loops or exceeds maxCNAMEChainDepth results in SERVFAIL.
+func (lh *Lighthouse) resolveExternalName(state *request.Request, r *dns.Msg, externalName string) (int, error) {
+	name := state.QName()
+	visited := map[string]bool{strings.ToLower(name): true}
+	answer := make([]dns.RR, 0)
+
+	for depth := 0; ; depth++ {
+		if depth >= maxCNAMEChainDepth {
+			log.Errorf("The CNAME chain for %q exceeds the maximum depth of %d", state.QName(), maxCNAMEChainDepth)
+			return dns.RcodeServerFailure, lh.error(fmt.Sprintf("CNAME chain for %q is too long", state.QName()))
+		}
+
+		target := dns.Fqdn(externalName)
+
+		if visited[strings.ToLower(target)] {
+			log.Errorf("The CNAME chain for %q loops at %q", state.QName(), target)
+			return dns.RcodeServerFailure, lh.error(fmt.Sprintf("CNAME chain for %q loops", state.QName()))
+		}
+
+		visited[strings.ToLower(target)] = true
+
+		answer = append(answer, &dns.CNAME{
+			Hdr:    dns.RR_Header{Name: name, Rrtype: dns.TypeCNAME, Class: state.QClass(), Ttl: lh.TTL},
+			Target: target,
+		})
+
+		targetState, pReq, ok := lh.parseCNAMETarget(state, target)
+		if !ok {
+			// The target isn't one of our services so let the client's resolver follow it.
+			break
+		}
+
+		externalName, ok = lh.ServiceImports.GetExternalName(pReq.namespace, pReq.service, pReq.cluster,
+			lh.ClusterStatus.IsConnected)
+		if !ok {
+			answer = append(answer, lh.getCNAMETargetRecords(targetState, pReq)...)
+			break
+		}
+
+		name = target
+	}
+
+	a := new(dns.Msg)
+	a.SetReply(r)
+	a.Answer = answer
+	log.Debugf("Responding to query with '%s'", a.Answer)
+
+	return lh.writeResponse(state, a)
+}
+
+func (lh *Lighthouse) parseCNAMETarget(state *request.Request, target string) (*request.Request, *recordRequest, bool) {
+	zone := plugin.Zones(lh.Zones).Matches(target)
+	if zone == "" {
+		return lh.parseClustersetServiceTarget(state, target)
+	}
+
+	req := new(dns.Msg)
+	req.SetQuestion(target, state.QType())
+
+	targetState := &request.Request{W: state.W, Req: req, Zone: target[len(target)-len(zone):]}
+
+	pReq, err := parseRequest(targetState)
+	if err != nil || pReq.podOrSvc != Svc || pReq.service == "" {
+		return nil, nil, false
+	}
+
+	return targetState, pReq, true
+}
+
+// parseClustersetServiceTarget handles a target outside our zones of the form <service>.<namespace>.svc.<domain>, eg the
+// cluster-local name of a Service. If that service is exported in the clusterset, its clusterset answer is preferred.
+func (lh *Lighthouse) parseClustersetServiceTarget(state *request.Request, target string) (*request.Request, *recordRequest,
+	bool,
+) {
+	segs := dns.SplitDomainName(strings.ToLower(target))
+	if len(segs) < 4 || segs[2] != Svc {
+		return nil, nil, false
+	}
+
+	pReq := &recordRequest{service: segs[0], namespace: segs[1], podOrSvc: Svc}
+	if !lh.ServiceImports.Contains(pReq.namespace, pReq.service) {
+		return nil, nil, false
+	}
+
+	req := new(dns.Msg)
+	req.SetQuestion(target, state.QType())
+
+	return &request.Request{W: state.W, Req: req}, pReq, true
+}
+
+func (lh *Lighthouse) getCNAMETargetRecords(targetState *request.Request, pReq *recordRequest) []dns.RR {
+	if targetState.QType() != dns.TypeA {
+		return nil
+	}
+
+	record, found := lh.getClusterIPForSvc(pReq, clientKey(targetState))
+	if !found || record == nil || record.IP == "" {
+		return nil
+	}
+
+	return lh.createARecords([]serviceimport.DNSRecord{*record}, targetState)
+}
@@ -38,6 +38,13 @@ func (lh *Lighthouse) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns
 
 	log.Debugf("Request received for %q", qname)
 
+	// If configured, the local cluster zone is answered by the kubernetes plugin so its queries are always declined, even if
+	// a configured zone encloses it, to never shadow a local Service with a clusterset service of the same name.
+	if lh.LocalZone != "" && dns.IsSubDomain(lh.LocalZone, qname) {
+		log.Debugf("Declining request for %q in the local cluster zone", qname)
+		return plugin.NextOrFailure(lh.Name(), lh.Next, ctx, w, r) // nolint:wrapcheck // Let the caller wrap it.
+	}
+
 	// qname: mysvc.default.svc.example.org.
 	// zone:  example.org.
 	// Matches will return zone in all lower cases
@@ -47,6 +54,15 @@ func (lh *Lighthouse) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns
 		return lh.nextOrFailure(ctx, state, r, dns.RcodeNotZone)
 	}
 
+	zone = qname[len(qname)-len(zone):] // maintain case of original query
+	state.Zone = zone
+
+	if lh.ClusterSubzones {
+		if subzone, cluster := lh.getClusterSubzone(state); subzone != "" {
+			return lh.serveClusterSubzone(ctx, state, subzone, cluster)
+		}
+	}
+
 	if state.QType() != dns.TypeA && state.QType() != dns.TypeAAAA && state.QType() != dns.TypeSRV {
 		msg := fmt.Sprintf("Query of type %d is not supported", state.QType())
 		log.Debugf(msg)
@@ -54,8 +70,25 @@ func (lh *Lighthouse) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns
 		return lh.nextOrFailure(ctx, state, r, dns.RcodeNotImplemented)
 	}
 
-	zone = qname[len(qname)-len(zone):] // maintain case of original query
-	state.Zone = zone
+	if lh.isApexQuery(state) {
+		log.Debugf("Resolving zone apex %q with the configured answer", qname)
+		return lh.apexResponse(state)
+	}
+
+	if hReq, conflict := lh.getClustersetHostnameRequest(state); conflict {
+		log.Errorf("Not resolving %q as its clusterset hostname is declared by multiple services", qname)
+		return lh.nextOrFailure(ctx, state, r, dns.RcodeNameError)
+	} else if hReq != nil {
+		log.Debugf("Resolving clusterset hostname %q to service %s/%s", qname, hReq.namespace, hReq.service)
+		return lh.getDNSRecord(ctx, zone, state, w, r, hReq)
+	}
+
+	if lh.ShortNames {
+		if sReq := parseShortNameRequest(state); sReq != nil {
+			log.Debugf("Resolving short name %q as service %s/%s", qname, sReq.namespace, sReq.service)
+			return lh.getDNSRecord(ctx, zone, state, w, r, sReq)
+		}
+	}
 
 	pReq, pErr := parseRequest(state)
 	if pErr != nil || pReq.podOrSvc != Svc {
@@ -77,7 +110,30 @@ func (lh *Lighthouse) getDNSRecord(ctx context.Context, zone string, state *requ
 		record     *serviceimport.DNSRecord
 	)
 
-	record, found = lh.getClusterIPForSvc(pReq)
+	pReq = lh.resolveServiceAlias(pReq)
+
+	if !lh.ServiceImports.IsConsumptionAllowed(pReq.namespace, pReq.service, pReq.cluster) {
+		log.Debugf("The local cluster isn't allowed to consume %q", state.QName())
+		return lh.nextOrFailure(ctx, state, r, dns.RcodeNameError)
+	}
+
+	if externalName, ok := lh.ServiceImports.GetExternalName(pReq.namespace, pReq.service, pReq.cluster,
+		lh.ClusterStatus.IsConnected); ok {
+		return lh.resolveExternalName(state, r, externalName)
+	}
+
+	if lh.ReadyOnly && !lh.EndpointSlices.HasReadyEndpoints(pReq.namespace, pReq.service, lh.ClusterStatus.IsConnected) {
+		log.Debugf("No ready endpoints found for %q", state.QName())
+		return lh.nextOrFailure(ctx, state, r, dns.RcodeNameError)
+	}
+
+	if minEndpoints := lh.ServiceImports.GetMinEndpoints(pReq.namespace, pReq.service); minEndpoints > 0 &&
+		lh.EndpointSlices.GetReadyEndpointCount(pReq.namespace, pReq.service, lh.ClusterStatus.IsConnected) < minEndpoints {
+		log.Debugf("Fewer than the minimum %d ready endpoints found for %q", minEndpoints, state.QName())
+		return lh.nextOrFailure(ctx, state, r, dns.RcodeNameError)
+	}
+
+	record, found = lh.getClusterIPForSvc(pReq, clientKey(state))
 	if !found {
 		dnsRecords, found = lh.EndpointSlices.GetDNSRecords(pReq.hostname, pReq.cluster, pReq.namespace,
 			pReq.service, lh.ClusterStatus.IsConnected)
@@ -87,6 +143,12 @@ func (lh *Lighthouse) getDNSRecord(ctx context.Context, zone string, state *requ
 		}
 
 		isHeadless = true
+		dnsRecords = lh.filterAllowedClusters(pReq, dnsRecords)
+		dnsRecords = lh.filterHealthyClusters(pReq, dnsRecords)
+
+		if pReq.cluster == "" {
+			dnsRecords = lh.capAnswerClusters(pReq, dnsRecords)
+		}
 	} else if record != nil && record.IP != "" {
 		dnsRecords = append(dnsRecords, *record)
 	}
@@ -96,7 +158,10 @@ func (lh *Lighthouse) getDNSRecord(ctx context.Context, zone string, state *requ
 		return lh.emptyResponse(state)
 	}
 
-	if state.QType() == dns.TypeAAAA {
+	mergeLocal := lh.MergeLocal && pReq.cluster == "" && pReq.port == ""
+
+	// Only a static clusterset IP or the local cluster's Service, if merged, may have IPv6 addresses.
+	if state.QType() == dns.TypeAAAA && !mergeLocal && !hasIPv6Record(dnsRecords) {
 		log.Debugf("Returning empty response for TypeAAAA query")
 		return lh.emptyResponse(state)
 	}
@@ -109,12 +174,27 @@ func (lh *Lighthouse) getDNSRecord(ctx context.Context, zone string, state *requ
 
 	records := make([]dns.RR, 0)
 
-	if state.QType() == dns.TypeA {
-		records = lh.createARecords(dnsRecords, state)
-	} else if state.QType() == dns.TypeSRV {
+	switch state.QType() {
+	case dns.TypeA, dns.TypeAAAA:
+		records = lh.createARecords(filterByPort(dnsRecords, pReq), state)
+	case dns.TypeSRV:
 		records = lh.createSRVRecords(dnsRecords, state, pReq, zone, isHeadless)
 	}
 
+	if mergeLocal && state.QType() != dns.TypeSRV {
+		records = lh.mergeLocalRecords(state, pReq, records)
+	}
+
+	if lh.TTLDecayWindow > 0 {
+		setTTL(records, lh.answerTTL(pReq, dnsRecords))
+	}
+
+	// A named port that the service doesn't have, eg after it was renamed, or that none of its endpoints expose doesn't exist.
+	if len(records) == 0 && pReq.port != "" {
+		log.Debugf("No port %q with protocol %q found for %q", pReq.port, pReq.protocol, state.QName())
+		return lh.nextOrFailure(ctx, state, r, dns.RcodeNameError)
+	}
+
 	if len(records) == 0 {
 		log.Debugf("Couldn't find a connected cluster or valid record for %q", state.QName())
 		return lh.emptyResponse(state)
@@ -20,7 +20,13 @@ package lighthouse_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
@@ -30,6 +36,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/submariner-io/lighthouse/coredns/endpointslice"
 	lighthouse "github.com/submariner-io/lighthouse/coredns/plugin"
 	"github.com/submariner-io/lighthouse/coredns/serviceimport"
@@ -69,6 +76,23 @@ var _ = Describe("Lighthouse DNS plugin Handler", func() {
 	Context("Headless services", testHeadlessService)
 	Context("Local services", testLocalService)
 	Context("SRV  records", testSRVMultiplePorts)
+	Context("ExternalName services", testExternalNameService)
+	Context("Ready-only records", testReadyOnly)
+	Context("Custom clusterset hostnames", testClustersetHostname)
+	Context("Short names", testShortNames)
+	Context("Zone apex answer", testApexAnswer)
+	Context("Cluster subzones", testClusterSubzones)
+	Context("Consistent hashing", testConsistentHashing)
+	Context("Service aliases", testServiceAliases)
+	Context("Static clusterset IPs", testStaticClustersetIP)
+	Context("Renamed ports", testRenamedPort)
+	Context("Capped answers", testCappedAnswer)
+	Context("TTL decay", testTTLDecay)
+	Context("Allowed consumer clusters", testAllowedClusters)
+	Context("Minimum endpoints", testMinEndpoints)
+	Context("Cluster health", testClusterHealth)
+	Context("Local cluster zone", testLocalClusterZone)
+	Context("Merged local answers", testMergeLocal)
 })
 
 type FailingResponseWriter struct {
@@ -107,10 +131,14 @@ func (m *MockClusterStatus) LocalClusterID() string {
 
 type MockLocalServices struct {
 	LocalServicesMap map[string]*serviceimport.DNSRecord
+	ClusterIPs       map[string][]string
 }
 
 func NewMockLocalServices() *MockLocalServices {
-	return &MockLocalServices{LocalServicesMap: make(map[string]*serviceimport.DNSRecord)}
+	return &MockLocalServices{
+		LocalServicesMap: make(map[string]*serviceimport.DNSRecord),
+		ClusterIPs:       make(map[string][]string),
+	}
 }
 
 func (m *MockLocalServices) GetIP(name, namespace string) (*serviceimport.DNSRecord, bool) {
@@ -118,6 +146,19 @@ func (m *MockLocalServices) GetIP(name, namespace string) (*serviceimport.DNSRec
 	return record, found
 }
 
+func (m *MockLocalServices) GetClusterIPs(name, namespace string) []string {
+	return m.ClusterIPs[getKey(name, namespace)]
+}
+
+type MockServiceAliases struct {
+	targets map[string][2]string
+}
+
+func (m *MockServiceAliases) GetTarget(name, namespace string) (targetName, targetNamespace string, found bool) {
+	target, found := m.targets[getKey(name, namespace)]
+	return target[0], target[1], found
+}
+
 func getKey(name, namespace string) string {
 	return namespace + "/" + name
 }
@@ -189,6 +230,74 @@ func testWithoutFallback() {
 		})
 	})
 
+	When("DNS query for an existing service with a mixed-case name", func() {
+		qname := fmt.Sprintf("%s.%s.SVC.ClusterSet.Local.", strings.ToUpper(service1), "NameSpace1")
+		It("of Type A record should succeed and write an A record response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+		})
+
+		It("of Type SRV should succeed and write an SRV record response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s.%s.svc.ClusterSet.Local.", qname, portNumber1,
+						service1, namespace1)),
+				},
+			})
+		})
+
+		It("with a cluster of Type A record should succeed and write an A record response", func() {
+			qname := fmt.Sprintf("%s.%s", strings.ToUpper(clusterID), qname)
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+		})
+	})
+
+	When("DNS query for an existing service with no ports", func() {
+		qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace2)
+
+		BeforeEach(func() {
+			serviceImport := newServiceImport(namespace2, service1, clusterID, serviceIP, "", 0, "", mcsv1a1.ClusterSetIP)
+			serviceImport.Spec.Ports = []mcsv1a1.ServicePort{}
+			t.lh.ServiceImports.Put(serviceImport)
+		})
+
+		It("of Type A record should succeed and write an A record response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+		})
+
+		It("of Type SRV should succeed and write an empty response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname:  qname,
+				Qtype:  dns.TypeSRV,
+				Rcode:  dns.RcodeSuccess,
+				Answer: []dns.RR{},
+			})
+		})
+	})
+
 	When("DNS query for an existing service with a different namespace", func() {
 		qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace2)
 		It("of Type A record should succeed and write an A record response", func() {
@@ -217,6 +326,25 @@ func testWithoutFallback() {
 		})
 	})
 
+	When("DNS query for an existing service whose ServiceImport has a hashed name", func() {
+		qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace2)
+		It("of Type A record should succeed and write an A record response", func() {
+			si := newServiceImport(namespace2, service1, clusterID, serviceIP2, portName1, portNumber1, protocol1,
+				mcsv1a1.ClusterSetIP)
+			si.Name = service1 + "-4f1d2c3b5a697887"
+			t.lh.ServiceImports.Put(si)
+
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP2)),
+				},
+			})
+		})
+	})
+
 	When("DNS query for a non-existent service", func() {
 		qname := fmt.Sprintf("unknown.%s.svc.clusterset.local.", namespace1)
 		It("of Type A record should return RcodeNameError for A record query", func() {
@@ -537,6 +665,98 @@ func testClusterStatus() {
 		})
 	})
 
+	When("service is in two clusters and only one is connected", func() {
+		JustBeforeEach(func() {
+			t.mockCs.clusterStatusMap[clusterID] = false
+		})
+
+		It("should report the reachability of each cluster", func() {
+			status, found := t.lh.GetClusterReachability(namespace1, service1)
+			Expect(found).To(BeTrue())
+			Expect(status).To(Equal([]lighthouse.ClusterReachability{
+				{ClusterID: clusterID, Reachable: false},
+				{ClusterID: clusterID2, Reachable: true},
+			}))
+		})
+
+		It("should publish the reachability of each cluster via the metric as the connection status changes", func() {
+			t.lh.UpdateClusterReachability(namespace1, service1)
+
+			Expect(getClusterReachableMetric(clusterID, service1, namespace1)).To(Equal(0.0))
+			Expect(getClusterReachableMetric(clusterID2, service1, namespace1)).To(Equal(1.0))
+
+			t.mockCs.clusterStatusMap[clusterID] = true
+			t.lh.UpdateAllClusterReachability()
+
+			Expect(getClusterReachableMetric(clusterID, service1, namespace1)).To(Equal(1.0))
+		})
+
+		It("should delete the series of a cluster when its ServiceImport is removed", func() {
+			t.lh.ServiceImports.SetChangeHandler(t.lh.UpdateClusterReachability)
+			t.lh.UpdateClusterReachability(namespace1, service1)
+
+			t.lh.ServiceImports.Remove(newServiceImport(namespace1, service1, clusterID2, serviceIP2, portName2,
+				portNumber2, protocol2, mcsv1a1.ClusterSetIP))
+
+			_, found := findClusterReachableMetric(clusterID2, service1, namespace1)
+			Expect(found).To(BeFalse())
+			Expect(getClusterReachableMetric(clusterID, service1, namespace1)).To(Equal(0.0))
+
+			t.lh.EndpointSlices.SetChangeHandler(t.lh.UpdateClusterReachability)
+			t.lh.ServiceImports.Remove(newServiceImport(namespace1, service1, clusterID, serviceIP, portName1,
+				portNumber1, protocol1, mcsv1a1.ClusterSetIP))
+			t.lh.EndpointSlices.Remove(newEndpointSlice(namespace1, service1, clusterID, portName1, []string{hostName1},
+				[]string{endpointIP}, portNumber1, protocol1))
+
+			_, found = findClusterReachableMetric(clusterID, service1, namespace1)
+			Expect(found).To(BeFalse())
+			Expect(t.lh.GetAllClusterReachability("", "")).To(BeEmpty())
+		})
+
+		It("should serve the reachability of each cluster via the introspection endpoint", func() {
+			t.lh.UpdateClusterReachability(namespace1, service1)
+
+			recorder := httptest.NewRecorder()
+			t.lh.IntrospectionHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet,
+				fmt.Sprintf("/reachability?namespace=%s&name=%s", namespace1, service1), http.NoBody))
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var reachability []lighthouse.ServiceReachability
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &reachability)).To(Succeed())
+			Expect(reachability).To(Equal([]lighthouse.ServiceReachability{{
+				Namespace: namespace1,
+				Name:      service1,
+				Clusters: []lighthouse.ClusterReachability{
+					{ClusterID: clusterID, Reachable: false},
+					{ClusterID: clusterID2, Reachable: true},
+				},
+			}}))
+
+			recorder = httptest.NewRecorder()
+			t.lh.IntrospectionHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet,
+				fmt.Sprintf("/reachability?namespace=%s", namespace2), http.NoBody))
+			Expect(recorder.Body.String()).To(Equal("[]\n"))
+		})
+	})
+
+	When("a headless service is in two clusters and only one is connected", func() {
+		JustBeforeEach(func() {
+			t.lh.ServiceImports = serviceimport.NewMap(localClusterID)
+			t.lh.EndpointSlices.Put(newEndpointSlice(namespace1, service1, clusterID2, portName1, []string{hostName2},
+				[]string{endpointIP2}, portNumber1, protocol1))
+			t.mockCs.clusterStatusMap[clusterID2] = false
+		})
+
+		It("should report the reachability of each cluster", func() {
+			status, found := t.lh.GetClusterReachability(namespace1, service1)
+			Expect(found).To(BeTrue())
+			Expect(status).To(Equal([]lighthouse.ClusterReachability{
+				{ClusterID: clusterID, Reachable: true},
+				{ClusterID: clusterID2, Reachable: false},
+			}))
+		})
+	})
+
 	When("service is present in two clusters and both are disconnected", func() {
 		JustBeforeEach(func() {
 			t.mockCs.clusterStatusMap[clusterID] = false
@@ -655,6 +875,17 @@ func testHeadlessService() {
 				},
 			})
 		})
+		It("should succeed and write an A record response for a mixed-case query with host name", func() {
+			qname = fmt.Sprintf("%s.%s.%s.%s.svc.clusterset.local.", strings.ToUpper(hostName1), clusterID, service1, "NAMESPACE1")
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, endpointIP)),
+				},
+			})
+		})
 		It("should succeed and write an SRV record response for query with cluster name", func() {
 			qname = fmt.Sprintf("%s.%s.%s.svc.clusterset.local.", clusterID, service1, namespace1)
 			t.executeTestCase(rec, test.Case{
@@ -729,6 +960,46 @@ func testHeadlessService() {
 		})
 	})
 
+	When("headless service has an IP and an FQDN endpoint", func() {
+		const fqdn = "ext.example.com"
+
+		JustBeforeEach(func() {
+			t.lh.ServiceImports.Put(newServiceImport(namespace1, service1, clusterID, "", portName1, portNumber1, protocol1,
+				mcsv1a1.Headless))
+
+			es := newEndpointSlice(namespace1, service1, clusterID, portName1, []string{hostName1}, []string{endpointIP},
+				portNumber1, protocol1)
+			es.Annotations = map[string]string{lhconstants.FQDNEndpointsAnnotation: fqdn}
+			t.lh.EndpointSlices.Put(es)
+		})
+
+		qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+
+		It("should succeed and write an A record and a CNAME record as response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, endpointIP)),
+					test.CNAME(fmt.Sprintf("%s    5    IN    CNAME    %s.", qname, fqdn)),
+				},
+			})
+		})
+
+		It("should succeed and write SRV records targeting the host name and the FQDN", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV  0 50 %d %s.", qname, portNumber1, fqdn)),
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV  0 50 %d %s.%s.%s", qname, portNumber1, hostName1, clusterID, qname)),
+				},
+			})
+		})
+	})
+
 	When("headless service is present in two clusters", func() {
 		JustBeforeEach(func() {
 			t.lh.ServiceImports.Put(newServiceImport(namespace1, service1, clusterID, "", portName1,
@@ -769,344 +1040,2269 @@ func testHeadlessService() {
 			})
 		})
 	})
-}
-
-func testLocalService() {
-	var (
-		rec *dnstest.Recorder
-		t   *handlerTestDriver
-	)
 
-	BeforeEach(func() {
-		t = newHandlerTestDriver()
-		t.mockCs.clusterStatusMap[clusterID] = true
-		t.mockCs.clusterStatusMap[clusterID2] = true
-		t.mockEs.endpointStatusMap[clusterID] = true
-		t.mockEs.endpointStatusMap[clusterID2] = true
-		t.mockCs.localClusterID = clusterID
-		t.mockLs.LocalServicesMap[getKey(service1, namespace1)] = &serviceimport.DNSRecord{
-			IP: serviceIP,
-			Ports: []mcsv1a1.ServicePort{
-				{
-					Name:        portName1,
-					Protocol:    protocol1,
-					AppProtocol: nil,
-					Port:        portNumber1,
-				},
-			},
-			ClusterName: clusterID,
-		}
+	When("headless service is present in two clusters with different named ports", func() {
+		const portName3 = "https"
 
-		t.lh.ServiceImports.Put(newServiceImport(namespace1, service1, clusterID2, serviceIP2, portName2, portNumber2,
-			protocol2, mcsv1a1.ClusterSetIP))
+		portNumber3 := int32(8443)
 
-		rec = dnstest.NewRecorder(&test.ResponseWriter{})
-	})
+		JustBeforeEach(func() {
+			t.lh.ServiceImports.Put(newServiceImport(namespace1, service1, clusterID, "", portName1,
+				portNumber1, protocol1, mcsv1a1.Headless))
+			t.lh.ServiceImports.Put(newServiceImport(namespace1, service1, clusterID2, "", portName3,
+				portNumber3, protocol1, mcsv1a1.Headless))
+			t.lh.EndpointSlices.Put(newEndpointSlice(namespace1, service1, clusterID, portName1, []string{hostName1}, []string{endpointIP},
+				portNumber1, protocol1))
+			t.lh.EndpointSlices.Put(newEndpointSlice(namespace1, service1, clusterID2, portName3, []string{hostName2},
+				[]string{endpointIP2}, portNumber3, protocol1))
+			t.mockCs.clusterStatusMap[clusterID2] = true
+		})
 
-	When("service is in local and remote clusters", func() {
-		qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
-		It("should succeed and write local cluster's IP as A record response", func() {
+		It("should write an SRV record for each endpoint that has the requested port name", func() {
+			qname := fmt.Sprintf("_%s._tcp.%s.%s.svc.clusterset.local.", portName3, service1, namespace1)
 			t.executeTestCase(rec, test.Case{
 				Qname: qname,
-				Qtype: dns.TypeA,
+				Qtype: dns.TypeSRV,
 				Rcode: dns.RcodeSuccess,
 				Answer: []dns.RR{
-					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV  0 50 %d %s.%s.%s.%s.svc.clusterset.local.", qname, portNumber3,
+						hostName2, clusterID2, service1, namespace1)),
 				},
 			})
-			// Execute again to make sure not round robin
+		})
+
+		It("should return RcodeNameError for an unknown port name", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: fmt.Sprintf("_unknown._tcp.%s.%s.svc.clusterset.local.", service1, namespace1),
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+	})
+
+	When("headless service has endpoints exposing different ports", func() {
+		JustBeforeEach(func() {
+			t.lh.ServiceImports.Put(newServiceImport(namespace1, service1, clusterID, "", portName1,
+				portNumber1, protocol1, mcsv1a1.Headless))
+
+			// The first endpoint exposes both ports whereas the second only exposes the first port.
+			es := newEndpointSlice(namespace1, service1, clusterID, portName1, []string{hostName1, hostName2},
+				[]string{endpointIP, endpointIP2}, portNumber1, protocol1)
+			es.Ports = append(es.Ports, newEndpointPort(portName2, portNumber2, protocol1))
+			es.Annotations = map[string]string{lhconstants.EndpointPortsAnnotation: fmt.Sprintf(
+				`{%q:[{"name":%q,"protocol":%q,"port":%d},{"name":%q,"protocol":%q,"port":%d}],%q:[{"name":%q,"protocol":%q,"port":%d}]}`,
+				endpointIP, portName1, protocol1, portNumber1, portName2, protocol1, portNumber2,
+				endpointIP2, portName1, protocol1, portNumber1)}
+			t.lh.EndpointSlices.Put(es)
+		})
+
+		It("should write an A record for each endpoint that exposes the requested port", func() {
+			qname := fmt.Sprintf("_%s._tcp.%s.%s.svc.clusterset.local.", portName2, service1, namespace1)
 			t.executeTestCase(rec, test.Case{
 				Qname: qname,
 				Qtype: dns.TypeA,
 				Rcode: dns.RcodeSuccess,
 				Answer: []dns.RR{
-					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, endpointIP)),
 				},
 			})
-		})
-		It("should succeed and write local cluster's IP as SRV record response", func() {
+
+			qname = fmt.Sprintf("_%s._tcp.%s.%s.svc.clusterset.local.", portName1, service1, namespace1)
 			t.executeTestCase(rec, test.Case{
 				Qname: qname,
-				Qtype: dns.TypeSRV,
+				Qtype: dns.TypeA,
 				Rcode: dns.RcodeSuccess,
 				Answer: []dns.RR{
-					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s", qname, portNumber1, qname)),
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, endpointIP)),
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, endpointIP2)),
 				},
 			})
 		})
-	})
 
-	When("service is in local and remote clusters, and remote cluster is requested", func() {
-		qname := fmt.Sprintf("%s.%s.%s.svc.clusterset.local.", clusterID2, service1, namespace1)
-		It("should succeed and write remote cluster's IP as A record response", func() {
+		It("should write an A record for each endpoint in the requested cluster that exposes the requested port", func() {
+			qname := fmt.Sprintf("_%s._tcp.%s.%s.%s.svc.clusterset.local.", portName2, clusterID, service1, namespace1)
 			t.executeTestCase(rec, test.Case{
 				Qname: qname,
 				Qtype: dns.TypeA,
 				Rcode: dns.RcodeSuccess,
 				Answer: []dns.RR{
-					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP2)),
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, endpointIP)),
 				},
 			})
 		})
 
-		It("should succeed and write remote cluster's IP as SRV record response", func() {
+		It("should write an SRV record for each endpoint that exposes the requested port", func() {
+			qname := fmt.Sprintf("_%s._tcp.%s.%s.svc.clusterset.local.", portName2, service1, namespace1)
 			t.executeTestCase(rec, test.Case{
 				Qname: qname,
 				Qtype: dns.TypeSRV,
 				Rcode: dns.RcodeSuccess,
 				Answer: []dns.RR{
-					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s", qname, portNumber2, qname)),
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV  0 50 %d %s.%s.%s.%s.svc.clusterset.local.", qname, portNumber2,
+						hostName1, clusterID, service1, namespace1)),
 				},
 			})
 		})
-	})
 
-	When("service is in local and remote clusters, and local has no active endpoints", func() {
-		JustBeforeEach(func() {
-			t.mockEs.endpointStatusMap[clusterID] = false
-		})
-		qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
-		It("should succeed and write remote cluster's IP as A record response", func() {
+		It("should write an SRV record for each port exposed by each endpoint", func() {
+			qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
 			t.executeTestCase(rec, test.Case{
 				Qname: qname,
-				Qtype: dns.TypeA,
+				Qtype: dns.TypeSRV,
 				Rcode: dns.RcodeSuccess,
 				Answer: []dns.RR{
-					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP2)),
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV  0 50 %d %s.%s.%s", qname, portNumber2, hostName1, clusterID, qname)),
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV  0 50 %d %s.%s.%s", qname, portNumber1, hostName1, clusterID, qname)),
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV  0 50 %d %s.%s.%s", qname, portNumber1, hostName2, clusterID, qname)),
 				},
 			})
 		})
-		It("should succeed and write remote cluster's IP as SRV record response", func() {
+
+		It("should return RcodeNameError for an A query of an unknown port name", func() {
 			t.executeTestCase(rec, test.Case{
-				Qname: qname,
-				Qtype: dns.TypeSRV,
-				Rcode: dns.RcodeSuccess,
-				Answer: []dns.RR{
-					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s", qname, portNumber2, qname)),
-				},
+				Qname: fmt.Sprintf("_unknown._tcp.%s.%s.svc.clusterset.local.", service1, namespace1),
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeNameError,
 			})
 		})
 	})
 }
 
-func testSRVMultiplePorts() {
+func testExternalNameService() {
+	const (
+		service2 = "service2"
+		service3 = "service3"
+	)
+
 	var (
 		rec *dnstest.Recorder
 		t   *handlerTestDriver
 	)
 
+	qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service2, namespace1)
+
 	BeforeEach(func() {
 		t = newHandlerTestDriver()
 		t.mockCs.clusterStatusMap[clusterID] = true
 		t.mockEs.endpointStatusMap[clusterID] = true
-		t.mockCs.localClusterID = clusterID
-		t.mockLs.LocalServicesMap[getKey(service1, namespace1)] = &serviceimport.DNSRecord{
-			IP: serviceIP,
-			Ports: []mcsv1a1.ServicePort{
-				{
-					Name:        portName1,
-					Protocol:    protocol1,
-					AppProtocol: nil,
-					Port:        portNumber1,
-				},
-				{
-					Name:        portName2,
-					Protocol:    protocol2,
-					AppProtocol: nil,
-					Port:        portNumber2,
-				},
-			},
-			ClusterName: clusterID,
-		}
 
 		rec = dnstest.NewRecorder(&test.ResponseWriter{})
 	})
 
-	When("DNS query of type SRV", func() {
-		It("without portName should return all the ports", func() {
-			qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+	When("the external name is outside the clusterset zone", func() {
+		BeforeEach(func() {
+			t.lh.ServiceImports.Put(newExternalNameServiceImport(namespace1, service2, clusterID, "db.example.com"))
+		})
+
+		It("should write a CNAME record response", func() {
 			t.executeTestCase(rec, test.Case{
 				Qname: qname,
-				Qtype: dns.TypeSRV,
+				Qtype: dns.TypeA,
 				Rcode: dns.RcodeSuccess,
 				Answer: []dns.RR{
-					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s", qname, portNumber2, qname)),
-					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s", qname, portNumber1, qname)),
+					test.CNAME(fmt.Sprintf("%s    5    IN    CNAME    db.example.com.", qname)),
 				},
 			})
 		})
-		It("with  HTTP portname  should return TCP port", func() {
-			qname := fmt.Sprintf("%s.%s.%s.%s.svc.clusterset.local.", portName1, protocol1, service1, namespace1)
+	})
+
+	When("a ClusterSetIP service declares an explicit CNAME target", func() {
+		BeforeEach(func() {
+			si := newServiceImport(namespace1, service2, clusterID, serviceIP2, portName1, portNumber1, protocol1, mcsv1a1.ClusterSetIP)
+			si.Annotations[lhconstants.CNAMETargetAnnotation] = "registry.example.com"
+			t.lh.ServiceImports.Put(si)
+		})
+
+		It("should write a CNAME record response to the target rather than the service IP", func() {
 			t.executeTestCase(rec, test.Case{
 				Qname: qname,
-				Qtype: dns.TypeSRV,
+				Qtype: dns.TypeA,
 				Rcode: dns.RcodeSuccess,
 				Answer: []dns.RR{
-					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s.%s.svc.clusterset.local.", qname, portNumber1, service1, namespace1)),
+					test.CNAME(fmt.Sprintf("%s    5    IN    CNAME    registry.example.com.", qname)),
 				},
 			})
 		})
-		It("with  DNS portname  should return UDP port", func() {
-			qname := fmt.Sprintf("%s.%s.%s.%s.svc.clusterset.local.", portName2, protocol2, service1, namespace1)
+	})
+
+	When("an explicit CNAME target refers to another clusterset service", func() {
+		qname1 := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+
+		BeforeEach(func() {
+			si := newExternalNameServiceImport(namespace1, service2, clusterID, "db.example.com")
+			si.Annotations[lhconstants.CNAMETargetAnnotation] = qname1
+			t.lh.ServiceImports.Put(si)
+		})
+
+		It("should take precedence over the external name and follow the chain", func() {
 			t.executeTestCase(rec, test.Case{
 				Qname: qname,
-				Qtype: dns.TypeSRV,
+				Qtype: dns.TypeA,
 				Rcode: dns.RcodeSuccess,
 				Answer: []dns.RR{
-					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s.%s.svc.clusterset.local.", qname, portNumber2, service1, namespace1)),
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname1, serviceIP)),
+					test.CNAME(fmt.Sprintf("%s    5    IN    CNAME    %s", qname, qname1)),
 				},
 			})
 		})
-		It("with  cluster name should return all the ports from the cluster", func() {
-			qname := fmt.Sprintf("%s.%s.%s.svc.clusterset.local.", clusterID, service1, namespace1)
+	})
+
+	When("the external names form a valid 2-hop chain", func() {
+		qname3 := fmt.Sprintf("%s.%s.svc.clusterset.local.", service3, namespace1)
+		qname1 := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+
+		BeforeEach(func() {
+			t.lh.ServiceImports.Put(newExternalNameServiceImport(namespace1, service2, clusterID, qname3))
+			t.lh.ServiceImports.Put(newExternalNameServiceImport(namespace1, service3, clusterID, qname1))
+		})
+
+		It("should follow the chain and write the CNAME and A records", func() {
 			t.executeTestCase(rec, test.Case{
 				Qname: qname,
-				Qtype: dns.TypeSRV,
+				Qtype: dns.TypeA,
 				Rcode: dns.RcodeSuccess,
 				Answer: []dns.RR{
-					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s", qname, portNumber2, qname)),
-					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s", qname, portNumber1, qname)),
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname1, serviceIP)),
+					test.CNAME(fmt.Sprintf("%s    5    IN    CNAME    %s", qname, qname3)),
+					test.CNAME(fmt.Sprintf("%s    5    IN    CNAME    %s", qname3, qname1)),
 				},
 			})
 		})
-		It("with  HTTP portname  should return TCP port with underscore prefix", func() {
-			qname := fmt.Sprintf("_%s._%s.%s.%s.svc.clusterset.local.", portName1, protocol1, service1, namespace1)
+	})
+
+	When("the external name is the cluster-local name of a clusterset service", func() {
+		localName := fmt.Sprintf("%s.%s.svc.cluster.local.", service1, namespace1)
+
+		BeforeEach(func() {
+			t.lh.ServiceImports.Put(newExternalNameServiceImport(namespace1, service2, clusterID, localName))
+		})
+
+		It("should resolve the target to the clusterset service", func() {
 			t.executeTestCase(rec, test.Case{
 				Qname: qname,
-				Qtype: dns.TypeSRV,
+				Qtype: dns.TypeA,
 				Rcode: dns.RcodeSuccess,
 				Answer: []dns.RR{
-					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s.%s.svc.clusterset.local.", qname, portNumber1, service1, namespace1)),
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", localName, serviceIP)),
+					test.CNAME(fmt.Sprintf("%s    5    IN    CNAME    %s", qname, localName)),
 				},
 			})
 		})
 	})
-}
 
-type handlerTestDriver struct {
-	mockCs *MockClusterStatus
-	mockEs *MockEndpointStatus
-	mockLs *MockLocalServices
-	lh     *lighthouse.Lighthouse
-}
+	When("the external name is the cluster-local name of a service not in the clusterset", func() {
+		localName := fmt.Sprintf("%s.%s.svc.cluster.local.", service3, namespace1)
 
-func newHandlerTestDriver() *handlerTestDriver {
-	t := &handlerTestDriver{
-		mockCs: NewMockClusterStatus(),
-		mockEs: NewMockEndpointStatus(),
-		mockLs: NewMockLocalServices(),
-	}
+		BeforeEach(func() {
+			t.lh.ServiceImports.Put(newExternalNameServiceImport(namespace1, service2, clusterID, localName))
+		})
 
-	t.lh = &lighthouse.Lighthouse{
-		Zones:           []string{"clusterset.local."},
-		ServiceImports:  setupServiceImportMap(),
-		EndpointSlices:  setupEndpointSliceMap(),
-		ClusterStatus:   t.mockCs,
-		EndpointsStatus: t.mockEs,
-		LocalServices:   t.mockLs,
-		TTL:             uint32(5),
-	}
+		It("should only write a CNAME record response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.CNAME(fmt.Sprintf("%s    5    IN    CNAME    %s", qname, localName)),
+				},
+			})
+		})
+	})
 
-	return t
+	When("the external name refers to itself", func() {
+		BeforeEach(func() {
+			t.lh.ServiceImports.Put(newExternalNameServiceImport(namespace1, service2, clusterID, qname))
+		})
+
+		It("should return SERVFAIL", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeServerFailure,
+			})
+		})
+	})
 }
 
-// nolint:gocritic // (hugeParam) It's fine to pass 'tc' by value here.
-func (t *handlerTestDriver) executeTestCase(rec *dnstest.Recorder, tc test.Case) {
-	code, err := t.lh.ServeDNS(context.TODO(), rec, tc.Msg())
+func testReadyOnly() {
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+	)
 
-	if plugin.ClientWrite(tc.Rcode) {
-		Expect(err).To(Succeed())
-		Expect(test.SortAndCheck(rec.Msg, tc)).To(Succeed())
-	} else {
-		Expect(err).To(HaveOccurred())
-		Expect(code).Should(Equal(tc.Rcode))
-	}
-}
+	qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
 
-func setupServiceImportMap() *serviceimport.Map {
-	siMap := serviceimport.NewMap(localClusterID)
-	siMap.Put(newServiceImport(namespace1, service1, clusterID, serviceIP, portName1, portNumber1, protocol1, mcsv1a1.ClusterSetIP))
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+		t.lh.ReadyOnly = true
 
-	return siMap
-}
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
 
-func setupEndpointSliceMap() *endpointslice.Map {
-	esMap := endpointslice.NewMap(localClusterID, fakeKubeClient.NewSimpleClientset())
-	esMap.Put(newEndpointSlice(namespace1, service1, clusterID, portName1, []string{hostName1}, []string{endpointIP}, portNumber1, protocol1))
+	When("the service has ready endpoints", func() {
+		It("should write an A record response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+		})
+	})
 
-	return esMap
+	When("the service has zero ready endpoints", func() {
+		BeforeEach(func() {
+			notReady := false
+			es := newEndpointSlice(namespace1, service1, clusterID, portName1, []string{hostName1}, []string{endpointIP},
+				portNumber1, protocol1)
+			es.Endpoints[0].Conditions.Ready = &notReady
+			t.lh.EndpointSlices.Put(es)
+		})
+
+		It("should return RcodeNameError", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+	})
 }
 
-// nolint:unparam // `name` always receives `service1'.
-func newServiceImport(namespace, name, clusterID, serviceIP, portName string,
-	portNumber int32, protocol v1.Protocol, siType mcsv1a1.ServiceImportType,
-) *mcsv1a1.ServiceImport {
-	return &mcsv1a1.ServiceImport{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: namespace,
-			Annotations: map[string]string{
-				"origin-name":      name,
-				"origin-namespace": namespace,
-			},
-			Labels: map[string]string{
-				lhconstants.LighthouseLabelSourceCluster: clusterID,
-			},
-		},
-		Spec: mcsv1a1.ServiceImportSpec{
-			Type: siType,
-			IPs:  []string{serviceIP},
-			Ports: []mcsv1a1.ServicePort{
-				{
-					Name:     portName,
-					Protocol: protocol,
-					Port:     portNumber,
+func testClustersetHostname() {
+	const hostname = "payments"
+
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+	)
+
+	qname := hostname + ".clusterset.local."
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+
+		t.lh.ServiceImports.Put(newClustersetHostnameServiceImport(namespace1, service1, clusterID, serviceIP, hostname))
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
+
+	When("DNS query for a declared clusterset hostname", func() {
+		It("should write an A record response for the service", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
 				},
-			},
-		},
-		Status: mcsv1a1.ServiceImportStatus{
-			Clusters: []mcsv1a1.ClusterStatus{
-				{
-					Cluster: clusterID,
+			})
+		})
+	})
+
+	When("DNS query for a declared clusterset hostname with a mixed-case name", func() {
+		It("should write an A record response for the service", func() {
+			mixedCaseQname := "Payments.clusterset.local."
+
+			t.executeTestCase(rec, test.Case{
+				Qname: mixedCaseQname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", mixedCaseQname, serviceIP)),
 				},
-			},
-		},
-	}
-}
+			})
+		})
+	})
 
-// nolint:unparam // `namespace` always receives `namespace1`.
-func newEndpointSlice(namespace, name, clusterID, portName string, hostName, endpointIPs []string, portNumber int32,
-	protocol v1.Protocol,
-) *discovery.EndpointSlice {
-	endpoints := make([]discovery.Endpoint, len(endpointIPs))
+	When("DNS query for an undeclared clusterset hostname", func() {
+		It("should return RcodeNameError", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: "billing.clusterset.local.",
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+	})
 
-	for i := range endpointIPs {
-		endpoint := discovery.Endpoint{
-			Addresses: []string{endpointIPs[i]},
-			Hostname:  &hostName[i],
-		}
-		endpoints[i] = endpoint
-	}
+	When("the same service declares the clusterset hostname in two clusters", func() {
+		BeforeEach(func() {
+			t.mockCs.clusterStatusMap[clusterID2] = true
+			t.mockEs.endpointStatusMap[clusterID2] = true
+			t.lh.ServiceImports.Put(newClustersetHostnameServiceImport(namespace1, service1, clusterID2, serviceIP2, hostname))
+		})
 
-	return &discovery.EndpointSlice{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: namespace,
-			Labels: map[string]string{
-				discovery.LabelManagedBy:          lhconstants.LabelValueManagedBy,
-				lhconstants.LabelSourceNamespace:  namespace,
-				lhconstants.MCSLabelSourceCluster: clusterID,
-				lhconstants.MCSLabelServiceName:   name,
+		It("should resolve to the service", func() {
+			code, err := t.lh.ServeDNS(context.TODO(), rec, (&test.Case{Qname: qname, Qtype: dns.TypeA}).Msg())
+			Expect(err).To(Succeed())
+			Expect(code).To(Equal(dns.RcodeSuccess))
+			Expect(rec.Msg.Answer).To(HaveLen(1))
+		})
+	})
+
+	When("the clusterset hostname is declared by two different services", func() {
+		BeforeEach(func() {
+			t.lh.ServiceImports.Put(newClustersetHostnameServiceImport(namespace2, service1, clusterID2, serviceIP2, hostname))
+		})
+
+		It("should return RcodeNameError", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+
+		It("should still resolve the services by their name and namespace", func() {
+			qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+		})
+	})
+}
+
+func testShortNames() {
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+	)
+
+	canonicalQname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+	shortQname := fmt.Sprintf("%s.%s.clusterset.local.", service1, namespace1)
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+		t.lh.ShortNames = true
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
+
+	When("DNS query for the canonical name of an existing service", func() {
+		It("should write an A record response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: canonicalQname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", canonicalQname, serviceIP)),
+				},
+			})
+		})
+	})
+
+	When("DNS query for the short name of an existing service", func() {
+		It("should write an A record response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: shortQname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", shortQname, serviceIP)),
+				},
+			})
+		})
+
+		It("should write an SRV record response targeting the canonical name", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: shortQname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s", shortQname, portNumber1, canonicalQname)),
+				},
+			})
+		})
+	})
+
+	When("DNS query for the short name of a non-existent service", func() {
+		It("should return RcodeNameError", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: fmt.Sprintf("unknown.%s.clusterset.local.", namespace1),
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+	})
+
+	When("short names aren't enabled", func() {
+		BeforeEach(func() {
+			t.lh.ShortNames = false
+		})
+
+		It("should return RcodeNameError for the short name", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: shortQname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+	})
+}
+
+func testLocalService() {
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+	)
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockCs.clusterStatusMap[clusterID2] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID2] = true
+		t.mockCs.localClusterID = clusterID
+		t.mockLs.LocalServicesMap[getKey(service1, namespace1)] = &serviceimport.DNSRecord{
+			IP: serviceIP,
+			Ports: []mcsv1a1.ServicePort{
+				{
+					Name:        portName1,
+					Protocol:    protocol1,
+					AppProtocol: nil,
+					Port:        portNumber1,
+				},
 			},
-		},
-		AddressType: discovery.AddressTypeIPv4,
-		Endpoints:   endpoints,
-		Ports: []discovery.EndpointPort{
-			{
-				Name:     &portName,
-				Protocol: &protocol,
-				Port:     &portNumber,
+			ClusterName: clusterID,
+		}
+
+		t.lh.ServiceImports.Put(newServiceImport(namespace1, service1, clusterID2, serviceIP2, portName2, portNumber2,
+			protocol2, mcsv1a1.ClusterSetIP))
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
+
+	When("service is in local and remote clusters", func() {
+		qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+		It("should succeed and write local cluster's IP as A record response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+			// Execute again to make sure not round robin
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+		})
+		It("should succeed and write local cluster's IP as SRV record response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s", qname, portNumber1, qname)),
+				},
+			})
+		})
+	})
+
+	When("service is in local and remote clusters, and remote cluster is requested", func() {
+		qname := fmt.Sprintf("%s.%s.%s.svc.clusterset.local.", clusterID2, service1, namespace1)
+		It("should succeed and write remote cluster's IP as A record response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP2)),
+				},
+			})
+		})
+
+		It("should succeed and write remote cluster's IP as SRV record response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s", qname, portNumber2, qname)),
+				},
+			})
+		})
+	})
+
+	When("service is in local and remote clusters, and local has no active endpoints", func() {
+		JustBeforeEach(func() {
+			t.mockEs.endpointStatusMap[clusterID] = false
+		})
+		qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+		It("should succeed and write remote cluster's IP as A record response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP2)),
+				},
+			})
+		})
+		It("should succeed and write remote cluster's IP as SRV record response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s", qname, portNumber2, qname)),
+				},
+			})
+		})
+	})
+}
+
+func testSRVMultiplePorts() {
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+	)
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+		t.mockCs.localClusterID = clusterID
+		t.mockLs.LocalServicesMap[getKey(service1, namespace1)] = &serviceimport.DNSRecord{
+			IP: serviceIP,
+			Ports: []mcsv1a1.ServicePort{
+				{
+					Name:        portName1,
+					Protocol:    protocol1,
+					AppProtocol: nil,
+					Port:        portNumber1,
+				},
+				{
+					Name:        portName2,
+					Protocol:    protocol2,
+					AppProtocol: nil,
+					Port:        portNumber2,
+				},
 			},
-		},
-	}
+			ClusterName: clusterID,
+		}
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
+
+	When("DNS query of type SRV", func() {
+		It("without portName should return all the ports", func() {
+			qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s", qname, portNumber2, qname)),
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s", qname, portNumber1, qname)),
+				},
+			})
+		})
+		It("with  HTTP portname  should return TCP port", func() {
+			qname := fmt.Sprintf("%s.%s.%s.%s.svc.clusterset.local.", portName1, protocol1, service1, namespace1)
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s.%s.svc.clusterset.local.", qname, portNumber1, service1, namespace1)),
+				},
+			})
+		})
+		It("with  DNS portname  should return UDP port", func() {
+			qname := fmt.Sprintf("%s.%s.%s.%s.svc.clusterset.local.", portName2, protocol2, service1, namespace1)
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s.%s.svc.clusterset.local.", qname, portNumber2, service1, namespace1)),
+				},
+			})
+		})
+		It("with  cluster name should return all the ports from the cluster", func() {
+			qname := fmt.Sprintf("%s.%s.%s.svc.clusterset.local.", clusterID, service1, namespace1)
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s", qname, portNumber2, qname)),
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s", qname, portNumber1, qname)),
+				},
+			})
+		})
+		It("with  HTTP portname  should return TCP port with underscore prefix", func() {
+			qname := fmt.Sprintf("_%s._%s.%s.%s.svc.clusterset.local.", portName1, protocol1, service1, namespace1)
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s.%s.svc.clusterset.local.", qname, portNumber1, service1, namespace1)),
+				},
+			})
+		})
+
+		It("with an unknown portname should return RcodeNameError", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: fmt.Sprintf("_https._%s.%s.%s.svc.clusterset.local.", protocol1, service1, namespace1),
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+	})
+
+	When("DNS query of type SRV for a service with an SCTP port", func() {
+		const (
+			sctpPortName   = "diameter"
+			sctpPortNumber = int32(3868)
+		)
+
+		BeforeEach(func() {
+			record := t.mockLs.LocalServicesMap[getKey(service1, namespace1)]
+			record.Ports = append(record.Ports, mcsv1a1.ServicePort{
+				Name:     sctpPortName,
+				Protocol: v1.ProtocolSCTP,
+				Port:     sctpPortNumber,
+			})
+		})
+
+		It("should return the SCTP port for the _sctp protocol label", func() {
+			qname := fmt.Sprintf("_%s._sctp.%s.%s.svc.clusterset.local.", sctpPortName, service1, namespace1)
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s.%s.svc.clusterset.local.", qname, sctpPortNumber, service1,
+						namespace1)),
+				},
+			})
+		})
+
+		It("should not return the SCTP port for the _tcp protocol label", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: fmt.Sprintf("_%s._tcp.%s.%s.svc.clusterset.local.", sctpPortName, service1, namespace1),
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+	})
+
+	When("DNS query of type SRV for a port with no protocol", func() {
+		BeforeEach(func() {
+			record := t.mockLs.LocalServicesMap[getKey(service1, namespace1)]
+			record.Ports[0].Protocol = ""
+		})
+
+		It("should match the _tcp protocol label", func() {
+			qname := fmt.Sprintf("_%s._tcp.%s.%s.svc.clusterset.local.", portName1, service1, namespace1)
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s.%s.svc.clusterset.local.", qname, portNumber1, service1, namespace1)),
+				},
+			})
+		})
+	})
+}
+
+func testRenamedPort() {
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+	)
+
+	const newPortName = "web"
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+
+		t.lh.ServiceImports.Put(newServiceImport(namespace1, service1, clusterID, serviceIP, newPortName, portNumber1, protocol1,
+			mcsv1a1.ClusterSetIP))
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
+
+	When("an SRV query for the old port name is received after a port is renamed", func() {
+		It("should return RcodeNameError", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: fmt.Sprintf("_%s._%s.%s.%s.svc.clusterset.local.", portName1, protocol1, service1, namespace1),
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+	})
+
+	When("an SRV query for the new port name is received after a port is renamed", func() {
+		It("should write an SRV record response", func() {
+			qname := fmt.Sprintf("_%s._%s.%s.%s.svc.clusterset.local.", newPortName, protocol1, service1, namespace1)
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s.%s.svc.clusterset.local.", qname, portNumber1, service1, namespace1)),
+				},
+			})
+		})
+	})
+}
+
+type handlerTestDriver struct {
+	mockCs *MockClusterStatus
+	mockEs *MockEndpointStatus
+	mockLs *MockLocalServices
+	lh     *lighthouse.Lighthouse
+}
+
+func newHandlerTestDriver() *handlerTestDriver {
+	t := &handlerTestDriver{
+		mockCs: NewMockClusterStatus(),
+		mockEs: NewMockEndpointStatus(),
+		mockLs: NewMockLocalServices(),
+	}
+
+	t.lh = &lighthouse.Lighthouse{
+		Zones:           []string{"clusterset.local."},
+		ServiceImports:  setupServiceImportMap(),
+		EndpointSlices:  setupEndpointSliceMap(),
+		ClusterStatus:   t.mockCs,
+		EndpointsStatus: t.mockEs,
+		LocalServices:   t.mockLs,
+		TTL:             uint32(5),
+	}
+
+	return t
+}
+
+// nolint:gocritic // (hugeParam) It's fine to pass 'tc' by value here.
+func (t *handlerTestDriver) executeTestCase(rec *dnstest.Recorder, tc test.Case) {
+	code, err := t.lh.ServeDNS(context.TODO(), rec, tc.Msg())
+
+	if plugin.ClientWrite(tc.Rcode) {
+		Expect(err).To(Succeed())
+		Expect(test.SortAndCheck(rec.Msg, tc)).To(Succeed())
+	} else {
+		Expect(err).To(HaveOccurred())
+		Expect(code).Should(Equal(tc.Rcode))
+	}
+}
+
+func setupServiceImportMap() *serviceimport.Map {
+	siMap := serviceimport.NewMap(localClusterID)
+	siMap.Put(newServiceImport(namespace1, service1, clusterID, serviceIP, portName1, portNumber1, protocol1, mcsv1a1.ClusterSetIP))
+
+	return siMap
+}
+
+func setupEndpointSliceMap() *endpointslice.Map {
+	esMap := endpointslice.NewMap(localClusterID, fakeKubeClient.NewSimpleClientset())
+	esMap.Put(newEndpointSlice(namespace1, service1, clusterID, portName1, []string{hostName1}, []string{endpointIP}, portNumber1, protocol1))
+
+	return esMap
+}
+
+func newServiceImport(namespace, name, clusterID, serviceIP, portName string,
+	portNumber int32, protocol v1.Protocol, siType mcsv1a1.ServiceImportType,
+) *mcsv1a1.ServiceImport {
+	return &mcsv1a1.ServiceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				"origin-name":      name,
+				"origin-namespace": namespace,
+			},
+			Labels: map[string]string{
+				lhconstants.LighthouseLabelSourceCluster: clusterID,
+			},
+		},
+		Spec: mcsv1a1.ServiceImportSpec{
+			Type: siType,
+			IPs:  []string{serviceIP},
+			Ports: []mcsv1a1.ServicePort{
+				{
+					Name:     portName,
+					Protocol: protocol,
+					Port:     portNumber,
+				},
+			},
+		},
+		Status: mcsv1a1.ServiceImportStatus{
+			Clusters: []mcsv1a1.ClusterStatus{
+				{
+					Cluster: clusterID,
+				},
+			},
+		},
+	}
+}
+
+func newExternalNameServiceImport(namespace, name, clusterID, externalName string) *mcsv1a1.ServiceImport {
+	si := newServiceImport(namespace, name, clusterID, "", portName1, portNumber1, protocol1, mcsv1a1.ClusterSetIP)
+	si.Spec.IPs = nil
+	si.Annotations[lhconstants.ExternalNameAnnotation] = externalName
+
+	return si
+}
+
+func newClustersetHostnameServiceImport(namespace, name, clusterID, serviceIP, hostname string) *mcsv1a1.ServiceImport {
+	si := newServiceImport(namespace, name, clusterID, serviceIP, portName1, portNumber1, protocol1, mcsv1a1.ClusterSetIP)
+	si.Annotations[lhconstants.ClustersetHostnameAnnotation] = hostname
+
+	return si
+}
+
+// nolint:unparam // `namespace` always receives `namespace1`.
+func newEndpointSlice(namespace, name, clusterID, portName string, hostName, endpointIPs []string, portNumber int32,
+	protocol v1.Protocol,
+) *discovery.EndpointSlice {
+	endpoints := make([]discovery.Endpoint, len(endpointIPs))
+
+	for i := range endpointIPs {
+		endpoint := discovery.Endpoint{
+			Addresses: []string{endpointIPs[i]},
+			Hostname:  &hostName[i],
+		}
+		endpoints[i] = endpoint
+	}
+
+	return &discovery.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				discovery.LabelManagedBy:          lhconstants.LabelValueManagedBy,
+				lhconstants.LabelSourceNamespace:  namespace,
+				lhconstants.MCSLabelSourceCluster: clusterID,
+				lhconstants.MCSLabelServiceName:   name,
+			},
+		},
+		AddressType: discovery.AddressTypeIPv4,
+		Endpoints:   endpoints,
+		Ports: []discovery.EndpointPort{
+			{
+				Name:     &portName,
+				Protocol: &protocol,
+				Port:     &portNumber,
+			},
+		},
+	}
+}
+
+func newEndpointPort(name string, port int32, protocol v1.Protocol) discovery.EndpointPort {
+	return discovery.EndpointPort{
+		Name:     &name,
+		Protocol: &protocol,
+		Port:     &port,
+	}
+}
+
+func testApexAnswer() {
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+	)
+
+	apexQname := "clusterset.local."
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
+
+	When("an apex answer is configured", func() {
+		BeforeEach(func() {
+			t.lh.ApexIPs = []net.IP{net.ParseIP("10.253.1.1"), net.ParseIP("fd00::1")}
+		})
+
+		It("should write an A record response for an A query", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: apexQname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", apexQname, "10.253.1.1")),
+				},
+			})
+		})
+
+		It("should write an AAAA record response for an AAAA query", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: apexQname,
+				Qtype: dns.TypeAAAA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.AAAA(fmt.Sprintf("%s    5    IN    AAAA    %s", apexQname, "fd00::1")),
+				},
+			})
+		})
+
+		It("should write an empty response for an SRV query", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname:  apexQname,
+				Qtype:  dns.TypeSRV,
+				Rcode:  dns.RcodeSuccess,
+				Answer: []dns.RR{},
+			})
+		})
+
+		It("should still resolve services in the zone", func() {
+			qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+		})
+
+		Context("with only IPv4 addresses", func() {
+			BeforeEach(func() {
+				t.lh.ApexIPs = []net.IP{net.ParseIP("10.253.1.1")}
+			})
+
+			It("should write an empty response for an AAAA query", func() {
+				t.executeTestCase(rec, test.Case{
+					Qname:  apexQname,
+					Qtype:  dns.TypeAAAA,
+					Rcode:  dns.RcodeSuccess,
+					Answer: []dns.RR{},
+				})
+			})
+		})
+	})
+
+	When("an apex answer is not configured", func() {
+		It("should return RcodeNameError for an A query", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname:  apexQname,
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeNameError,
+				Answer: []dns.RR{},
+			})
+		})
+	})
+}
+
+func testClusterSubzones() {
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+	)
+
+	subzone := clusterID + ".clusterset.local."
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+		t.lh.ClusterSubzones = true
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
+
+	When("DNS query for the SOA of a cluster subzone apex", func() {
+		It("should write the subzone SOA record", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: subzone,
+				Qtype: dns.TypeSOA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SOA(fmt.Sprintf("%s    5    IN    SOA    ns.dns.%s hostmaster.%s 1 7200 1800 86400 5", subzone, subzone,
+						subzone)),
+				},
+			})
+		})
+	})
+
+	When("DNS query for the NS of a cluster subzone apex", func() {
+		It("should write the subzone NS record", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: subzone,
+				Qtype: dns.TypeNS,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.NS(fmt.Sprintf("%s    5    IN    NS    ns.dns.%s", subzone, subzone)),
+				},
+			})
+		})
+	})
+
+	When("type A DNS query for a cluster subzone apex", func() {
+		It("should write an empty response with the subzone SOA", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname:  subzone,
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: []dns.RR{},
+				Ns: []dns.RR{
+					test.SOA(fmt.Sprintf("%s    5    IN    SOA    ns.dns.%s hostmaster.%s 1 7200 1800 86400 5", subzone, subzone,
+						subzone)),
+				},
+			})
+		})
+	})
+
+	When("type A DNS query for a service in a cluster subzone", func() {
+		It("should write an A record response", func() {
+			qname := fmt.Sprintf("%s.%s.svc.%s", service1, namespace1, subzone)
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+		})
+	})
+
+	When("type SRV DNS query for a service in a cluster subzone", func() {
+		It("should write an SRV record response targeting the per-cluster name", func() {
+			qname := fmt.Sprintf("%s.%s.svc.%s", service1, namespace1, subzone)
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s.%s.%s.svc.clusterset.local.", qname, portNumber1, clusterID,
+						service1, namespace1)),
+				},
+			})
+		})
+	})
+
+	When("DNS query for a subzone of a cluster that isn't connected", func() {
+		BeforeEach(func() {
+			t.mockCs.clusterStatusMap[clusterID] = false
+		})
+
+		It("should return RcodeNameError", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname:  fmt.Sprintf("%s.%s.svc.%s", service1, namespace1, subzone),
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeNameError,
+				Answer: []dns.RR{},
+			})
+		})
+	})
+
+	When("cluster subzones aren't enabled", func() {
+		BeforeEach(func() {
+			t.lh.ClusterSubzones = false
+		})
+
+		It("should return RcodeNameError for a service in a cluster subzone", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname:  fmt.Sprintf("%s.%s.svc.%s", service1, namespace1, subzone),
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeNameError,
+				Answer: []dns.RR{},
+			})
+		})
+	})
+}
+
+func testConsistentHashing() {
+	var t *handlerTestDriver
+
+	qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.lh.ServiceImports.SetPolicy(mustNewPolicy(serviceimport.ConsistentHashPolicy))
+		t.lh.ServiceImports.Put(newServiceImport(namespace1, service1, clusterID2, serviceIP2, portName1, portNumber1, protocol1,
+			mcsv1a1.ClusterSetIP))
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockCs.clusterStatusMap[clusterID2] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID2] = true
+	})
+
+	queryFromSubnet := func(subnet string) string {
+		m := new(dns.Msg)
+		m.SetQuestion(qname, dns.TypeA)
+		m.SetEdns0(4096, false)
+		m.IsEdns0().Option = append(m.IsEdns0().Option, &dns.EDNS0_SUBNET{
+			Code:          dns.EDNS0SUBNET,
+			Family:        1,
+			SourceNetmask: 24,
+			Address:       net.ParseIP(subnet).To4(),
+		})
+
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		code, err := t.lh.ServeDNS(context.TODO(), rec, m)
+		Expect(err).To(Succeed())
+		Expect(code).To(Equal(dns.RcodeSuccess))
+		Expect(rec.Msg.Answer).To(HaveLen(1))
+
+		return rec.Msg.Answer[0].(*dns.A).A.String()
+	}
+
+	When("queries carry an EDNS client subnet", func() {
+		It("should consistently answer a client subnet with the same cluster's IP", func() {
+			ips := map[string]string{}
+
+			for i := 0; i < 20; i++ {
+				subnet := fmt.Sprintf("10.1.%d.0", i)
+				ips[subnet] = queryFromSubnet(subnet)
+			}
+
+			for i := 0; i < 3; i++ {
+				for subnet, ip := range ips {
+					Expect(queryFromSubnet(subnet)).To(Equal(ip))
+				}
+			}
+
+			Expect(ips).To(ContainElements(serviceIP, serviceIP2))
+		})
+	})
+}
+
+func mustNewPolicy(name string) serviceimport.Policy {
+	policy, err := serviceimport.NewPolicy(name)
+	Expect(err).To(Succeed())
+
+	return policy
+}
+
+func testServiceAliases() {
+	const alias = "legacy"
+
+	var (
+		rec     *dnstest.Recorder
+		t       *handlerTestDriver
+		aliases *MockServiceAliases
+	)
+
+	qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", alias, namespace2)
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+
+		aliases = &MockServiceAliases{targets: map[string][2]string{
+			getKey(alias, namespace2): {service1, namespace1},
+		}}
+		t.lh.ServiceAliases = aliases
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
+
+	When("DNS query for an alias of an existing service", func() {
+		It("should write an A record response for the target service", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+		})
+
+		It("should write an SRV record response targeting the target service", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeSRV,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.SRV(fmt.Sprintf("%s    5    IN    SRV 0 50 %d %s.%s.svc.clusterset.local.", qname, portNumber1,
+						service1, namespace1)),
+				},
+			})
+		})
+	})
+
+	When("DNS query for an alias of a non-existent service", func() {
+		BeforeEach(func() {
+			aliases.targets[getKey(alias, namespace2)] = [2]string{"missing", namespace1}
+		})
+
+		It("should return RcodeNameError", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+	})
+
+	When("an exported service has the same name as an alias", func() {
+		BeforeEach(func() {
+			t.lh.ServiceImports.Put(newServiceImport(namespace2, alias, clusterID, serviceIP2, portName1, portNumber1, protocol1,
+				mcsv1a1.ClusterSetIP))
+		})
+
+		It("should resolve the exported service rather than the alias", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP2)),
+				},
+			})
+		})
+	})
+
+	When("DNS query for a name that isn't an alias", func() {
+		It("should return RcodeNameError", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: fmt.Sprintf("other.%s.svc.clusterset.local.", namespace2),
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+	})
+
+	When("the target service is re-homed to another namespace", func() {
+		const movedName = "moved"
+
+		assertResolves := func(ip string) {
+			t.executeTestCase(dnstest.NewRecorder(&test.ResponseWriter{}), test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, ip)),
+				},
+			})
+		}
+
+		It("should continuously resolve the alias across the move", func() {
+			assertResolves(serviceIP)
+
+			aliases.targets[getKey(alias, namespace2)] = [2]string{movedName, namespace2}
+			t.lh.ServiceImports.RetainUntil(namespace1, service1, namespace2, movedName)
+			assertResolves(serviceIP)
+
+			t.lh.ServiceImports.Remove(newServiceImport(namespace1, service1, clusterID, serviceIP, portName1, portNumber1,
+				protocol1, mcsv1a1.ClusterSetIP))
+			assertResolves(serviceIP)
+
+			t.lh.ServiceImports.Put(newServiceImport(namespace2, movedName, clusterID, serviceIP2, portName1, portNumber1,
+				protocol1, mcsv1a1.ClusterSetIP))
+			assertResolves(serviceIP2)
+			Expect(t.lh.ServiceImports.Contains(namespace1, service1)).To(BeFalse())
+		})
+	})
+}
+
+func testStaticClustersetIP() {
+	const staticIP = "243.1.1.1"
+
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+	)
+
+	qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockCs.clusterStatusMap[clusterID2] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID2] = true
+		t.mockCs.localClusterID = clusterID
+		t.mockLs.LocalServicesMap[getKey(service1, namespace1)] = &serviceimport.DNSRecord{
+			IP:          serviceIP,
+			ClusterName: clusterID,
+		}
+
+		for _, si := range []*mcsv1a1.ServiceImport{
+			newServiceImport(namespace1, service1, clusterID, serviceIP, portName1, portNumber1, protocol1, mcsv1a1.ClusterSetIP),
+			newServiceImport(namespace1, service1, clusterID2, serviceIP2, portName1, portNumber1, protocol1, mcsv1a1.ClusterSetIP),
+		} {
+			si.Annotations[lhconstants.StaticClustersetIPAnnotation] = staticIP
+			t.lh.ServiceImports.Put(si)
+		}
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
+
+	When("DNS query for a service with a static clusterset IP", func() {
+		It("should consistently write an A record response with the static IP", func() {
+			for i := 0; i < 4; i++ {
+				rec = dnstest.NewRecorder(&test.ResponseWriter{})
+
+				t.executeTestCase(rec, test.Case{
+					Qname: qname,
+					Qtype: dns.TypeA,
+					Rcode: dns.RcodeSuccess,
+					Answer: []dns.RR{
+						test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, staticIP)),
+					},
+				})
+			}
+		})
+	})
+
+	When("DNS query for a service with a static clusterset IP in the local cluster", func() {
+		It("should write an A record response with the static IP rather than the local IP", func() {
+			qname := fmt.Sprintf("%s.%s.%s.svc.clusterset.local.", clusterID, service1, namespace1)
+
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, staticIP)),
+				},
+			})
+		})
+	})
+
+	When("AAAA DNS query for a service with an IPv4 static clusterset IP", func() {
+		It("should write an empty response", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname:  qname,
+				Qtype:  dns.TypeAAAA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: []dns.RR{},
+			})
+		})
+	})
+
+	When("DNS query for a service with an IPv6 static clusterset IP", func() {
+		const staticIPv6 = "fd00::243:1"
+
+		BeforeEach(func() {
+			for _, si := range []*mcsv1a1.ServiceImport{
+				newServiceImport(namespace1, service1, clusterID, serviceIP, portName1, portNumber1, protocol1, mcsv1a1.ClusterSetIP),
+				newServiceImport(namespace1, service1, clusterID2, serviceIP2, portName1, portNumber1, protocol1, mcsv1a1.ClusterSetIP),
+			} {
+				si.Annotations[lhconstants.StaticClustersetIPAnnotation] = staticIPv6
+				t.lh.ServiceImports.Put(si)
+			}
+		})
+
+		It("should write an AAAA record response with the static IP for an AAAA query", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeAAAA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.AAAA(fmt.Sprintf("%s    5    IN    AAAA    %s", qname, staticIPv6)),
+				},
+			})
+		})
+
+		It("should write an empty response for an A query", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname:  qname,
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: []dns.RR{},
+			})
+		})
+	})
+}
+
+func testCappedAnswer() {
+	const service = "service-many"
+
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+	)
+
+	qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service, namespace1)
+
+	clusterIPs := map[string]string{
+		"cluster-a": "100.96.160.1",
+		"cluster-b": "100.96.160.2",
+		"cluster-c": "100.96.160.3",
+		"cluster-d": "100.96.160.4",
+		"cluster-e": "100.96.160.5",
+		"cluster-f": "100.96.160.6",
+	}
+
+	weights := map[string]string{
+		"cluster-b": "5",
+		"cluster-d": "3",
+		"cluster-f": "3",
+	}
+
+	aRecord := func(clusterID string) dns.RR {
+		return test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, clusterIPs[clusterID]))
+	}
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.localClusterID = "cluster-e"
+		t.lh.MaxAnswerClusters = 3
+
+		for clusterID, ip := range clusterIPs {
+			t.mockCs.clusterStatusMap[clusterID] = true
+			t.mockEs.endpointStatusMap[clusterID] = true
+
+			si := newServiceImport(namespace1, service, clusterID, "", portName1, portNumber1, protocol1, mcsv1a1.Headless)
+			if weight, ok := weights[clusterID]; ok {
+				si.Annotations[lhconstants.WeightAnnotation] = weight
+			}
+
+			t.lh.ServiceImports.Put(si)
+			t.lh.EndpointSlices.Put(newEndpointSlice(namespace1, service, clusterID, portName1, []string{hostName1}, []string{ip},
+				portNumber1, protocol1))
+		}
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
+
+	When("DNS query for a headless service exported from more clusters than the cap", func() {
+		It("should only include the local cluster followed by the clusters with the highest weight", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname:  qname,
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: []dns.RR{aRecord("cluster-b"), aRecord("cluster-d"), aRecord("cluster-e")},
+			})
+		})
+	})
+
+	When("the local cluster is disconnected", func() {
+		BeforeEach(func() {
+			t.mockCs.clusterStatusMap["cluster-e"] = false
+		})
+
+		It("should include the clusters with the highest weight", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname:  qname,
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: []dns.RR{aRecord("cluster-b"), aRecord("cluster-d"), aRecord("cluster-f")},
+			})
+		})
+	})
+
+	When("the cap isn't exceeded", func() {
+		BeforeEach(func() {
+			t.lh.MaxAnswerClusters = len(clusterIPs)
+		})
+
+		It("should include all the clusters", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					aRecord("cluster-a"), aRecord("cluster-b"), aRecord("cluster-c"),
+					aRecord("cluster-d"), aRecord("cluster-e"), aRecord("cluster-f"),
+				},
+			})
+		})
+	})
+
+	When("DNS query for a specific cluster that isn't prioritized", func() {
+		It("should write an A record response for that cluster", func() {
+			qname := fmt.Sprintf("%s.%s.%s.svc.clusterset.local.", "cluster-a", service, namespace1)
+
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, clusterIPs["cluster-a"])),
+				},
+			})
+		})
+	})
+}
+
+func testTTLDecay() {
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+		now time.Time
+	)
+
+	qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+
+	executeWithTTL := func(ttl uint32) {
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+
+		t.executeTestCase(rec, test.Case{
+			Qname: qname,
+			Qtype: dns.TypeA,
+			Rcode: dns.RcodeSuccess,
+			Answer: []dns.RR{
+				test.A(fmt.Sprintf("%s    %d    IN    A    %s", qname, ttl, serviceIP)),
+			},
+		})
+	}
+
+	putWithHeartbeat := func(heartbeat time.Time) {
+		si := newServiceImport(namespace1, service1, clusterID, serviceIP, portName1, portNumber1, protocol1, mcsv1a1.ClusterSetIP)
+		si.Annotations[lhconstants.HeartbeatAnnotation] = heartbeat.Format(time.RFC3339)
+		t.lh.ServiceImports.Put(si)
+	}
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+		t.lh.MinTTL = 1
+		t.lh.MaxTTL = 31
+		t.lh.TTLDecayWindow = 5 * time.Minute
+
+		now = time.Now().Truncate(time.Second)
+		t.lh.ServiceImports.SetClock(func() time.Time {
+			return now
+		})
+
+		putWithHeartbeat(now)
+	})
+
+	When("the source cluster's heartbeat is current", func() {
+		It("should answer with the max TTL", func() {
+			executeWithTTL(31)
+		})
+	})
+
+	When("the source cluster's heartbeat ages", func() {
+		It("should shrink the TTL proportionally down to the min TTL", func() {
+			now = now.Add(time.Minute)
+			executeWithTTL(25)
+
+			now = now.Add(90 * time.Second)
+			executeWithTTL(16)
+
+			now = now.Add(150 * time.Second)
+			executeWithTTL(1)
+
+			now = now.Add(time.Hour)
+			executeWithTTL(1)
+		})
+	})
+
+	When("the source cluster's heartbeat resumes", func() {
+		It("should answer with the max TTL again", func() {
+			now = now.Add(10 * time.Minute)
+			executeWithTTL(1)
+
+			putWithHeartbeat(now)
+			executeWithTTL(31)
+		})
+	})
+
+	When("the service is stable and the source cluster keeps publishing heartbeats", func() {
+		It("should keep answering with the max TTL", func() {
+			for i := 0; i < 10; i++ {
+				now = now.Add(time.Minute)
+				putWithHeartbeat(now)
+				executeWithTTL(31)
+			}
+		})
+	})
+
+	When("the source cluster doesn't publish a heartbeat", func() {
+		BeforeEach(func() {
+			t.lh.ServiceImports.Put(newServiceImport(namespace1, service1, clusterID, serviceIP, portName1, portNumber1, protocol1,
+				mcsv1a1.ClusterSetIP))
+		})
+
+		It("should keep answering with the max TTL", func() {
+			now = now.Add(time.Hour)
+			executeWithTTL(31)
+		})
+	})
+
+	When("TTL decay isn't configured", func() {
+		BeforeEach(func() {
+			t.lh.TTLDecayWindow = 0
+		})
+
+		It("should answer with the configured TTL", func() {
+			now = now.Add(10 * time.Minute)
+			executeWithTTL(5)
+		})
+	})
+}
+
+func testAllowedClusters() {
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+	)
+
+	qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+
+	newAllowedServiceImport := func(clusterID, ip, allowed string, siType mcsv1a1.ServiceImportType) *mcsv1a1.ServiceImport {
+		si := newServiceImport(namespace1, service1, clusterID, ip, portName1, portNumber1, protocol1, siType)
+		si.Annotations[lhconstants.AllowedClustersAnnotation] = allowed
+
+		return si
+	}
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockCs.clusterStatusMap[clusterID2] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID2] = true
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
+
+	When("a service is exported from a cluster that allows the local cluster and one that doesn't", func() {
+		BeforeEach(func() {
+			t.lh.ServiceImports.Put(newAllowedServiceImport(clusterID, serviceIP, "other,"+localClusterID, mcsv1a1.ClusterSetIP))
+			t.lh.ServiceImports.Put(newAllowedServiceImport(clusterID2, serviceIP2, "other", mcsv1a1.ClusterSetIP))
+		})
+
+		It("should only resolve the IP from the allowing cluster", func() {
+			for i := 0; i < 4; i++ {
+				rec = dnstest.NewRecorder(&test.ResponseWriter{})
+
+				t.executeTestCase(rec, test.Case{
+					Qname: qname,
+					Qtype: dns.TypeA,
+					Rcode: dns.RcodeSuccess,
+					Answer: []dns.RR{
+						test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+					},
+				})
+			}
+		})
+
+		It("should write a response with NXDOMAIN for the disallowing cluster", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: fmt.Sprintf("%s.%s.%s.svc.clusterset.local.", clusterID2, service1, namespace1),
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+	})
+
+	When("a service is exported only from clusters that don't allow the local cluster", func() {
+		BeforeEach(func() {
+			t.lh.ServiceImports.Put(newAllowedServiceImport(clusterID, serviceIP, "other", mcsv1a1.ClusterSetIP))
+			t.lh.ServiceImports.Put(newAllowedServiceImport(clusterID2, serviceIP2, "other", mcsv1a1.ClusterSetIP))
+		})
+
+		It("should write a response with NXDOMAIN", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+
+		Context("and the allowed clusters are subsequently updated to include the local cluster", func() {
+			It("should resolve the service", func() {
+				t.lh.ServiceImports.Put(newAllowedServiceImport(clusterID, serviceIP, localClusterID, mcsv1a1.ClusterSetIP))
+
+				t.executeTestCase(rec, test.Case{
+					Qname: qname,
+					Qtype: dns.TypeA,
+					Rcode: dns.RcodeSuccess,
+					Answer: []dns.RR{
+						test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+					},
+				})
+			})
+		})
+	})
+
+	When("a headless service is exported from a cluster that allows the local cluster and one that doesn't", func() {
+		BeforeEach(func() {
+			t.lh.ServiceImports = serviceimport.NewMap(localClusterID)
+			t.lh.ServiceImports.Put(newAllowedServiceImport(clusterID, "", localClusterID, mcsv1a1.Headless))
+			t.lh.ServiceImports.Put(newAllowedServiceImport(clusterID2, "", "other", mcsv1a1.Headless))
+			t.lh.EndpointSlices.Put(newEndpointSlice(namespace1, service1, clusterID2, portName1, []string{hostName2},
+				[]string{endpointIP2}, portNumber1, protocol1))
+		})
+
+		It("should only resolve the endpoints from the allowing cluster", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, endpointIP)),
+				},
+			})
+		})
+	})
+}
+
+func testClusterHealth() {
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+	)
+
+	qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+
+	newServiceImportWithHealth := func(clusterID, ip, health string, siType mcsv1a1.ServiceImportType) *mcsv1a1.ServiceImport {
+		si := newServiceImport(namespace1, service1, clusterID, ip, portName1, portNumber1, protocol1, siType)
+		si.Annotations[lhconstants.HealthAnnotation] = health
+
+		return si
+	}
+
+	executeA := func(rcode int, ips ...string) {
+		answer := make([]dns.RR, len(ips))
+		for i, ip := range ips {
+			answer[i] = test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, ip))
+		}
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+		t.executeTestCase(rec, test.Case{
+			Qname:  qname,
+			Qtype:  dns.TypeA,
+			Rcode:  rcode,
+			Answer: answer,
+		})
+	}
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockCs.clusterStatusMap[clusterID2] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID2] = true
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
+
+	When("a cluster exporting a service toggles its health", func() {
+		BeforeEach(func() {
+			t.lh.ServiceImports.Put(newServiceImportWithHealth(clusterID, serviceIP, lhconstants.HealthyValue, mcsv1a1.ClusterSetIP))
+			t.lh.ServiceImports.Put(newServiceImportWithHealth(clusterID2, serviceIP2, lhconstants.UnhealthyValue,
+				mcsv1a1.ClusterSetIP))
+		})
+
+		It("should only include the cluster's IP while it's healthy", func() {
+			for i := 0; i < 4; i++ {
+				executeA(dns.RcodeSuccess, serviceIP)
+			}
+
+			t.lh.ServiceImports.Put(newServiceImportWithHealth(clusterID2, serviceIP2, lhconstants.HealthyValue,
+				mcsv1a1.ClusterSetIP))
+			t.lh.ServiceImports.Put(newServiceImportWithHealth(clusterID, serviceIP, lhconstants.UnhealthyValue,
+				mcsv1a1.ClusterSetIP))
+
+			for i := 0; i < 4; i++ {
+				executeA(dns.RcodeSuccess, serviceIP2)
+			}
+		})
+
+		It("should write an empty response for the unhealthy cluster", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname:  fmt.Sprintf("%s.%s.%s.svc.clusterset.local.", clusterID2, service1, namespace1),
+				Qtype:  dns.TypeA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: []dns.RR{},
+			})
+		})
+
+		It("should report the unhealthy cluster as unreachable", func() {
+			reachability, found := t.lh.GetClusterReachability(namespace1, service1)
+			Expect(found).To(BeTrue())
+			Expect(reachability).To(Equal([]lighthouse.ClusterReachability{
+				{ClusterID: clusterID, Reachable: true},
+				{ClusterID: clusterID2, Reachable: false},
+			}))
+		})
+	})
+
+	When("all the clusters exporting a service are unhealthy", func() {
+		BeforeEach(func() {
+			t.lh.ServiceImports.Put(newServiceImportWithHealth(clusterID, serviceIP, lhconstants.UnhealthyValue, mcsv1a1.ClusterSetIP))
+		})
+
+		It("should write an empty response", func() {
+			executeA(dns.RcodeSuccess)
+		})
+	})
+
+	When("a cluster exporting a headless service toggles its health", func() {
+		BeforeEach(func() {
+			t.lh.ServiceImports = serviceimport.NewMap(localClusterID)
+			t.lh.ServiceImports.Put(newServiceImportWithHealth(clusterID, "", lhconstants.HealthyValue, mcsv1a1.Headless))
+			t.lh.ServiceImports.Put(newServiceImportWithHealth(clusterID2, "", lhconstants.UnhealthyValue, mcsv1a1.Headless))
+			t.lh.EndpointSlices.Put(newEndpointSlice(namespace1, service1, clusterID2, portName1, []string{hostName2},
+				[]string{endpointIP2}, portNumber1, protocol1))
+		})
+
+		It("should only include the cluster's endpoints while it's healthy", func() {
+			executeA(dns.RcodeSuccess, endpointIP)
+
+			t.lh.ServiceImports.Put(newServiceImportWithHealth(clusterID2, "", lhconstants.HealthyValue, mcsv1a1.Headless))
+			executeA(dns.RcodeSuccess, endpointIP, endpointIP2)
+
+			t.lh.ServiceImports.Put(newServiceImportWithHealth(clusterID, "", lhconstants.UnhealthyValue, mcsv1a1.Headless))
+			executeA(dns.RcodeSuccess, endpointIP2)
+		})
+	})
+}
+
+func testMinEndpoints() {
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+	)
+
+	qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockCs.clusterStatusMap[clusterID2] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID2] = true
+
+		t.lh.ServiceImports = serviceimport.NewMap(localClusterID)
+
+		si := newServiceImport(namespace1, service1, clusterID, "", portName1, portNumber1, protocol1, mcsv1a1.Headless)
+		si.Annotations[lhconstants.MinEndpointsAnnotation] = "2"
+		t.lh.ServiceImports.Put(si)
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
+
+	When("the number of ready endpoints clusterset-wide is below the minimum", func() {
+		It("should write a response with NXDOMAIN", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+	})
+
+	When("the number of ready endpoints clusterset-wide crosses above and back below the minimum", func() {
+		It("should resolve the service only while the minimum is met", func() {
+			es := newEndpointSlice(namespace1, service1, clusterID2, portName1, []string{hostName2}, []string{endpointIP2},
+				portNumber1, protocol1)
+			t.lh.EndpointSlices.Put(es)
+
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, endpointIP)),
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, endpointIP2)),
+				},
+			})
+
+			t.lh.EndpointSlices.Remove(es)
+
+			rec = dnstest.NewRecorder(&test.ResponseWriter{})
+
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+	})
+
+	When("a cluster contributing endpoints is disconnected", func() {
+		It("should not count its endpoints", func() {
+			t.lh.EndpointSlices.Put(newEndpointSlice(namespace1, service1, clusterID2, portName1, []string{hostName2},
+				[]string{endpointIP2}, portNumber1, protocol1))
+			t.mockCs.clusterStatusMap[clusterID2] = false
+
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeNameError,
+			})
+		})
+	})
+}
+
+func testLocalClusterZone() {
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+	)
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+
+		// A zone enclosing the local cluster zone.
+		t.lh.Zones = []string{"clusterset.local.", "local."}
+		t.lh.LocalZone = "cluster.local."
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
+
+	When("a query for a clusterset service's name in the local cluster zone is received", func() {
+		BeforeEach(func() {
+			t.lh.Next = test.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+				m := new(dns.Msg)
+				m.SetRcode(r, dns.RcodeBadCookie)
+				_ = w.WriteMsg(m)
+				return dns.RcodeBadCookie, nil
+			})
+		})
+
+		It("should decline it to the next plugin", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: fmt.Sprintf("%s.%s.svc.cluster.local.", service1, namespace1),
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeBadCookie,
+			})
+		})
+
+		It("should decline it regardless of case", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: fmt.Sprintf("%s.%s.svc.Cluster.LOCAL.", service1, namespace1),
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeBadCookie,
+			})
+		})
+
+		It("should still answer the clusterset zone", func() {
+			qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+		})
+	})
+
+	When("a custom local cluster zone is configured and a query in it is received", func() {
+		BeforeEach(func() {
+			t.lh.LocalZone = "east.local."
+			t.lh.Next = test.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+				m := new(dns.Msg)
+				m.SetRcode(r, dns.RcodeBadCookie)
+				_ = w.WriteMsg(m)
+				return dns.RcodeBadCookie, nil
+			})
+		})
+
+		It("should decline it to the next plugin", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: fmt.Sprintf("%s.%s.svc.east.local.", service1, namespace1),
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeBadCookie,
+			})
+		})
+	})
+
+	When("no local cluster zone is configured and the plugin is authoritative for it", func() {
+		BeforeEach(func() {
+			t.lh.LocalZone = ""
+			t.lh.Zones = []string{"cluster.local."}
+		})
+
+		It("should answer queries in it", func() {
+			qname := fmt.Sprintf("%s.%s.svc.cluster.local.", service1, namespace1)
+
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+		})
+	})
+
+	When("a query in the local cluster zone is received and there's no next plugin", func() {
+		It("should not answer it", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: fmt.Sprintf("%s.%s.svc.cluster.local.", service1, namespace1),
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeServerFailure,
+			})
+		})
+	})
+}
+
+func testMergeLocal() {
+	const (
+		localIP   = "10.243.0.9"
+		localIPv6 = "fd00::9"
+	)
+
+	var (
+		rec *dnstest.Recorder
+		t   *handlerTestDriver
+	)
+
+	qname := fmt.Sprintf("%s.%s.svc.clusterset.local.", service1, namespace1)
+
+	BeforeEach(func() {
+		t = newHandlerTestDriver()
+		t.mockCs.clusterStatusMap[clusterID] = true
+		t.mockEs.endpointStatusMap[clusterID] = true
+		t.lh.MergeLocal = true
+
+		// The local cluster's dual-stack Service, one of whose IPs is also in the clusterset answer.
+		t.mockLs.ClusterIPs[getKey(service1, namespace1)] = []string{localIP, serviceIP, localIPv6}
+
+		// Merging mustn't depend on the next plugin.
+		t.lh.Next = test.ErrorHandler()
+
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	})
+
+	When("a type A query for a clusterset service is received", func() {
+		It("should merge the local cluster's IPv4 ClusterIPs into the clusterset answer", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, localIP)),
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+		})
+	})
+
+	When("a type AAAA query for a clusterset service is received", func() {
+		It("should answer with the local cluster's IPv6 ClusterIPs", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeAAAA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.AAAA(fmt.Sprintf("%s    5    IN    AAAA    %s", qname, localIPv6)),
+				},
+			})
+		})
+	})
+
+	When("the local cluster has no Service with the name", func() {
+		BeforeEach(func() {
+			delete(t.mockLs.ClusterIPs, getKey(service1, namespace1))
+		})
+
+		It("should write the clusterset answer only", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+		})
+
+		It("should write an empty answer for a type AAAA query", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname:  qname,
+				Qtype:  dns.TypeAAAA,
+				Rcode:  dns.RcodeSuccess,
+				Answer: []dns.RR{},
+			})
+		})
+	})
+
+	When("a type A query for a specific cluster is received", func() {
+		It("should not merge the local cluster's ClusterIPs", func() {
+			qname := fmt.Sprintf("%s.%s.%s.svc.clusterset.local.", clusterID, service1, namespace1)
+
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+		})
+	})
+
+	When("merging local answers isn't configured", func() {
+		BeforeEach(func() {
+			t.lh.MergeLocal = false
+		})
+
+		It("should not merge the local cluster's ClusterIPs", func() {
+			t.executeTestCase(rec, test.Case{
+				Qname: qname,
+				Qtype: dns.TypeA,
+				Rcode: dns.RcodeSuccess,
+				Answer: []dns.RR{
+					test.A(fmt.Sprintf("%s    5    IN    A    %s", qname, serviceIP)),
+				},
+			})
+		})
+	})
+}
+
+func getClusterReachableMetric(clusterID, service, namespace string) float64 {
+	value, found := findClusterReachableMetric(clusterID, service, namespace)
+	if !found {
+		Fail(fmt.Sprintf("No cluster reachability metric found for cluster %q", clusterID))
+	}
+
+	return value
+}
+
+func findClusterReachableMetric(clusterID, service, namespace string) (float64, bool) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	Expect(err).To(Succeed())
+
+	for _, family := range families {
+		if family.GetName() != lighthouse.ServiceDiscoveryClusterReachableName {
+			continue
+		}
+
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+
+			if labels["destination_cluster"] == clusterID && labels["destination_service_name"] == service &&
+				labels["destination_service_namespace"] == namespace {
+				return m.GetGauge().GetValue(), true
+			}
+		}
+	}
+
+	return 0, false
 }
@@ -0,0 +1,35 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lighthouse
+
+import "github.com/submariner-io/lighthouse/coredns/serviceimport"
+
+// filterHealthyClusters removes the records from clusters whose external health check reported the service unhealthy,
+// even if they have endpoints.
+func (lh *Lighthouse) filterHealthyClusters(pReq *recordRequest, records []serviceimport.DNSRecord) []serviceimport.DNSRecord {
+	healthy := make([]serviceimport.DNSRecord, 0, len(records))
+
+	for i := range records {
+		if lh.ServiceImports.IsClusterHealthy(pReq.namespace, pReq.service, records[i].ClusterName) {
+			healthy = append(healthy, records[i])
+		}
+	}
+
+	return healthy
+}
@@ -0,0 +1,133 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lighthouse
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coredns/caddy"
+	"github.com/pkg/errors"
+)
+
+const reachabilityPath = "/reachability"
+
+// ServiceReachability describes the reachability of the clusters contributing to an imported service.
+type ServiceReachability struct {
+	Namespace string                `json:"namespace"`
+	Name      string                `json:"name"`
+	Clusters  []ClusterReachability `json:"clusters"`
+}
+
+// GetAllClusterReachability returns the last published reachability of the clusters of the imported services, sorted by
+// namespace and name, optionally restricted to the given namespace and name if not empty.
+func (lh *Lighthouse) GetAllClusterReachability(namespace, name string) []ServiceReachability {
+	lh.reachabilityMutex.Lock()
+	defer lh.reachabilityMutex.Unlock()
+
+	result := []ServiceReachability{}
+
+	for key, clusters := range lh.reachability {
+		svcNamespace, svcName, _ := strings.Cut(key, "/")
+		if (namespace != "" && namespace != svcNamespace) || (name != "" && name != svcName) {
+			continue
+		}
+
+		result = append(result, ServiceReachability{
+			Namespace: svcNamespace,
+			Name:      svcName,
+			Clusters:  append([]ClusterReachability(nil), clusters...),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// IntrospectionHandler returns the HTTP handler serving the reachability of the clusters of the imported services as JSON
+// on the "/reachability" path, optionally restricted by the "namespace" and "name" query parameters.
+func (lh *Lighthouse) IntrospectionHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(reachabilityPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(lh.GetAllClusterReachability(query.Get("namespace"), query.Get("name"))); err != nil {
+			log.Errorf("Error writing the cluster reachability: %v", err)
+		}
+	})
+
+	return mux
+}
+
+// parseIntrospect parses the "introspect ADDRESS" directive, eg "introspect :9154", which serves the introspection
+// endpoint on the given address.
+func parseIntrospect(c *caddy.Controller) (string, error) {
+	args := c.RemainingArgs()
+	if len(args) != 1 {
+		return "", c.ArgErr() // nolint:wrapcheck // No need to wrap this.
+	}
+
+	if _, _, err := net.SplitHostPort(args[0]); err != nil {
+		return "", c.Errf("invalid introspect address %q: %v", args[0], err) // nolint:wrapcheck // No need to wrap this.
+	}
+
+	return args[0], nil
+}
+
+// startIntrospection serves the introspection endpoint on the given address while the server is running.
+func startIntrospection(c *caddy.Controller, lh *Lighthouse, address string) {
+	server := &http.Server{Addr: address, Handler: lh.IntrospectionHandler(), ReadHeaderTimeout: 10 * time.Second}
+
+	c.OnStartup(func() error {
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			return errors.Wrapf(err, "error listening on the introspect address %q", address)
+		}
+
+		go func() {
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Errorf("Error serving the introspection endpoint: %v", err)
+			}
+		}()
+
+		return nil
+	})
+
+	c.OnShutdown(func() error {
+		return server.Close() // nolint:wrapcheck // No need to wrap this.
+	})
+}
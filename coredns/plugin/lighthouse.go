@@ -20,6 +20,9 @@ package lighthouse
 
 import (
 	"errors"
+	"net"
+	"sync"
+	"time"
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/pkg/fall"
@@ -41,15 +44,30 @@ var errInvalidRequest = errors.New("invalid query name")
 var log = clog.NewWithPlugin(PluginName)
 
 type Lighthouse struct {
-	Next            plugin.Handler
-	Fall            fall.F
-	Zones           []string
-	TTL             uint32
-	ServiceImports  *serviceimport.Map
-	EndpointSlices  *endpointslice.Map
-	ClusterStatus   ClusterStatus
-	EndpointsStatus EndpointsStatus
-	LocalServices   LocalServices
+	Next              plugin.Handler
+	Fall              fall.F
+	Zones             []string
+	LocalZone         string
+	TTL               uint32
+	ClustersetGroup   string
+	ReadyOnly         bool
+	ShortNames        bool
+	ClusterSubzones   bool
+	MergeLocal        bool
+	MaxAnswerClusters int
+	MinTTL            uint32
+	MaxTTL            uint32
+	TTLDecayWindow    time.Duration
+	ApexIPs           []net.IP
+	Policy            serviceimport.Policy
+	ServiceImports    *serviceimport.Map
+	EndpointSlices    *endpointslice.Map
+	ClusterStatus     ClusterStatus
+	EndpointsStatus   EndpointsStatus
+	LocalServices     LocalServices
+	ServiceAliases    ServiceAliases
+	reachabilityMutex sync.Mutex
+	reachability      map[string][]ClusterReachability
 }
 
 type ClusterStatus interface {
@@ -59,6 +77,11 @@ type ClusterStatus interface {
 
 type LocalServices interface {
 	GetIP(name, namespace string) (*serviceimport.DNSRecord, bool)
+	GetClusterIPs(name, namespace string) []string
+}
+
+type ServiceAliases interface {
+	GetTarget(name, namespace string) (targetName, targetNamespace string, found bool)
 }
 
 type EndpointsStatus interface {
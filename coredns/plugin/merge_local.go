@@ -0,0 +1,72 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lighthouse
+
+import (
+	"net"
+
+	"github.com/coredns/coredns/request"
+	"github.com/miekg/dns"
+)
+
+// mergeLocalRecords augments the A or AAAA records of a clusterset answer with the ClusterIPs of the local cluster's
+// Service of the same name, retrieved from the local Service informer, so it doesn't depend on the next plugin being the
+// kubernetes plugin. The local records are named as queried and IPs already in the answer aren't repeated.
+func (lh *Lighthouse) mergeLocalRecords(state *request.Request, pReq *recordRequest, records []dns.RR) []dns.RR {
+	ips := lh.LocalServices.GetClusterIPs(pReq.service, pReq.namespace)
+	if len(ips) == 0 {
+		log.Debugf("No local cluster Service found for %s/%s", pReq.namespace, pReq.service)
+		return records
+	}
+
+	seen := map[string]bool{}
+
+	for _, rr := range records {
+		switch r := rr.(type) {
+		case *dns.A:
+			seen[r.A.String()] = true
+		case *dns.AAAA:
+			seen[r.AAAA.String()] = true
+		}
+	}
+
+	hdr := dns.RR_Header{Name: state.QName(), Rrtype: state.QType(), Class: state.QClass(), Ttl: lh.TTL}
+
+	for _, s := range ips {
+		ip := net.ParseIP(s)
+		if ip == nil || seen[ip.String()] {
+			continue
+		}
+
+		isIPv4 := ip.To4() != nil
+
+		switch {
+		case state.QType() == dns.TypeA && isIPv4:
+			records = append(records, &dns.A{Hdr: hdr, A: ip.To4()})
+		case state.QType() == dns.TypeAAAA && !isIPv4:
+			records = append(records, &dns.AAAA{Hdr: hdr, AAAA: ip})
+		default:
+			continue
+		}
+
+		seen[ip.String()] = true
+	}
+
+	return records
+}
@@ -30,10 +30,14 @@ const (
 	dstSvcIPKey        = "destination_service_ip"
 	dstSvcNamespaceKey = "destination_service_namespace"
 
-	ServiceDiscoveryQueryCounterName = "submariner_service_discovery_query"
+	ServiceDiscoveryQueryCounterName     = "submariner_service_discovery_query"
+	ServiceDiscoveryClusterReachableName = "submariner_service_discovery_cluster_reachable"
 )
 
-var dnsQueryCounter *prometheus.GaugeVec
+var (
+	dnsQueryCounter       *prometheus.GaugeVec
+	clusterReachableGauge *prometheus.GaugeVec
+)
 
 func init() {
 	klog.Infof("Initializing dns query counter")
@@ -46,7 +50,15 @@ func init() {
 		[]string{srcClusterKey, dstClusterKey, dstSvcNameKey, dstSvcNamespaceKey, dstSvcIPKey},
 	)
 
-	prometheus.MustRegister(dnsQueryCounter)
+	clusterReachableGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: ServiceDiscoveryClusterReachableName,
+			Help: "Whether a cluster contributing to an imported service is reachable",
+		},
+		[]string{dstClusterKey, dstSvcNameKey, dstSvcNamespaceKey},
+	)
+
+	prometheus.MustRegister(dnsQueryCounter, clusterReachableGauge)
 }
 
 func incDNSQueryCounter(srcCluster, dstCluster, dstSvcName, dstSvcNamespace, dstSvcIP string) {
@@ -60,3 +72,24 @@ func incDNSQueryCounter(srcCluster, dstCluster, dstSvcName, dstSvcNamespace, dst
 
 	dnsQueryCounter.With(labels).Inc()
 }
+
+func setClusterReachable(dstCluster, dstSvcName, dstSvcNamespace string, reachable bool) {
+	value := 0.0
+	if reachable {
+		value = 1
+	}
+
+	clusterReachableGauge.With(prometheus.Labels{
+		dstClusterKey:      dstCluster,
+		dstSvcNameKey:      dstSvcName,
+		dstSvcNamespaceKey: dstSvcNamespace,
+	}).Set(value)
+}
+
+func deleteClusterReachable(dstCluster, dstSvcName, dstSvcNamespace string) {
+	clusterReachableGauge.Delete(prometheus.Labels{
+		dstClusterKey:      dstCluster,
+		dstSvcNameKey:      dstSvcName,
+		dstSvcNamespaceKey: dstSvcNamespace,
+	})
+}
@@ -30,7 +30,7 @@ type recordRequest struct {
 	// The named port from the kubernetes DNS spec, this is the service part (think _https) from a well formed
 	// SRV record.
 	port string
-	// The protocol is usually _udp or _tcp (if set), and comes from the protocol part of a well formed
+	// The protocol is _tcp, _udp or _sctp (if set), and comes from the protocol part of a well formed
 	// SRV record.
 	protocol string
 	// The hostname referring to individual pod backing a headless multiclusterservice.
@@ -104,6 +104,23 @@ func parseRequest(state *request.Request) (*recordRequest, error) {
 	return parseSegments(segs, last, r, state.QType())
 }
 
+// parseShortNameRequest parses a short name query, ie "service.namespace.zone" without the "svc" label, as an alias for
+// the canonical "service.namespace.svc.zone". Returns nil if the qname isn't a short name.
+func parseShortNameRequest(state *request.Request) *recordRequest {
+	base, _ := dnsutil.TrimZone(state.Name(), state.Zone)
+
+	segs := dns.SplitDomainName(base)
+	if len(segs) != 2 || segs[1] == Svc || segs[1] == Pod {
+		return nil
+	}
+
+	return &recordRequest{
+		service:   segs[0],
+		namespace: segs[1],
+		podOrSvc:  Svc,
+	}
+}
+
 // String return a string representation of r, it just returns all fields concatenated with dots.
 // This is mostly used in tests.
 func (r *recordRequest) String() string {
@@ -119,13 +136,22 @@ func (r *recordRequest) String() string {
 func parseSegments(segs []string, count int, r *recordRequest, qType uint16) (*recordRequest, error) {
 	// Because of ambiguity we check the labels left: 1: a cluster. 2: hostname and cluster.
 	// Anything else is a query that is too long to answer and can safely be delegated to return an nxdomain.
+	// An A query may also embed a named port, as for SRV, to only return the endpoints that expose it. A hostname can't start
+	// with an underscore so the labels are unambiguous.
 	if qType == dns.TypeA {
-		switch count {
-		case 0: // cluster only
+		switch {
+		case count == 0: // cluster only
 			r.cluster = segs[count]
-		case 1: // cluster and hostname
+		case count == 1 && isPortLabel(segs[count-1]) && isPortLabel(segs[count]): // port only
+			r.protocol = stripUnderscore(segs[count])
+			r.port = stripUnderscore(segs[count-1])
+		case count == 1: // cluster and hostname
 			r.cluster = segs[count]
 			r.hostname = segs[count-1]
+		case count == 2 && isPortLabel(segs[count-2]) && isPortLabel(segs[count-1]): // cluster and port
+			r.cluster = segs[count]
+			r.protocol = stripUnderscore(segs[count-1])
+			r.port = stripUnderscore(segs[count-2])
 		default: // too long
 			return r, errInvalidRequest
 		}
@@ -149,6 +175,11 @@ func parseSegments(segs []string, count int, r *recordRequest, qType uint16) (*r
 	return r, nil
 }
 
+// isPortLabel returns whether s is a port or protocol label, ie prefixed with an underscore.
+func isPortLabel(s string) bool {
+	return s != "" && s[0] == '_'
+}
+
 // stripUnderscore removes a prefixed underscore from s.
 func stripUnderscore(s string) string {
 	if s[0] != '_' {
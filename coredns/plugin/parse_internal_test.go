@@ -71,6 +71,30 @@ func testParseValid() {
 			Expect(r.String()).Should(Equal(tc.expected))
 		})
 	})
+	When("An A request of a named port", func() {
+		It("Should parse the port and protocol", func() {
+			m := new(dns.Msg)
+			m.SetQuestion("_http._tcp.webs.mynamespace.svc.inter.webs.tests.", dns.TypeA)
+			state := &request.Request{Zone: zone, Req: m}
+			r, e := parseRequest(state)
+			Expect(e).NotTo(HaveOccurred())
+			Expect(r.String()).Should(Equal("..webs.mynamespace.svc"))
+			Expect(r.port).To(Equal("http"))
+			Expect(r.protocol).To(Equal("tcp"))
+		})
+	})
+	When("An A request of a named port in a cluster", func() {
+		It("Should parse the port, protocol and cluster", func() {
+			m := new(dns.Msg)
+			m.SetQuestion("_http._tcp.cluster1.webs.mynamespace.svc.inter.webs.tests.", dns.TypeA)
+			state := &request.Request{Zone: zone, Req: m}
+			r, e := parseRequest(state)
+			Expect(e).NotTo(HaveOccurred())
+			Expect(r.String()).Should(Equal(".cluster1.webs.mynamespace.svc"))
+			Expect(r.port).To(Equal("http"))
+			Expect(r.protocol).To(Equal("tcp"))
+		})
+	})
 	When("Wildcard request", func() {
 		It("Should give no error", func() {
 			tc := parseTest{"*.any.*.any.svc.inter.webs.tests.", "*.any.*.any.svc"}
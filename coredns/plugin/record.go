@@ -19,12 +19,14 @@ limitations under the License.
 package lighthouse
 
 import (
+	"fmt"
 	"net"
 	"strings"
 
 	"github.com/coredns/coredns/request"
 	"github.com/miekg/dns"
 	"github.com/submariner-io/lighthouse/coredns/serviceimport"
+	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
 )
 
@@ -32,11 +34,32 @@ func (lh *Lighthouse) createARecords(dnsrecords []serviceimport.DNSRecord, state
 	records := make([]dns.RR, 0)
 
 	for _, record := range dnsrecords {
-		dnsRecord := &dns.A{Hdr: dns.RR_Header{
-			Name: state.QName(), Rrtype: dns.TypeA, Class: state.QClass(),
-			Ttl: lh.TTL,
-		}, A: net.ParseIP(record.IP).To4()}
-		records = append(records, dnsRecord)
+		// An FQDN-only endpoint has no IP so answer with a CNAME to its FQDN.
+		if record.ExternalName != "" {
+			records = append(records, &dns.CNAME{Hdr: dns.RR_Header{
+				Name: state.QName(), Rrtype: dns.TypeCNAME, Class: state.QClass(),
+				Ttl: lh.TTL,
+			}, Target: dns.Fqdn(record.ExternalName)})
+
+			continue
+		}
+
+		// Only a static clusterset IP may be IPv6 so answer with the records of the queried address family.
+		ip := net.ParseIP(record.IP)
+		ip4 := ip.To4()
+
+		switch {
+		case state.QType() != dns.TypeAAAA && ip4 != nil:
+			records = append(records, &dns.A{Hdr: dns.RR_Header{
+				Name: state.QName(), Rrtype: dns.TypeA, Class: state.QClass(),
+				Ttl: lh.TTL,
+			}, A: ip4})
+		case state.QType() == dns.TypeAAAA && ip != nil && ip4 == nil:
+			records = append(records, &dns.AAAA{Hdr: dns.RR_Header{
+				Name: state.QName(), Rrtype: dns.TypeAAAA, Class: state.QClass(),
+				Ttl: lh.TTL,
+			}, AAAA: ip})
+		}
 	}
 
 	return records
@@ -48,25 +71,11 @@ func (lh *Lighthouse) createSRVRecords(dnsrecords []serviceimport.DNSRecord, sta
 	var records []dns.RR
 
 	for _, dnsRecord := range dnsrecords {
-		var reqPorts []v1alpha1.ServicePort
-
-		if pReq.port == "" {
-			reqPorts = dnsRecord.Ports
-		} else {
-			log.Debugf("Requested port %q, protocol %q for SRV", pReq.port, pReq.protocol)
-			for _, port := range dnsRecord.Ports {
-				name := strings.ToLower(port.Name)
-				protocol := strings.ToLower(string(port.Protocol))
-
-				log.Debugf("Checking port %q, protocol %q", name, protocol)
-				if name == pReq.port && protocol == pReq.protocol {
-					reqPorts = append(reqPorts, port)
-				}
-			}
-		}
+		reqPorts := requestedPorts(&dnsRecord, pReq)
 
+		// The endpoints in another cluster may still have the requested port, eg if the clusters' ports differ.
 		if len(reqPorts) == 0 {
-			return nil
+			continue
 		}
 
 		target := pReq.service + "." + pReq.namespace + ".svc." + zone
@@ -81,6 +90,10 @@ func (lh *Lighthouse) createSRVRecords(dnsrecords []serviceimport.DNSRecord, sta
 			target = dnsRecord.HostName + "." + target
 		}
 
+		if dnsRecord.ExternalName != "" {
+			target = dns.Fqdn(dnsRecord.ExternalName)
+		}
+
 		for _, port := range reqPorts {
 			record := &dns.SRV{
 				Hdr:      dns.RR_Header{Name: state.QName(), Rrtype: dns.TypeSRV, Class: state.QClass(), Ttl: lh.TTL},
@@ -96,16 +109,92 @@ func (lh *Lighthouse) createSRVRecords(dnsrecords []serviceimport.DNSRecord, sta
 	return records
 }
 
-func (lh *Lighthouse) getClusterIPForSvc(pReq *recordRequest) (*serviceimport.DNSRecord, bool) {
+// requestedPorts returns the ports of the record matching the named port of the request, if any, otherwise all its ports.
+func requestedPorts(dnsRecord *serviceimport.DNSRecord, pReq *recordRequest) []v1alpha1.ServicePort {
+	if pReq.port == "" {
+		return dnsRecord.Ports
+	}
+
+	log.Debugf("Requested port %q, protocol %q", pReq.port, pReq.protocol)
+
+	var reqPorts []v1alpha1.ServicePort
+
+	for _, port := range dnsRecord.Ports {
+		name := strings.ToLower(port.Name)
+		protocol := protocolLabel(port.Protocol)
+
+		log.Debugf("Checking port %q, protocol %q", name, protocol)
+
+		if name == pReq.port && protocol == pReq.protocol {
+			reqPorts = append(reqPorts, port)
+		}
+	}
+
+	return reqPorts
+}
+
+// filterByPort returns the records that expose the named port of the request, if any. The endpoints of a multi-port
+// headless service may not all expose all its ports.
+func filterByPort(dnsRecords []serviceimport.DNSRecord, pReq *recordRequest) []serviceimport.DNSRecord {
+	if pReq.port == "" {
+		return dnsRecords
+	}
+
+	filtered := make([]serviceimport.DNSRecord, 0, len(dnsRecords))
+
+	for i := range dnsRecords {
+		if len(requestedPorts(&dnsRecords[i], pReq)) > 0 {
+			filtered = append(filtered, dnsRecords[i])
+		}
+	}
+
+	return filtered
+}
+
+func (lh *Lighthouse) getClusterIPForSvc(pReq *recordRequest, client string) (*serviceimport.DNSRecord, bool) {
 	localClusterID := lh.ClusterStatus.LocalClusterID()
 
-	record, found, isLocal := lh.ServiceImports.GetIP(pReq.namespace, pReq.service, pReq.cluster, localClusterID, lh.ClusterStatus.IsConnected,
-		lh.EndpointsStatus.IsHealthy)
+	record, found, isLocal := lh.ServiceImports.GetIPForClient(pReq.namespace, pReq.service, pReq.cluster, localClusterID, client,
+		lh.ClusterStatus.IsConnected, lh.EndpointsStatus.IsHealthy)
 
-	getLocal := isLocal || pReq.cluster != "" && pReq.cluster == localClusterID
-	if found && getLocal {
+	if found && isLocal {
 		record, found = lh.LocalServices.GetIP(pReq.service, pReq.namespace)
 	}
 
 	return record, found
 }
+
+// hasIPv6Record checks if any of the records has an IPv6 address.
+func hasIPv6Record(dnsRecords []serviceimport.DNSRecord) bool {
+	for i := range dnsRecords {
+		if ip := net.ParseIP(dnsRecords[i].IP); ip != nil && ip.To4() == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clientKey returns the key identifying the client of a query for consistent hashing, ie the EDNS Client Subnet if the
+// query carries one, otherwise the query's source IP.
+func clientKey(state *request.Request) string {
+	if opt := state.Req.IsEdns0(); opt != nil {
+		for _, o := range opt.Option {
+			if subnet, ok := o.(*dns.EDNS0_SUBNET); ok {
+				return fmt.Sprintf("%s/%d", subnet.Address, subnet.SourceNetmask)
+			}
+		}
+	}
+
+	return state.IP()
+}
+
+// protocolLabel returns the SRV protocol label, without the leading underscore, for a port protocol, eg "sctp" for
+// SCTP. As for Kubernetes Services, the protocol defaults to TCP if not specified.
+func protocolLabel(protocol corev1.Protocol) string {
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+
+	return strings.ToLower(string(protocol))
+}
@@ -0,0 +1,51 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lighthouse
+
+// resolveServiceAlias returns the request for the target service if the requested service is an alias declared by a
+// ServiceAlias, otherwise the given request. A service that's actually exported takes precedence over an alias of the same
+// name and aliases aren't chained. If the alias was retargeted, eg to re-home the service to another namespace, and the new
+// target isn't exported yet, the previous target is resolved so there's no resolution gap.
+func (lh *Lighthouse) resolveServiceAlias(pReq *recordRequest) *recordRequest {
+	if lh.ServiceAliases == nil || lh.ServiceImports.Contains(pReq.namespace, pReq.service) {
+		return pReq
+	}
+
+	name, namespace, found := lh.ServiceAliases.GetTarget(pReq.service, pReq.namespace)
+	if !found {
+		return pReq
+	}
+
+	if !lh.ServiceImports.Contains(namespace, name) {
+		if prevNamespace, prevName, found := lh.ServiceImports.GetPredecessor(namespace, name); found {
+			log.Debugf("The target %s/%s of alias %s/%s isn't exported yet - resolving its predecessor %s/%s", namespace, name,
+				pReq.namespace, pReq.service, prevNamespace, prevName)
+
+			name, namespace = prevName, prevNamespace
+		}
+	}
+
+	log.Debugf("Resolving alias %s/%s to service %s/%s", pReq.namespace, pReq.service, namespace, name)
+
+	aReq := *pReq
+	aReq.service = name
+	aReq.namespace = namespace
+
+	return &aReq
+}
@@ -25,11 +25,14 @@ import (
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
+	"github.com/miekg/dns"
 	"github.com/pkg/errors"
 	"github.com/submariner-io/lighthouse/coredns/endpointslice"
 	"github.com/submariner-io/lighthouse/coredns/gateway"
 	"github.com/submariner-io/lighthouse/coredns/service"
+	"github.com/submariner-io/lighthouse/coredns/servicealias"
 	"github.com/submariner-io/lighthouse/coredns/serviceimport"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 )
@@ -75,6 +78,127 @@ func lighthouseParse(c *caddy.Controller) (*Lighthouse, error) {
 		return nil, errors.Wrap(err, "error building kubeconfig")
 	}
 
+	lh := &Lighthouse{TTL: defaultTTL, Policy: serviceimport.DefaultPolicy()}
+	introspectAddress := ""
+
+	// Changed `for` to `if` to satisfy golint:
+	//	 SA4004: the surrounding loop is unconditionally terminated (staticcheck)
+	if c.Next() {
+		lh.Zones = c.RemainingArgs()
+		if len(lh.Zones) == 0 {
+			lh.Zones = make([]string, len(c.ServerBlockKeys))
+			copy(lh.Zones, c.ServerBlockKeys)
+		}
+
+		for i, str := range lh.Zones {
+			lh.Zones[i] = plugin.Host(str).Normalize()
+		}
+
+		for c.NextBlock() {
+			switch c.Val() {
+			case "fallthrough":
+				lh.Fall.SetZonesFromArgs(c.RemainingArgs())
+			case "ttl":
+				t, err := parseTTL(c)
+				if err != nil {
+					return nil, err
+				}
+
+				lh.TTL = t
+			case "clusterset_group":
+				g, err := parseClustersetGroup(c)
+				if err != nil {
+					return nil, err
+				}
+
+				lh.ClustersetGroup = g
+			case "ready_only":
+				if c.NextArg() {
+					return nil, c.ArgErr() // nolint:wrapcheck // No need to wrap this.
+				}
+
+				lh.ReadyOnly = true
+			case "short_names":
+				if c.NextArg() {
+					return nil, c.ArgErr() // nolint:wrapcheck // No need to wrap this.
+				}
+
+				lh.ShortNames = true
+			case "cluster_subzones":
+				if c.NextArg() {
+					return nil, c.ArgErr() // nolint:wrapcheck // No need to wrap this.
+				}
+
+				lh.ClusterSubzones = true
+			case "merge_local":
+				if c.NextArg() {
+					return nil, c.ArgErr() // nolint:wrapcheck // No need to wrap this.
+				}
+
+				lh.MergeLocal = true
+			case "apex_answer":
+				ips, err := parseApexAnswer(c)
+				if err != nil {
+					return nil, err
+				}
+
+				lh.ApexIPs = ips
+			case "policy":
+				p, err := parsePolicy(c)
+				if err != nil {
+					return nil, err
+				}
+
+				lh.Policy = p
+			case "max_answer_clusters":
+				n, err := parseMaxAnswerClusters(c)
+				if err != nil {
+					return nil, err
+				}
+
+				lh.MaxAnswerClusters = n
+			case "local_zone":
+				z, err := parseLocalZone(c)
+				if err != nil {
+					return nil, err
+				}
+
+				lh.LocalZone = z
+			case "ttl_decay":
+				minTTL, maxTTL, window, err := parseTTLDecay(c)
+				if err != nil {
+					return nil, err
+				}
+
+				lh.MinTTL, lh.MaxTTL, lh.TTLDecayWindow = minTTL, maxTTL, window
+			case "introspect":
+				a, err := parseIntrospect(c)
+				if err != nil {
+					return nil, err
+				}
+
+				introspectAddress = a
+			default:
+				if c.Val() != "}" {
+					return nil, c.Errf("unknown property '%s'", c.Val()) // nolint:wrapcheck // No need to wrap this.
+				}
+			}
+		}
+
+		for _, zone := range lh.Zones {
+			if lh.LocalZone != "" && dns.IsSubDomain(lh.LocalZone, zone) {
+				return nil, c.Errf("zone %q is within the local cluster zone %q", // nolint:wrapcheck // No need to wrap this.
+					zone, lh.LocalZone)
+			}
+		}
+
+		if lh.ShortNames {
+			if err := validateShortNameZones(lh.Zones); err != nil {
+				return nil, c.Err(err.Error()) // nolint:wrapcheck // No need to wrap this.
+			}
+		}
+	}
+
 	gwController := gateway.NewController()
 
 	err = gwController.Start(cfg)
@@ -82,17 +206,31 @@ func lighthouseParse(c *caddy.Controller) (*Lighthouse, error) {
 		return nil, errors.Wrap(err, "error starting the Gateway controller")
 	}
 
+	kubeClient := kubernetes.NewForConfigOrDie(cfg)
 	siMap := serviceimport.NewMap(gwController.LocalClusterID())
+	siMap.SetPolicy(lh.Policy)
+	epMap := endpointslice.NewMap(gwController.LocalClusterID(), kubeClient)
+
+	lh.ServiceImports = siMap
+	lh.ClusterStatus = gwController
+	lh.EndpointSlices = epMap
+
+	// The cluster reachability is updated as the ServiceImports, EndpointSlices and connection status change so the maps
+	// must be set on the Lighthouse before the controllers are started.
+	siMap.SetChangeHandler(lh.UpdateClusterReachability)
+	epMap.SetChangeHandler(lh.UpdateClusterReachability)
+	gwController.SetChangeHandler(lh.UpdateAllClusterReachability)
+
 	siController := serviceimport.NewController(siMap)
+	siController.ClustersetGroup = lh.ClustersetGroup
 
 	err = siController.Start(cfg)
 	if err != nil {
 		return nil, errors.Wrap(err, "error starting the ServiceImport controller")
 	}
 
-	kubeClient := kubernetes.NewForConfigOrDie(cfg)
-	epMap := endpointslice.NewMap(gwController.LocalClusterID(), kubeClient)
 	epController := endpointslice.NewController(epMap)
+	epController.ClustersetGroup = lh.ClustersetGroup
 
 	err = epController.Start(cfg)
 	if err != nil {
@@ -106,49 +244,31 @@ func lighthouseParse(c *caddy.Controller) (*Lighthouse, error) {
 		return nil, errors.Wrap(err, "error starting the Service controller")
 	}
 
+	aliasController := servicealias.NewController()
+	aliasController.OnRetarget = func(oldName, oldNamespace, newName, newNamespace string) {
+		siMap.RetainUntil(oldNamespace, oldName, newNamespace, newName)
+	}
+
+	err = aliasController.Start(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "error starting the ServiceAlias controller")
+	}
+
 	c.OnShutdown(func() error {
 		siController.Stop()
 		epController.Stop()
 		gwController.Stop()
 		svcController.Stop()
+		aliasController.Stop()
 		return nil
 	})
 
-	lh := &Lighthouse{
-		TTL: defaultTTL, ServiceImports: siMap, ClusterStatus: gwController, EndpointSlices: epMap,
-		EndpointsStatus: epController, LocalServices: svcController,
-	}
-
-	// Changed `for` to `if` to satisfy golint:
-	//	 SA4004: the surrounding loop is unconditionally terminated (staticcheck)
-	if c.Next() {
-		lh.Zones = c.RemainingArgs()
-		if len(lh.Zones) == 0 {
-			lh.Zones = make([]string, len(c.ServerBlockKeys))
-			copy(lh.Zones, c.ServerBlockKeys)
-		}
+	lh.EndpointsStatus = epController
+	lh.LocalServices = svcController
+	lh.ServiceAliases = aliasController
 
-		for i, str := range lh.Zones {
-			lh.Zones[i] = plugin.Host(str).Normalize()
-		}
-
-		for c.NextBlock() {
-			switch c.Val() {
-			case "fallthrough":
-				lh.Fall.SetZonesFromArgs(c.RemainingArgs())
-			case "ttl":
-				t, err := parseTTL(c)
-				if err != nil {
-					return nil, err
-				}
-
-				lh.TTL = t
-			default:
-				if c.Val() != "}" {
-					return nil, c.Errf("unknown property '%s'", c.Val()) // nolint:wrapcheck // No need to wrap this.
-				}
-			}
-		}
+	if introspectAddress != "" {
+		startIntrospection(c, lh, introspectAddress)
 	}
 
 	return lh, nil
@@ -173,6 +293,77 @@ func parseTTL(c *caddy.Controller) (uint32, error) {
 	return uint32(t), nil
 }
 
+func parseClustersetGroup(c *caddy.Controller) (string, error) {
+	args := c.RemainingArgs()
+	if len(args) != 1 {
+		return "", c.ArgErr() // nolint:wrapcheck // No need to wrap this.
+	}
+
+	if errs := validation.IsDNS1123Label(args[0]); len(errs) > 0 {
+		return "", c.Errf("invalid clusterset_group %q: %v", args[0], errs) // nolint:wrapcheck // No need to wrap this.
+	}
+
+	return args[0], nil
+}
+
+// parseLocalZone parses the "local_zone ZONE" directive, eg "local_zone cluster.local", which sets the zone of the local
+// cluster's Services so queries in it are always passed on to the next plugin.
+func parseLocalZone(c *caddy.Controller) (string, error) {
+	args := c.RemainingArgs()
+	if len(args) != 1 {
+		return "", c.ArgErr() // nolint:wrapcheck // No need to wrap this.
+	}
+
+	zone := plugin.Host(args[0]).Normalize()
+	if zone == "." {
+		return "", c.Errf("invalid local_zone %q: it can't be the root zone", args[0]) // nolint:wrapcheck // No need to wrap this.
+	}
+
+	return zone, nil
+}
+
+func parsePolicy(c *caddy.Controller) (serviceimport.Policy, error) {
+	args := c.RemainingArgs()
+	if len(args) != 1 {
+		return nil, c.ArgErr() // nolint:wrapcheck // No need to wrap this.
+	}
+
+	p, err := serviceimport.NewPolicy(args[0])
+	if err != nil {
+		return nil, c.Errf("invalid policy: %v", err) // nolint:wrapcheck // No need to wrap this.
+	}
+
+	return p, nil
+}
+
+func parseMaxAnswerClusters(c *caddy.Controller) (int, error) {
+	args := c.RemainingArgs()
+	if len(args) != 1 {
+		return 0, c.ArgErr() // nolint:wrapcheck // No need to wrap this.
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, c.Errf("max_answer_clusters must be a positive integer: %q", args[0]) // nolint:wrapcheck // No need to wrap this.
+	}
+
+	return n, nil
+}
+
+// validateShortNameZones checks that no zone is a subdomain of another zone as a short name, ie
+// "<service>.<namespace>.<zone>", could then be ambiguous with a name in the subdomain zone.
+func validateShortNameZones(zones []string) error {
+	for _, parent := range zones {
+		for _, child := range zones {
+			if parent != child && dns.IsSubDomain(parent, child) {
+				return errors.Errorf("short_names can't be used with zone %q as it's a subdomain of zone %q", child, parent)
+			}
+		}
+	}
+
+	return nil
+}
+
 func init() {
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
 	flag.StringVar(&masterURL, "master", "",
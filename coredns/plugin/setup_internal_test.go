@@ -21,6 +21,7 @@ package lighthouse
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
@@ -30,6 +31,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/submariner-io/lighthouse/coredns/endpointslice"
 	"github.com/submariner-io/lighthouse/coredns/gateway"
+	"github.com/submariner-io/lighthouse/coredns/servicealias"
 	"github.com/submariner-io/lighthouse/coredns/serviceimport"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -66,6 +68,12 @@ var _ = Describe("Plugin setup", func() {
 			}), nil
 		}
 
+		servicealias.NewClientset = func(c *rest.Config) (dynamic.Interface, error) {
+			return fakeClient.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+				servicealias.GVR: "ServiceAliasList",
+			}), nil
+		}
+
 		serviceimport.NewClientset = func(kubeConfig *rest.Config) (mcsClientset.Interface, error) {
 			return fakeMCSClientset.NewSimpleClientset(), nil
 		}
@@ -77,6 +85,7 @@ var _ = Describe("Plugin setup", func() {
 
 	AfterEach(func() {
 		gateway.NewClientset = nil
+		servicealias.NewClientset = nil
 	})
 
 	Context("Parsing correct configurations", testCorrectConfig)
@@ -149,6 +158,142 @@ func testCorrectConfig() {
 		})
 	})
 
+	When("clusterset_group argument is specified", func() {
+		BeforeEach(func() {
+			config = `lighthouse {
+			    clusterset_group blue
+            }`
+		})
+
+		It("should succeed with the clusterset group field populated correctly", func() {
+			Expect(lh.ClustersetGroup).Should(Equal("blue"))
+		})
+	})
+
+	When("ready_only argument is specified", func() {
+		BeforeEach(func() {
+			config = `lighthouse {
+			    ready_only
+            }`
+		})
+
+		It("should succeed with the ready only field set", func() {
+			Expect(lh.ReadyOnly).Should(BeTrue())
+		})
+	})
+
+	When("short_names argument is specified", func() {
+		BeforeEach(func() {
+			config = `lighthouse clusterset.local {
+			    short_names
+            }`
+		})
+
+		It("should succeed with the short names field set", func() {
+			Expect(lh.ShortNames).Should(BeTrue())
+		})
+	})
+
+	When("cluster_subzones argument is specified", func() {
+		BeforeEach(func() {
+			config = `lighthouse clusterset.local {
+			    cluster_subzones
+            }`
+		})
+
+		It("should succeed with the cluster subzones field set", func() {
+			Expect(lh.ClusterSubzones).Should(BeTrue())
+		})
+	})
+
+	When("merge_local argument is specified", func() {
+		BeforeEach(func() {
+			config = `lighthouse clusterset.local {
+			    merge_local
+            }`
+		})
+
+		It("should succeed with the merge local field set", func() {
+			Expect(lh.MergeLocal).Should(BeTrue())
+		})
+	})
+
+	When("apex_answer argument is specified", func() {
+		BeforeEach(func() {
+			config = `lighthouse clusterset.local {
+			    apex_answer 10.253.1.1 fd00::1
+            }`
+		})
+
+		It("should succeed with the apex addresses set", func() {
+			Expect(lh.ApexIPs).To(HaveLen(2))
+			Expect(lh.ApexIPs[0].String()).To(Equal("10.253.1.1"))
+			Expect(lh.ApexIPs[1].String()).To(Equal("fd00::1"))
+		})
+	})
+
+	When("policy argument is specified", func() {
+		BeforeEach(func() {
+			config = `lighthouse clusterset.local {
+			    policy round-robin
+            }`
+		})
+
+		It("should succeed with the policy set", func() {
+			Expect(lh.Policy.Name()).To(Equal(serviceimport.RoundRobinPolicy))
+		})
+	})
+
+	When("max_answer_clusters argument is specified", func() {
+		BeforeEach(func() {
+			config = `lighthouse clusterset.local {
+			    max_answer_clusters 3
+            }`
+		})
+
+		It("should succeed with the cap set", func() {
+			Expect(lh.MaxAnswerClusters).To(Equal(3))
+		})
+	})
+
+	When("ttl_decay argument is specified", func() {
+		BeforeEach(func() {
+			config = `lighthouse clusterset.local {
+			    ttl_decay 1 30 5m
+            }`
+		})
+
+		It("should succeed with the TTL decay set", func() {
+			Expect(lh.MinTTL).To(Equal(uint32(1)))
+			Expect(lh.MaxTTL).To(Equal(uint32(30)))
+			Expect(lh.TTLDecayWindow).To(Equal(5 * time.Minute))
+		})
+	})
+
+	When("a zone within the default local cluster zone is specified without local_zone", func() {
+		BeforeEach(func() {
+			config = `lighthouse clusterset.local cluster.local`
+		})
+
+		It("should succeed without a local zone", func() {
+			Expect(lh.LocalZone).To(BeEmpty())
+			Expect(lh.Zones).To(Equal([]string{"clusterset.local.", "cluster.local."}))
+		})
+	})
+
+	When("local_zone argument is specified", func() {
+		BeforeEach(func() {
+			config = `lighthouse clusterset.local cluster.local {
+			    local_zone cluster.example
+            }`
+		})
+
+		It("should succeed with the local zone set", func() {
+			Expect(lh.LocalZone).To(Equal("cluster.example."))
+			Expect(lh.Zones).To(Equal([]string{"clusterset.local.", "cluster.local."}))
+		})
+	})
+
 	It("Should handle missing optional fields", func() {
 		config := `lighthouse`
 		c := caddy.NewTestController("dns", config)
@@ -159,6 +304,8 @@ func testCorrectConfig() {
 		Expect(lh.Fall).Should(Equal(fall.F{}))
 		Expect(lh.Zones).Should(BeEmpty())
 		Expect(lh.TTL).Should(Equal(defaultTTL))
+		Expect(lh.LocalZone).Should(BeEmpty())
+		Expect(lh.Policy.Name()).Should(Equal(serviceimport.LocalFirstPolicy))
 	})
 }
 
@@ -220,6 +367,248 @@ func testIncorrectConfig() {
 		})
 	})
 
+	When("an invalid clusterset_group is specified", func() {
+		BeforeEach(func() {
+			config = `lighthouse {
+                clusterset_group Not_Valid
+		    } noplugin`
+
+			buildKubeConfigFunc = func(masterUrl, kubeconfigPath string) (*rest.Config, error) {
+				return &rest.Config{}, nil
+			}
+		})
+
+		It("should return an appropriate plugin error", func() {
+			verifyPluginError(setupErr, "invalid clusterset_group \"Not_Valid\"")
+		})
+	})
+
+	When("ready_only is specified with an argument", func() {
+		BeforeEach(func() {
+			config = `lighthouse {
+                ready_only true
+		    } noplugin`
+
+			buildKubeConfigFunc = func(masterUrl, kubeconfigPath string) (*rest.Config, error) {
+				return &rest.Config{}, nil
+			}
+		})
+
+		It("should return an appropriate plugin error", func() {
+			verifyPluginError(setupErr, "Wrong argument count")
+		})
+	})
+
+	When("merge_local is specified with an argument", func() {
+		BeforeEach(func() {
+			config = `lighthouse {
+                merge_local true
+		    } noplugin`
+
+			buildKubeConfigFunc = func(masterUrl, kubeconfigPath string) (*rest.Config, error) {
+				return &rest.Config{}, nil
+			}
+		})
+
+		It("should return an appropriate plugin error", func() {
+			verifyPluginError(setupErr, "Wrong argument count")
+		})
+	})
+
+	When("short_names is specified with an argument", func() {
+		BeforeEach(func() {
+			config = `lighthouse {
+                short_names true
+		    } noplugin`
+
+			buildKubeConfigFunc = func(masterUrl, kubeconfigPath string) (*rest.Config, error) {
+				return &rest.Config{}, nil
+			}
+		})
+
+		It("should return an appropriate plugin error", func() {
+			verifyPluginError(setupErr, "Wrong argument count")
+		})
+	})
+
+	When("cluster_subzones is specified with an argument", func() {
+		BeforeEach(func() {
+			config = `lighthouse {
+                cluster_subzones true
+		    } noplugin`
+
+			buildKubeConfigFunc = func(masterUrl, kubeconfigPath string) (*rest.Config, error) {
+				return &rest.Config{}, nil
+			}
+		})
+
+		It("should return an appropriate plugin error", func() {
+			verifyPluginError(setupErr, "Wrong argument count")
+		})
+	})
+
+	When("apex_answer is specified without an address", func() {
+		BeforeEach(func() {
+			config = `lighthouse {
+                apex_answer
+		    } noplugin`
+
+			buildKubeConfigFunc = func(masterUrl, kubeconfigPath string) (*rest.Config, error) {
+				return &rest.Config{}, nil
+			}
+		})
+
+		It("should return an appropriate plugin error", func() {
+			verifyPluginError(setupErr, "Wrong argument count")
+		})
+	})
+
+	When("policy is specified with an unknown name", func() {
+		BeforeEach(func() {
+			config = `lighthouse {
+                policy fastest
+		    } noplugin`
+
+			buildKubeConfigFunc = func(masterUrl, kubeconfigPath string) (*rest.Config, error) {
+				return &rest.Config{}, nil
+			}
+		})
+
+		It("should return an appropriate plugin error", func() {
+			verifyPluginError(setupErr, "unknown policy \"fastest\"")
+		})
+	})
+
+	When("the local cluster zone is specified", func() {
+		BeforeEach(func() {
+			config = `lighthouse clusterset.local cluster.local {
+                local_zone cluster.local
+		    } noplugin`
+
+			buildKubeConfigFunc = func(masterUrl, kubeconfigPath string) (*rest.Config, error) {
+				return &rest.Config{}, nil
+			}
+		})
+
+		It("should return an appropriate plugin error", func() {
+			verifyPluginError(setupErr, "zone \"cluster.local.\" is within the local cluster zone \"cluster.local.\"")
+		})
+	})
+
+	When("a zone within a custom local cluster zone is specified", func() {
+		BeforeEach(func() {
+			config = `lighthouse clusterset.local east.cluster.example {
+                local_zone cluster.example
+		    } noplugin`
+
+			buildKubeConfigFunc = func(masterUrl, kubeconfigPath string) (*rest.Config, error) {
+				return &rest.Config{}, nil
+			}
+		})
+
+		It("should return an appropriate plugin error", func() {
+			verifyPluginError(setupErr,
+				"zone \"east.cluster.example.\" is within the local cluster zone \"cluster.example.\"")
+		})
+	})
+
+	When("local_zone is specified without a zone", func() {
+		BeforeEach(func() {
+			config = `lighthouse {
+                local_zone
+		    } noplugin`
+
+			buildKubeConfigFunc = func(masterUrl, kubeconfigPath string) (*rest.Config, error) {
+				return &rest.Config{}, nil
+			}
+		})
+
+		It("should return an appropriate plugin error", func() {
+			verifyPluginError(setupErr, "Wrong argument count")
+		})
+	})
+
+	When("max_answer_clusters is specified with a non-positive value", func() {
+		BeforeEach(func() {
+			config = `lighthouse {
+                max_answer_clusters 0
+		    } noplugin`
+
+			buildKubeConfigFunc = func(masterUrl, kubeconfigPath string) (*rest.Config, error) {
+				return &rest.Config{}, nil
+			}
+		})
+
+		It("should return an appropriate plugin error", func() {
+			verifyPluginError(setupErr, "max_answer_clusters must be a positive integer: \"0\"")
+		})
+	})
+
+	When("ttl_decay is specified with a min TTL exceeding the max TTL", func() {
+		BeforeEach(func() {
+			config = `lighthouse {
+                ttl_decay 30 1 5m
+		    } noplugin`
+
+			buildKubeConfigFunc = func(masterUrl, kubeconfigPath string) (*rest.Config, error) {
+				return &rest.Config{}, nil
+			}
+		})
+
+		It("should return an appropriate plugin error", func() {
+			verifyPluginError(setupErr, "ttl_decay min TTL 30 exceeds max TTL 1")
+		})
+	})
+
+	When("introspect is specified with an invalid address", func() {
+		BeforeEach(func() {
+			config = `lighthouse {
+                introspect 9154
+		    } noplugin`
+
+			buildKubeConfigFunc = func(masterUrl, kubeconfigPath string) (*rest.Config, error) {
+				return &rest.Config{}, nil
+			}
+		})
+
+		It("should return an appropriate plugin error", func() {
+			verifyPluginError(setupErr, "invalid introspect address \"9154\"")
+		})
+	})
+
+	When("apex_answer is specified with an invalid address", func() {
+		BeforeEach(func() {
+			config = `lighthouse {
+                apex_answer 10.253.1.1 not-an-ip
+		    } noplugin`
+
+			buildKubeConfigFunc = func(masterUrl, kubeconfigPath string) (*rest.Config, error) {
+				return &rest.Config{}, nil
+			}
+		})
+
+		It("should return an appropriate plugin error", func() {
+			verifyPluginError(setupErr, "invalid apex_answer address \"not-an-ip\"")
+		})
+	})
+
+	When("short_names is specified with a zone that's a subdomain of another zone", func() {
+		BeforeEach(func() {
+			config = `lighthouse clusterset.local ns.clusterset.local {
+                short_names
+		    } noplugin`
+
+			buildKubeConfigFunc = func(masterUrl, kubeconfigPath string) (*rest.Config, error) {
+				return &rest.Config{}, nil
+			}
+		})
+
+		It("should return an appropriate plugin error", func() {
+			verifyPluginError(setupErr,
+				"short_names can't be used with zone \"ns.clusterset.local.\" as it's a subdomain of zone \"clusterset.local.\"")
+		})
+	})
+
 	When("building the kubeconfig fails", func() {
 		BeforeEach(func() {
 			config = PluginName
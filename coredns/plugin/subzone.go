@@ -0,0 +1,141 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lighthouse
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+	"github.com/coredns/coredns/request"
+	"github.com/miekg/dns"
+)
+
+// getClusterSubzone checks if the query is within a per-cluster subzone of the matched zone, eg "east.clusterset.local",
+// and returns the subzone and the cluster ID. The subzone label must be the local cluster ID or that of a connected
+// cluster. Empty strings are returned if the query isn't within a cluster subzone.
+func (lh *Lighthouse) getClusterSubzone(state *request.Request) (subzone, cluster string) {
+	base, _ := dnsutil.TrimZone(state.Name(), state.Zone)
+
+	segs := dns.SplitDomainName(base)
+	if len(segs) == 0 {
+		return "", ""
+	}
+
+	label := segs[len(segs)-1]
+	if label == Svc || label == Pod {
+		return "", ""
+	}
+
+	cluster = strings.ToLower(label)
+	if cluster != lh.ClusterStatus.LocalClusterID() && !lh.ClusterStatus.IsConnected(cluster) {
+		return "", ""
+	}
+
+	qname := state.QName()
+
+	return qname[len(qname)-len(label)-1-len(state.Zone):], cluster
+}
+
+// serveClusterSubzone answers a query within a cluster subzone as a delegated zone. The subzone apex has its own SOA and
+// NS records and "[hostname.]service.namespace.svc.<cluster>.<zone>" resolves as the flat per-cluster name
+// "[hostname.]<cluster>.service.namespace.svc.<zone>".
+func (lh *Lighthouse) serveClusterSubzone(ctx context.Context, state *request.Request, subzone, cluster string) (int, error) {
+	zone := state.Zone
+	state.Zone = subzone
+
+	if strings.EqualFold(state.Name(), subzone) {
+		return lh.subzoneApexResponse(state)
+	}
+
+	if state.QType() != dns.TypeA && state.QType() != dns.TypeAAAA && state.QType() != dns.TypeSRV {
+		log.Debugf("Query of type %d is not supported", state.QType())
+		return lh.nextOrFailure(ctx, state, state.Req, dns.RcodeNotImplemented)
+	}
+
+	pReq, err := parseSubzoneRequest(state, cluster)
+	if err != nil || pReq.podOrSvc != Svc {
+		log.Debugf("Request %q in cluster subzone %q is not a 'svc' type query - err was %v", state.QName(), subzone, err)
+		return lh.nextOrFailure(ctx, state, state.Req, dns.RcodeNameError)
+	}
+
+	log.Debugf("Resolving %q in cluster subzone %q as service %s/%s", state.QName(), subzone, pReq.namespace, pReq.service)
+
+	return lh.getDNSRecord(ctx, zone, state, state.W, state.Req, pReq)
+}
+
+// parseSubzoneRequest parses a qname within a cluster subzone. In a subzone, the single label left of the service name in
+// an A query is the hostname rather than the cluster, and a cluster label isn't allowed.
+func parseSubzoneRequest(state *request.Request, cluster string) (*recordRequest, error) {
+	r, err := parseRequest(state)
+	if err != nil {
+		return r, err
+	}
+
+	if r.cluster != "" {
+		if state.QType() != dns.TypeA || r.hostname != "" {
+			return r, errInvalidRequest
+		}
+
+		r.hostname = r.cluster
+	}
+
+	r.cluster = cluster
+
+	return r, nil
+}
+
+// subzoneApexResponse answers a query for a cluster subzone apex with its SOA or NS record. Other query types get an empty
+// NOERROR response with the SOA in the authority section.
+func (lh *Lighthouse) subzoneApexResponse(state *request.Request) (int, error) {
+	a := new(dns.Msg)
+	a.SetReply(state.Req)
+
+	switch state.QType() {
+	case dns.TypeSOA:
+		a.Answer = []dns.RR{lh.subzoneSOA(state)}
+	case dns.TypeNS:
+		a.Answer = []dns.RR{&dns.NS{
+			Hdr: dns.RR_Header{Name: state.QName(), Rrtype: dns.TypeNS, Class: state.QClass(), Ttl: lh.TTL},
+			Ns:  subzoneNameserver(state.Zone),
+		}}
+	default:
+		a.Ns = []dns.RR{lh.subzoneSOA(state)}
+	}
+
+	return lh.writeResponse(state, a)
+}
+
+func (lh *Lighthouse) subzoneSOA(state *request.Request) *dns.SOA {
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: state.Zone, Rrtype: dns.TypeSOA, Class: state.QClass(), Ttl: lh.TTL},
+		Ns:      subzoneNameserver(state.Zone),
+		Mbox:    "hostmaster." + state.Zone,
+		Serial:  uint32(time.Now().Unix()),
+		Refresh: 7200,
+		Retry:   1800,
+		Expire:  86400,
+		Minttl:  lh.TTL,
+	}
+}
+
+func subzoneNameserver(subzone string) string {
+	return "ns.dns." + subzone
+}
@@ -0,0 +1,99 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lighthouse
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/coredns/caddy"
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
+	"github.com/submariner-io/lighthouse/coredns/serviceimport"
+)
+
+// answerTTL returns the TTL for an answer with the given records. If TTL decay is configured, the TTL decays linearly from
+// the max TTL to the min TTL as the time since the records' source clusters last refreshed their ServiceImports, via the
+// agent's heartbeat, approaches the decay window so clients re-query stale data sooner. A record whose source cluster
+// doesn't publish a heartbeat doesn't decay the TTL.
+func (lh *Lighthouse) answerTTL(pReq *recordRequest, records []serviceimport.DNSRecord) uint32 {
+	if lh.TTLDecayWindow <= 0 {
+		return lh.TTL
+	}
+
+	var age time.Duration
+
+	for i := range records {
+		if a, ok := lh.ServiceImports.GetSyncAge(pReq.namespace, pReq.service, records[i].ClusterName); ok && a > age {
+			age = a
+		}
+	}
+
+	if age >= lh.TTLDecayWindow {
+		return lh.MinTTL
+	}
+
+	decay := uint64(lh.MaxTTL-lh.MinTTL) * uint64(age) / uint64(lh.TTLDecayWindow)
+
+	return lh.MaxTTL - uint32(decay)
+}
+
+func setTTL(records []dns.RR, ttl uint32) {
+	for _, rr := range records {
+		rr.Header().Ttl = ttl
+	}
+}
+
+// parseTTLDecay parses the "ttl_decay MIN MAX WINDOW" directive, eg "ttl_decay 1 30 5m".
+func parseTTLDecay(c *caddy.Controller) (minTTL, maxTTL uint32, window time.Duration, err error) {
+	args := c.RemainingArgs()
+	if len(args) != 3 {
+		return 0, 0, 0, c.ArgErr() // nolint:wrapcheck // No need to wrap this.
+	}
+
+	ttls := make([]uint32, 2)
+
+	for i := range ttls {
+		t, err := strconv.Atoi(args[i])
+		if err != nil {
+			return 0, 0, 0, errors.Wrap(err, "error parsing TTL")
+		}
+
+		if t < 0 || t > 3600 {
+			return 0, 0, 0, c.Errf("ttl_decay TTLs must be in range [0, 3600]: %d", t) // nolint:wrapcheck // No need to wrap this.
+		}
+
+		ttls[i] = uint32(t)
+	}
+
+	if ttls[0] > ttls[1] {
+		return 0, 0, 0, c.Errf("ttl_decay min TTL %d exceeds max TTL %d", ttls[0], ttls[1]) // nolint:wrapcheck // No need to wrap this.
+	}
+
+	window, err = time.ParseDuration(args[2])
+	if err != nil {
+		return 0, 0, 0, errors.Wrap(err, "error parsing the TTL decay window")
+	}
+
+	if window <= 0 {
+		return 0, 0, 0, c.Errf("ttl_decay window must be positive: %v", window) // nolint:wrapcheck // No need to wrap this.
+	}
+
+	return ttls[0], ttls[1], window, nil
+}
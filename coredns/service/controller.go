@@ -132,3 +132,24 @@ func (c *Controller) GetIP(name, namespace string) (*serviceimport.DNSRecord, bo
 
 	return record, true
 }
+
+// GetClusterIPs returns all the ClusterIPs of a local ClusterIP Service, eg both the IPv4 and IPv6 ones of a dual-stack
+// Service.
+func (c *Controller) GetClusterIPs(name, namespace string) []string {
+	obj, exists, err := c.svcStore.GetByKey(namespace + "/" + name)
+	if err != nil || !exists {
+		return nil
+	}
+
+	svc := obj.(*v1.Service)
+
+	if svc.Spec.Type != v1.ServiceTypeClusterIP || svc.Spec.ClusterIP == "" || svc.Spec.ClusterIP == v1.ClusterIPNone {
+		return nil
+	}
+
+	if len(svc.Spec.ClusterIPs) > 0 {
+		return svc.Spec.ClusterIPs
+	}
+
+	return []string{svc.Spec.ClusterIP}
+}
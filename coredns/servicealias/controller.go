@@ -0,0 +1,265 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicealias
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/log"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const aliasIndex = "alias"
+
+type NewClientsetFunc func(c *rest.Config) (dynamic.Interface, error)
+
+// NewClientset is an indirection hook for unit tests to supply fake client sets.
+var NewClientset NewClientsetFunc
+
+// GVR is the resource of the cluster-scoped ServiceAlias CR. Its spec maps an alias service name and namespace to a
+// target service whose clusterset records are served for the alias, eg:
+//
+//	spec:
+//	  alias:
+//	    name: db
+//	    namespace: legacy
+//	  target:
+//	    name: postgres
+//	    namespace: data
+var GVR = schema.GroupVersionResource{
+	Group:    "lighthouse.submariner.io",
+	Version:  "v1alpha1",
+	Resource: "servicealiases",
+}
+
+type Controller struct {
+	NewClientset NewClientsetFunc
+	// OnRetarget, if set, is called when a ServiceAlias is updated to map its alias to a different target service.
+	OnRetarget func(oldName, oldNamespace, newName, newNamespace string)
+	// DiscoveryBackoff paces the retries to discover the ServiceAlias resource if it isn't installed yet.
+	DiscoveryBackoff wait.Backoff
+	informer         cache.Controller
+	storeMutex       sync.RWMutex
+	store            cache.Indexer
+	stopCh           chan struct{}
+}
+
+func NewController() *Controller {
+	return &Controller{
+		NewClientset: getNewClientsetFunc(),
+		DiscoveryBackoff: wait.Backoff{
+			Duration: time.Second,
+			Factor:   2,
+			Steps:    math.MaxInt32,
+			Cap:      5 * time.Minute,
+		},
+		stopCh: make(chan struct{}),
+	}
+}
+
+func getNewClientsetFunc() NewClientsetFunc {
+	if NewClientset != nil {
+		return NewClientset
+	}
+
+	return dynamic.NewForConfig
+}
+
+func (c *Controller) Start(kubeConfig *rest.Config) error {
+	clientSet, err := c.NewClientset(kubeConfig)
+	if err != nil {
+		return errors.Wrap(err, "error creating client set")
+	}
+
+	client := clientSet.Resource(GVR)
+
+	err = checkResource(client)
+	if apierrors.IsNotFound(err) {
+		klog.Infof("ServiceAlias resource not found - the ServiceAlias controller will start once it's installed")
+
+		go c.awaitResource(client)
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return c.startInformer(client)
+}
+
+func (c *Controller) awaitResource(client dynamic.ResourceInterface) {
+	backoff := c.DiscoveryBackoff
+
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-time.After(backoff.Step()):
+		}
+
+		err := checkResource(client)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+
+		if err == nil {
+			err = c.startInformer(client)
+		}
+
+		if err != nil {
+			klog.Errorf("Error starting the ServiceAlias controller: %v", err)
+			continue
+		}
+
+		return
+	}
+}
+
+func (c *Controller) startInformer(client dynamic.ResourceInterface) error {
+	klog.Infof("Starting ServiceAlias Controller")
+
+	// nolint:wrapcheck // Let the caller wrap these errors.
+	store, informer := cache.NewIndexerInformer(&cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return client.List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.Watch(context.TODO(), options)
+		},
+	}, &unstructured.Unstructured{}, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			klog.V(log.DEBUG).Infof("ServiceAlias %q added", obj.(*unstructured.Unstructured).GetName())
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			klog.V(log.DEBUG).Infof("ServiceAlias %q updated", newObj.(*unstructured.Unstructured).GetName())
+			c.onUpdated(oldObj.(*unstructured.Unstructured), newObj.(*unstructured.Unstructured))
+		},
+		DeleteFunc: func(obj interface{}) {
+			key, _ := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			klog.V(log.DEBUG).Infof("ServiceAlias %q deleted", key)
+		},
+	}, cache.Indexers{aliasIndex: indexByAlias})
+
+	go informer.Run(c.stopCh)
+
+	if ok := cache.WaitForCacheSync(c.stopCh, informer.HasSynced); !ok {
+		return fmt.Errorf("failed to wait for informer cache to sync")
+	}
+
+	c.storeMutex.Lock()
+	defer c.storeMutex.Unlock()
+
+	c.store, c.informer = store, informer
+
+	return nil
+}
+
+func (c *Controller) Stop() {
+	close(c.stopCh)
+	klog.Infof("ServiceAlias Controller stopped")
+}
+
+func (c *Controller) onUpdated(oldObj, newObj *unstructured.Unstructured) {
+	if c.OnRetarget == nil {
+		return
+	}
+
+	oldName, oldNamespace, ok := getNameAndNamespace(oldObj, "target")
+	if !ok {
+		return
+	}
+
+	newName, newNamespace, ok := getNameAndNamespace(newObj, "target")
+	if !ok || (newName == oldName && newNamespace == oldNamespace) {
+		return
+	}
+
+	klog.Infof("ServiceAlias %q retargeted from %s/%s to %s/%s", newObj.GetName(), oldNamespace, oldName, newNamespace, newName)
+
+	c.OnRetarget(oldName, oldNamespace, newName, newNamespace)
+}
+
+func checkResource(client dynamic.ResourceInterface) error {
+	_, err := client.List(context.TODO(), metav1.ListOptions{Limit: 1})
+
+	return errors.Wrap(err, "error listing resources")
+}
+
+func indexByAlias(obj interface{}) ([]string, error) {
+	name, namespace, ok := getNameAndNamespace(obj.(*unstructured.Unstructured), "alias")
+	if !ok {
+		return nil, nil
+	}
+
+	return []string{keyFunc(name, namespace)}, nil
+}
+
+func getNameAndNamespace(obj *unstructured.Unstructured, field string) (name, namespace string, ok bool) {
+	name, _, _ = unstructured.NestedString(obj.Object, "spec", field, "name")
+	namespace, _, _ = unstructured.NestedString(obj.Object, "spec", field, "namespace")
+
+	return name, namespace, name != "" && namespace != ""
+}
+
+func keyFunc(name, namespace string) string {
+	return namespace + "/" + name
+}
+
+// Public API.
+
+// GetTarget returns the name and namespace of the service the given alias service name and namespace maps to, if any.
+// If the alias is declared by more than one ServiceAlias, it's ambiguous and isn't resolved.
+func (c *Controller) GetTarget(name, namespace string) (targetName, targetNamespace string, found bool) {
+	c.storeMutex.RLock()
+	store := c.store
+	c.storeMutex.RUnlock()
+
+	if store == nil {
+		return "", "", false
+	}
+
+	objs, err := store.ByIndex(aliasIndex, keyFunc(name, namespace))
+	if err != nil || len(objs) == 0 {
+		return "", "", false
+	}
+
+	if len(objs) > 1 {
+		klog.Errorf("The alias %s/%s is declared by %d ServiceAliases - not resolving it", namespace, name, len(objs))
+		return "", "", false
+	}
+
+	return getNameAndNamespace(objs[0].(*unstructured.Unstructured), "target")
+}
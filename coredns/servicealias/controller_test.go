@@ -0,0 +1,211 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicealias_test
+
+import (
+	"context"
+	"math"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/fake"
+	"github.com/submariner-io/lighthouse/coredns/servicealias"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	fakeClient "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	aliasName       = "db"
+	aliasNamespace  = "legacy"
+	targetName      = "postgres"
+	targetNamespace = "data"
+)
+
+var _ = Describe("ServiceAlias controller", func() {
+	t := newTestDriver()
+
+	When("a ServiceAlias is created", func() {
+		It("should resolve the alias to the target service", func() {
+			t.createServiceAlias("alias1", aliasName, aliasNamespace, targetName, targetNamespace)
+			t.awaitTarget(aliasName, aliasNamespace, targetName, targetNamespace)
+		})
+	})
+
+	When("a ServiceAlias is updated", func() {
+		It("should resolve the alias to the new target service", func() {
+			obj := t.createServiceAlias("alias1", aliasName, aliasNamespace, targetName, targetNamespace)
+			t.awaitTarget(aliasName, aliasNamespace, targetName, targetNamespace)
+
+			Expect(unstructured.SetNestedField(obj.Object, "mysql", "spec", "target", "name")).To(Succeed())
+			_, err := t.aliasClient.Update(context.TODO(), obj, metav1.UpdateOptions{})
+			Expect(err).To(Succeed())
+
+			t.awaitTarget(aliasName, aliasNamespace, "mysql", targetNamespace)
+		})
+
+		It("should notify that the alias was retargeted", func() {
+			obj := t.createServiceAlias("alias1", aliasName, aliasNamespace, targetName, targetNamespace)
+			t.awaitTarget(aliasName, aliasNamespace, targetName, targetNamespace)
+
+			Expect(unstructured.SetNestedField(obj.Object, "other", "spec", "target", "namespace")).To(Succeed())
+			_, err := t.aliasClient.Update(context.TODO(), obj, metav1.UpdateOptions{})
+			Expect(err).To(Succeed())
+
+			Eventually(t.retargets, 5).Should(Receive(Equal([4]string{targetName, targetNamespace, targetName, "other"})))
+		})
+	})
+
+	When("a ServiceAlias is deleted", func() {
+		It("should no longer resolve the alias", func() {
+			t.createServiceAlias("alias1", aliasName, aliasNamespace, targetName, targetNamespace)
+			t.awaitTarget(aliasName, aliasNamespace, targetName, targetNamespace)
+
+			Expect(t.aliasClient.Delete(context.TODO(), "alias1", metav1.DeleteOptions{})).To(Succeed())
+			t.awaitNoTarget(aliasName, aliasNamespace)
+		})
+	})
+
+	When("a ServiceAlias doesn't specify the target namespace", func() {
+		It("should not resolve the alias", func() {
+			t.createServiceAlias("alias1", aliasName, aliasNamespace, targetName, "")
+			t.createServiceAlias("alias2", "other", aliasNamespace, targetName, targetNamespace)
+			t.awaitTarget("other", aliasNamespace, targetName, targetNamespace)
+
+			_, _, found := t.controller.GetTarget(aliasName, aliasNamespace)
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	When("the same alias is declared by two ServiceAliases", func() {
+		It("should not resolve the alias", func() {
+			t.createServiceAlias("alias1", aliasName, aliasNamespace, targetName, targetNamespace)
+			t.awaitTarget(aliasName, aliasNamespace, targetName, targetNamespace)
+
+			t.createServiceAlias("alias2", aliasName, aliasNamespace, "mysql", targetNamespace)
+			t.awaitNoTarget(aliasName, aliasNamespace)
+		})
+	})
+
+	When("the ServiceAlias resource doesn't exist", func() {
+		BeforeEach(func() {
+			t.aliasReactor.SetFailOnList(errors.NewNotFound(schema.GroupResource{}, ""))
+		})
+
+		It("should not resolve any alias", func() {
+			_, _, found := t.controller.GetTarget(aliasName, aliasNamespace)
+			Expect(found).To(BeFalse())
+		})
+
+		Context("and is subsequently installed", func() {
+			It("should resolve the alias", func() {
+				t.createServiceAlias("alias1", aliasName, aliasNamespace, targetName, targetNamespace)
+
+				_, _, found := t.controller.GetTarget(aliasName, aliasNamespace)
+				Expect(found).To(BeFalse())
+
+				t.aliasReactor.SetFailOnList(nil)
+				t.awaitTarget(aliasName, aliasNamespace, targetName, targetNamespace)
+			})
+		})
+	})
+})
+
+type testDriver struct {
+	controller   *servicealias.Controller
+	dynClient    *fakeClient.FakeDynamicClient
+	aliasClient  dynamic.ResourceInterface
+	aliasReactor *fake.FailingReactor
+	retargets    chan [4]string
+}
+
+func newTestDriver() *testDriver {
+	t := &testDriver{}
+
+	BeforeEach(func() {
+		t.dynClient = fakeClient.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+			servicealias.GVR: "ServiceAliasList",
+		})
+
+		t.aliasClient = t.dynClient.Resource(servicealias.GVR)
+		t.aliasReactor = fake.NewFailingReactorForResource(&t.dynClient.Fake, servicealias.GVR.Resource)
+	})
+
+	JustBeforeEach(func() {
+		t.retargets = make(chan [4]string, 10)
+		t.controller = servicealias.NewController()
+		t.controller.OnRetarget = func(oldName, oldNamespace, newName, newNamespace string) {
+			t.retargets <- [4]string{oldName, oldNamespace, newName, newNamespace}
+		}
+		t.controller.DiscoveryBackoff = wait.Backoff{Duration: 100 * time.Millisecond, Steps: math.MaxInt32}
+		t.controller.NewClientset = func(c *rest.Config) (dynamic.Interface, error) {
+			return t.dynClient, nil
+		}
+
+		Expect(t.controller.Start(&rest.Config{})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		t.controller.Stop()
+	})
+
+	return t
+}
+
+func (t *testDriver) createServiceAlias(name, alias, namespace, target, targetNs string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(servicealias.GVR.GroupVersion().String())
+	obj.SetKind("ServiceAlias")
+	obj.SetName(name)
+
+	Expect(unstructured.SetNestedStringMap(obj.Object, map[string]string{"name": alias, "namespace": namespace},
+		"spec", "alias")).To(Succeed())
+	Expect(unstructured.SetNestedStringMap(obj.Object, map[string]string{"name": target, "namespace": targetNs},
+		"spec", "target")).To(Succeed())
+
+	created, err := t.aliasClient.Create(context.TODO(), obj, metav1.CreateOptions{})
+	Expect(err).To(Succeed())
+
+	return created
+}
+
+func (t *testDriver) awaitTarget(alias, namespace, expName, expNamespace string) {
+	Eventually(func() []string {
+		name, ns, found := t.controller.GetTarget(alias, namespace)
+		if !found {
+			return nil
+		}
+
+		return []string{name, ns}
+	}, 5).Should(Equal([]string{expName, expNamespace}))
+}
+
+func (t *testDriver) awaitNoTarget(alias, namespace string) {
+	Eventually(func() bool {
+		_, _, found := t.controller.GetTarget(alias, namespace)
+		return found
+	}, 5).Should(BeFalse())
+}
@@ -0,0 +1,68 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serviceimport
+
+import "hash/fnv"
+
+// selectIPForClient selects the available cluster with the highest rendezvous hash score for the given client. As each
+// cluster's score only depends on the client and the cluster, adding or removing a cluster only remaps the clients that
+// gain or lose that cluster, and the clients of an unavailable cluster fall back to their next highest scoring cluster.
+func selectIPForClient(si *serviceInfo, name, namespace, client string, checkCluster func(string) bool,
+	checkEndpoint func(string, string, string) bool,
+) *DNSRecord {
+	var (
+		selected  *clusterInfo
+		bestScore uint64
+	)
+
+	for _, info := range si.records {
+		if !checkCluster(info.name) || !checkEndpoint(name, namespace, info.name) {
+			continue
+		}
+
+		score := rendezvousScore(client, info.name)
+		if selected == nil || score > bestScore || (score == bestScore && info.name < selected.name) {
+			selected = info
+			bestScore = score
+		}
+	}
+
+	if selected == nil {
+		return nil
+	}
+
+	return selected.record
+}
+
+func rendezvousScore(client, cluster string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(client))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(cluster))
+
+	// FNV doesn't spread similar inputs well enough on its own so apply the splitmix64 finalizer.
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
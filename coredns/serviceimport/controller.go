@@ -23,6 +23,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/submariner-io/admiral/pkg/log"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
@@ -39,7 +40,9 @@ var NewClientset NewClientsetFunc
 
 type Controller struct {
 	// Indirection hook for unit tests to supply fake client sets.
-	NewClientset    NewClientsetFunc
+	NewClientset NewClientsetFunc
+	// ClustersetGroup, if set, restricts the ServiceImports to those labeled with the given clusterset group.
+	ClustersetGroup string
 	serviceInformer cache.SharedIndexInformer
 	stopCh          chan struct{}
 	store           Store
@@ -75,6 +78,11 @@ func (c *Controller) Start(kubeConfig *rest.Config) error {
 		mcsInformers.WithNamespace(metav1.NamespaceAll))
 
 	c.serviceInformer = informerFactory.Multicluster().V1alpha1().ServiceImports().Informer()
+
+	if err := c.serviceInformer.AddIndexers(Indexers()); err != nil {
+		return errors.Wrap(err, "error adding ServiceImport indexers")
+	}
+
 	c.serviceInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: c.serviceImportCreatedOrUpdated,
 		UpdateFunc: func(oldObj, newObj interface{}) {
@@ -98,10 +106,28 @@ func (c *Controller) Stop() {
 	klog.Infof("ServiceImport Controller stopped")
 }
 
+// GetByOrigin returns the cached ServiceImports for the Service with the given origin namespace and name.
+func (c *Controller) GetByOrigin(namespace, name string) ([]*mcsv1a1.ServiceImport, error) {
+	return GetByOrigin(c.serviceInformer.GetIndexer(), namespace, name)
+}
+
+// GetByClusterID returns the cached ServiceImports from the given source cluster.
+func (c *Controller) GetByClusterID(clusterID string) ([]*mcsv1a1.ServiceImport, error) {
+	return GetByClusterID(c.serviceInformer.GetIndexer(), clusterID)
+}
+
 func (c *Controller) serviceImportCreatedOrUpdated(obj interface{}) {
 	klog.V(log.DEBUG).Infof("In serviceImportCreatedOrUpdated for: %#v, ", obj)
 
-	c.store.Put(obj.(*mcsv1a1.ServiceImport))
+	serviceImport := obj.(*mcsv1a1.ServiceImport)
+
+	if c.ClustersetGroup != "" && serviceImport.Labels[lhconstants.ClustersetGroupLabel] != c.ClustersetGroup {
+		// Remove in case the ServiceImport was previously in our clusterset group.
+		c.store.Remove(serviceImport)
+		return
+	}
+
+	c.store.Put(serviceImport)
 }
 
 func (c *Controller) serviceImportDeleted(obj interface{}) {
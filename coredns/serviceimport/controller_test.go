@@ -66,7 +66,9 @@ func testLifecycleNotifications() {
 		controller.NewClientset = func(c *rest.Config) (mcsClientset.Interface, error) {
 			return fakeClientSet, nil
 		}
+	})
 
+	JustBeforeEach(func() {
 		Expect(controller.Start(&rest.Config{})).To(Succeed())
 	})
 
@@ -130,12 +132,42 @@ func testLifecycleNotifications() {
 		})
 	})
 
+	When("a ServiceImport is cached", func() {
+		It("should be retrievable by origin and cluster ID", func() {
+			testOnAdd(serviceImport)
+
+			Eventually(func() ([]*mcsv1a1.ServiceImport, error) {
+				return controller.GetByOrigin(namespace1, service1)
+			}, 5).Should(ConsistOf(serviceImport))
+
+			Eventually(func() ([]*mcsv1a1.ServiceImport, error) {
+				return controller.GetByClusterID(clusterID)
+			}, 5).Should(ConsistOf(serviceImport))
+		})
+	})
+
 	When("the same ServiceImport is added in another cluster", func() {
 		It("both should be added to the ServiceImport store", func() {
 			testOnDoubleAdd(serviceImport, newServiceImport(namespace1, service1, serviceIP2, clusterID2))
 		})
 	})
 
+	When("a clusterset group is configured", func() {
+		BeforeEach(func() {
+			controller.ClustersetGroup = "blue"
+		})
+
+		It("should only add ServiceImports in the same clusterset group to the ServiceImport store", func() {
+			other := newServiceImport(namespace1, service1, serviceIP2, clusterID2)
+			other.Labels[lhconstants.ClustersetGroupLabel] = "red"
+			Expect(createService(other)).To(Succeed())
+			store.verifyRemove(other)
+
+			serviceImport.Labels[lhconstants.ClustersetGroupLabel] = "blue"
+			testOnAdd(serviceImport)
+		})
+	})
+
 	When("a ServiceImport is deleted", func() {
 		It("it should be removed from the ServiceImport store", func() {
 			testOnRemove(serviceImport)
@@ -143,7 +175,6 @@ func testLifecycleNotifications() {
 	})
 }
 
-// nolint:unparam // `name` always receives `service1'.
 func newServiceImport(namespace, name, serviceIP, clusterID string) *mcsv1a1.ServiceImport {
 	return &mcsv1a1.ServiceImport{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,93 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serviceimport
+
+import (
+	"github.com/pkg/errors"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"k8s.io/client-go/tools/cache"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const (
+	OriginIndex    = "origin"
+	ClusterIDIndex = "clusterID"
+)
+
+// Indexers returns the informer indexers used to look up ServiceImports by origin and by source cluster.
+func Indexers() cache.Indexers {
+	return cache.Indexers{
+		OriginIndex:    OriginIndexFunc,
+		ClusterIDIndex: ClusterIDIndexFunc,
+	}
+}
+
+// OriginIndexFunc indexes a ServiceImport by the namespace and name of the Service it originated from.
+func OriginIndexFunc(obj interface{}) ([]string, error) {
+	si, ok := obj.(*mcsv1a1.ServiceImport)
+	if !ok {
+		return nil, nil
+	}
+
+	name, ok := si.Annotations[lhconstants.OriginName]
+	if !ok {
+		return nil, nil
+	}
+
+	return []string{keyFunc(si.Annotations[lhconstants.OriginNamespace], name)}, nil
+}
+
+// ClusterIDIndexFunc indexes a ServiceImport by its source cluster ID.
+func ClusterIDIndexFunc(obj interface{}) ([]string, error) {
+	si, ok := obj.(*mcsv1a1.ServiceImport)
+	if !ok {
+		return nil, nil
+	}
+
+	clusterID, ok := si.Labels[lhconstants.LighthouseLabelSourceCluster]
+	if !ok {
+		return nil, nil
+	}
+
+	return []string{clusterID}, nil
+}
+
+// GetByOrigin returns the ServiceImports for the Service with the given origin namespace and name.
+func GetByOrigin(indexer cache.Indexer, namespace, name string) ([]*mcsv1a1.ServiceImport, error) {
+	return byIndex(indexer, OriginIndex, keyFunc(namespace, name))
+}
+
+// GetByClusterID returns the ServiceImports from the given source cluster.
+func GetByClusterID(indexer cache.Indexer, clusterID string) ([]*mcsv1a1.ServiceImport, error) {
+	return byIndex(indexer, ClusterIDIndex, clusterID)
+}
+
+func byIndex(indexer cache.Indexer, indexName, key string) ([]*mcsv1a1.ServiceImport, error) {
+	objs, err := indexer.ByIndex(indexName, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving ServiceImports by index %q", indexName)
+	}
+
+	serviceImports := make([]*mcsv1a1.ServiceImport, 0, len(objs))
+	for _, obj := range objs {
+		serviceImports = append(serviceImports, obj.(*mcsv1a1.ServiceImport))
+	}
+
+	return serviceImports, nil
+}
@@ -0,0 +1,152 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serviceimport_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/lighthouse/coredns/serviceimport"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"k8s.io/client-go/tools/cache"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const (
+	numIndexNamespaces = 5
+	numIndexServices   = 20
+	numIndexClusters   = 3
+)
+
+var _ = Describe("ServiceImport indexers", func() {
+	var indexer cache.Indexer
+
+	BeforeEach(func() {
+		indexer = newPopulatedIndexer()
+	})
+
+	When("looking up by origin", func() {
+		It("should return the same ServiceImports as a linear scan", func() {
+			for n := 0; n < numIndexNamespaces; n++ {
+				for s := 0; s < numIndexServices; s++ {
+					namespace, name := indexNamespace(n), indexService(s)
+
+					serviceImports, err := serviceimport.GetByOrigin(indexer, namespace, name)
+					Expect(err).To(Succeed())
+					Expect(serviceImports).To(HaveLen(numIndexClusters))
+					Expect(serviceImports).To(ConsistOf(linearScan(indexer, func(si *mcsv1a1.ServiceImport) bool {
+						return si.Annotations[lhconstants.OriginNamespace] == namespace &&
+							si.Annotations[lhconstants.OriginName] == name
+					})))
+				}
+			}
+		})
+	})
+
+	When("looking up by cluster ID", func() {
+		It("should return the same ServiceImports as a linear scan", func() {
+			for c := 0; c < numIndexClusters; c++ {
+				clusterID := indexCluster(c)
+
+				serviceImports, err := serviceimport.GetByClusterID(indexer, clusterID)
+				Expect(err).To(Succeed())
+				Expect(serviceImports).To(HaveLen(numIndexNamespaces * numIndexServices))
+				Expect(serviceImports).To(ConsistOf(linearScan(indexer, func(si *mcsv1a1.ServiceImport) bool {
+					return si.Labels[lhconstants.LighthouseLabelSourceCluster] == clusterID
+				})))
+			}
+		})
+	})
+
+	When("looking up a non-existent key", func() {
+		It("should return no ServiceImports", func() {
+			serviceImports, err := serviceimport.GetByOrigin(indexer, "unknown", "unknown")
+			Expect(err).To(Succeed())
+			Expect(serviceImports).To(BeEmpty())
+
+			serviceImports, err = serviceimport.GetByClusterID(indexer, "unknown")
+			Expect(err).To(Succeed())
+			Expect(serviceImports).To(BeEmpty())
+		})
+	})
+
+	When("a ServiceImport is removed", func() {
+		It("should no longer be returned", func() {
+			si := newServiceImport(indexNamespace(0), indexService(0), "10.0.0.1", indexCluster(0))
+			Expect(indexer.Delete(si)).To(Succeed())
+
+			serviceImports, err := serviceimport.GetByOrigin(indexer, indexNamespace(0), indexService(0))
+			Expect(err).To(Succeed())
+			Expect(serviceImports).To(HaveLen(numIndexClusters - 1))
+		})
+	})
+})
+
+func BenchmarkGetByOrigin(b *testing.B) {
+	indexer := newPopulatedIndexer()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = serviceimport.GetByOrigin(indexer, indexNamespace(i%numIndexNamespaces), indexService(i%numIndexServices))
+	}
+}
+
+func newPopulatedIndexer() cache.Indexer {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, serviceimport.Indexers())
+
+	for n := 0; n < numIndexNamespaces; n++ {
+		for s := 0; s < numIndexServices; s++ {
+			for c := 0; c < numIndexClusters; c++ {
+				err := indexer.Add(newServiceImport(indexNamespace(n), indexService(s), "10.0.0.1", indexCluster(c)))
+				if err != nil {
+					panic(err)
+				}
+			}
+		}
+	}
+
+	return indexer
+}
+
+func linearScan(indexer cache.Indexer, matches func(si *mcsv1a1.ServiceImport) bool) []*mcsv1a1.ServiceImport {
+	var found []*mcsv1a1.ServiceImport
+
+	for _, obj := range indexer.List() {
+		if si := obj.(*mcsv1a1.ServiceImport); matches(si) {
+			found = append(found, si)
+		}
+	}
+
+	return found
+}
+
+func indexNamespace(i int) string {
+	return fmt.Sprintf("namespace%d", i)
+}
+
+func indexService(i int) string {
+	return fmt.Sprintf("service%d", i)
+}
+
+func indexCluster(i int) string {
+	return fmt.Sprintf("cluster%d", i)
+}
@@ -19,8 +19,12 @@ limitations under the License.
 package serviceimport
 
 import (
+	"net"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
 	"github.com/submariner-io/lighthouse/pkg/loadbalancer"
@@ -29,10 +33,11 @@ import (
 )
 
 type DNSRecord struct {
-	IP          string
-	Ports       []mcsv1a1.ServicePort
-	HostName    string
-	ClusterName string
+	IP           string
+	Ports        []mcsv1a1.ServicePort
+	HostName     string
+	ClusterName  string
+	ExternalName string
 }
 
 type clusterInfo struct {
@@ -42,17 +47,39 @@ type clusterInfo struct {
 }
 
 type serviceInfo struct {
-	key        string
-	records    map[string]*clusterInfo
-	balancer   loadbalancer.Interface
-	isHeadless bool
+	key          string
+	records      map[string]*clusterInfo
+	hostnames    map[string]string
+	staticIPs    map[string]bool
+	weights      map[string]int64
+	syncedAt     map[string]time.Time
+	heartbeats   map[string]time.Time
+	disallowed   map[string]bool
+	unhealthy    map[string]bool
+	minEndpoints map[string]int
+	balancer     loadbalancer.Interface
+	isHeadless   bool
 }
 
-func (si *serviceInfo) resetLoadBalancing() {
+// isLocal returns true if the given cluster is the local cluster and it doesn't export a static clusterset IP, in which
+// case the local Service's IP is resolved.
+func (si *serviceInfo) isLocal(cluster, localCluster string) bool {
+	return localCluster != "" && cluster == localCluster && !si.staticIPs[cluster]
+}
+
+// checkHealthy returns a function that checks the given cluster with checkCluster and whether it reported the service
+// healthy. A cluster whose external health check reported the service unhealthy isn't selected even if it has endpoints.
+func (si *serviceInfo) checkHealthy(checkCluster func(string) bool) func(string) bool {
+	return func(cluster string) bool {
+		return !si.unhealthy[cluster] && checkCluster(cluster)
+	}
+}
+
+func (si *serviceInfo) resetLoadBalancing(policy Policy) {
 	si.balancer.RemoveAll()
 
 	for _, info := range si.records {
-		err := si.balancer.Add(info.name, info.weight)
+		err := si.balancer.Add(info.name, policy.Weight(info.weight))
 		if err != nil {
 			klog.Error(err)
 		}
@@ -61,7 +88,13 @@ func (si *serviceInfo) resetLoadBalancing() {
 
 type Map struct {
 	svcMap         map[string]*serviceInfo
+	successors     map[string]string
+	pendingRemoval map[string][]*mcsv1a1.ServiceImport
 	localClusterID string
+	policy         Policy
+	now            func() time.Time
+	onChange       func(namespace, name string)
+	changed        []string
 	mutex          sync.RWMutex
 }
 
@@ -86,6 +119,14 @@ func (m *Map) selectIP(si *serviceInfo, name, namespace string, checkCluster fun
 
 func (m *Map) GetIP(namespace, name, cluster, localCluster string, checkCluster func(string) bool,
 	checkEndpoint func(string, string, string) bool,
+) (record *DNSRecord, found, isLocal bool) {
+	return m.GetIPForClient(namespace, name, cluster, localCluster, "", checkCluster, checkEndpoint)
+}
+
+// GetIPForClient is like GetIP but, if the policy uses consistent hashing and the client is known, the cluster is
+// selected by hashing the client so the same client consistently gets the same cluster's IP.
+func (m *Map) GetIPForClient(namespace, name, cluster, localCluster, client string, checkCluster func(string) bool,
+	checkEndpoint func(string, string, string) bool,
 ) (record *DNSRecord, found, isLocal bool) {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
@@ -102,40 +143,297 @@ func (m *Map) GetIP(namespace, name, cluster, localCluster string, checkCluster
 			return nil, found, cluster == localCluster
 		}
 
-		return info.record, found, cluster == localCluster
+		if si.unhealthy[cluster] {
+			return nil, true, false
+		}
+
+		return info.record, found, si.isLocal(cluster, localCluster)
 	}
 
-	// If we are aware of the local cluster
+	// If the policy prefers the local cluster and we're aware of it
 	// And we found some accessible IP, we shall return it
-	if localCluster != "" {
+	if m.policy.PreferLocal() && localCluster != "" {
 		info, found := si.records[localCluster]
-		if found && info != nil && checkEndpoint(name, namespace, localCluster) {
-			return info.record, found, true
+		if found && info != nil && !si.unhealthy[localCluster] && checkEndpoint(name, namespace, localCluster) {
+			return info.record, found, si.isLocal(localCluster, localCluster)
 		}
 	}
 
-	// Fall back to selected load balancer (weighted/RR/etc) if service is not presented in the local cluster
-	record = m.selectIP(si, name, namespace, checkCluster, checkEndpoint)
+	checkCluster = si.checkHealthy(checkCluster)
+
+	// Otherwise select a cluster by hashing the client if the policy asks for it, else via the policy's load
+	// balancer (weighted/RR/etc)
+	if m.policy.ConsistentHash() && client != "" {
+		record = selectIPForClient(si, name, namespace, client, checkCluster, checkEndpoint)
+	} else {
+		record = m.selectIP(si, name, namespace, checkCluster, checkEndpoint)
+	}
 
 	if record != nil {
-		return record, true, false
+		return record, true, si.isLocal(record.ClusterName, localCluster)
 	}
 
 	return nil, true, false
 }
 
+// GetExternalName returns the external name for the given service if it's exported as an ExternalName Service. If a
+// cluster is specified, only that cluster is considered, otherwise the local cluster is preferred followed by the
+// connected clusters in name order.
+func (m *Map) GetExternalName(namespace, name, cluster string, checkCluster func(string) bool) (string, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	si, ok := m.svcMap[keyFunc(namespace, name)]
+	if !ok || si.isHeadless {
+		return "", false
+	}
+
+	clusters := []string{cluster}
+
+	if cluster == "" {
+		clusters = make([]string, 0, len(si.records))
+		for clusterID := range si.records {
+			if clusterID != m.localClusterID {
+				clusters = append(clusters, clusterID)
+			}
+		}
+
+		sort.Strings(clusters)
+		clusters = append([]string{m.localClusterID}, clusters...)
+	}
+
+	for _, clusterID := range clusters {
+		info, found := si.records[clusterID]
+		if !found || info.record.ExternalName == "" || si.unhealthy[clusterID] {
+			continue
+		}
+
+		if cluster != "" || clusterID == m.localClusterID || checkCluster(clusterID) {
+			return info.record.ExternalName, true
+		}
+	}
+
+	return "", false
+}
+
+// GetServiceForClustersetHostname returns the namespace and name of the service that declared the given custom
+// clusterset hostname. If more than one service declared it, conflict is returned as true as the hostname is ambiguous
+// and mustn't be resolved.
+func (m *Map) GetServiceForClustersetHostname(hostname string) (namespace, name string, found, conflict bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var keys []string
+
+	for key, si := range m.svcMap {
+		for _, h := range si.hostnames {
+			if strings.EqualFold(h, hostname) {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+
+	if len(keys) == 0 {
+		return "", "", false, false
+	}
+
+	if len(keys) > 1 {
+		sort.Strings(keys)
+		klog.Errorf("The clusterset hostname %q is declared by multiple services %v", hostname, keys)
+
+		return "", "", true, true
+	}
+
+	namespace, name, _ = strings.Cut(keys[0], "/")
+
+	return namespace, name, true, false
+}
+
+// Contains returns true if the given service is exported by any cluster.
+func (m *Map) Contains(namespace, name string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	_, ok := m.svcMap[keyFunc(namespace, name)]
+
+	return ok
+}
+
+// GetClusterWeights returns the weight of each cluster that exports the given service.
+func (m *Map) GetClusterWeights(namespace, name string) (map[string]int64, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	si, ok := m.svcMap[keyFunc(namespace, name)]
+	if !ok {
+		return nil, false
+	}
+
+	weights := make(map[string]int64, len(si.weights))
+	for clusterID, weight := range si.weights {
+		weights[clusterID] = weight
+	}
+
+	return weights, true
+}
+
+// IsConsumptionAllowed returns false if the local cluster isn't allowed to consume the given service from the given cluster
+// or, if no cluster is specified, from any of the clusters that export it. Unknown services are allowed.
+func (m *Map) IsConsumptionAllowed(namespace, name, cluster string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	si, ok := m.svcMap[keyFunc(namespace, name)]
+	if !ok {
+		return true
+	}
+
+	if cluster != "" {
+		return !si.disallowed[cluster]
+	}
+
+	// Every exporting cluster has a sync time.
+	for clusterID := range si.syncedAt {
+		if !si.disallowed[clusterID] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsClusterHealthy returns false if the given cluster's external health check reported the given service unhealthy, in
+// which case its contribution to the service is excluded. Unknown services and clusters are healthy.
+func (m *Map) IsClusterHealthy(namespace, name, cluster string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	si, ok := m.svcMap[keyFunc(namespace, name)]
+	if !ok {
+		return true
+	}
+
+	return !si.unhealthy[cluster]
+}
+
+// GetMinEndpoints returns the minimum number of ready endpoints clusterset-wide required for the given service to be
+// resolved, being the highest minimum required by any of the exporting clusters, or zero if none is required.
+func (m *Map) GetMinEndpoints(namespace, name string) int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	si, ok := m.svcMap[keyFunc(namespace, name)]
+	if !ok {
+		return 0
+	}
+
+	minEndpoints := 0
+
+	for _, n := range si.minEndpoints {
+		if n > minEndpoints {
+			minEndpoints = n
+		}
+	}
+
+	return minEndpoints
+}
+
+// GetSyncAge returns the time elapsed since the source cluster last refreshed the ServiceImport for the given service, as
+// published by its agent's heartbeat. The ServiceImport of a stable service doesn't otherwise change so, if the source
+// cluster doesn't publish a heartbeat, the age is unknown.
+func (m *Map) GetSyncAge(namespace, name, cluster string) (time.Duration, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	si, ok := m.svcMap[keyFunc(namespace, name)]
+	if !ok {
+		return 0, false
+	}
+
+	heartbeat, ok := si.heartbeats[cluster]
+	if !ok {
+		return 0, false
+	}
+
+	// Clock skew between the clusters may make the heartbeat appear to be in the future.
+	age := m.now().Sub(heartbeat)
+	if age < 0 {
+		age = 0
+	}
+
+	return age, true
+}
+
+// GetClusterStatus returns the reachability of each cluster that contributes to the given service, as determined by
+// checkCluster. A cluster that reported the service unhealthy is unreachable.
+func (m *Map) GetClusterStatus(namespace, name string, checkCluster func(string) bool) (map[string]bool, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	si, ok := m.svcMap[keyFunc(namespace, name)]
+	if !ok {
+		return nil, false
+	}
+
+	status := make(map[string]bool, len(si.records))
+	for clusterID := range si.records {
+		status[clusterID] = !si.unhealthy[clusterID] && checkCluster(clusterID)
+	}
+
+	return status, true
+}
+
 func NewMap(localClusterID string) *Map {
 	return &Map{
 		svcMap:         make(map[string]*serviceInfo),
+		successors:     make(map[string]string),
+		pendingRemoval: make(map[string][]*mcsv1a1.ServiceImport),
 		localClusterID: localClusterID,
+		policy:         DefaultPolicy(),
+		now:            time.Now,
+	}
+}
+
+// SetClock sets the function used to obtain the current time when recording and aging the last sync of a ServiceImport.
+func (m *Map) SetClock(now func() time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.now = now
+}
+
+// SetPolicy sets the Policy used to select the cluster whose IP is returned for a ClusterSetIP service.
+func (m *Map) SetPolicy(policy Policy) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.policy = policy
+
+	for _, si := range m.svcMap {
+		if !si.isHeadless {
+			si.balancer = policy.NewBalancer()
+			si.resetLoadBalancing(policy)
+		}
 	}
 }
 
+// SetChangeHandler sets the function invoked, outside of the Map's lock, with the namespace and name of each service whose
+// records were added, updated or removed.
+func (m *Map) SetChangeHandler(onChange func(namespace, name string)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.onChange = onChange
+}
+
 func (m *Map) Put(serviceImport *mcsv1a1.ServiceImport) {
 	if name, ok := serviceImport.Annotations["origin-name"]; ok {
 		namespace := serviceImport.Annotations["origin-namespace"]
 		key := keyFunc(namespace, name)
 
+		defer m.notifyChanged()
+
 		m.mutex.Lock()
 		defer m.mutex.Unlock()
 
@@ -143,34 +441,94 @@ func (m *Map) Put(serviceImport *mcsv1a1.ServiceImport) {
 
 		if !ok {
 			remoteService = &serviceInfo{
-				key:        key,
-				records:    make(map[string]*clusterInfo),
-				balancer:   loadbalancer.NewSmoothWeightedRR(),
-				isHeadless: serviceImport.Spec.Type == mcsv1a1.Headless,
+				key:          key,
+				records:      make(map[string]*clusterInfo),
+				hostnames:    make(map[string]string),
+				staticIPs:    make(map[string]bool),
+				weights:      make(map[string]int64),
+				syncedAt:     make(map[string]time.Time),
+				heartbeats:   make(map[string]time.Time),
+				disallowed:   make(map[string]bool),
+				unhealthy:    make(map[string]bool),
+				minEndpoints: make(map[string]int),
+				balancer:     m.policy.NewBalancer(),
+				isHeadless:   serviceImport.Spec.Type == mcsv1a1.Headless,
 			}
 		}
 
-		if serviceImport.Spec.Type == mcsv1a1.ClusterSetIP {
-			clusterName := serviceImport.GetLabels()[lhconstants.LighthouseLabelSourceCluster]
+		clusterName := serviceImport.GetLabels()[lhconstants.LighthouseLabelSourceCluster]
+
+		if hostname := serviceImport.Annotations[lhconstants.ClustersetHostnameAnnotation]; hostname != "" {
+			remoteService.hostnames[clusterName] = hostname
+		} else {
+			delete(remoteService.hostnames, clusterName)
+		}
+
+		remoteService.weights[clusterName] = getServiceWeight(serviceImport, m.localClusterID)
+		remoteService.syncedAt[clusterName] = m.now()
 
+		if heartbeat, ok := getHeartbeat(serviceImport); ok {
+			remoteService.heartbeats[clusterName] = heartbeat
+		} else {
+			delete(remoteService.heartbeats, clusterName)
+		}
+
+		if minEndpoints := getMinEndpoints(serviceImport); minEndpoints > 0 {
+			remoteService.minEndpoints[clusterName] = minEndpoints
+		} else {
+			delete(remoteService.minEndpoints, clusterName)
+		}
+
+		if serviceImport.Annotations[lhconstants.HealthAnnotation] == lhconstants.UnhealthyValue {
+			remoteService.unhealthy[clusterName] = true
+		} else {
+			delete(remoteService.unhealthy, clusterName)
+		}
+
+		if isConsumptionAllowed(serviceImport, clusterName, m.localClusterID) {
+			delete(remoteService.disallowed, clusterName)
+		} else {
+			remoteService.disallowed[clusterName] = true
+			delete(remoteService.records, clusterName)
+		}
+
+		if serviceImport.Spec.Type == mcsv1a1.ClusterSetIP && !remoteService.disallowed[clusterName] {
 			record := &DNSRecord{
-				IP:          serviceImport.Spec.IPs[0],
-				Ports:       serviceImport.Spec.Ports,
-				ClusterName: clusterName,
+				Ports:        serviceImport.Spec.Ports,
+				ClusterName:  clusterName,
+				ExternalName: getExternalName(serviceImport),
+			}
+
+			// An exported ExternalName Service has no IP.
+			if len(serviceImport.Spec.IPs) > 0 {
+				record.IP = serviceImport.Spec.IPs[0]
+			}
+
+			// A static clusterset IP is resolved regardless of the cluster backing the service, the routing to the
+			// active cluster being handled by the network.
+			if staticIP := getStaticIP(serviceImport); staticIP != "" {
+				record.IP = staticIP
+				remoteService.staticIPs[clusterName] = true
+			} else {
+				delete(remoteService.staticIPs, clusterName)
 			}
 
 			remoteService.records[clusterName] = &clusterInfo{
 				name:   clusterName,
 				record: record,
-				weight: getServiceWeightFrom(serviceImport, m.localClusterID),
+				weight: remoteService.weights[clusterName],
 			}
 		}
 
 		if !remoteService.isHeadless {
-			remoteService.resetLoadBalancing()
+			remoteService.resetLoadBalancing(m.policy)
 		}
 
 		m.svcMap[key] = remoteService
+		m.changed = append(m.changed, key)
+
+		m.cancelPendingRemoval(key, clusterName)
+		m.releasePredecessors(key)
 	}
 }
 
@@ -179,24 +537,155 @@ func (m *Map) Remove(serviceImport *mcsv1a1.ServiceImport) {
 		namespace := serviceImport.Annotations["origin-namespace"]
 		key := keyFunc(namespace, name)
 
+		defer m.notifyChanged()
+
 		m.mutex.Lock()
 		defer m.mutex.Unlock()
 
-		remoteService, ok := m.svcMap[key]
-		if !ok {
+		if _, retained := m.successors[key]; retained {
+			klog.Infof("Retaining the removed ServiceImport %q for service %q until its successor %q is exported",
+				serviceImport.Name, key, m.successors[key])
+
+			m.pendingRemoval[key] = append(m.pendingRemoval[key], serviceImport)
+
 			return
 		}
 
-		for _, info := range serviceImport.Status.Clusters {
-			delete(remoteService.records, info.Cluster)
+		m.remove(key, serviceImport)
+	}
+}
+
+func (m *Map) remove(key string, serviceImport *mcsv1a1.ServiceImport) {
+	remoteService, ok := m.svcMap[key]
+	if !ok {
+		return
+	}
+
+	m.changed = append(m.changed, key)
+
+	for _, info := range serviceImport.Status.Clusters {
+		delete(remoteService.records, info.Cluster)
+		delete(remoteService.hostnames, info.Cluster)
+		delete(remoteService.staticIPs, info.Cluster)
+		delete(remoteService.weights, info.Cluster)
+		delete(remoteService.syncedAt, info.Cluster)
+		delete(remoteService.heartbeats, info.Cluster)
+		delete(remoteService.disallowed, info.Cluster)
+		delete(remoteService.unhealthy, info.Cluster)
+		delete(remoteService.minEndpoints, info.Cluster)
+	}
+
+	// Headless services and those whose remaining clusters don't allow consumption have no records so check whether any
+	// exporting cluster remains, each of which has a sync time.
+	if len(remoteService.syncedAt) == 0 {
+		delete(m.svcMap, key)
+	} else if !remoteService.isHeadless {
+		remoteService.resetLoadBalancing(m.policy)
+	}
+}
+
+// notifyChanged invokes the change handler for the services changed since the last notification. It must be called
+// without holding the lock.
+func (m *Map) notifyChanged() {
+	m.mutex.Lock()
+	changed, onChange := m.changed, m.onChange
+	m.changed = nil
+	m.mutex.Unlock()
+
+	if onChange == nil {
+		return
+	}
+
+	for _, key := range changed {
+		namespace, name, _ := strings.Cut(key, "/")
+		onChange(namespace, name)
+	}
+}
+
+// isConsumptionAllowed returns true if the ServiceImport from the given cluster doesn't restrict its consumers or the local
+// cluster is among the allowed clusters. The exporting cluster itself is always allowed.
+func isConsumptionAllowed(si *mcsv1a1.ServiceImport, clusterName, localClusterID string) bool {
+	allowed, ok := si.Annotations[lhconstants.AllowedClustersAnnotation]
+	if !ok || (localClusterID != "" && clusterName == localClusterID) {
+		return true
+	}
+
+	for _, clusterID := range strings.Split(allowed, ",") {
+		if clusterID = strings.TrimSpace(clusterID); clusterID != "" && clusterID == localClusterID {
+			return true
 		}
+	}
+
+	return false
+}
+
+// getExternalName returns the name the service resolves to with a CNAME, being the explicit CNAME target declared by the
+// export, if any, otherwise the external name of an ExternalName Service.
+func getExternalName(si *mcsv1a1.ServiceImport) string {
+	if target := si.Annotations[lhconstants.CNAMETargetAnnotation]; target != "" {
+		return target
+	}
+
+	return si.Annotations[lhconstants.ExternalNameAnnotation]
+}
+
+func getMinEndpoints(si *mcsv1a1.ServiceImport) int {
+	val, ok := si.Annotations[lhconstants.MinEndpointsAnnotation]
+	if !ok {
+		return 0
+	}
+
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 1 {
+		klog.Errorf("Ignoring the invalid minimum endpoints %q on ServiceImport %s/%s", val, si.Namespace, si.Name)
+		return 0
+	}
+
+	return n
+}
+
+func getHeartbeat(si *mcsv1a1.ServiceImport) (time.Time, bool) {
+	val, ok := si.Annotations[lhconstants.HeartbeatAnnotation]
+	if !ok {
+		return time.Time{}, false
+	}
 
-		if len(remoteService.records) == 0 {
-			delete(m.svcMap, key)
-		} else if !remoteService.isHeadless {
-			remoteService.resetLoadBalancing()
+	heartbeat, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		klog.Errorf("Ignoring the invalid heartbeat %q on ServiceImport %s/%s", val, si.Namespace, si.Name)
+		return time.Time{}, false
+	}
+
+	return heartbeat, true
+}
+
+func getStaticIP(si *mcsv1a1.ServiceImport) string {
+	staticIP, ok := si.Annotations[lhconstants.StaticClustersetIPAnnotation]
+	if !ok {
+		return ""
+	}
+
+	if net.ParseIP(staticIP) == nil {
+		klog.Errorf("Ignoring the invalid static clusterset IP %q on ServiceImport %s/%s", staticIP, si.Namespace, si.Name)
+		return ""
+	}
+
+	return staticIP
+}
+
+// getServiceWeight returns the weight explicitly requested by the exporting cluster, if any, which overrides the weight
+// assigned for the local cluster.
+func getServiceWeight(si *mcsv1a1.ServiceImport, localClusterID string) int64 {
+	if val, ok := si.Annotations[lhconstants.WeightAnnotation]; ok {
+		w, err := strconv.ParseInt(val, 10, 64)
+		if err == nil {
+			return w
 		}
+
+		klog.Errorf("Error: %v parsing the %q annotation from ServiceImport %q", err, lhconstants.WeightAnnotation, si.Name)
 	}
+
+	return getServiceWeightFrom(si, localClusterID)
 }
 
 func getServiceWeightFrom(si *mcsv1a1.ServiceImport, forClusterName string) int64 {
@@ -19,9 +19,14 @@ limitations under the License.
 package serviceimport_test
 
 import (
+	"fmt"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/submariner-io/lighthouse/coredns/serviceimport"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
 )
 
 var _ = Describe("ServiceImport Map", func() {
@@ -211,6 +216,45 @@ var _ = Describe("ServiceImport Map", func() {
 		})
 	})
 
+	When("a service is present in two connected clusters with explicit weights", func() {
+		BeforeEach(func() {
+			si1 := newServiceImport(namespace1, service1, serviceIP1, clusterID1)
+			si1.Annotations[lhconstants.WeightAnnotation] = "70"
+			serviceImportMap.Put(si1)
+
+			si2 := newServiceImport(namespace1, service1, serviceIP2, clusterID2)
+			si2.Annotations[lhconstants.WeightAnnotation] = "30"
+			serviceImportMap.Put(si2)
+		})
+
+		It("should distribute the IPs according to the weights", func() {
+			counts := map[string]int{}
+			for i := 0; i < 100; i++ {
+				counts[getIP(namespace1, service1)]++
+			}
+
+			Expect(counts).To(Equal(map[string]int{serviceIP1: 70, serviceIP2: 30}))
+		})
+
+		When("one has a weight of zero", func() {
+			BeforeEach(func() {
+				si2 := newServiceImport(namespace1, service1, serviceIP2, clusterID2)
+				si2.Annotations[lhconstants.WeightAnnotation] = "0"
+				serviceImportMap.Put(si2)
+			})
+
+			It("should consistently return the IP of the other cluster", func() {
+				for i := 0; i < 10; i++ {
+					Expect(getIP(namespace1, service1)).To(Equal(serviceIP1))
+				}
+			})
+
+			It("should still return its IP when the cluster is specified", func() {
+				Expect(getClusterIP(namespace1, service1, clusterID2)).To(Equal(serviceIP2))
+			})
+		})
+	})
+
 	When("a service is present in one disconnected cluster", func() {
 		It("should consistently return found with empty IP", func() {
 			clusterStatusMap[clusterID1] = false
@@ -247,6 +291,132 @@ var _ = Describe("ServiceImport Map", func() {
 		})
 	})
 
+	When("the cluster status is requested for a service present in two clusters with one disconnected", func() {
+		It("should report the reachability of each cluster", func() {
+			clusterStatusMap[clusterID1] = false
+			serviceImportMap.Put(newServiceImport(namespace1, service1, serviceIP1, clusterID1))
+			serviceImportMap.Put(newServiceImport(namespace1, service1, serviceIP2, clusterID2))
+
+			status, found := serviceImportMap.GetClusterStatus(namespace1, service1, checkCluster)
+			Expect(found).To(BeTrue())
+			Expect(status).To(Equal(map[string]bool{clusterID1: false, clusterID2: true}))
+
+			_, found = serviceImportMap.GetClusterStatus(namespace2, service1, checkCluster)
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	When("the cluster weights are requested for a service present in two clusters", func() {
+		It("should return the weight of each cluster", func() {
+			si := newServiceImport(namespace1, service1, serviceIP1, clusterID1)
+			si.Annotations[lhconstants.WeightAnnotation] = "5"
+			serviceImportMap.Put(si)
+			serviceImportMap.Put(newServiceImport(namespace1, service1, serviceIP2, clusterID2))
+
+			weights, found := serviceImportMap.GetClusterWeights(namespace1, service1)
+			Expect(found).To(BeTrue())
+			Expect(weights).To(Equal(map[string]int64{clusterID1: 5, clusterID2: 1}))
+
+			serviceImportMap.Remove(newServiceImport(namespace1, service1, serviceIP1, clusterID1))
+
+			weights, found = serviceImportMap.GetClusterWeights(namespace1, service1)
+			Expect(found).To(BeTrue())
+			Expect(weights).To(Equal(map[string]int64{clusterID2: 1}))
+
+			_, found = serviceImportMap.GetClusterWeights(namespace2, service1)
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	When("a service is present in two clusters with one not allowing the local cluster to consume it", func() {
+		BeforeEach(func() {
+			si := newServiceImport(namespace1, service1, serviceIP1, clusterID1)
+			si.Annotations[lhconstants.AllowedClustersAnnotation] = "other, " + localClusterID
+			serviceImportMap.Put(si)
+
+			si = newServiceImport(namespace1, service1, serviceIP2, clusterID2)
+			si.Annotations[lhconstants.AllowedClustersAnnotation] = "other"
+			serviceImportMap.Put(si)
+		})
+
+		It("should report consumption as allowed only from the allowing cluster", func() {
+			Expect(serviceImportMap.IsConsumptionAllowed(namespace1, service1, "")).To(BeTrue())
+			Expect(serviceImportMap.IsConsumptionAllowed(namespace1, service1, clusterID1)).To(BeTrue())
+			Expect(serviceImportMap.IsConsumptionAllowed(namespace1, service1, clusterID2)).To(BeFalse())
+			Expect(serviceImportMap.IsConsumptionAllowed(namespace2, service1, "")).To(BeTrue())
+		})
+
+		It("should only return the IP of the allowing cluster", func() {
+			for i := 0; i < 4; i++ {
+				Expect(getIP(namespace1, service1)).To(Equal(serviceIP1))
+			}
+		})
+
+		Context("and the allowing cluster is removed", func() {
+			It("should report consumption as not allowed", func() {
+				serviceImportMap.Remove(newServiceImport(namespace1, service1, serviceIP1, clusterID1))
+				Expect(serviceImportMap.IsConsumptionAllowed(namespace1, service1, "")).To(BeFalse())
+			})
+		})
+	})
+
+	When("the clusters exporting a service require different minimum endpoints", func() {
+		It("should return the highest minimum of the remaining clusters", func() {
+			si := newServiceImport(namespace1, service1, serviceIP1, clusterID1)
+			si.Annotations[lhconstants.MinEndpointsAnnotation] = "2"
+			serviceImportMap.Put(si)
+
+			si = newServiceImport(namespace1, service1, serviceIP2, clusterID2)
+			si.Annotations[lhconstants.MinEndpointsAnnotation] = "3"
+			serviceImportMap.Put(si)
+
+			Expect(serviceImportMap.GetMinEndpoints(namespace1, service1)).To(Equal(3))
+			Expect(serviceImportMap.GetMinEndpoints(namespace2, service1)).To(BeZero())
+
+			serviceImportMap.Remove(newServiceImport(namespace1, service1, serviceIP2, clusterID2))
+			Expect(serviceImportMap.GetMinEndpoints(namespace1, service1)).To(Equal(2))
+		})
+	})
+
+	When("the sync age is requested for a service", func() {
+		It("should return the time since each cluster's last heartbeat", func() {
+			now := time.Now().Truncate(time.Second)
+			serviceImportMap.SetClock(func() time.Time {
+				return now
+			})
+
+			si := newServiceImport(namespace1, service1, serviceIP1, clusterID1)
+			si.Annotations[lhconstants.HeartbeatAnnotation] = now.Add(-2 * time.Minute).Format(time.RFC3339)
+			serviceImportMap.Put(si)
+
+			si = newServiceImport(namespace1, service1, serviceIP2, clusterID2)
+			si.Annotations[lhconstants.HeartbeatAnnotation] = now.Add(-time.Minute).Format(time.RFC3339)
+			serviceImportMap.Put(si)
+
+			serviceImportMap.Put(newServiceImport(namespace1, service1, serviceIP3, clusterID3))
+
+			age, found := serviceImportMap.GetSyncAge(namespace1, service1, clusterID1)
+			Expect(found).To(BeTrue())
+			Expect(age).To(Equal(2 * time.Minute))
+
+			age, found = serviceImportMap.GetSyncAge(namespace1, service1, clusterID2)
+			Expect(found).To(BeTrue())
+			Expect(age).To(Equal(time.Minute))
+
+			now = now.Add(time.Hour)
+
+			age, found = serviceImportMap.GetSyncAge(namespace1, service1, clusterID2)
+			Expect(found).To(BeTrue())
+			Expect(age).To(Equal(time.Hour + time.Minute))
+
+			_, found = serviceImportMap.GetSyncAge(namespace1, service1, clusterID3)
+			Expect(found).To(BeFalse())
+
+			_, found = serviceImportMap.GetSyncAge(namespace2, service1, clusterID1)
+			Expect(found).To(BeFalse())
+		})
+	})
+
 	When("a service exists in two namespaces", func() {
 		It("should return the correct IP for each namespace", func() {
 			serviceImportMap.Put(newServiceImport(namespace1, service1, serviceIP1, clusterID1))
@@ -301,4 +471,297 @@ var _ = Describe("ServiceImport Map", func() {
 			}
 		})
 	})
+
+	When("a change handler is set", func() {
+		It("should be notified of the services added, updated and removed", func() {
+			var changed []string
+
+			serviceImportMap.SetChangeHandler(func(namespace, name string) {
+				// The lock must be released when the handler is invoked.
+				serviceImportMap.Contains(namespace, name)
+				changed = append(changed, namespace+"/"+name)
+			})
+
+			si := newServiceImport(namespace1, service1, serviceIP1, clusterID1)
+			serviceImportMap.Put(si)
+			serviceImportMap.Put(newServiceImport(namespace2, service1, serviceIP2, clusterID1))
+			serviceImportMap.Remove(si)
+
+			// Should not notify as the service was already removed
+			serviceImportMap.Remove(si)
+
+			Expect(changed).To(Equal([]string{
+				namespace1 + "/" + service1,
+				namespace2 + "/" + service1,
+				namespace1 + "/" + service1,
+			}))
+		})
+	})
+
+	When("a service declares a clusterset hostname", func() {
+		It("should be returned for the hostname until removed", func() {
+			si := newServiceImport(namespace1, service1, serviceIP1, clusterID1)
+			si.Annotations[lhconstants.ClustersetHostnameAnnotation] = "payments"
+			serviceImportMap.Put(si)
+
+			namespace, name, found, conflict := serviceImportMap.GetServiceForClustersetHostname("payments")
+			Expect(found).To(BeTrue())
+			Expect(conflict).To(BeFalse())
+			Expect(namespace).To(Equal(namespace1))
+			Expect(name).To(Equal(service1))
+
+			_, _, found, _ = serviceImportMap.GetServiceForClustersetHostname("billing")
+			Expect(found).To(BeFalse())
+
+			serviceImportMap.Remove(si)
+
+			_, _, found, _ = serviceImportMap.GetServiceForClustersetHostname("payments")
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	When("two services declare the same clusterset hostname", func() {
+		It("should report a conflict until one is removed", func() {
+			si1 := newServiceImport(namespace1, service1, serviceIP1, clusterID1)
+			si1.Annotations[lhconstants.ClustersetHostnameAnnotation] = "payments"
+			serviceImportMap.Put(si1)
+
+			si2 := newServiceImport(namespace2, service1, serviceIP2, clusterID2)
+			si2.Annotations[lhconstants.ClustersetHostnameAnnotation] = "payments"
+			serviceImportMap.Put(si2)
+
+			_, _, found, conflict := serviceImportMap.GetServiceForClustersetHostname("payments")
+			Expect(found).To(BeTrue())
+			Expect(conflict).To(BeTrue())
+
+			serviceImportMap.Remove(si1)
+
+			namespace, _, _, conflict := serviceImportMap.GetServiceForClustersetHostname("payments")
+			Expect(conflict).To(BeFalse())
+			Expect(namespace).To(Equal(namespace2))
+		})
+	})
+
+	When("a service is present in three connected clusters with explicit weights", func() {
+		var policyName string
+
+		JustBeforeEach(func() {
+			policy, err := serviceimport.NewPolicy(policyName)
+			Expect(err).To(Succeed())
+			serviceImportMap.SetPolicy(policy)
+
+			for _, c := range []struct {
+				ip, clusterID, weight string
+			}{{serviceIP1, clusterID1, "60"}, {serviceIP2, clusterID2, "30"}, {serviceIP3, clusterID3, "10"}} {
+				si := newServiceImport(namespace1, service1, c.ip, c.clusterID)
+				si.Annotations[lhconstants.WeightAnnotation] = c.weight
+				serviceImportMap.Put(si)
+			}
+		})
+
+		countIPs := func(n int, localCluster string) map[string]int {
+			counts := map[string]int{}
+			for i := 0; i < n; i++ {
+				counts[getIPExpectFound(namespace1, service1, "", localCluster)]++
+			}
+
+			return counts
+		}
+
+		Context("and the round-robin policy", func() {
+			BeforeEach(func() {
+				policyName = serviceimport.RoundRobinPolicy
+			})
+
+			It("should distribute the IPs equally ignoring the weights and the local cluster", func() {
+				Expect(countIPs(99, clusterID1)).To(Equal(map[string]int{serviceIP1: 33, serviceIP2: 33, serviceIP3: 33}))
+			})
+		})
+
+		Context("and the local-first policy", func() {
+			BeforeEach(func() {
+				policyName = serviceimport.LocalFirstPolicy
+			})
+
+			It("should consistently return the local cluster's IP", func() {
+				Expect(countIPs(10, clusterID1)).To(Equal(map[string]int{serviceIP1: 10}))
+			})
+
+			It("should distribute the IPs of the other clusters according to the weights if the local cluster is unhealthy", func() {
+				endpointStatusMap[clusterID1] = false
+				Expect(countIPs(40, clusterID1)).To(Equal(map[string]int{serviceIP2: 30, serviceIP3: 10}))
+			})
+		})
+
+		Context("and the weighted policy", func() {
+			BeforeEach(func() {
+				policyName = serviceimport.WeightedPolicy
+			})
+
+			It("should distribute the IPs according to the weights ignoring the local cluster", func() {
+				Expect(countIPs(100, clusterID1)).To(Equal(map[string]int{serviceIP1: 60, serviceIP2: 30, serviceIP3: 10}))
+			})
+		})
+
+		Context("and the random policy", func() {
+			BeforeEach(func() {
+				policyName = serviceimport.RandomPolicy
+			})
+
+			It("should return the IPs of all available clusters", func() {
+				Expect(countIPs(300, "")).To(HaveLen(3))
+			})
+
+			It("should not return the IP of a disconnected cluster", func() {
+				clusterStatusMap[clusterID2] = false
+				Expect(countIPs(100, "")).To(And(HaveKey(serviceIP1), HaveKey(serviceIP3), Not(HaveKey(serviceIP2))))
+			})
+		})
+	})
+
+	When("a service is present in three connected clusters and the consistent-hash policy", func() {
+		var sis map[string]*mcsv1a1.ServiceImport
+
+		BeforeEach(func() {
+			policy, err := serviceimport.NewPolicy(serviceimport.ConsistentHashPolicy)
+			Expect(err).To(Succeed())
+			serviceImportMap.SetPolicy(policy)
+
+			sis = map[string]*mcsv1a1.ServiceImport{}
+
+			for _, c := range []struct {
+				ip, clusterID string
+			}{{serviceIP1, clusterID1}, {serviceIP2, clusterID2}, {serviceIP3, clusterID3}} {
+				sis[c.clusterID] = newServiceImport(namespace1, service1, c.ip, c.clusterID)
+				serviceImportMap.Put(sis[c.clusterID])
+			}
+		})
+
+		getIPForClients := func() map[string]string {
+			ips := map[string]string{}
+
+			for i := 0; i < 100; i++ {
+				client := fmt.Sprintf("10.0.%d.0/24", i)
+
+				dnsRecord, found, _ := serviceImportMap.GetIPForClient(namespace1, service1, "", "", client, checkCluster, checkEndpoint)
+				Expect(found).To(BeTrue())
+				Expect(dnsRecord).ToNot(BeNil())
+
+				ips[client] = dnsRecord.IP
+			}
+
+			return ips
+		}
+
+		expectRemapped := func(before, after map[string]string, removedIP string) {
+			for client, ip := range before {
+				if ip == removedIP {
+					Expect(after[client]).To(Or(Equal(serviceIP1), Equal(serviceIP3)))
+				} else {
+					Expect(after[client]).To(Equal(ip), "client %q was remapped", client)
+				}
+			}
+		}
+
+		It("should consistently return the same IP for a client and spread the clients across the clusters", func() {
+			ips := getIPForClients()
+
+			for i := 0; i < 5; i++ {
+				Expect(getIPForClients()).To(Equal(ips))
+			}
+
+			counts := map[string]int{}
+			for _, ip := range ips {
+				counts[ip]++
+			}
+
+			Expect(counts).To(HaveLen(3))
+		})
+
+		It("should only remap the clients of a cluster that's removed", func() {
+			before := getIPForClients()
+
+			serviceImportMap.Remove(sis[clusterID2])
+
+			expectRemapped(before, getIPForClients(), serviceIP2)
+		})
+
+		It("should only remap the clients of a cluster that's disconnected and restore them on reconnection", func() {
+			before := getIPForClients()
+
+			clusterStatusMap[clusterID2] = false
+			expectRemapped(before, getIPForClients(), serviceIP2)
+
+			clusterStatusMap[clusterID2] = true
+			Expect(getIPForClients()).To(Equal(before))
+		})
+	})
+
+	When("a service is present in two clusters that declare a static clusterset IP", func() {
+		const staticIP = "243.1.1.1"
+
+		BeforeEach(func() {
+			for _, si := range []*mcsv1a1.ServiceImport{
+				newServiceImport(namespace1, service1, serviceIP1, clusterID1),
+				newServiceImport(namespace1, service1, serviceIP2, clusterID2),
+			} {
+				si.Annotations[lhconstants.StaticClustersetIPAnnotation] = staticIP
+				serviceImportMap.Put(si)
+			}
+		})
+
+		It("should consistently return the static IP regardless of the selected cluster", func() {
+			for i := 0; i < 10; i++ {
+				Expect(getIP(namespace1, service1)).To(Equal(staticIP))
+				Expect(getClusterIP(namespace1, service1, clusterID2)).To(Equal(staticIP))
+			}
+		})
+
+		It("should not report the local cluster's record as local", func() {
+			record, found, isLocal := serviceImportMap.GetIP(namespace1, service1, "", clusterID1, checkCluster, checkEndpoint)
+			Expect(found).To(BeTrue())
+			Expect(record.IP).To(Equal(staticIP))
+			Expect(isLocal).To(BeFalse())
+		})
+
+		It("should return found with empty IP when both clusters are disconnected", func() {
+			clusterStatusMap[clusterID1] = false
+			clusterStatusMap[clusterID2] = false
+
+			Expect(getIP(namespace1, service1)).To(BeEmpty())
+		})
+	})
+
+	When("a service declares an IPv6 static clusterset IP", func() {
+		const staticIP = "fd00::243:1"
+
+		BeforeEach(func() {
+			si := newServiceImport(namespace1, service1, serviceIP1, clusterID1)
+			si.Annotations[lhconstants.StaticClustersetIPAnnotation] = staticIP
+			serviceImportMap.Put(si)
+		})
+
+		It("should return the static IP", func() {
+			Expect(getIP(namespace1, service1)).To(Equal(staticIP))
+		})
+	})
+
+	When("a service declares an invalid static clusterset IP", func() {
+		BeforeEach(func() {
+			si := newServiceImport(namespace1, service1, serviceIP1, clusterID1)
+			si.Annotations[lhconstants.StaticClustersetIPAnnotation] = "243.1.1"
+			serviceImportMap.Put(si)
+		})
+
+		It("should ignore it", func() {
+			Expect(getIP(namespace1, service1)).To(Equal(serviceIP1))
+		})
+	})
+
+	When("an unknown policy is requested", func() {
+		It("should return an error", func() {
+			_, err := serviceimport.NewPolicy("fastest")
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
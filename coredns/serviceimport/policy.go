@@ -0,0 +1,104 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serviceimport
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/submariner-io/lighthouse/pkg/loadbalancer"
+)
+
+const (
+	RoundRobinPolicy     = "round-robin"
+	LocalFirstPolicy     = "local-first"
+	WeightedPolicy       = "weighted"
+	RandomPolicy         = "random"
+	ConsistentHashPolicy = "consistent-hash"
+)
+
+// Policy is a strategy for selecting the cluster whose IP is returned in an answer for a ClusterSetIP service.
+type Policy interface {
+	// Name returns the name by which the policy is configured.
+	Name() string
+	// PreferLocal returns true if the local cluster is selected whenever it's available.
+	PreferLocal() bool
+	// NewBalancer returns a balancer used to select among the available clusters.
+	NewBalancer() loadbalancer.Interface
+	// Weight returns the balancer weight of a cluster given the weight requested for it via annotations.
+	Weight(requested int64) int64
+	// ConsistentHash returns true if a client is consistently mapped to the same cluster by hashing its address. The
+	// balancer is only used if the client is unknown.
+	ConsistentHash() bool
+}
+
+type policy struct {
+	name           string
+	preferLocal    bool
+	weighted       bool
+	consistentHash bool
+	newBalancer    func() loadbalancer.Interface
+}
+
+var policies = map[string]*policy{
+	RoundRobinPolicy:     {name: RoundRobinPolicy, newBalancer: loadbalancer.NewSmoothWeightedRR},
+	LocalFirstPolicy:     {name: LocalFirstPolicy, preferLocal: true, weighted: true, newBalancer: loadbalancer.NewSmoothWeightedRR},
+	WeightedPolicy:       {name: WeightedPolicy, weighted: true, newBalancer: loadbalancer.NewSmoothWeightedRR},
+	RandomPolicy:         {name: RandomPolicy, newBalancer: loadbalancer.NewRandom},
+	ConsistentHashPolicy: {name: ConsistentHashPolicy, consistentHash: true, newBalancer: loadbalancer.NewSmoothWeightedRR},
+}
+
+// NewPolicy returns the Policy with the given name.
+func NewPolicy(name string) (Policy, error) {
+	p, ok := policies[name]
+	if !ok {
+		return nil, errors.Errorf("unknown policy %q", name)
+	}
+
+	return p, nil
+}
+
+// DefaultPolicy returns the Policy used if none is configured, which prefers the local cluster and otherwise honors the
+// requested weights.
+func DefaultPolicy() Policy {
+	return policies[LocalFirstPolicy]
+}
+
+func (p *policy) Name() string {
+	return p.name
+}
+
+func (p *policy) PreferLocal() bool {
+	return p.preferLocal
+}
+
+func (p *policy) NewBalancer() loadbalancer.Interface {
+	return p.newBalancer()
+}
+
+func (p *policy) Weight(requested int64) int64 {
+	if p.weighted {
+		return requested
+	}
+
+	return 1
+}
+
+func (p *policy) ConsistentHash() bool {
+	return p.consistentHash
+}
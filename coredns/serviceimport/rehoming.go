@@ -0,0 +1,124 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serviceimport
+
+import (
+	"strings"
+
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+// RetainUntil retains the records of the given service, even if its ServiceImports are removed, until the given successor
+// service is exported. This bridges the re-homing of a service to another namespace under a stable alias, ie the alias is
+// retargeted to the successor and the service is deleted from the old namespace before it's exported from the new one.
+// Nothing is retained if the successor is already exported or the service isn't.
+func (m *Map) RetainUntil(namespace, name, successorNamespace, successorName string) {
+	key := keyFunc(namespace, name)
+	successorKey := keyFunc(successorNamespace, successorName)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// The successor may itself be retained for a previous move, eg if the alias is retargeted back, which no longer applies.
+	m.release(successorKey)
+
+	if _, exported := m.svcMap[successorKey]; exported || key == successorKey {
+		return
+	}
+
+	if _, exported := m.svcMap[key]; !exported {
+		return
+	}
+
+	klog.Infof("Retaining service %q until its successor %q is exported", key, successorKey)
+
+	m.successors[key] = successorKey
+}
+
+// GetPredecessor returns the namespace and name of the service retained until the given service is exported, if any.
+func (m *Map) GetPredecessor(namespace, name string) (predecessorNamespace, predecessorName string, found bool) {
+	key := keyFunc(namespace, name)
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for predecessor, successor := range m.successors {
+		if successor == key {
+			predecessorNamespace, predecessorName, _ = strings.Cut(predecessor, "/")
+			return predecessorNamespace, predecessorName, true
+		}
+	}
+
+	return "", "", false
+}
+
+// releasePredecessors stops retaining the services whose successor is the given, now exported, service and removes their
+// ServiceImports that were removed in the meantime.
+func (m *Map) releasePredecessors(successorKey string) {
+	for predecessor, successor := range m.successors {
+		if successor == successorKey {
+			klog.Infof("The successor %q of service %q is exported - releasing it", successorKey, predecessor)
+			m.release(predecessor)
+		}
+	}
+}
+
+func (m *Map) release(key string) {
+	delete(m.successors, key)
+
+	for _, serviceImport := range m.pendingRemoval[key] {
+		m.remove(key, serviceImport)
+	}
+
+	delete(m.pendingRemoval, key)
+}
+
+// cancelPendingRemoval drops the pending removal of the retained service's ServiceImport from the given cluster as it was
+// exported again.
+func (m *Map) cancelPendingRemoval(key, cluster string) {
+	pending, ok := m.pendingRemoval[key]
+	if !ok {
+		return
+	}
+
+	remaining := pending[:0]
+
+	for _, serviceImport := range pending {
+		if !exportedFrom(serviceImport.Status.Clusters, cluster) {
+			remaining = append(remaining, serviceImport)
+		}
+	}
+
+	if len(remaining) == 0 {
+		delete(m.pendingRemoval, key)
+	} else {
+		m.pendingRemoval[key] = remaining
+	}
+}
+
+func exportedFrom(clusters []mcsv1a1.ClusterStatus, cluster string) bool {
+	for i := range clusters {
+		if clusters[i].Cluster == cluster {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,86 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/submariner-io/admiral/pkg/log"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const (
+	scheduledActivation   = "ScheduledActivation"
+	invalidActivationTime = "InvalidActivationTime"
+)
+
+// getServiceExportActivation returns the time before which the Service isn't exported, as requested via the ServiceExport
+// annotation for a coordinated rollout across the clusterset, if any. If it's not an RFC 3339 time, a non-empty reason and
+// message are returned.
+func getServiceExportActivation(svcExport *mcsv1a1.ServiceExport) (activation time.Time, reason, msg string) {
+	value, ok := svcExport.GetAnnotations()[lhconstants.ActivationTimeAnnotation]
+	if !ok {
+		return time.Time{}, "", ""
+	}
+
+	activation, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, invalidActivationTime, fmt.Sprintf("The activation time %q is invalid: it must be an RFC 3339 time, "+
+			"eg %q", value, time.RFC3339)
+	}
+
+	return activation, "", ""
+}
+
+// scheduleServiceExportActivation returns true if the given activation time hasn't been reached yet, in which case a timer
+// is scheduled to resync the ServiceExport, and so export the Service, at that time.
+func (a *Controller) scheduleServiceExportActivation(svcExport *mcsv1a1.ServiceExport, activation time.Time) bool {
+	remaining := time.Until(activation)
+	if activation.IsZero() || remaining <= 0 {
+		return false
+	}
+
+	key := svcExport.Namespace + "/" + svcExport.Name
+	if scheduled, ok := a.exportActivationScheduled.Load(key); ok && scheduled.(time.Time).Equal(activation) {
+		return true
+	}
+
+	a.exportActivationScheduled.Store(key, activation)
+
+	klog.V(log.DEBUG).Infof("ServiceExport %s/%s is scheduled to be activated in %v", svcExport.Namespace, svcExport.Name, remaining)
+
+	time.AfterFunc(remaining, func() {
+		select {
+		case <-a.stopCh:
+		default:
+			klog.Infof("The activation time for ServiceExport %s/%s has been reached - exporting it", svcExport.Namespace,
+				svcExport.Name)
+			a.queueServiceExportForResync(svcExport)
+		}
+	})
+
+	return true
+}
+
+func scheduledActivationMessage(activation time.Time) string {
+	return fmt.Sprintf("The Service is scheduled to be exported at %s", activation.Format(time.RFC3339))
+}
@@ -22,20 +22,27 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/submariner-io/admiral/pkg/federate"
 	"github.com/submariner-io/admiral/pkg/log"
 	"github.com/submariner-io/admiral/pkg/resource"
 	"github.com/submariner-io/admiral/pkg/syncer"
 	"github.com/submariner-io/admiral/pkg/syncer/broker"
 	"github.com/submariner-io/admiral/pkg/util"
+	"github.com/submariner-io/admiral/pkg/workqueue"
 	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	discovery "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	validations "k8s.io/apimachinery/pkg/util/validation"
@@ -46,14 +53,30 @@ import (
 )
 
 const (
-	serviceUnavailable = "ServiceUnavailable"
-	invalidServiceType = "UnsupportedServiceType"
-	clusterIP          = "cluster-ip"
+	serviceUnavailable     = "ServiceUnavailable"
+	invalidServiceType     = "UnsupportedServiceType"
+	serviceRetrievalFailed = "ServiceRetrievalFailed"
+	localSyncFailed        = "LocalSyncFailed"
+	brokerSyncFailed       = "BrokerSyncFailed"
+	brokerPermissionDenied = "BrokerPermissionDenied"
+	noExportablePorts      = "NoExportablePorts"
+	serviceNotExportable   = "ServiceNotExportable"
+	serviceIPPending       = "ServiceIPPending"
+	serviceNotSelected     = "ServiceNotSelected"
+	clusterIP              = "cluster-ip"
 )
 
 type AgentConfig struct {
 	ServiceImportCounterName string
 	ServiceExportCounterName string
+	// TracerProvider is used to emit reconcile spans when tracing is enabled. If nil, the global provider is used.
+	TracerProvider trace.TracerProvider
+	// AvailabilityNotifier is notified when an imported service loses all its backing clusters and when it regains one. If
+	// nil, a webhook notifier is used if a webhook URL is configured.
+	AvailabilityNotifier AvailabilityNotifier
+	// BrokerClientFactory rebuilds the broker client from the current credentials once the broker persistently rejects
+	// them. If nil and no broker client is given, the client is built from the broker environment and secret.
+	BrokerClientFactory BrokerClientFactory
 }
 
 // nolint:gocritic // (hugeParam) This function modifies syncerConf so we don't want to pass by pointer.
@@ -61,14 +84,93 @@ func New(spec *AgentSpecification, syncerConf broker.SyncerConfig, kubeClientSet
 	syncerMetricNames AgentConfig,
 ) (*Controller, error) {
 	if errs := validations.IsDNS1123Label(spec.ClusterID); len(errs) > 0 {
-		return nil, errors.Errorf("%s is not a valid ClusterID %v", spec.ClusterID, errs)
+		return nil, errors.Errorf("%q is not a valid ClusterID %v", spec.ClusterID, errs)
+	}
+
+	if errs := validations.IsDNS1123Label(spec.Namespace); len(errs) > 0 {
+		return nil, errors.Errorf("%q is not a valid Namespace %v", spec.Namespace, errs)
+	}
+
+	if spec.ClustersetGroup != "" {
+		if errs := validations.IsDNS1123Label(spec.ClustersetGroup); len(errs) > 0 {
+			return nil, errors.Errorf("%q is not a valid ClustersetGroup %v", spec.ClustersetGroup, errs)
+		}
+	}
+
+	if !isValidImportNameScheme(spec.ImportNameScheme) {
+		return nil, errors.Errorf("%q is not a valid ImportNameScheme", spec.ImportNameScheme)
+	}
+
+	allowedProtocols, err := parseAllowedProtocols(spec.AllowedProtocols)
+	if err != nil {
+		return nil, err
+	}
+
+	var exportSelector labels.Selector
+
+	if spec.ExportLabelSelector != "" {
+		exportSelector, err = labels.Parse(spec.ExportLabelSelector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%q is not a valid ExportLabelSelector", spec.ExportLabelSelector)
+		}
+	}
+
+	serviceSelector := labels.Everything()
+
+	if spec.ServiceLabelSelector != "" {
+		serviceSelector, err = labels.Parse(spec.ServiceLabelSelector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%q is not a valid ServiceLabelSelector", spec.ServiceLabelSelector)
+		}
 	}
 
 	agentController := &Controller{
-		clusterID:        spec.ClusterID,
-		namespace:        spec.Namespace,
-		globalnetEnabled: spec.GlobalnetEnabled,
-		kubeClientSet:    kubeClientSet,
+		clusterID:                 spec.ClusterID,
+		namespace:                 spec.Namespace,
+		globalnetEnabled:          spec.GlobalnetEnabled,
+		kubeClientSet:             kubeClientSet,
+		clustersetGroup:           spec.ClustersetGroup,
+		importNameScheme:          spec.ImportNameScheme,
+		maxEndpointsPerImport:     spec.MaxEndpointsPerImport,
+		brokerThrottle:            newBrokerThrottle(spec.BrokerThrottleDelay),
+		propagationLatencyEnabled: spec.PropagationLatencyEnabled,
+		heartbeatPeriod:           spec.HeartbeatPeriod,
+		tracer:                    newTracer(spec.TracingEnabled, syncerMetricNames.TracerProvider),
+		serviceExportWorkers: serviceExportWorkers{
+			serviceExportQueue: workqueue.New("ServiceExport"),
+			workers:            spec.Workers,
+		},
+		exportSelection: exportSelection{
+			allowedProtocols: allowedProtocols,
+			exportSelector:   exportSelector,
+			serviceSelector:  serviceSelector,
+		},
+		serviceTracking: serviceTracking{
+			serviceNamespaces: map[string]map[string]bool{},
+		},
+		exportQuota: exportQuota{
+			maxExportedServices: spec.MaxExportedServices,
+		},
+		summaryState: summaryState{
+			summaryTrigger: make(chan struct{}, 1),
+		},
+		namespaceMappingState: namespaceMappingState{
+			namespaceMapping: newNamespaceMapping(),
+		},
+	}
+
+	if agentController.workers <= 0 {
+		agentController.workers = defaultWorkers
+	}
+
+	agentController.summaryUpdatePeriod = spec.SummaryUpdatePeriod
+	if agentController.summaryUpdatePeriod <= 0 {
+		agentController.summaryUpdatePeriod = defaultSummaryUpdatePeriod
+	}
+
+	agentController.exportAllNamespaces = make(map[string]bool, len(spec.ExportAllNamespaces))
+	for _, ns := range spec.ExportAllNamespaces {
+		agentController.exportAllNamespaces[ns] = true
 	}
 
 	_, gvr, err := util.ToUnstructuredResource(&mcsv1a1.ServiceExport{}, syncerConf.RestMapper)
@@ -78,14 +180,51 @@ func New(spec *AgentSpecification, syncerConf broker.SyncerConfig, kubeClientSet
 
 	agentController.serviceExportClient = syncerConf.LocalClient.Resource(*gvr)
 
+	_, serviceImportGVR, err := util.ToUnstructuredResource(&mcsv1a1.ServiceImport{}, syncerConf.RestMapper)
+	if err != nil {
+		return nil, errors.Wrap(err, "error converting resource")
+	}
+
+	_, endpointSliceGVR, err := util.ToUnstructuredResource(&discovery.EndpointSlice{}, syncerConf.RestMapper)
+	if err != nil {
+		return nil, errors.Wrap(err, "error converting resource")
+	}
+
+	agentController.endpointSliceGVR = *endpointSliceGVR
+
+	syncerConf.LocalClient = newWatchRecoveringClient(syncerConf.LocalClient, spec.WatchIdleTimeout)
+	syncerConf.LocalClient = newStatusSubresourceClient(syncerConf.LocalClient, *serviceImportGVR)
+
+	brokerClientFactory := syncerMetricNames.BrokerClientFactory
+	if brokerClientFactory == nil && syncerConf.BrokerClient == nil {
+		brokerClientFactory, err = newEnvironmentBrokerClientFactory(&syncerConf, *serviceImportGVR)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	syncerConf.BrokerClient, err = newCredentialRefreshingClient(syncerConf.BrokerClient, brokerClientFactory,
+		spec.BrokerAuthFailureThreshold)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating the broker client")
+	}
+
+	syncerConf.BrokerClient = newServerSideApplyClient(syncerConf.BrokerClient, *serviceImportGVR, spec.ClusterID)
+	syncerConf.BrokerClient = newStatusSubresourceClient(syncerConf.BrokerClient, *serviceImportGVR)
+
 	syncerConf.LocalNamespace = spec.Namespace
 	syncerConf.LocalClusterID = spec.ClusterID
 
 	syncerConf.ResourceConfigs = []broker.ResourceConfig{
 		{
-			LocalSourceNamespace: metav1.NamespaceAll,
-			LocalResourceType:    &mcsv1a1.ServiceImport{},
-			BrokerResourceType:   &mcsv1a1.ServiceImport{},
+			LocalSourceNamespace:  metav1.NamespaceAll,
+			LocalResourceType:     &mcsv1a1.ServiceImport{},
+			LocalTransform:        agentController.localServiceImportToBroker,
+			LocalOnSuccessfulSync: agentController.onSuccessfulServiceImportSync,
+			LocalResyncPeriod:     spec.ResyncPeriod,
+			BrokerResourceType:    &mcsv1a1.ServiceImport{},
+			BrokerTransform:       agentController.remoteServiceImportToLocal,
+			BrokerResyncPeriod:    spec.ResyncPeriod,
 			SyncCounterOpts: &prometheus.GaugeOpts{
 				Name: syncerMetricNames.ServiceImportCounterName,
 				Help: "Count of imported services",
@@ -98,6 +237,40 @@ func New(spec *AgentSpecification, syncerConf broker.SyncerConfig, kubeClientSet
 		return nil, errors.Wrap(err, "error creating ServiceImport syncer")
 	}
 
+	agentController.brokerImportClient = agentController.serviceImportSyncer.GetBrokerClient().Resource(*serviceImportGVR).Namespace(
+		agentController.serviceImportSyncer.GetBrokerNamespace())
+
+	agentController.brokerServiceImportWatcher, err = agentController.newBrokerServiceImportWatcher(syncerConf.RestMapper,
+		syncerConf.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	notifier := syncerMetricNames.AvailabilityNotifier
+	if notifier == nil && spec.AvailabilityWebhookURL != "" {
+		notifier = NewWebhookNotifier(spec.AvailabilityWebhookURL)
+	}
+
+	if notifier != nil {
+		agentController.availabilityTracker = newAvailabilityTracker(notifier)
+
+		agentController.availabilityWatcher, err = agentController.newAvailabilityWatcher(syncerConf.RestMapper, syncerConf.Scheme)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	agentController.minEndpointsWatcher, err = agentController.newMinEndpointsWatcher(syncerConf.RestMapper, syncerConf.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	agentController.serviceAliasController, err = agentController.newServiceAliasController(syncerConf.LocalClient,
+		syncerConf.RestMapper, syncerConf.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
 	syncerConf.LocalNamespace = metav1.NamespaceAll
 	syncerConf.ResourceConfigs = []broker.ResourceConfig{
 		{
@@ -120,35 +293,40 @@ func New(spec *AgentSpecification, syncerConf broker.SyncerConfig, kubeClientSet
 		return nil, errors.Wrap(err, "error creating EndpointSlice syncer")
 	}
 
-	agentController.serviceExportSyncer, err = syncer.NewResourceSyncer(&syncer.ResourceSyncerConfig{
-		Name:             "ServiceExport -> ServiceImport",
-		SourceClient:     syncerConf.LocalClient,
-		SourceNamespace:  metav1.NamespaceAll,
-		RestMapper:       syncerConf.RestMapper,
-		Federator:        agentController.serviceImportSyncer.GetLocalFederator(),
-		ResourceType:     &mcsv1a1.ServiceExport{},
-		Transform:        agentController.serviceExportToServiceImport,
-		OnSuccessfulSync: agentController.onSuccessfulServiceImportSync,
-		Scheme:           syncerConf.Scheme,
-		SyncCounterOpts: &prometheus.GaugeOpts{
-			Name: syncerMetricNames.ServiceExportCounterName,
-			Help: "Count of exported services",
-		},
+	err = agentController.newServiceExportSyncer(&syncer.ResourceSyncerConfig{
+		Name:                "ServiceExport -> ServiceImport",
+		SourceClient:        syncerConf.LocalClient,
+		SourceNamespace:     metav1.NamespaceAll,
+		RestMapper:          syncerConf.RestMapper,
+		ResourceType:        &mcsv1a1.ServiceExport{},
+		ResourcesEquivalent: serviceExportsEquivalent,
+		Scheme:              syncerConf.Scheme,
+		ResyncPeriod:        spec.ResyncPeriod,
+	}, &prometheus.GaugeOpts{
+		Name: syncerMetricNames.ServiceExportCounterName,
+		Help: "Count of exported services",
 	})
 	if err != nil {
-		return nil, errors.Wrap(err, "error creating ServiceExport syncer")
-	}
-
-	agentController.serviceSyncer, err = syncer.NewResourceSyncer(&syncer.ResourceSyncerConfig{
-		Name:            "Service deletion",
-		SourceClient:    syncerConf.LocalClient,
-		SourceNamespace: metav1.NamespaceAll,
-		RestMapper:      syncerConf.RestMapper,
-		Federator:       agentController.serviceImportSyncer.GetLocalFederator(),
-		ResourceType:    &corev1.Service{},
-		Transform:       agentController.serviceToRemoteServiceImport,
-		Scheme:          syncerConf.Scheme,
-	})
+		return nil, err
+	}
+
+	// The Service informer caches every Service in the cluster so allow restricting it via selectors to reduce the
+	// memory footprint. Services that don't match are treated as non-existent.
+	serviceSyncerConfig := &syncer.ResourceSyncerConfig{
+		Name:                "Service -> ServiceImport",
+		SourceClient:        syncerConf.LocalClient,
+		SourceNamespace:     metav1.NamespaceAll,
+		SourceLabelSelector: spec.ServiceLabelSelector,
+		SourceFieldSelector: spec.ServiceFieldSelector,
+		RestMapper:          syncerConf.RestMapper,
+		Federator:           federate.NewNoopFederator(),
+		ResourceType:        &corev1.Service{},
+		Transform:           agentController.syncServiceToServiceImport,
+		Scheme:              syncerConf.Scheme,
+		ResyncPeriod:        spec.ResyncPeriod,
+	}
+
+	agentController.serviceSyncer, err = syncer.NewResourceSyncer(serviceSyncerConfig)
 	if err != nil {
 		return nil, errors.Wrap(err, "error creating Service syncer")
 	}
@@ -159,6 +337,20 @@ func New(spec *AgentSpecification, syncerConf broker.SyncerConfig, kubeClientSet
 		return nil, err
 	}
 
+	if spec.NamespaceMappingConfigMap != "" {
+		agentController.namespaceMappingConfigMap = spec.NamespaceMappingConfigMap
+
+		agentController.namespaceMappingWatcher, err = agentController.newNamespaceMappingWatcher(spec.NamespaceMappingConfigMap,
+			syncerConf.RestMapper, syncerConf.LocalClient, syncerConf.Scheme)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if agentController.serviceImportController.globalIngressIPCache != nil {
+		agentController.serviceImportController.globalIngressIPCache.onServiceIPChanged = agentController.onServiceGlobalIPChanged
+	}
+
 	return agentController, nil
 }
 
@@ -168,6 +360,16 @@ func (a *Controller) Start(stopCh <-chan struct{}) error {
 	// Start the informer factories to begin populating the informer caches
 	klog.Info("Starting Agent controller")
 
+	a.stopCh = stopCh
+
+	if a.namespaceMappingWatcher != nil {
+		if err := a.namespaceMappingWatcher.Start(stopCh); err != nil {
+			return errors.Wrap(err, "error starting namespace mapping watcher")
+		}
+
+		a.loadNamespaceMapping()
+	}
+
 	if err := a.serviceExportSyncer.Start(stopCh); err != nil {
 		return errors.Wrap(err, "error starting ServiceExport syncer")
 	}
@@ -184,20 +386,35 @@ func (a *Controller) Start(stopCh <-chan struct{}) error {
 		return errors.Wrap(err, "error starting ServiceImport syncer")
 	}
 
+	if err := a.brokerServiceImportWatcher.Start(stopCh); err != nil {
+		return errors.Wrap(err, "error starting broker ServiceImport watcher")
+	}
+
+	if err := a.minEndpointsWatcher.Start(stopCh); err != nil {
+		return errors.Wrap(err, "error starting EndpointSlice minimum endpoints watcher")
+	}
+
+	if a.availabilityWatcher != nil {
+		go a.availabilityTracker.run(stopCh)
+
+		if err := a.availabilityWatcher.Start(stopCh); err != nil {
+			return errors.Wrap(err, "error starting ServiceImport availability watcher")
+		}
+	}
+
 	if err := a.serviceImportController.start(stopCh); err != nil {
 		return errors.Wrap(err, "error starting ServiceImport controller")
 	}
 
-	a.serviceExportSyncer.Reconcile(func() []runtime.Object {
-		return a.serviceImportLister(func(si *mcsv1a1.ServiceImport) runtime.Object {
-			return &mcsv1a1.ServiceExport{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      si.GetAnnotations()[lhconstants.OriginName],
-					Namespace: si.GetAnnotations()[lhconstants.OriginNamespace],
-				},
-			}
-		})
-	})
+	if err := a.serviceAliasController.start(stopCh); err != nil {
+		return errors.Wrap(err, "error starting ServiceAlias controller")
+	}
+
+	a.reconcileServiceExports()
+
+	a.startServiceExportWorkers(stopCh)
+
+	go a.syncUnprocessedServiceExports()
 
 	a.serviceSyncer.Reconcile(func() []runtime.Object {
 		return a.serviceImportLister(func(si *mcsv1a1.ServiceImport) runtime.Object {
@@ -210,6 +427,10 @@ func (a *Controller) Start(stopCh <-chan struct{}) error {
 		})
 	})
 
+	go a.runSummaryUpdater(stopCh)
+
+	go a.runHeartbeat(stopCh)
+
 	klog.Info("Agent controller started")
 
 	return nil
@@ -238,14 +459,38 @@ func (a *Controller) serviceExportToServiceImport(obj runtime.Object, numRequeue
 
 	klog.V(log.DEBUG).Infof("ServiceExport %s/%s %sd", svcExport.Namespace, svcExport.Name, op)
 
+	ctx, span := a.startSpan(context.Background(), "ServiceExport reconcile", svcExport.Name, svcExport.Namespace,
+		operationAttr.String(op.String()))
+	defer span.End()
+
+	if a.IsPaused() {
+		return nil, true
+	}
+
 	if op == syncer.Delete {
+		a.exportExpiryScheduled.Delete(svcExport.Namespace + "/" + svcExport.Name)
+		a.exportActivationScheduled.Delete(svcExport.Namespace + "/" + svcExport.Name)
+		a.releaseExportQuota(svcExport.Name, svcExport.Namespace)
+		a.triggerSummaryUpdate()
+
+		if _, dup := a.getDuplicateExportOrigin(svcExport.Name, svcExport.Namespace); dup {
+			return nil, false
+		}
+
 		return a.newServiceImport(svcExport.Name, svcExport.Namespace), false
 	}
 
-	obj, found, err := a.serviceSyncer.GetResource(svcExport.Name, svcExport.Namespace)
+	if a.checkServiceExportExpiry(svcExport) {
+		return nil, false
+	}
+
+	_, getSpan := a.tracer.Start(ctx, "get Service")
+	obj, found, err := a.getService(svcExport.Name, svcExport.Namespace)
+	endSpan(getSpan, err)
+
 	if err != nil {
 		// some other error. Log and requeue
-		a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionUnknown, "ServiceRetrievalFailed",
+		a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionUnknown, serviceRetrievalFailed,
 			fmt.Sprintf("Error retrieving the Service: %v", err))
 		klog.Errorf("Error retrieving Service (%s/%s): %v", svcExport.Namespace, svcExport.Name, err)
 
@@ -255,27 +500,85 @@ func (a *Controller) serviceExportToServiceImport(obj runtime.Object, numRequeue
 	if !found {
 		klog.V(log.DEBUG).Infof("Service to be exported (%s/%s) doesn't exist", svcExport.Namespace, svcExport.Name)
 		a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionFalse, serviceUnavailable,
-			"Service to be exported doesn't exist")
+			a.serviceUnavailableMessage(svcExport.Name, svcExport.Namespace))
 
 		return nil, true
 	}
 
-	if op == syncer.Update && getLastExportConditionReason(svcExport) != serviceUnavailable {
+	// A ServiceExport awaiting its activation time is re-evaluated on update in case the time was changed or removed.
+	if op == syncer.Update && getLastExportConditionReason(svcExport) != serviceUnavailable &&
+		getLastExportConditionReason(svcExport) != scheduledActivation && !a.isExportAnnotationsChanged(svcExport) {
 		return nil, false
 	}
 
-	svc := obj.(*corev1.Service)
+	return a.serviceToServiceImport(svcExport, obj.(*corev1.Service))
+}
 
-	svcType, ok := getServiceImportType(svc)
+func (a *Controller) serviceToServiceImport(svcExport *mcsv1a1.ServiceExport, svc *corev1.Service) (runtime.Object, bool) {
+	if !a.isServiceSelected(svc) {
+		a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionFalse, serviceNotSelected,
+			a.serviceNotSelectedMessage())
+		klog.V(log.DEBUG).Infof("Service (%s/%s) isn't selected for export", svc.Namespace, svc.Name)
 
-	if !ok {
-		a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionFalse, invalidServiceType,
-			fmt.Sprintf("Service of type %v not supported", svc.Spec.Type))
-		klog.Errorf("Service type %q not supported", svc.Spec.Type)
+		// The Service is re-evaluated when it's updated so no need to requeue.
+		return nil, false
+	}
+
+	if ok, reason := validateExportability(svc, a.allowedProtocols); !ok {
+		msg := a.exportabilityMessage(svc, reason)
+		a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionFalse, reason, msg)
+		klog.Errorf("Service (%s/%s) can't be exported: %s", svc.Namespace, svc.Name, msg)
+
+		return nil, false
+	}
+
+	if origin, dup := a.getDuplicateExportOrigin(svcExport.Name, svcExport.Namespace); dup {
+		a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionFalse, duplicateExport,
+			fmt.Sprintf("The ServiceImport name %q is already in use by the exported Service %q",
+				a.getObjectNameWithClusterID(svcExport.Name, svcExport.Namespace), origin))
+		klog.Errorf("ServiceExport (%s/%s) duplicates the export of Service %q", svcExport.Namespace, svcExport.Name, origin)
+
+		// Requeue so it's exported if the other Service is unexported.
+		return nil, true
+	}
+
+	svcType, _ := getExportedServiceImportType(svcExport, svc)
+
+	annotations, reason, msg := a.validateExportAnnotations(svcExport, svc, svcType)
+	if reason != "" {
+		a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionFalse, reason, msg)
+		klog.Errorf("ServiceExport (%s/%s) can't be exported: %s", svcExport.Namespace, svcExport.Name, msg)
+
+		// Requeue so it's exported if the annotation is fixed or the conflicting Service is unexported.
+		return nil, true
+	}
+
+	if a.scheduleServiceExportActivation(svcExport, annotations.activation) {
+		a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionFalse, scheduledActivation,
+			scheduledActivationMessage(annotations.activation))
+		klog.V(log.DEBUG).Infof("ServiceExport (%s/%s) isn't exported until %v", svcExport.Namespace, svcExport.Name,
+			annotations.activation)
 
+		// The ServiceExport is resynced at the activation time so no need to requeue.
 		return nil, false
 	}
 
+	if svcType == mcsv1a1.Headless && isHeadlessForced(svcExport) {
+		reason, msg, err := a.checkServiceEndpoints(svc)
+		if err != nil {
+			klog.Errorf("Error checking the Endpoints of Service (%s/%s): %v", svc.Namespace, svc.Name, err)
+			return nil, true
+		}
+
+		if reason != "" {
+			a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionFalse, reason, msg)
+			klog.V(log.DEBUG).Infof("ServiceExport (%s/%s) can't be exported yet: %s", svcExport.Namespace, svcExport.Name, msg)
+
+			// Requeue so it's exported once the Endpoints are populated.
+			return nil, true
+		}
+	}
+
 	serviceImport := a.newServiceImport(svcExport.Name, svcExport.Namespace)
 
 	serviceImport.Spec = mcsv1a1.ServiceImportSpec{
@@ -292,7 +595,33 @@ func (a *Controller) serviceExportToServiceImport(obj runtime.Object, numRequeue
 		},
 	}
 
-	if svcType == mcsv1a1.ClusterSetIP {
+	// The ServiceImport spec has no equivalent field so record the policy as an annotation for consumers that need to
+	// honor source IP preservation.
+	if svc.Spec.ExternalTrafficPolicy != "" {
+		serviceImport.Annotations[lhconstants.ExternalTrafficPolicyAnnotation] = string(svc.Spec.ExternalTrafficPolicy)
+	}
+
+	for k, v := range annotations.serviceImport {
+		serviceImport.Annotations[k] = v
+	}
+
+	a.stampExportTimestamp(serviceImport)
+	a.stampHeartbeat(serviceImport)
+
+	if svc.Spec.Type == corev1.ServiceTypeExternalName {
+		serviceImport.Annotations[lhconstants.ExternalNameAnnotation] = svc.Spec.ExternalName
+		serviceImport.Spec.Ports = a.getPortsForService(svc)
+	} else if svcType == mcsv1a1.ClusterSetIP {
+		// A newly created Service may briefly have no ClusterIP until the apiserver assigns one so wait rather than export
+		// an empty IP. The Service update on assignment, or the requeue, re-syncs it.
+		if getClusterIP(svc) == "" {
+			klog.V(log.DEBUG).Infof("Service to be exported (%s/%s) doesn't have a ClusterIP yet", svcExport.Namespace, svcExport.Name)
+			a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionFalse, serviceIPPending,
+				"Awaiting the assignment of a ClusterIP to the Service")
+
+			return nil, true
+		}
+
 		if a.globalnetEnabled {
 			ip, reason, msg := a.getGlobalIP(svc)
 			if ip == "" {
@@ -305,18 +634,50 @@ func (a *Controller) serviceExportToServiceImport(obj runtime.Object, numRequeue
 
 			serviceImport.Spec.IPs = []string{ip}
 		} else {
-			serviceImport.Spec.IPs = []string{svc.Spec.ClusterIP}
+			serviceImport.Spec.IPs = []string{getClusterIP(svc)}
 		}
 
 		serviceImport.Spec.Ports = a.getPortsForService(svc)
+
 		/* We also store the clusterIP in an annotation as an optimization to recover it in case the IPs are
 		cleared out when here's no backing Endpoint pods.
 		*/
 		serviceImport.Annotations[clusterIP] = serviceImport.Spec.IPs[0]
 	}
 
-	a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionFalse, "AwaitingSync",
-		"Awaiting sync of the ServiceImport to the broker")
+	// Check the quota last so exports that aren't otherwise ready don't take up a slot.
+	if reason, msg := a.checkExportQuota(svcExport.Name, svcExport.Namespace); reason != "" {
+		a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionFalse, reason, msg)
+		klog.Errorf("ServiceExport (%s/%s) can't be exported: %s", svcExport.Namespace, svcExport.Name, msg)
+
+		// Requeue so it's exported if the export quota frees up.
+		return nil, true
+	}
+
+	/* Record the handled force-resync value on the ServiceImport so the re-derived ServiceImport differs from the
+	existing one and is rewritten locally and to the broker.
+	*/
+	if resync := svcExport.GetAnnotations()[lhconstants.ForceResyncAnnotation]; resync != "" {
+		serviceImport.Annotations[lhconstants.ForceResyncAnnotation] = resync
+	}
+
+	if hash := hashExportAnnotations(svcExport); hash != "" {
+		serviceImport.Annotations[exportAnnotationsHash] = hash
+	}
+
+	/* Writing an unchanged ServiceImport is a no-op so the ServiceImport syncer won't sync it to the broker and report the
+	status - report it here from the broker's copy instead.
+	*/
+	if a.isLocalServiceImportUnchanged(serviceImport) {
+		a.updateBrokerSyncedStatus(serviceImport)
+		return nil, false
+	}
+
+	// Don't overwrite a previous local sync failure while retrying to avoid flapping the status.
+	if getLastExportConditionReason(svcExport) != localSyncFailed {
+		a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionFalse, "AwaitingSync",
+			"Awaiting sync of the ServiceImport to the broker")
+	}
 
 	klog.V(log.DEBUG).Infof("Returning ServiceImport: %#v", serviceImport)
 
@@ -333,17 +694,37 @@ func getLastExportConditionReason(svcExport *mcsv1a1.ServiceExport) string {
 }
 
 func getServiceImportType(service *corev1.Service) (mcsv1a1.ServiceImportType, bool) {
+	// An ExternalName Service has no IP so it's exported as a ClusterSetIP ServiceImport that resolves to a CNAME.
+	if service.Spec.Type == corev1.ServiceTypeExternalName {
+		return mcsv1a1.ClusterSetIP, true
+	}
+
 	if service.Spec.Type != "" && service.Spec.Type != corev1.ServiceTypeClusterIP {
 		return "", false
 	}
 
-	if service.Spec.ClusterIP == corev1.ClusterIPNone {
+	if getClusterIP(service) == corev1.ClusterIPNone {
 		return mcsv1a1.Headless, true
 	}
 
 	return mcsv1a1.ClusterSetIP, true
 }
 
+// getClusterIP returns the primary cluster IP of the Service. The ClusterIP field is authoritative if set, otherwise we
+// fall back to the first entry in ClusterIPs as the ClusterIP field may not be populated yet while the Service is being
+// mutated.
+func getClusterIP(service *corev1.Service) string {
+	if service.Spec.ClusterIP != "" {
+		return service.Spec.ClusterIP
+	}
+
+	if len(service.Spec.ClusterIPs) > 0 {
+		return service.Spec.ClusterIPs[0]
+	}
+
+	return ""
+}
+
 func (a *Controller) onSuccessfulServiceImportSync(synced runtime.Object, op syncer.Operation) {
 	if op == syncer.Delete {
 		return
@@ -351,18 +732,140 @@ func (a *Controller) onSuccessfulServiceImportSync(synced runtime.Object, op syn
 
 	serviceImport := synced.(*mcsv1a1.ServiceImport)
 
-	a.updateExportedServiceStatus(serviceImport.GetAnnotations()[lhconstants.OriginName],
-		serviceImport.GetAnnotations()[lhconstants.OriginNamespace], corev1.ConditionTrue, "",
-		"Service was successfully synced to the broker")
+	a.updateSyncedExportStatus(serviceImport.GetAnnotations()[lhconstants.OriginName],
+		serviceImport.GetAnnotations()[lhconstants.OriginNamespace], getMinEndpoints(serviceImport.GetAnnotations()))
+}
+
+// isLocalServiceImportUnchanged returns true if the existing local ServiceImport already matches the given derived one.
+// Labels added by the federator on write are ignored.
+func (a *Controller) isLocalServiceImportUnchanged(serviceImport *mcsv1a1.ServiceImport) bool {
+	obj, found, err := a.serviceImportSyncer.GetLocalResource(serviceImport.Name, a.namespace, &mcsv1a1.ServiceImport{})
+	if err != nil || !found {
+		return false
+	}
+
+	existing := obj.(*mcsv1a1.ServiceImport)
+
+	for k, v := range serviceImport.Labels {
+		if existing.Labels[k] != v {
+			return false
+		}
+	}
+
+	return reflect.DeepEqual(existing.Annotations, serviceImport.Annotations) &&
+		equality.Semantic.DeepEqual(existing.Spec, serviceImport.Spec)
+}
+
+// updateBrokerSyncedStatus reports the given ServiceImport as synced in the status of its ServiceExport if the broker has
+// it. Otherwise the status is left as is - the broker ServiceImport watcher restores it.
+func (a *Controller) updateBrokerSyncedStatus(serviceImport *mcsv1a1.ServiceImport) {
+	_, err := a.brokerImportClient.Get(context.TODO(), serviceImport.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return
+	}
+
+	if err != nil {
+		klog.Errorf("Error retrieving the broker ServiceImport %q: %v", serviceImport.Name, err)
+		return
+	}
+
+	a.onSuccessfulServiceImportSync(serviceImport, syncer.Update)
+}
+
+func (a *Controller) localServiceImportToBroker(obj runtime.Object, numRequeues int, op syncer.Operation) (runtime.Object, bool) {
+	if a.IsPaused() {
+		return nil, true
+	}
+
+	_, span := a.startSpan(context.Background(), "ServiceImport broker sync",
+		obj.(*mcsv1a1.ServiceImport).GetAnnotations()[lhconstants.OriginName],
+		obj.(*mcsv1a1.ServiceImport).GetAnnotations()[lhconstants.OriginNamespace], operationAttr.String(op.String()))
+	defer span.End()
+
+	if op == syncer.Delete {
+		a.recreateLocalServiceImport(obj.(*mcsv1a1.ServiceImport))
+		return obj, false
+	}
+
+	serviceImport := obj.(*mcsv1a1.ServiceImport)
+
+	if wait := a.brokerThrottle.remaining(); wait > 0 {
+		klog.V(log.DEBUG).Infof("Holding off syncing ServiceImport %q to the broker for %v as it's throttling requests",
+			serviceImport.Name, wait)
+		return nil, true
+	}
+
+	// A requeue here means the previous attempt to distribute the ServiceImport to the broker failed. The syncer doesn't
+	// expose the error so retry the write here to check if the broker is throttling requests. On success, the syncer's
+	// subsequent write is a no-op.
+	if numRequeues > 0 {
+		err := a.distributeToBroker(serviceImport)
+		if err == nil {
+			return a.toBrokerServiceImport(serviceImport), false
+		}
+
+		reason := brokerSyncFailed
+		msg := "Failed to sync the ServiceImport to the broker - retrying"
+
+		switch {
+		case a.brokerThrottle.observe(err):
+			msg = fmt.Sprintf("The broker is throttling requests - retrying in %v", a.brokerThrottle.remaining().Round(time.Second))
+		case apierrors.IsForbidden(err):
+			// The requeue backoff is capped so this is retried periodically in case the RBAC permissions are fixed.
+			reason = brokerPermissionDenied
+			msg = fmt.Sprintf("The agent isn't permitted to write ServiceImports in the broker namespace %q - retrying",
+				a.serviceImportSyncer.GetBrokerNamespace())
+			klog.Errorf("Permission denied syncing ServiceImport %q to the broker: %v", serviceImport.Name, err)
+		default:
+			klog.Errorf("Error syncing ServiceImport %q to the broker: %v", serviceImport.Name, err)
+		}
+
+		a.updateExportedServiceStatus(serviceImport.GetAnnotations()[lhconstants.OriginName],
+			serviceImport.GetAnnotations()[lhconstants.OriginNamespace], corev1.ConditionFalse, reason, msg)
+
+		return nil, true
+	}
+
+	return a.toBrokerServiceImport(serviceImport), false
 }
 
 func (a *Controller) serviceToRemoteServiceImport(obj runtime.Object, numRequeues int, op syncer.Operation) (runtime.Object, bool) {
-	if op != syncer.Delete {
-		// Ignore create/update
+	svc := obj.(*corev1.Service)
+
+	a.trackServiceNamespace(svc, op)
+
+	if a.isServiceSelectorChanged(svc, op) {
+		klog.V(log.DEBUG).Infof("The selector for Service %s/%s changed", svc.Namespace, svc.Name)
+		a.serviceImportController.resyncEndpoints(a.namespace + "/" + a.getObjectNameWithClusterID(svc.Name, svc.Namespace))
+	}
+
+	if op == syncer.Create && !a.isExportAllNamespace(svc.Namespace) {
+		a.onServiceCreated(svc)
 		return nil, false
 	}
 
-	svc := obj.(*corev1.Service)
+	if a.IsPaused() {
+		return nil, true
+	}
+
+	if a.isExportAllNamespace(svc.Namespace) {
+		var err error
+
+		if op == syncer.Delete {
+			err = a.deleteAutoExport(svc)
+		} else {
+			err = a.autoExportService(svc)
+		}
+
+		if err != nil {
+			klog.Errorf("Error auto-exporting Service (%s/%s): %v", svc.Namespace, svc.Name, err)
+			return nil, true
+		}
+
+		if op == syncer.Create {
+			return nil, false
+		}
+	}
 
 	obj, found, err := a.serviceExportSyncer.GetResource(svc.Name, svc.Namespace)
 	if err != nil {
@@ -378,19 +881,124 @@ func (a *Controller) serviceToRemoteServiceImport(obj runtime.Object, numRequeue
 
 	svcExport := obj.(*mcsv1a1.ServiceExport)
 
+	// The existing ServiceImport belongs to another Service so it mustn't be modified or deleted.
+	if _, dup := a.getDuplicateExportOrigin(svcExport.Name, svcExport.Namespace); dup {
+		return nil, false
+	}
+
+	if op == syncer.Update {
+		return a.onServiceUpdated(svcExport, svc)
+	}
+
 	serviceImport := a.newServiceImport(svcExport.Name, svcExport.Namespace)
 
 	// Update the status and requeue
 	a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionFalse, serviceUnavailable,
-		"Service to be exported doesn't exist")
+		a.serviceUnavailableMessage(svcExport.Name, svcExport.Namespace))
 
 	return serviceImport, false
 }
 
+// isServiceSelectorChanged records the Service's selector and returns true if it changed since the Service was last seen.
+// A selector change changes the backing pods so the Endpoints need to be resynced.
+func (a *Controller) isServiceSelectorChanged(svc *corev1.Service, op syncer.Operation) bool {
+	key := svc.Namespace + "/" + svc.Name
+
+	if op == syncer.Delete {
+		a.serviceSelectors.Delete(key)
+		return false
+	}
+
+	selector := labels.SelectorFromSet(svc.Spec.Selector).String()
+
+	prev, found := a.serviceSelectors.Load(key)
+	a.serviceSelectors.Store(key, selector)
+
+	return found && prev.(string) != selector
+}
+
+// onServiceUpdated handles an exported Service whose type flipped between ClusterIP and headless, eg if it was deleted
+// and recreated as a different type while the deletion was missed. The ServiceImport type is treated as immutable so the
+// existing ServiceImport is deleted and a new one is returned to be created.
+func (a *Controller) onServiceUpdated(svcExport *mcsv1a1.ServiceExport, svc *corev1.Service) (runtime.Object, bool) {
+	svcType, ok := getExportedServiceImportType(svcExport, svc)
+	if !ok {
+		return nil, false
+	}
+
+	lastReason := getLastExportConditionReason(svcExport)
+
+	if !a.serviceSelector.Matches(labels.Set(svc.Labels)) {
+		if lastReason == serviceUnavailable {
+			return nil, false
+		}
+
+		return a.onServiceNoLongerMatched(svcExport, svc)
+	}
+
+	if !a.isServiceSelected(svc) {
+		if lastReason == serviceNotSelected {
+			return nil, false
+		}
+
+		return a.onServiceUnselected(svcExport, svc)
+	}
+
+	if lastReason == serviceNotSelected {
+		klog.V(log.DEBUG).Infof("Service %s/%s is now selected for export", svc.Namespace, svc.Name)
+		return a.serviceToServiceImport(svcExport, svc)
+	}
+
+	if lastReason == serviceIPPending && getClusterIP(svc) != "" {
+		klog.V(log.DEBUG).Infof("A ClusterIP was assigned to exported Service %s/%s", svc.Namespace, svc.Name)
+		return a.serviceToServiceImport(svcExport, svc)
+	}
+
+	serviceImport := a.newServiceImport(svcExport.Name, svcExport.Namespace)
+
+	obj, found, err := a.serviceImportSyncer.GetLocalResource(serviceImport.Name, a.namespace, serviceImport)
+	if err != nil {
+		klog.Errorf("Error retrieving ServiceImport for Service (%s/%s): %v", svc.Namespace, svc.Name, err)
+		return nil, true
+	}
+
+	if !found {
+		return nil, false
+	}
+
+	existing := obj.(*mcsv1a1.ServiceImport)
+
+	if existing.Spec.Type == svcType {
+		// Rebuild the ServiceImport if the ports changed, eg a port was renamed, so the port set is replaced.
+		if svcType == mcsv1a1.ClusterSetIP && !servicePortsEqual(existing.Spec.Ports, a.getPortsForService(svc)) {
+			klog.Infof("The ports of exported Service %s/%s changed - updating the ServiceImport", svc.Namespace, svc.Name)
+			return a.serviceToServiceImport(svcExport, svc)
+		}
+
+		return nil, false
+	}
+
+	klog.Infof("The type of exported Service %s/%s changed to %q - recreating the ServiceImport", svc.Namespace, svc.Name,
+		svcType)
+
+	err = a.serviceImportSyncer.GetLocalFederator().Delete(serviceImport)
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Error deleting ServiceImport for Service (%s/%s): %v", svc.Namespace, svc.Name, err)
+		return nil, true
+	}
+
+	return a.serviceToServiceImport(svcExport, svc)
+}
+
 func (a *Controller) updateExportedServiceStatus(name, namespace string, status corev1.ConditionStatus, reason, msg string) {
 	klog.V(log.DEBUG).Infof("updateExportedServiceStatus for (%s/%s) - Type: %q, Status: %q, Reason: %q, Message: %q",
 		namespace, name, mcsv1a1.ServiceExportValid, status, reason, msg)
 
+	_, span := a.startSpan(context.Background(), "update ServiceExport status", name, namespace,
+		attribute.String("status", string(status)), attribute.String("reason", reason))
+
+	conditionSet := false
+
 	retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		toUpdate, err := a.getServiceExport(name, namespace)
 		if apierrors.IsNotFound(err) {
@@ -424,13 +1032,23 @@ func (a *Controller) updateExportedServiceStatus(name, namespace string, status
 		}
 
 		_, err = a.serviceExportClient.Namespace(toUpdate.Namespace).UpdateStatus(context.TODO(), raw, metav1.UpdateOptions{})
+		conditionSet = err == nil
 
 		return errors.Wrap(err, "error from UpdateStatus")
 	})
 
+	endSpan(span, retryErr)
+
 	if retryErr != nil {
 		klog.Errorf("Error updating status for ServiceExport (%s/%s): %+v", namespace, name, retryErr)
 	}
+
+	if conditionSet {
+		countConflict(reason)
+	}
+
+	a.updateLastExportError(name, namespace, status, reason, msg)
+	a.triggerSummaryUpdate()
 }
 
 func (a *Controller) getServiceExport(name, namespace string) (*mcsv1a1.ServiceExport, error) {
@@ -455,7 +1073,7 @@ func serviceExportConditionEqual(c1, c2 *mcsv1a1.ServiceExportCondition) bool {
 }
 
 func (a *Controller) newServiceImport(name, namespace string) *mcsv1a1.ServiceImport {
-	return &mcsv1a1.ServiceImport{
+	serviceImport := &mcsv1a1.ServiceImport{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: a.getObjectNameWithClusterID(name, namespace),
 			Annotations: map[string]string{
@@ -469,12 +1087,28 @@ func (a *Controller) newServiceImport(name, namespace string) *mcsv1a1.ServiceIm
 			},
 		},
 	}
+
+	if a.clustersetGroup != "" {
+		serviceImport.Labels[lhconstants.ClustersetGroupLabel] = a.clustersetGroup
+	}
+
+	return serviceImport
+}
+
+// isInClustersetGroup returns whether a resource on the broker, which may be shared by several clustersets, belongs to
+// this cluster's clusterset group. If no group is configured, resources of all groups are imported.
+func (a *Controller) isInClustersetGroup(labels map[string]string) bool {
+	return a.clustersetGroup == "" || labels[lhconstants.ClustersetGroupLabel] == a.clustersetGroup
 }
 
 func (a *Controller) getPortsForService(service *corev1.Service) []mcsv1a1.ServicePort {
 	mcsPorts := make([]mcsv1a1.ServicePort, 0, len(service.Spec.Ports))
 
 	for _, port := range service.Spec.Ports {
+		if !a.isProtocolAllowed(port.Protocol) {
+			continue
+		}
+
 		mcsPorts = append(mcsPorts, mcsv1a1.ServicePort{
 			Name:     port.Name,
 			Protocol: port.Protocol,
@@ -485,12 +1119,31 @@ func (a *Controller) getPortsForService(service *corev1.Service) []mcsv1a1.Servi
 	return mcsPorts
 }
 
-func (a *Controller) getObjectNameWithClusterID(name, namespace string) string {
-	return name + "-" + namespace + "-" + a.clusterID
+func servicePortsEqual(p1, p2 []mcsv1a1.ServicePort) bool {
+	if len(p1) != len(p2) {
+		return false
+	}
+
+	for i := range p1 {
+		if !reflect.DeepEqual(p1[i], p2[i]) {
+			return false
+		}
+	}
+
+	return true
 }
 
 func (a *Controller) remoteEndpointSliceToLocal(obj runtime.Object, numRequeues int, op syncer.Operation) (runtime.Object, bool) {
 	endpointSlice := obj.(*discovery.EndpointSlice)
+
+	if !a.isInClustersetGroup(endpointSlice.Labels) {
+		return nil, false
+	}
+
+	if clusterset, ok := endpointSlice.Labels[lhconstants.LabelSourceNamespace]; ok {
+		endpointSlice.Labels[lhconstants.LabelSourceNamespace] = a.namespaceMapping.localNamespace(clusterset)
+	}
+
 	endpointSlice.Namespace = endpointSlice.GetObjectMeta().GetLabels()[lhconstants.LabelSourceNamespace]
 
 	return endpointSlice, false
@@ -504,7 +1157,11 @@ func (a *Controller) filterLocalEndpointSlices(obj runtime.Object, numRequeues i
 		return nil, false
 	}
 
-	return obj, false
+	if a.IsPaused() {
+		return nil, true
+	}
+
+	return a.toBrokerEndpointSlice(endpointSlice), false
 }
 
 func (a *Controller) getGlobalIP(service *corev1.Service) (ip, reason, msg string) {
@@ -528,3 +1185,29 @@ func (a *Controller) getIngressIP(name, namespace string) (*IngressIP, bool) {
 
 	return parseIngressIP(obj), true
 }
+
+// isServiceSelected returns true if the given Service matches the export label selector, if one is configured.
+func (a *Controller) isServiceSelected(svc *corev1.Service) bool {
+	return a.exportSelector == nil || a.exportSelector.Matches(labels.Set(svc.Labels))
+}
+
+func (a *Controller) serviceNotSelectedMessage() string {
+	return fmt.Sprintf("The Service's labels do not match the export label selector %q", a.exportSelector.String())
+}
+
+// onServiceUnselected handles an exported Service whose labels no longer match the export label selector by deleting
+// its ServiceImport.
+func (a *Controller) onServiceUnselected(svcExport *mcsv1a1.ServiceExport, svc *corev1.Service) (runtime.Object, bool) {
+	klog.Infof("Exported Service %s/%s is no longer selected for export - deleting the ServiceImport", svc.Namespace, svc.Name)
+
+	err := a.serviceImportSyncer.GetLocalFederator().Delete(a.newServiceImport(svcExport.Name, svcExport.Namespace))
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Error deleting ServiceImport for Service (%s/%s): %v", svc.Namespace, svc.Name, err)
+		return nil, true
+	}
+
+	a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionFalse, serviceNotSelected,
+		a.serviceNotSelectedMessage())
+
+	return nil, false
+}
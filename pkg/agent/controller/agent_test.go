@@ -0,0 +1,101 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/lighthouse/pkg/agent/controller"
+)
+
+var _ = Describe("Agent creation", func() {
+	var (
+		t    *testDriver
+		spec controller.AgentSpecification
+	)
+
+	BeforeEach(func() {
+		t = newTestDiver()
+		spec = t.cluster1.agentSpec
+	})
+
+	newAgent := func() error {
+		syncerConfig := *t.syncerConfig
+		syncerConfig.LocalClient = t.cluster1.localDynClient
+
+		_, err := controller.New(&spec, syncerConfig, t.cluster1.localKubeClient, newAgentConfig())
+
+		return err
+	}
+
+	When("the ClusterID and Namespace are valid", func() {
+		It("should succeed", func() {
+			Expect(newAgent()).To(Succeed())
+		})
+	})
+
+	When("the ClusterID contains invalid DNS characters", func() {
+		It("should return an error", func() {
+			spec.ClusterID = "east_1"
+			Expect(newAgent()).To(MatchError(ContainSubstring("not a valid ClusterID")))
+		})
+	})
+
+	When("an invalid allowed protocol is specified", func() {
+		It("should return an error", func() {
+			spec.AllowedProtocols = []string{"TCP", "ICMP"}
+			Expect(newAgent()).To(MatchError(ContainSubstring("not a valid allowed protocol")))
+		})
+	})
+
+	When("an invalid clusterset group is specified", func() {
+		It("should return an error", func() {
+			spec.ClustersetGroup = "Blue_Group"
+			Expect(newAgent()).To(MatchError(ContainSubstring("not a valid ClustersetGroup")))
+		})
+	})
+
+	When("an invalid import name scheme is specified", func() {
+		It("should return an error", func() {
+			spec.ImportNameScheme = "short"
+			Expect(newAgent()).To(MatchError(ContainSubstring("not a valid ImportNameScheme")))
+		})
+	})
+
+	When("an invalid export label selector is specified", func() {
+		It("should return an error", func() {
+			spec.ExportLabelSelector = "export in (true"
+			Expect(newAgent()).To(MatchError(ContainSubstring("not a valid ExportLabelSelector")))
+		})
+	})
+
+	When("the ClusterID is empty", func() {
+		It("should return an error", func() {
+			spec.ClusterID = ""
+			Expect(newAgent()).To(MatchError(ContainSubstring("not a valid ClusterID")))
+		})
+	})
+
+	When("the Namespace is invalid", func() {
+		It("should return an error", func() {
+			spec.Namespace = "Submariner.Operator"
+			Expect(newAgent()).To(MatchError(ContainSubstring("not a valid Namespace")))
+		})
+	})
+})
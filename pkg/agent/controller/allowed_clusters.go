@@ -0,0 +1,61 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	validations "k8s.io/apimachinery/pkg/util/validation"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const invalidAllowedClusters = "InvalidAllowedClusters"
+
+// getServiceExportAllowedClusters returns the comma-separated IDs of the clusters allowed to consume the service, as requested
+// via the ServiceExport annotation, if any, normalized to a sorted list without duplicates. If the list is empty or any
+// ID isn't a valid cluster ID, a non-empty reason and message are returned.
+func getServiceExportAllowedClusters(svcExport *mcsv1a1.ServiceExport) (allowed, reason, msg string) {
+	value, ok := svcExport.GetAnnotations()[lhconstants.AllowedClustersAnnotation]
+	if !ok {
+		return "", "", ""
+	}
+
+	seen := map[string]bool{}
+	clusterIDs := []string{}
+
+	for _, clusterID := range strings.Split(value, ",") {
+		clusterID = strings.TrimSpace(clusterID)
+		if errs := validations.IsDNS1123Label(clusterID); len(errs) > 0 {
+			return "", invalidAllowedClusters, fmt.Sprintf("The allowed clusters %q are invalid: %q is not a valid cluster ID %v",
+				value, clusterID, errs)
+		}
+
+		if !seen[clusterID] {
+			seen[clusterID] = true
+			clusterIDs = append(clusterIDs, clusterID)
+		}
+	}
+
+	sort.Strings(clusterIDs)
+
+	return strings.Join(clusterIDs, ","), "", ""
+}
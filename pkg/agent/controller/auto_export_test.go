@@ -0,0 +1,88 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Auto-exporting Services", func() {
+	var t *testDriver
+
+	BeforeEach(func() {
+		t = newTestDiver()
+		t.cluster1.agentSpec.ExportAllNamespaces = []string{serviceNamespace}
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("a Service is created in an export-all namespace", func() {
+		It("should auto-create a ServiceExport and sync a ServiceImport", func() {
+			t.createService()
+
+			obj := test.AwaitResource(t.cluster1.localServiceExportClient, t.service.Name)
+			Expect(obj.GetLabels()).To(HaveKeyWithValue(lhconstants.AutoExportedLabel, "true"))
+
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+		})
+
+		When("the Service is subsequently deleted", func() {
+			It("should delete the auto-created ServiceExport and the ServiceImport", func() {
+				t.createService()
+				t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+				t.deleteService()
+				test.AwaitNoResource(t.cluster1.localServiceExportClient, t.service.Name)
+				t.awaitServiceUnexported()
+			})
+		})
+	})
+
+	When("a ServiceExport was manually created", func() {
+		It("should not modify or delete it", func() {
+			t.createServiceExport()
+			t.createService()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			obj := test.AwaitResource(t.cluster1.localServiceExportClient, t.service.Name)
+			Expect(obj.GetLabels()).ToNot(HaveKey(lhconstants.AutoExportedLabel))
+
+			t.deleteService()
+			t.awaitServiceUnexported()
+
+			Consistently(func() error {
+				_, err := t.cluster1.localServiceExportClient.Get(context.TODO(), t.service.Name, metav1.GetOptions{})
+				return err
+			}, 300*time.Millisecond).Should(Succeed())
+		})
+	})
+})
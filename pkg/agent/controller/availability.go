@@ -0,0 +1,233 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/federate"
+	"github.com/submariner-io/admiral/pkg/log"
+	"github.com/submariner-io/admiral/pkg/syncer"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const availabilityQueueSize = 100
+
+// AvailabilityEvent describes a clusterset service becoming unavailable, ie no cluster exports it anymore, or available
+// again.
+type AvailabilityEvent struct {
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
+	Available bool      `json:"available"`
+	Time      time.Time `json:"time"`
+}
+
+// AvailabilityNotifier is notified of the availability transitions of the clusterset services imported by this cluster.
+// A returned error causes the notification to be retried.
+type AvailabilityNotifier interface {
+	Notify(event *AvailabilityEvent) error
+}
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier returns an AvailabilityNotifier that POSTs each event as JSON to the given URL.
+func NewWebhookNotifier(url string) AvailabilityNotifier {
+	return &webhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (w *webhookNotifier) Notify(event *AvailabilityEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "error marshalling the availability event")
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "error creating the webhook request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "error posting to webhook %q", w.url)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("webhook %q responded with status %d", w.url, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// availabilityTracker tracks the clusters exporting each imported service to detect when a service loses its last
+// cluster or regains one. The notifications are delivered asynchronously so a slow or failing notifier doesn't hold up
+// the ServiceImport syncing.
+type availabilityTracker struct {
+	mutex    sync.Mutex
+	clusters map[string]map[string]bool
+	notifier AvailabilityNotifier
+	events   chan *AvailabilityEvent
+	backoff  wait.Backoff
+}
+
+func newAvailabilityTracker(notifier AvailabilityNotifier) *availabilityTracker {
+	return &availabilityTracker{
+		clusters: map[string]map[string]bool{},
+		notifier: notifier,
+		events:   make(chan *AvailabilityEvent, availabilityQueueSize),
+		backoff: wait.Backoff{
+			Duration: 100 * time.Millisecond,
+			Factor:   2,
+			Steps:    6,
+		},
+	}
+}
+
+// update records whether the given cluster exports the service and queues a notification if the service's availability
+// changed. A service seen for the first time is presumed to have been available so no notification is sent.
+func (t *availabilityTracker) update(namespace, name, cluster string, exported bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	key := namespace + "/" + name
+
+	clusters, known := t.clusters[key]
+	if !known {
+		if exported {
+			t.clusters[key] = map[string]bool{cluster: true}
+		}
+
+		return
+	}
+
+	wasAvailable := len(clusters) > 0
+
+	if exported {
+		clusters[cluster] = true
+	} else {
+		delete(clusters, cluster)
+	}
+
+	isAvailable := len(clusters) > 0
+	if wasAvailable == isAvailable {
+		return
+	}
+
+	event := &AvailabilityEvent{Name: name, Namespace: namespace, Available: isAvailable, Time: time.Now()}
+
+	select {
+	case t.events <- event:
+	default:
+		klog.Errorf("The availability notification queue is full - dropping the notification for service %q", key)
+	}
+}
+
+func (t *availabilityTracker) run(stopCh <-chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		case event := <-t.events:
+			t.notify(event, stopCh)
+		}
+	}
+}
+
+func (t *availabilityTracker) notify(event *AvailabilityEvent, stopCh <-chan struct{}) {
+	var lastErr error
+
+	err := wait.ExponentialBackoff(t.backoff, func() (bool, error) {
+		select {
+		case <-stopCh:
+			return false, wait.ErrWaitTimeout
+		default:
+		}
+
+		lastErr = t.notifier.Notify(event)
+		if lastErr != nil {
+			klog.Warningf("Error notifying the availability of service %s/%s - retrying: %v", event.Namespace, event.Name, lastErr)
+			return false, nil
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		klog.Errorf("Failed to notify the availability (%v) of service %s/%s: %v", event.Available, event.Namespace,
+			event.Name, lastErr)
+	}
+}
+
+// newAvailabilityWatcher creates a syncer that watches the local ServiceImports, which include those imported from other
+// clusters, to track the availability of each clusterset service.
+func (a *Controller) newAvailabilityWatcher(restMapper meta.RESTMapper, scheme *runtime.Scheme) (syncer.Interface, error) {
+	watcher, err := syncer.NewResourceSyncer(&syncer.ResourceSyncerConfig{
+		Name:            "ServiceImport availability watcher",
+		SourceClient:    a.serviceImportSyncer.GetLocalClient(),
+		SourceNamespace: a.namespace,
+		Direction:       syncer.None,
+		RestMapper:      restMapper,
+		Federator:       federate.NewNoopFederator(),
+		ResourceType:    &mcsv1a1.ServiceImport{},
+		Transform:       a.onServiceImportAvailabilityChanged,
+		Scheme:          scheme,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating ServiceImport availability watcher")
+	}
+
+	return watcher, nil
+}
+
+func (a *Controller) onServiceImportAvailabilityChanged(obj runtime.Object, _ int, op syncer.Operation) (runtime.Object, bool) {
+	serviceImport := obj.(*mcsv1a1.ServiceImport)
+
+	cluster := serviceImport.GetLabels()[lhconstants.LighthouseLabelSourceCluster]
+	name := serviceImport.GetAnnotations()[lhconstants.OriginName]
+	namespace := serviceImport.GetAnnotations()[lhconstants.OriginNamespace]
+
+	if cluster == "" || name == "" {
+		return nil, false
+	}
+
+	klog.V(log.DEBUG).Infof("ServiceImport %q from cluster %q %sd", serviceImport.Name, cluster, op)
+
+	a.availabilityTracker.update(namespace, name, cluster, op != syncer.Delete)
+
+	return nil, false
+}
@@ -0,0 +1,179 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/lighthouse/pkg/agent/controller"
+)
+
+var _ = Describe("Service availability notification", func() {
+	var (
+		t        *testDriver
+		notifier *fakeAvailabilityNotifier
+	)
+
+	BeforeEach(func() {
+		t = newTestDiver()
+
+		notifier = &fakeAvailabilityNotifier{failures: 1}
+		t.cluster2.availabilityNotifier = notifier
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+		t.createService()
+		t.createServiceExport()
+		t.awaitServiceExported(t.service.Spec.ClusterIP)
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("an imported service loses its only exporting cluster and then regains it", func() {
+		It("should notify the transitions, retrying a failed notification", func() {
+			Consistently(notifier.availability).Should(BeEmpty())
+
+			By("Unexporting the service")
+
+			t.deleteServiceExport()
+			t.awaitNoServiceImport(t.brokerServiceImportClient)
+
+			Eventually(notifier.availability, 5).Should(Equal([]bool{false}))
+
+			By("Re-exporting the service")
+
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			Eventually(notifier.availability, 5).Should(Equal([]bool{false, true}))
+
+			for _, event := range notifier.received() {
+				Expect(event.Name).To(Equal(t.service.Name))
+				Expect(event.Namespace).To(Equal(t.service.Namespace))
+			}
+
+			Expect(notifier.attempts()).To(Equal(3))
+		})
+	})
+})
+
+var _ = Describe("Webhook availability notifier", func() {
+	var (
+		server   *httptest.Server
+		status   int
+		received *controller.AvailabilityEvent
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		received = nil
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+
+			Expect(r.Method).To(Equal(http.MethodPost))
+			Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+
+			received = &controller.AvailabilityEvent{}
+			Expect(json.NewDecoder(r.Body).Decode(received)).To(Succeed())
+
+			w.WriteHeader(status)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should POST the event as JSON", func() {
+		Expect(controller.NewWebhookNotifier(server.URL).Notify(&controller.AvailabilityEvent{
+			Name:      "nginx",
+			Namespace: "service-ns",
+			Available: false,
+		})).To(Succeed())
+
+		Expect(received).ToNot(BeNil())
+		Expect(received.Name).To(Equal("nginx"))
+		Expect(received.Namespace).To(Equal("service-ns"))
+		Expect(received.Available).To(BeFalse())
+	})
+
+	When("the webhook responds with an error status", func() {
+		It("should return an error", func() {
+			status = http.StatusServiceUnavailable
+
+			Expect(controller.NewWebhookNotifier(server.URL).Notify(&controller.AvailabilityEvent{Name: "nginx"})).ToNot(Succeed())
+		})
+	})
+})
+
+type fakeAvailabilityNotifier struct {
+	mutex     sync.Mutex
+	failures  int
+	numTries  int
+	delivered []*controller.AvailabilityEvent
+}
+
+func (f *fakeAvailabilityNotifier) Notify(event *controller.AvailabilityEvent) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.numTries++
+
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("fake notification failure")
+	}
+
+	f.delivered = append(f.delivered, event)
+
+	return nil
+}
+
+func (f *fakeAvailabilityNotifier) received() []*controller.AvailabilityEvent {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return append([]*controller.AvailabilityEvent{}, f.delivered...)
+}
+
+func (f *fakeAvailabilityNotifier) availability() []bool {
+	available := []bool{}
+	for _, event := range f.received() {
+		available = append(available, event.Available)
+	}
+
+	return available
+}
+
+func (f *fakeAvailabilityNotifier) attempts() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.numTries
+}
@@ -0,0 +1,350 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/submariner-io/admiral/pkg/resource"
+	"github.com/submariner-io/admiral/pkg/syncer/broker"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+const (
+	BrokerClientRebuildsName = "submariner_agent_broker_client_rebuilds"
+
+	defaultBrokerAuthFailureThreshold = 3
+)
+
+var brokerClientRebuilds = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: BrokerClientRebuildsName,
+		Help: "Number of times the broker client was rebuilt from refreshed credentials after the broker rejected the current ones",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(brokerClientRebuilds)
+}
+
+// BrokerClientFactory builds a client for the broker from the current credentials.
+type BrokerClientFactory func() (dynamic.Interface, error)
+
+// brokerSpecification mirrors the broker settings that admiral reads from the environment.
+type brokerSpecification struct {
+	APIServer       string
+	APIServerToken  string
+	RemoteNamespace string
+	Insecure        bool `default:"false"`
+	Ca              string
+	Secret          string
+}
+
+// newEnvironmentBrokerClientFactory returns a factory that builds the broker client the way admiral does, from the given
+// REST config if there is one, otherwise from the broker environment. The secret files are re-read on each call so a
+// rotated ServiceAccount token or CA is picked up. The broker namespace is set from the environment if not already set.
+func newEnvironmentBrokerClientFactory(syncerConf *broker.SyncerConfig, gvr schema.GroupVersionResource) (BrokerClientFactory, error) {
+	if syncerConf.BrokerRestConfig != nil {
+		restConfig := syncerConf.BrokerRestConfig
+
+		return func() (dynamic.Interface, error) {
+			client, err := dynamic.NewForConfig(restConfig)
+			return client, errors.Wrap(err, "error creating the broker client")
+		}, nil
+	}
+
+	spec := &brokerSpecification{}
+
+	err := envconfig.Process("broker_k8s", spec)
+	if err != nil {
+		return nil, errors.Wrap(err, "error processing the broker environment")
+	}
+
+	if syncerConf.BrokerNamespace == "" {
+		syncerConf.BrokerNamespace = spec.RemoteNamespace
+	}
+
+	return func() (dynamic.Interface, error) {
+		var (
+			restConfig *rest.Config
+			authorized bool
+			err        error
+		)
+
+		if spec.Secret != "" {
+			restConfig, authorized, err = resource.GetAuthorizedRestConfigFromFiles(spec.APIServer,
+				filepath.Join(broker.SecretPath(spec.Secret), "token"), filepath.Join(broker.SecretPath(spec.Secret), "ca.crt"),
+				&rest.TLSClientConfig{Insecure: spec.Insecure}, gvr, spec.RemoteNamespace)
+			if err != nil {
+				klog.Errorf("Error accessing the %s secret: %v", spec.Secret, err)
+			}
+		}
+
+		if spec.Secret == "" || err != nil {
+			restConfig, authorized, err = resource.GetAuthorizedRestConfigFromData(spec.APIServer, spec.APIServerToken, spec.Ca,
+				&rest.TLSClientConfig{Insecure: spec.Insecure}, gvr, spec.RemoteNamespace)
+		}
+
+		if !authorized {
+			return nil, errors.Wrap(err, "error authorizing access to the broker API server")
+		}
+
+		if err != nil {
+			klog.Errorf("Error accessing the broker API server: %v", err)
+		}
+
+		client, err := dynamic.NewForConfig(restConfig)
+
+		return client, errors.Wrap(err, "error creating the broker client")
+	}, nil
+}
+
+// credentialRefreshingClient wraps the broker client so that, once the broker has rejected the credentials for the given
+// number of consecutive requests, the client is rebuilt by the factory from the possibly refreshed credentials. The
+// syncers keep using this wrapper so subsequent requests, including the informers' relists, go through the new client
+// and the syncers re-establish themselves without a restart.
+type credentialRefreshingClient struct {
+	mutex        sync.Mutex
+	delegate     dynamic.Interface
+	factory      BrokerClientFactory
+	threshold    int
+	authFailures int
+	rebuilding   bool
+}
+
+type credentialRefreshingNamespaceableClient struct {
+	credentialRefreshingResourceClient
+}
+
+type credentialRefreshingResourceClient struct {
+	parent    *credentialRefreshingClient
+	gvr       schema.GroupVersionResource
+	namespace string
+	// namespaced distinguishes a namespace-scoped client for the empty (all) namespace from the cluster-scoped one.
+	namespaced bool
+}
+
+func newCredentialRefreshingClient(client dynamic.Interface, factory BrokerClientFactory, threshold int) (dynamic.Interface, error) {
+	if factory == nil {
+		return client, nil
+	}
+
+	if client == nil {
+		var err error
+
+		client, err = factory()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if threshold <= 0 {
+		threshold = defaultBrokerAuthFailureThreshold
+	}
+
+	return &credentialRefreshingClient{delegate: client, factory: factory, threshold: threshold}, nil
+}
+
+func (c *credentialRefreshingClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &credentialRefreshingNamespaceableClient{credentialRefreshingResourceClient{parent: c, gvr: gvr}}
+}
+
+func (c *credentialRefreshingClient) current() dynamic.Interface {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.delegate
+}
+
+func (c *credentialRefreshingClient) observe(used dynamic.Interface, err error) {
+	if !c.shouldRebuild(used, err) {
+		return
+	}
+
+	// The factory may make network calls, eg to read the credentials, so it's invoked without holding the mutex, which
+	// would otherwise block every broker request.
+	client, err := c.factory()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.rebuilding = false
+
+	if err != nil {
+		klog.Errorf("Error rebuilding the broker client: %v", err)
+		return
+	}
+
+	c.delegate = client
+	c.authFailures = 0
+
+	brokerClientRebuilds.Inc()
+}
+
+// shouldRebuild records the outcome of a request issued by the given client and returns true if the client should be
+// rebuilt, in which case the caller is responsible for the rebuild. Only one rebuild is in progress at a time.
+func (c *credentialRefreshingClient) shouldRebuild(used dynamic.Interface, err error) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// A request that was issued by a client that has since been replaced says nothing about the current credentials.
+	if used != c.delegate {
+		return false
+	}
+
+	if !apierrors.IsUnauthorized(err) {
+		if err == nil {
+			c.authFailures = 0
+		}
+
+		return false
+	}
+
+	c.authFailures++
+	if c.authFailures < c.threshold || c.rebuilding {
+		return false
+	}
+
+	klog.Warningf("The broker rejected the credentials for %d consecutive requests - rebuilding the client: %v", c.authFailures, err)
+
+	c.rebuilding = true
+
+	return true
+}
+
+func (c *credentialRefreshingNamespaceableClient) Namespace(namespace string) dynamic.ResourceInterface {
+	return &credentialRefreshingResourceClient{parent: c.parent, gvr: c.gvr, namespace: namespace, namespaced: true}
+}
+
+func (c *credentialRefreshingResourceClient) resourceClient() (dynamic.Interface, dynamic.ResourceInterface) {
+	client := c.parent.current()
+
+	if c.namespaced {
+		return client, client.Resource(c.gvr).Namespace(c.namespace)
+	}
+
+	return client, client.Resource(c.gvr)
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *credentialRefreshingResourceClient) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions,
+	subresources ...string,
+) (*unstructured.Unstructured, error) {
+	client, resourceClient := c.resourceClient()
+	created, err := resourceClient.Create(ctx, obj, options, subresources...)
+	c.parent.observe(client, err)
+
+	return created, err // nolint:wrapcheck // Let the caller wrap it.
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *credentialRefreshingResourceClient) Update(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions,
+	subresources ...string,
+) (*unstructured.Unstructured, error) {
+	client, resourceClient := c.resourceClient()
+	updated, err := resourceClient.Update(ctx, obj, options, subresources...)
+	c.parent.observe(client, err)
+
+	return updated, err // nolint:wrapcheck // Let the caller wrap it.
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *credentialRefreshingResourceClient) UpdateStatus(ctx context.Context, obj *unstructured.Unstructured,
+	options metav1.UpdateOptions,
+) (*unstructured.Unstructured, error) {
+	client, resourceClient := c.resourceClient()
+	updated, err := resourceClient.UpdateStatus(ctx, obj, options)
+	c.parent.observe(client, err)
+
+	return updated, err // nolint:wrapcheck // Let the caller wrap it.
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *credentialRefreshingResourceClient) Delete(ctx context.Context, name string, options metav1.DeleteOptions,
+	subresources ...string,
+) error {
+	client, resourceClient := c.resourceClient()
+	err := resourceClient.Delete(ctx, name, options, subresources...)
+	c.parent.observe(client, err)
+
+	return err // nolint:wrapcheck // Let the caller wrap it.
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *credentialRefreshingResourceClient) DeleteCollection(ctx context.Context, options metav1.DeleteOptions,
+	listOptions metav1.ListOptions,
+) error {
+	client, resourceClient := c.resourceClient()
+	err := resourceClient.DeleteCollection(ctx, options, listOptions)
+	c.parent.observe(client, err)
+
+	return err // nolint:wrapcheck // Let the caller wrap it.
+}
+
+func (c *credentialRefreshingResourceClient) Get(ctx context.Context, name string, options metav1.GetOptions,
+	subresources ...string,
+) (*unstructured.Unstructured, error) {
+	client, resourceClient := c.resourceClient()
+	obj, err := resourceClient.Get(ctx, name, options, subresources...)
+	c.parent.observe(client, err)
+
+	return obj, err // nolint:wrapcheck // Let the caller wrap it.
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *credentialRefreshingResourceClient) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	client, resourceClient := c.resourceClient()
+	list, err := resourceClient.List(ctx, opts)
+	c.parent.observe(client, err)
+
+	return list, err // nolint:wrapcheck // Let the caller wrap it.
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *credentialRefreshingResourceClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	client, resourceClient := c.resourceClient()
+	w, err := resourceClient.Watch(ctx, opts)
+	c.parent.observe(client, err)
+
+	return w, err // nolint:wrapcheck // Let the caller wrap it.
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *credentialRefreshingResourceClient) Patch(ctx context.Context, name string, pt types.PatchType, data []byte,
+	options metav1.PatchOptions, subresources ...string,
+) (*unstructured.Unstructured, error) {
+	client, resourceClient := c.resourceClient()
+	patched, err := resourceClient.Patch(ctx, name, pt, data, options, subresources...)
+	c.parent.observe(client, err)
+
+	return patched, err // nolint:wrapcheck // Let the caller wrap it.
+}
@@ -0,0 +1,135 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/testing"
+)
+
+var _ = Describe("Broker credential refresh", func() {
+	var (
+		t        *testDriver
+		rebuilds int32
+		started  chan error
+	)
+
+	BeforeEach(func() {
+		t = newTestDiver()
+
+		atomic.StoreInt32(&rebuilds, 0)
+
+		// The broker rejects the credentials that cluster1 starts with, as it would after its ServiceAccount token was
+		// rotated. The rebuilt client uses the refreshed credentials and is accepted.
+		revoked := fake.NewDynamicClient(t.syncerConfig.Scheme)
+		revoked.PrependReactor("*", "*", func(action testing.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewUnauthorized("token has been invalidated")
+		})
+
+		t.cluster1.brokerClient = revoked
+		t.cluster1.brokerClientFactory = func() (dynamic.Interface, error) {
+			atomic.AddInt32(&rebuilds, 1)
+			return t.syncerConfig.BrokerClient, nil
+		}
+
+		// Don't wait for the broker informers to sync with the rejected credentials.
+		t.doStart = false
+		started = make(chan error, 1)
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+
+		go func() {
+			started <- t.cluster1.agentController.Start(t.stopCh)
+		}()
+
+		Expect(t.cluster2.agentController.Start(t.stopCh)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("the broker persistently rejects the credentials", func() {
+		It("should rebuild the broker client from the refreshed credentials and sync the export", func() {
+			Eventually(func() int32 {
+				return atomic.LoadInt32(&rebuilds)
+			}, 5).Should(Equal(int32(1)))
+
+			Eventually(started, 5).Should(Receive(BeNil()))
+
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			Expect(atomic.LoadInt32(&rebuilds)).To(Equal(int32(1)))
+		})
+	})
+
+	When("rebuilding the broker client is slow", func() {
+		var (
+			rejected int32
+			called   chan struct{}
+			release  chan struct{}
+		)
+
+		BeforeEach(func() {
+			atomic.StoreInt32(&rejected, 0)
+			called = make(chan struct{}, 1)
+			release = make(chan struct{})
+
+			revoked := t.cluster1.brokerClient.(*fake.DynamicClient)
+			revoked.PrependReactor("*", "*", func(action testing.Action) (bool, runtime.Object, error) {
+				atomic.AddInt32(&rejected, 1)
+				return false, nil, nil
+			})
+
+			factory := t.cluster1.brokerClientFactory
+			t.cluster1.brokerClientFactory = func() (dynamic.Interface, error) {
+				called <- struct{}{}
+				<-release
+
+				return factory()
+			}
+		})
+
+		It("should not block broker requests while the client is rebuilt", func() {
+			Eventually(called, 5).Should(Receive())
+
+			n := atomic.LoadInt32(&rejected)
+
+			Eventually(func() int32 {
+				return atomic.LoadInt32(&rejected)
+			}, 5).Should(BeNumerically(">", n))
+
+			close(release)
+
+			Eventually(started, 5).Should(Receive(BeNil()))
+			Expect(atomic.LoadInt32(&rebuilds)).To(Equal(int32(1)))
+		})
+	})
+})
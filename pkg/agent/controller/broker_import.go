@@ -0,0 +1,106 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/federate"
+	"github.com/submariner-io/admiral/pkg/syncer"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+// newBrokerServiceImportWatcher creates a syncer that watches for deletions of this cluster's ServiceImports on the broker.
+// The broker syncer ignores this cluster's own ServiceImports on the broker so, if one is removed out-of-band while the
+// export is still valid, the service would otherwise be unreachable from other clusters until the next resync.
+func (a *Controller) newBrokerServiceImportWatcher(restMapper meta.RESTMapper, scheme *runtime.Scheme) (syncer.Interface, error) {
+	watcher, err := syncer.NewResourceSyncer(&syncer.ResourceSyncerConfig{
+		Name:            "Broker ServiceImport watcher",
+		SourceClient:    a.serviceImportSyncer.GetBrokerClient(),
+		SourceNamespace: a.serviceImportSyncer.GetBrokerNamespace(),
+		Direction:       syncer.None,
+		RestMapper:      restMapper,
+		Federator:       federate.NewNoopFederator(),
+		ResourceType:    &mcsv1a1.ServiceImport{},
+		Transform:       a.onBrokerServiceImportDeleted,
+		ShouldProcess: func(obj *unstructured.Unstructured, op syncer.Operation) bool {
+			return op == syncer.Delete && obj.GetLabels()[lhconstants.LighthouseLabelSourceCluster] == a.clusterID
+		},
+		Scheme: scheme,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating broker ServiceImport watcher")
+	}
+
+	return watcher, nil
+}
+
+// onBrokerServiceImportDeleted restores this cluster's ServiceImport on the broker if it was deleted while the local
+// ServiceImport still exists. When a Service is unexported, the local ServiceImport is deleted first so nothing is
+// restored.
+func (a *Controller) onBrokerServiceImportDeleted(obj runtime.Object, _ int, _ syncer.Operation) (runtime.Object, bool) {
+	if a.IsPaused() {
+		return nil, true
+	}
+
+	serviceImport := obj.(*mcsv1a1.ServiceImport)
+
+	localObj, found, err := a.serviceImportSyncer.GetLocalResource(serviceImport.Name, a.namespace, &mcsv1a1.ServiceImport{})
+	if err != nil {
+		klog.Errorf("Error retrieving the local ServiceImport %q: %v", serviceImport.Name, err)
+		return nil, true
+	}
+
+	if !found {
+		return nil, false
+	}
+
+	klog.Infof("The broker ServiceImport %q was deleted while the local ServiceImport still exists - restoring it",
+		serviceImport.Name)
+
+	err = a.distributeToBroker(localObj.(*mcsv1a1.ServiceImport))
+	if err != nil {
+		klog.Errorf("Error restoring the broker ServiceImport %q: %v", serviceImport.Name, err)
+		return nil, true
+	}
+
+	return nil, false
+}
+
+// distributeToBroker writes the given local ServiceImport to the broker as the broker syncer would. It fails while syncing
+// is paused.
+func (a *Controller) distributeToBroker(serviceImport *mcsv1a1.ServiceImport) error {
+	if a.IsPaused() {
+		return errors.New("syncing is paused")
+	}
+
+	toDistribute := a.toBrokerServiceImport(serviceImport)
+
+	if toDistribute.Labels == nil {
+		toDistribute.Labels = map[string]string{}
+	}
+
+	toDistribute.Labels[syncer.OrigNamespaceLabelKey] = toDistribute.Namespace
+
+	return a.serviceImportSyncer.GetBrokerFederator().Distribute(toDistribute) // nolint:wrapcheck // Let the caller wrap it.
+}
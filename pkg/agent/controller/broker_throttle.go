@@ -0,0 +1,81 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+)
+
+const defaultBrokerThrottleDelay = 5 * time.Second
+
+// brokerThrottle records when the broker API server throttled a write so further writes are held off until the delay
+// it suggested, via Retry-After, elapses rather than hammering it.
+type brokerThrottle struct {
+	mutex        sync.Mutex
+	until        time.Time
+	defaultDelay time.Duration
+}
+
+func newBrokerThrottle(defaultDelay time.Duration) *brokerThrottle {
+	if defaultDelay <= 0 {
+		defaultDelay = defaultBrokerThrottleDelay
+	}
+
+	return &brokerThrottle{defaultDelay: defaultDelay}
+}
+
+// observe returns true if the given error indicates the broker is throttling requests, in which case writes are held
+// off for the suggested delay or, if none, the default delay.
+func (t *brokerThrottle) observe(err error) bool {
+	if !apierrors.IsTooManyRequests(err) {
+		return false
+	}
+
+	delay := t.defaultDelay
+	if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+		delay = time.Duration(seconds) * time.Second
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if until := time.Now().Add(delay); until.After(t.until) {
+		t.until = until
+	}
+
+	klog.Warningf("The broker is throttling requests - holding off writes for %v", delay)
+
+	return true
+}
+
+// remaining returns how long writes should still be held off or zero if not throttled.
+func (t *brokerThrottle) remaining() time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if remaining := time.Until(t.until); remaining > 0 {
+		return remaining
+	}
+
+	return 0
+}
@@ -0,0 +1,77 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const (
+	invalidClustersetHostname  = "InvalidClustersetHostname"
+	clustersetHostnameConflict = "ClustersetHostnameConflict"
+)
+
+// The DNS plugin parses these as the query type so they can't be used as a custom hostname.
+var reservedClustersetHostnames = map[string]bool{"svc": true, "pod": true}
+
+// getClustersetHostname returns the custom clusterset hostname requested via the ServiceExport annotation, if any. If
+// it's not a valid DNS label or it's already used by another exported service in the clusterset, a non-empty reason and
+// message are returned.
+func (a *Controller) getClustersetHostname(svcExport *mcsv1a1.ServiceExport) (hostname, reason, msg string) {
+	hostname = svcExport.GetAnnotations()[lhconstants.ClustersetHostnameAnnotation]
+	if hostname == "" {
+		return "", "", ""
+	}
+
+	if errs := validation.IsDNS1123Label(hostname); len(errs) > 0 {
+		return "", invalidClustersetHostname, fmt.Sprintf("The clusterset hostname %q is invalid: %s", hostname,
+			strings.Join(errs, ", "))
+	}
+
+	if reservedClustersetHostnames[hostname] {
+		return "", invalidClustersetHostname, fmt.Sprintf("The clusterset hostname %q is reserved", hostname)
+	}
+
+	// The local ServiceImports include those synced from the broker so this covers the whole clusterset.
+	list, err := a.serviceImportSyncer.ListLocalResources(&mcsv1a1.ServiceImport{})
+	if err != nil {
+		klog.Errorf("Error listing ServiceImports: %v", err)
+		return hostname, "", ""
+	}
+
+	for _, obj := range list {
+		annotations := obj.(*mcsv1a1.ServiceImport).GetAnnotations()
+		if annotations[lhconstants.ClustersetHostnameAnnotation] != hostname {
+			continue
+		}
+
+		if annotations[lhconstants.OriginName] != svcExport.Name || annotations[lhconstants.OriginNamespace] != svcExport.Namespace {
+			return "", clustersetHostnameConflict, fmt.Sprintf("The clusterset hostname %q is already in use by the exported Service %q",
+				hostname, annotations[lhconstants.OriginNamespace]+"/"+annotations[lhconstants.OriginName])
+		}
+	}
+
+	return hostname, "", ""
+}
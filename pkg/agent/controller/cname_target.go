@@ -0,0 +1,63 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	validations "k8s.io/apimachinery/pkg/util/validation"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const invalidCNAMETarget = "InvalidCNAMETarget"
+
+// getServiceExportCNAMETarget returns the explicit CNAME target requested via the ServiceExport annotation, if any, to which
+// the clusterset name resolves instead of the service's IPs. The target is normalized to lower case without a trailing
+// dot. If it isn't a valid DNS name, refers back to the service itself, or the service isn't exported with a ClusterSetIP,
+// a non-empty reason and message are returned.
+func getServiceExportCNAMETarget(svcExport *mcsv1a1.ServiceExport, svcType mcsv1a1.ServiceImportType,
+) (target, reason, msg string) {
+	value, ok := svcExport.GetAnnotations()[lhconstants.CNAMETargetAnnotation]
+	if !ok {
+		return "", "", ""
+	}
+
+	target = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), ".")
+	if errs := validations.IsDNS1123Subdomain(target); len(errs) > 0 {
+		return "", invalidCNAMETarget, fmt.Sprintf("The CNAME target %q is invalid: %v", value, errs)
+	}
+
+	// Any <service>.<namespace>.svc.<domain> name of the service itself, eg its clusterset or cluster-local name, would
+	// resolve back to this CNAME.
+	if strings.HasPrefix(target, svcExport.Name+"."+svcExport.Namespace+".svc.") {
+		return "", invalidCNAMETarget, fmt.Sprintf("The CNAME target %q refers to the exported service itself", value)
+	}
+
+	if svcType != mcsv1a1.ClusterSetIP {
+		return "", invalidCNAMETarget, "A CNAME target is only supported for a Service exported with a ClusterSetIP"
+	}
+
+	if _, ok := svcExport.GetAnnotations()[lhconstants.StaticClustersetIPAnnotation]; ok {
+		return "", invalidCNAMETarget, "A CNAME target can't be combined with a static clusterset IP"
+	}
+
+	return target, "", ""
+}
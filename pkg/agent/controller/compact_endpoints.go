@@ -0,0 +1,40 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/submariner-io/lighthouse/pkg/endpointslice"
+	discovery "k8s.io/api/discovery/v1"
+	"k8s.io/klog/v2"
+)
+
+// compact compacts the ready endpoints of a headless Service's EndpointSlice into CIDR ranges, if configured, to reduce the
+// size of the EndpointSlice for a Service with a huge number of endpoints. Endpoints with a hostname are left as is so
+// queries for an individual endpoint by hostname are still answered. With Globalnet, the endpoints are left as is since the
+// global IPs allocated per pod are rarely contiguous and the DNS plugin maps the local cluster's endpoints by node name.
+func (e *EndpointController) compact(endpointSlice *discovery.EndpointSlice) {
+	if !e.compactEndpoints || !e.isHeadless || e.globalIngressIPCache != nil {
+		return
+	}
+
+	if err := endpointslice.Compact(endpointSlice); err != nil {
+		klog.Errorf("Error compacting the endpoints of service %s/%s - publishing them as is: %v", e.serviceImportSourceNameSpace,
+			e.serviceName, err)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"github.com/submariner-io/lighthouse/pkg/endpointslice"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Compacted endpoints", func() {
+	var t *testDriver
+
+	BeforeEach(func() {
+		t = newTestDiver()
+
+		t.service.Spec.ClusterIP = corev1.ClusterIPNone
+		t.cluster1.agentSpec.CompactEndpoints = true
+
+		t.endpoints.Subsets[0].Addresses = append(t.endpoints.Subsets[0].Addresses,
+			corev1.EndpointAddress{IP: "192.168.5.3"}, corev1.EndpointAddress{IP: "192.168.5.4"})
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+		t.createService()
+		t.createEndpoints()
+		t.createServiceExport()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("compacting endpoints is enabled for a headless Service", func() {
+		It("should publish the ready endpoints without a hostname compacted", func() {
+			t.awaitHeadlessServiceImport()
+
+			endpointSlice := awaitBrokerEndpointSliceWithCount(t, 3)
+			Expect(endpointSlice.Annotations).To(HaveKeyWithValue(lhconstants.CompactedEndpointsAnnotation,
+				"192.168.5.3,192.168.5.4"))
+
+			var retained []string
+			for i := range endpointSlice.Endpoints {
+				retained = append(retained, endpointSlice.Endpoints[i].Addresses...)
+
+				if endpointSlice.Endpoints[i].Addresses[0] != "10.253.6.1" {
+					Expect(endpointSlice.Endpoints[i].Hostname).ToNot(BeNil())
+				}
+			}
+
+			Expect(retained).To(ConsistOf("192.168.5.1", "192.168.5.2", "10.253.6.1"))
+
+			endpoints, err := endpointslice.GetEndpoints(endpointSlice)
+			Expect(err).To(Succeed())
+			Expect(endpoints).To(HaveLen(5))
+
+			By("Removing the ready endpoints")
+
+			t.endpoints.Subsets[0].Addresses = nil
+			t.updateEndpoints()
+
+			Eventually(func() map[string]string {
+				return getBrokerEndpointSlice(t).Annotations
+			}, 5).ShouldNot(HaveKey(lhconstants.CompactedEndpointsAnnotation))
+		})
+	})
+
+	When("compacting endpoints is disabled", func() {
+		BeforeEach(func() {
+			t.cluster1.agentSpec.CompactEndpoints = false
+		})
+
+		It("should publish the endpoints as is", func() {
+			t.awaitHeadlessServiceImport()
+
+			endpointSlice := awaitBrokerEndpointSliceWithCount(t, 5)
+			Expect(endpointSlice.Annotations).ToNot(HaveKey(lhconstants.CompactedEndpointsAnnotation))
+		})
+	})
+})
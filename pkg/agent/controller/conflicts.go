@@ -0,0 +1,55 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	reasonLabel = "reason"
+
+	ServiceExportConflictsName = "lighthouse_service_export_conflicts_total"
+)
+
+var serviceExportConflicts = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: ServiceExportConflictsName,
+		Help: "Number of times a ServiceExport condition was set because the export conflicts with another exported Service",
+	},
+	[]string{reasonLabel},
+)
+
+// conflictReasons are the ServiceExport condition reasons that denote a conflict with another exported Service.
+var conflictReasons = map[string]bool{
+	clustersetHostnameConflict: true,
+	duplicateExport:            true,
+	staticIPConflict:           true,
+}
+
+func init() {
+	prometheus.MustRegister(serviceExportConflicts)
+}
+
+// countConflict counts a ServiceExport condition with the given reason that was set, if the reason denotes a conflict.
+func countConflict(reason string) {
+	if conflictReasons[reason] {
+		serviceExportConflicts.WithLabelValues(reason).Inc()
+	}
+}
@@ -0,0 +1,124 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	"github.com/submariner-io/lighthouse/pkg/agent/controller"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+var _ = Describe("ServiceExport conflicts metric", func() {
+	const (
+		hostname = "payments"
+		reason   = "ClustersetHostnameConflict"
+	)
+
+	var (
+		t            *testDriver
+		initialCount float64
+	)
+
+	BeforeEach(func() {
+		t = newTestDiver()
+
+		t.serviceExport.Annotations = map[string]string{lhconstants.ClustersetHostnameAnnotation: hostname}
+		initialCount = getServiceExportConflicts(reason)
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+		t.createService()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("the export conflicts with another exported Service", func() {
+		JustBeforeEach(func() {
+			test.CreateResource(t.cluster1.localServiceImportClient, &mcsv1a1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "billing-other-ns-" + clusterID2,
+					Annotations: map[string]string{
+						lhconstants.OriginName:                   "billing",
+						lhconstants.OriginNamespace:              "other-ns",
+						lhconstants.ClustersetHostnameAnnotation: hostname,
+					},
+					Labels: map[string]string{
+						lhconstants.LighthouseLabelSourceCluster: clusterID2,
+					},
+				},
+				Spec: mcsv1a1.ServiceImportSpec{
+					Type: mcsv1a1.ClusterSetIP,
+					IPs:  []string{"10.253.1.1"},
+				},
+			})
+		})
+
+		It("should increment the conflicts counter for the reason", func() {
+			t.createServiceExport()
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, reason))
+
+			Eventually(func() float64 {
+				return getServiceExportConflicts(reason)
+			}).Should(Equal(initialCount + 1))
+
+			Consistently(func() float64 {
+				return getServiceExportConflicts(reason)
+			}).Should(Equal(initialCount + 1))
+		})
+	})
+
+	When("the export doesn't conflict", func() {
+		It("should not increment the conflicts counter", func() {
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			Expect(getServiceExportConflicts(reason)).To(Equal(initialCount))
+		})
+	})
+})
+
+func getServiceExportConflicts(reason string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	Expect(err).To(Succeed())
+
+	for _, family := range families {
+		if family.GetName() != controller.ServiceExportConflictsName {
+			continue
+		}
+
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "reason" && l.GetValue() == reason {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+
+	return 0
+}
@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"net/http"
 	"reflect"
 	"sort"
 	"testing"
@@ -32,11 +33,13 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
 	"github.com/submariner-io/admiral/pkg/fake"
 	"github.com/submariner-io/admiral/pkg/syncer/broker"
 	"github.com/submariner-io/admiral/pkg/syncer/test"
 	"github.com/submariner-io/lighthouse/pkg/agent/controller"
 	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"github.com/submariner-io/lighthouse/pkg/testutil"
 	corev1 "k8s.io/api/core/v1"
 	discovery "k8s.io/api/discovery/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -44,11 +47,13 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	fakeKubeClient "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/klog/v2"
 	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
 )
@@ -93,6 +98,9 @@ type cluster struct {
 	localKubeClient          kubernetes.Interface
 	endpointsReactor         *fake.FailingReactor
 	agentController          *controller.Controller
+	availabilityNotifier     controller.AvailabilityNotifier
+	brokerClient             dynamic.Interface
+	brokerClientFactory      controller.BrokerClientFactory
 }
 
 type testDriver struct {
@@ -121,6 +129,9 @@ func newTestDiver() *testDriver {
 		Kind:    "GlobalIngressIPList",
 	}, &unstructured.UnstructuredList{})
 
+	syncerScheme.AddKnownTypeWithName(controller.ServiceAliasGVR.GroupVersion().WithKind("ServiceAliasList"),
+		&unstructured.UnstructuredList{})
+
 	t := &testDriver{
 		cluster1: cluster{
 			agentSpec: controller.AgentSpecification{
@@ -149,7 +160,7 @@ func newTestDiver() *testDriver {
 		syncerConfig: &broker.SyncerConfig{
 			BrokerNamespace: test.RemoteNamespace,
 			RestMapper: test.GetRESTMapperFor(&mcsv1a1.ServiceExport{}, &mcsv1a1.ServiceImport{}, &corev1.Service{},
-				&corev1.Endpoints{}, &discovery.EndpointSlice{}, controller.GetGlobalIngressIPObj()),
+				&corev1.Endpoints{}, &discovery.EndpointSlice{}, &corev1.ConfigMap{}, controller.GetGlobalIngressIPObj()),
 			BrokerClient: fake.NewDynamicClient(syncerScheme),
 			Scheme:       syncerScheme,
 		},
@@ -157,6 +168,8 @@ func newTestDiver() *testDriver {
 		doStart: true,
 	}
 
+	rejectServerSideApply(t.syncerConfig.BrokerClient)
+
 	t.serviceExport = &mcsv1a1.ServiceExport{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      t.service.Name,
@@ -219,6 +232,22 @@ func newTestDiver() *testDriver {
 	return t
 }
 
+// rejectServerSideApply emulates an older broker that doesn't support server-side apply, as the fake client doesn't.
+func rejectServerSideApply(client dynamic.Interface) {
+	client.(*fake.DynamicClient).PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.PatchAction).GetPatchType() != k8stypes.ApplyPatchType {
+			return false, nil, nil
+		}
+
+		return true, nil, &apierrors.StatusError{ErrStatus: metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusUnsupportedMediaType,
+			Reason:  metav1.StatusReasonUnsupportedMediaType,
+			Message: "the body of the request was in an unknown format",
+		}}
+	})
+}
+
 func (t *testDriver) newGlobalIngressIP(name, ip string) *unstructured.Unstructured {
 	ingressIP := controller.GetGlobalIngressIPObj()
 	ingressIP.SetName(name)
@@ -280,6 +309,27 @@ func (c *cluster) init(syncerConfig *broker.SyncerConfig) {
 // nolint:gocritic // (hugeParam) This function modifies syncerConf so we don't want to pass by pointer.
 func (c *cluster) start(t *testDriver, syncerConfig broker.SyncerConfig) {
 	syncerConfig.LocalClient = c.localDynClient
+
+	if c.brokerClient != nil {
+		syncerConfig.BrokerClient = c.brokerClient
+	}
+
+	var err error
+
+	agentConfig := newAgentConfig()
+	agentConfig.AvailabilityNotifier = c.availabilityNotifier
+	agentConfig.BrokerClientFactory = c.brokerClientFactory
+
+	c.agentController, err = controller.New(&c.agentSpec, syncerConfig, c.localKubeClient, agentConfig)
+
+	Expect(err).To(Succeed())
+
+	if t.doStart {
+		Expect(c.agentController.Start(t.stopCh)).To(Succeed())
+	}
+}
+
+func newAgentConfig() controller.AgentConfig {
 	bigint, err := rand.Int(rand.Reader, big.NewInt(1000000))
 	Expect(err).To(Succeed())
 
@@ -290,16 +340,9 @@ func (c *cluster) start(t *testDriver, syncerConfig broker.SyncerConfig) {
 
 	serviceExportCounterName := "submariner_service_export" + bigint.String()
 
-	c.agentController, err = controller.New(&c.agentSpec, syncerConfig, c.localKubeClient,
-		controller.AgentConfig{
-			ServiceImportCounterName: serviceImportCounterName,
-			ServiceExportCounterName: serviceExportCounterName,
-		})
-
-	Expect(err).To(Succeed())
-
-	if t.doStart {
-		Expect(c.agentController.Start(t.stopCh)).To(Succeed())
+	return controller.AgentConfig{
+		ServiceImportCounterName: serviceImportCounterName,
+		ServiceExportCounterName: serviceExportCounterName,
 	}
 }
 
@@ -449,6 +492,10 @@ func awaitUpdatedEndpointSlice(endpointSliceClient dynamic.ResourceInterface, en
 
 	err := wait.PollImmediate(50*time.Millisecond, 5*time.Second, func() (bool, error) {
 		obj, err := endpointSliceClient.Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+
 		Expect(err).To(Succeed())
 
 		endpointSlice := &discovery.EndpointSlice{}
@@ -487,6 +534,22 @@ func (t *testDriver) awaitBrokerEndpointSlice() *discovery.EndpointSlice {
 	return awaitEndpointSlice(t.brokerEndpointSliceClient, t.endpoints, t.service, test.RemoteNamespace, t.endpointGlobalIPs)
 }
 
+func (t *testDriver) awaitGlobalIPChangedEvent() {
+	Eventually(func() []string {
+		events, err := t.cluster1.localKubeClient.CoreV1().Events(t.service.Namespace).List(context.TODO(), metav1.ListOptions{})
+		Expect(err).To(Succeed())
+
+		var reasons []string
+		for i := range events.Items {
+			if events.Items[i].InvolvedObject.Name == t.serviceExport.Name {
+				reasons = append(reasons, events.Items[i].Reason)
+			}
+		}
+
+		return reasons
+	}, 5).Should(ContainElement("GlobalIPChanged"))
+}
+
 func (t *testDriver) awaitUpdatedServiceImport(serviceIP string) {
 	awaitUpdatedServiceImport(t.brokerServiceImportClient, t.service, serviceIP)
 	t.cluster1.awaitUpdatedServiceImport(t.service, serviceIP)
@@ -512,6 +575,13 @@ func (t *testDriver) createService() {
 	test.CreateResource(t.cluster1.dynamicServiceClient().Namespace(t.service.Namespace), t.service)
 }
 
+func (t *testDriver) updateService() {
+	_, err := t.cluster1.localKubeClient.CoreV1().Services(t.service.Namespace).Update(context.TODO(), t.service, metav1.UpdateOptions{})
+	Expect(err).To(Succeed())
+
+	test.UpdateResource(t.cluster1.dynamicServiceClient().Namespace(t.service.Namespace), t.service)
+}
+
 func (t *testDriver) createEndpoints() {
 	_, err := t.cluster1.localKubeClient.CoreV1().Endpoints(t.endpoints.Namespace).Create(context.TODO(), t.endpoints, metav1.CreateOptions{})
 	Expect(err).To(Succeed())
@@ -526,6 +596,13 @@ func (t *testDriver) updateEndpoints() {
 	test.UpdateResource(t.dynamicEndpointsClient(), t.endpoints)
 }
 
+func (t *testDriver) deleteEndpoints() {
+	Expect(t.cluster1.localKubeClient.CoreV1().Endpoints(t.endpoints.Namespace).Delete(context.TODO(), t.endpoints.Name,
+		metav1.DeleteOptions{})).To(Succeed())
+
+	Expect(t.dynamicEndpointsClient().Delete(context.TODO(), t.endpoints.Name, metav1.DeleteOptions{})).To(Succeed())
+}
+
 func (t *testDriver) dynamicEndpointsClient() dynamic.ResourceInterface {
 	return t.cluster1.localDynClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "endpoints"}).Namespace(t.service.Namespace)
 }
@@ -534,6 +611,27 @@ func (t *testDriver) createServiceExport() {
 	test.CreateResource(t.cluster1.localServiceExportClient, t.serviceExport)
 }
 
+// setServiceExportAnnotation updates the current ServiceExport, rather than t.serviceExport, so its status is retained. An
+// empty value removes the annotation.
+func (t *testDriver) setServiceExportAnnotation(key, value string) {
+	obj, err := t.cluster1.localServiceExportClient.Get(context.TODO(), t.serviceExport.Name, metav1.GetOptions{})
+	Expect(err).To(Succeed())
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	if value == "" {
+		delete(annotations, key)
+	} else {
+		annotations[key] = value
+	}
+
+	obj.SetAnnotations(annotations)
+	test.UpdateResource(t.cluster1.localServiceExportClient, obj)
+}
+
 func (t *testDriver) deleteServiceExport() {
 	Expect(t.cluster1.localServiceExportClient.Delete(context.TODO(), t.service.GetName(), metav1.DeleteOptions{})).To(Succeed())
 }
@@ -561,6 +659,20 @@ func (t *testDriver) awaitNoServiceImport(client dynamic.ResourceInterface) {
 	test.AwaitNoResource(client, t.service.Name+"-"+t.service.Namespace+"-"+clusterID1)
 }
 
+func (t *testDriver) ensureNoServiceImport(client dynamic.ResourceInterface) {
+	Consistently(func() bool {
+		_, err := client.Get(context.TODO(), t.service.Name+"-"+t.service.Namespace+"-"+clusterID1, metav1.GetOptions{})
+		return apierrors.IsNotFound(err)
+	}, 300*time.Millisecond).Should(BeTrue())
+}
+
+func (t *testDriver) ensureServiceImport(client dynamic.ResourceInterface) {
+	Consistently(func() error {
+		_, err := client.Get(context.TODO(), t.service.Name+"-"+t.service.Namespace+"-"+clusterID1, metav1.GetOptions{})
+		return err
+	}, 300*time.Millisecond).Should(Succeed())
+}
+
 func (t *testDriver) awaitNoEndpointSlice(client dynamic.ResourceInterface) {
 	test.AwaitNoResource(client, t.endpoints.Name+"-"+clusterID1)
 }
@@ -590,10 +702,14 @@ func (t *testDriver) awaitServiceExportStatus(expCond ...*mcsv1a1.ServiceExportC
 		exp := expCond[0]
 		actual := se.Status.Conditions[0]
 		Expect(actual.Type).To(Equal(exp.Type))
-		Expect(actual.Status).To(Equal(exp.Status))
 		Expect(actual.LastTransitionTime).To(Not(BeNil()))
 		Expect(actual.Reason).To(Not(BeNil()))
-		Expect(*actual.Reason).To(Equal(*exp.Reason))
+
+		// The status may still be transitioning, eg as the endpoints change.
+		if actual.Status != exp.Status || *actual.Reason != *exp.Reason {
+			return false, nil
+		}
+
 		Expect(actual.Message).To(Not(BeNil()))
 
 		if exp.Message != nil {
@@ -651,7 +767,7 @@ func (t *testDriver) awaitServiceExported(serviceIP string) {
 	t.awaitBrokerServiceImport(mcsv1a1.ClusterSetIP, serviceIP)
 	t.cluster2.awaitServiceImport(t.service, mcsv1a1.ClusterSetIP, serviceIP)
 
-	t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionTrue, ""))
+	Expect(testutil.WaitForExported(t.cluster1.localDynClient, t.service.Namespace, t.service.Name, 5*time.Second)).To(Succeed())
 }
 
 func (t *testDriver) awaitHeadlessServiceImport() {
@@ -684,6 +800,61 @@ func (t *testDriver) awaitHeadlessServiceUnexported() {
 	t.awaitNoEndpointSlice(t.cluster1.localEndpointSliceClient)
 }
 
+func (t *testDriver) awaitForceResyncHandled(value string) {
+	name := t.service.Name + "-" + t.service.Namespace + "-" + clusterID1
+
+	for _, client := range []dynamic.ResourceInterface{t.cluster1.localServiceImportClient, t.brokerServiceImportClient} {
+		test.AwaitAndVerifyResource(client, name, func(obj *unstructured.Unstructured) bool {
+			return obj.GetAnnotations()[lhconstants.ForceResyncAnnotation] == value
+		})
+	}
+}
+
+// awaitServiceImportAnnotation awaits the given annotation value on the ServiceImport in the broker and the other cluster.
+// An empty value awaits the removal of the annotation.
+func (t *testDriver) awaitServiceImportAnnotation(key, value string) {
+	name := t.service.Name + "-" + t.service.Namespace + "-" + clusterID1
+
+	for _, client := range []dynamic.ResourceInterface{t.brokerServiceImportClient, t.cluster2.localServiceImportClient} {
+		test.AwaitAndVerifyResource(client, name, func(obj *unstructured.Unstructured) bool {
+			return obj.GetAnnotations()[key] == value
+		})
+	}
+}
+
+func (t *testDriver) awaitServiceImportType(sType mcsv1a1.ServiceImportType) {
+	name := t.service.Name + "-" + t.service.Namespace + "-" + clusterID1
+
+	for _, client := range []dynamic.ResourceInterface{
+		t.cluster1.localServiceImportClient, t.brokerServiceImportClient,
+		t.cluster2.localServiceImportClient,
+	} {
+		test.AwaitAndVerifyResource(client, name, func(obj *unstructured.Unstructured) bool {
+			actual, _, _ := unstructured.NestedString(obj.Object, "spec", "type")
+			return actual == string(sType)
+		})
+	}
+}
+
+func (t *testDriver) awaitLastExportError(matcher types.GomegaMatcher) *controller.ExportError {
+	var lastError *controller.ExportError
+
+	Eventually(func() *controller.ExportError {
+		obj, err := t.cluster1.localServiceExportClient.Get(context.TODO(), t.service.Name, metav1.GetOptions{})
+		Expect(err).To(Succeed())
+
+		svcExport := &mcsv1a1.ServiceExport{}
+		Expect(scheme.Scheme.Convert(obj, svcExport, nil)).To(Succeed())
+
+		lastError, err = controller.GetLastExportError(svcExport)
+		Expect(err).To(Succeed())
+
+		return lastError
+	}, 5).Should(matcher)
+
+	return lastError
+}
+
 func (t *testDriver) awaitServiceUnavailableStatus() {
 	t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "ServiceUnavailable"))
 }
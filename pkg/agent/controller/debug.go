@@ -0,0 +1,115 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/klog/v2"
+)
+
+// DebugServer serves the metrics and net/http/pprof endpoints for profiling the agent and, if given a Resyncer, an
+// endpoint to trigger a resync of every ServiceExport.
+type DebugServer struct {
+	server   *http.Server
+	listener net.Listener
+}
+
+// Resyncer triggers a resync of every ServiceExport, returning the number queued.
+type Resyncer interface {
+	ResyncAll() (int, error)
+}
+
+// StartDebugServer starts a DebugServer listening on the given bind address. The debug server is disabled by default so
+// nil is returned if the bind address is empty.
+func StartDebugServer(bindAddress string, resyncer Resyncer) (*DebugServer, error) {
+	if bindAddress == "" {
+		return nil, nil
+	}
+
+	listener, err := net.Listen("tcp", bindAddress)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listening on debug bind address %q", bindAddress)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	if resyncer != nil {
+		mux.HandleFunc("/resync", resyncHandler(resyncer))
+	}
+
+	d := &DebugServer{
+		server:   &http.Server{Handler: mux, ReadHeaderTimeout: 60 * time.Second},
+		listener: listener,
+	}
+
+	go func() {
+		if err := d.server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			klog.Errorf("Error running debug server: %v", err)
+		}
+	}()
+
+	klog.Infof("Debug server listening on %s", listener.Addr())
+
+	return d, nil
+}
+
+// resyncHandler triggers a resync on a POST request.
+func resyncHandler(resyncer Resyncer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		n, err := resyncer.ResyncAll()
+		if err != nil {
+			klog.Errorf("Error triggering a resync: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		fmt.Fprintf(w, "Queued %d ServiceExports for resync\n", n)
+	}
+}
+
+// Addr returns the address the DebugServer is listening on.
+func (d *DebugServer) Addr() string {
+	return d.listener.Addr().String()
+}
+
+func (d *DebugServer) Shutdown(ctx context.Context) error {
+	return errors.Wrap(d.server.Shutdown(ctx), "error shutting down the debug server")
+}
@@ -0,0 +1,101 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/lighthouse/pkg/agent/controller"
+)
+
+var _ = Describe("Debug server", func() {
+	When("no bind address is configured", func() {
+		It("should not start", func() {
+			server, err := controller.StartDebugServer("", nil)
+			Expect(err).To(Succeed())
+			Expect(server).To(BeNil())
+		})
+	})
+
+	When("a bind address is configured", func() {
+		var (
+			server   *controller.DebugServer
+			resyncer *fakeResyncer
+		)
+
+		BeforeEach(func() {
+			var err error
+
+			resyncer = &fakeResyncer{queued: 3}
+
+			server, err = controller.StartDebugServer("127.0.0.1:0", resyncer)
+			Expect(err).To(Succeed())
+			Expect(server).ToNot(BeNil())
+		})
+
+		AfterEach(func() {
+			Expect(server.Shutdown(context.TODO())).To(Succeed())
+		})
+
+		It("should serve the pprof and metrics endpoints", func() {
+			for _, path := range []string{"/debug/pprof/", "/metrics"} {
+				resp, err := http.Get("http://" + server.Addr() + path) // nolint:noctx // Not needed for the test
+				Expect(err).To(Succeed())
+				Expect(resp.Body.Close()).To(Succeed())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK), "Unexpected status for %q", path)
+			}
+		})
+
+		It("should trigger a resync on a POST to the resync endpoint", func() {
+			resp, err := http.Post("http://"+server.Addr()+"/resync", "", http.NoBody) // nolint:noctx // Not needed for the test
+			Expect(err).To(Succeed())
+
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).To(Succeed())
+			Expect(resp.Body.Close()).To(Succeed())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(string(body)).To(ContainSubstring("Queued 3 ServiceExports"))
+			Expect(resyncer.calls).To(Equal(1))
+		})
+
+		It("should reject other methods on the resync endpoint", func() {
+			resp, err := http.Get("http://" + server.Addr() + "/resync") // nolint:noctx // Not needed for the test
+			Expect(err).To(Succeed())
+			Expect(resp.Body.Close()).To(Succeed())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+			Expect(resyncer.calls).To(BeZero())
+		})
+	})
+})
+
+type fakeResyncer struct {
+	queued int
+	calls  int
+}
+
+func (r *fakeResyncer) ResyncAll() (int, error) {
+	r.calls++
+	return r.queued, nil
+}
@@ -0,0 +1,74 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+// ClustersetDomain is the default DNS domain for exported services.
+const ClustersetDomain = "clusterset.local"
+
+// DNSNameFor returns the clusterset DNS name at which the service exported with the given namespace and name is
+// reachable in the default domain.
+func DNSNameFor(namespace, name string) string {
+	return DNSNameInDomain(ClustersetDomain, namespace, name)
+}
+
+// ClusterDNSNameFor returns the DNS name at which the given cluster's instance of an exported service is reachable in the
+// default domain.
+func ClusterDNSNameFor(clusterID, namespace, name string) string {
+	return ClusterDNSNameInDomain(ClustersetDomain, clusterID, namespace, name)
+}
+
+// DNSNameInDomain returns the DNS name of an exported service in the given domain, eg a custom zone configured for the
+// DNS plugin. If the domain is empty, the default is used.
+func DNSNameInDomain(domain, namespace, name string) string {
+	return name + "." + namespace + ".svc." + normalizeDomain(domain)
+}
+
+// ClusterDNSNameInDomain returns the DNS name of the given cluster's instance of an exported service in the given domain.
+// If the domain is empty, the default is used.
+func ClusterDNSNameInDomain(domain, clusterID, namespace, name string) string {
+	return clusterID + "." + DNSNameInDomain(domain, namespace, name)
+}
+
+// AliasDNSNameFor returns the DNS name for the custom clusterset hostname requested by the given ServiceExport in the
+// given domain, if any. If the domain is empty, the default is used. The alias is only served if the hostname is valid
+// and not in use by another exported service.
+func AliasDNSNameFor(svcExport *mcsv1a1.ServiceExport, domain string) (string, bool) {
+	hostname := svcExport.GetAnnotations()[lhconstants.ClustersetHostnameAnnotation]
+	if hostname == "" {
+		return "", false
+	}
+
+	return hostname + "." + normalizeDomain(domain), true
+}
+
+func normalizeDomain(domain string) string {
+	domain = strings.Trim(domain, ".")
+	if domain == "" {
+		return ClustersetDomain
+	}
+
+	return domain
+}
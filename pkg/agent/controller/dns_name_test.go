@@ -0,0 +1,76 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/lighthouse/pkg/agent/controller"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+var _ = Describe("DNS names", func() {
+	When("the default domain is used", func() {
+		It("should return the clusterset.local names", func() {
+			Expect(controller.DNSNameFor("ns", "nginx")).To(Equal("nginx.ns.svc.clusterset.local"))
+			Expect(controller.ClusterDNSNameFor("east", "ns", "nginx")).To(Equal("east.nginx.ns.svc.clusterset.local"))
+		})
+	})
+
+	When("a custom domain is used", func() {
+		It("should return the names in that domain", func() {
+			Expect(controller.DNSNameInDomain("example.org.", "ns", "nginx")).To(Equal("nginx.ns.svc.example.org"))
+			Expect(controller.ClusterDNSNameInDomain("example.org", "east", "ns", "nginx")).To(
+				Equal("east.nginx.ns.svc.example.org"))
+		})
+	})
+
+	When("the custom domain is empty", func() {
+		It("should return the names in the default domain", func() {
+			Expect(controller.DNSNameInDomain("", "ns", "nginx")).To(Equal("nginx.ns.svc.clusterset.local"))
+		})
+	})
+
+	When("a ServiceExport declares a clusterset hostname", func() {
+		It("should return the alias name", func() {
+			svcExport := &mcsv1a1.ServiceExport{ObjectMeta: metav1.ObjectMeta{
+				Name:        "nginx",
+				Namespace:   "ns",
+				Annotations: map[string]string{lhconstants.ClustersetHostnameAnnotation: "web"},
+			}}
+
+			alias, ok := controller.AliasDNSNameFor(svcExport, "")
+			Expect(ok).To(BeTrue())
+			Expect(alias).To(Equal("web.clusterset.local"))
+
+			alias, ok = controller.AliasDNSNameFor(svcExport, "example.org")
+			Expect(ok).To(BeTrue())
+			Expect(alias).To(Equal("web.example.org"))
+		})
+	})
+
+	When("a ServiceExport doesn't declare a clusterset hostname", func() {
+		It("should not return an alias name", func() {
+			_, ok := controller.AliasDNSNameFor(&mcsv1a1.ServiceExport{}, "")
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
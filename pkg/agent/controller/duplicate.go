@@ -0,0 +1,51 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const duplicateExport = "DuplicateExport"
+
+// getDuplicateExportOrigin returns the namespace/name of another exported Service whose local ServiceImport has the
+// same effective name as the one for the given Service, if any. With the legacy naming scheme, eg Service "a-b" in
+// namespace "c" and Service "a" in namespace "b-c" both map to "a-b-c-<cluster ID>". The first one exported owns the
+// name so the later one must not be synced nor delete the existing ServiceImport.
+func (a *Controller) getDuplicateExportOrigin(name, namespace string) (string, bool) {
+	obj, found, err := a.serviceImportSyncer.GetLocalResource(a.getObjectNameWithClusterID(name, namespace), a.namespace,
+		&mcsv1a1.ServiceImport{})
+	if err != nil {
+		klog.Errorf("Error retrieving the local ServiceImport for Service (%s/%s): %v", namespace, name, err)
+		return "", false
+	}
+
+	if !found {
+		return "", false
+	}
+
+	annotations := obj.(*mcsv1a1.ServiceImport).GetAnnotations()
+	if annotations[lhconstants.OriginName] == name && annotations[lhconstants.OriginNamespace] == namespace {
+		return "", false
+	}
+
+	return annotations[lhconstants.OriginNamespace] + "/" + annotations[lhconstants.OriginName], true
+}
@@ -0,0 +1,110 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+var _ = Describe("Duplicate ServiceExports", func() {
+	var (
+		t                *testDriver
+		dupService       *corev1.Service
+		dupServiceExport *mcsv1a1.ServiceExport
+		dupExportClient  dynamic.ResourceInterface
+	)
+
+	BeforeEach(func() {
+		t = newTestDiver()
+
+		// With the legacy naming scheme, "nginx-service" in namespace "ns" maps to the same ServiceImport name as "nginx"
+		// in namespace "service-ns".
+		dupService = t.service.DeepCopy()
+		dupService.Name = t.service.Name + "-service"
+		dupService.Namespace = "ns"
+		dupService.Spec.ClusterIP = "10.253.9.2"
+
+		dupServiceExport = &mcsv1a1.ServiceExport{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      dupService.Name,
+				Namespace: dupService.Namespace,
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+
+		dupExportClient = t.cluster1.localDynClient.Resource(*test.GetGroupVersionResourceFor(t.syncerConfig.RestMapper,
+			&mcsv1a1.ServiceExport{})).Namespace(dupService.Namespace)
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("a ServiceExport resolves to the same effective name as an existing export", func() {
+		It("should set the DuplicateExport condition on the later one and not sync it", func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			test.CreateResource(t.cluster1.dynamicServiceClient().Namespace(dupService.Namespace), dupService)
+			test.CreateResource(dupExportClient, dupServiceExport)
+
+			Eventually(func() string {
+				obj, err := dupExportClient.Get(context.TODO(), dupServiceExport.Name, metav1.GetOptions{})
+				Expect(err).To(Succeed())
+
+				se := &mcsv1a1.ServiceExport{}
+				Expect(scheme.Scheme.Convert(obj, se, nil)).To(Succeed())
+
+				if len(se.Status.Conditions) == 0 || se.Status.Conditions[0].Reason == nil {
+					return ""
+				}
+
+				return *se.Status.Conditions[0].Reason
+			}, 5).Should(Equal("DuplicateExport"))
+
+			verifyOrigin := func() map[string]string {
+				return test.AwaitResource(t.brokerServiceImportClient,
+					t.service.Name+"-"+t.service.Namespace+"-"+clusterID1).GetAnnotations()
+			}
+
+			Consistently(verifyOrigin, 300*time.Millisecond).Should(And(
+				HaveKeyWithValue(lhconstants.OriginName, t.service.Name),
+				HaveKeyWithValue(lhconstants.OriginNamespace, t.service.Namespace)))
+
+			Expect(dupExportClient.Delete(context.TODO(), dupServiceExport.Name, metav1.DeleteOptions{})).To(Succeed())
+
+			Consistently(verifyOrigin, 300*time.Millisecond).Should(HaveKeyWithValue(lhconstants.OriginName, t.service.Name))
+		})
+	})
+})
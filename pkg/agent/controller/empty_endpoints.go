@@ -0,0 +1,64 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"github.com/submariner-io/lighthouse/pkg/endpointslice"
+	discovery "k8s.io/api/discovery/v1"
+	"k8s.io/klog/v2"
+)
+
+// deferEmptyEndpoints returns true if publishing the given EndpointSlice should be deferred because it has no endpoints
+// and the grace period since the endpoints were lost hasn't elapsed yet. On the first loss, a resync is scheduled for the
+// end of the grace period which publishes the EndpointSlice if the endpoints weren't restored in the meantime.
+func (e *EndpointController) deferEmptyEndpoints(endpointSlice *discovery.EndpointSlice) bool {
+	if e.emptyEndpointsGracePeriod <= 0 {
+		return false
+	}
+
+	e.emptyEndpointsMutex.Lock()
+	defer e.emptyEndpointsMutex.Unlock()
+
+	if endpointslice.HasEndpoints(endpointSlice) || endpointSlice.Annotations[lhconstants.FQDNEndpointsAnnotation] != "" {
+		e.emptyEndpointsSince = time.Time{}
+		return false
+	}
+
+	if e.emptyEndpointsSince.IsZero() {
+		klog.Infof("Endpoints %s/%s have no endpoints - deferring the update for %v", e.serviceImportSourceNameSpace,
+			e.serviceName, e.emptyEndpointsGracePeriod)
+
+		e.emptyEndpointsSince = time.Now()
+
+		time.AfterFunc(e.emptyEndpointsGracePeriod, func() {
+			select {
+			case <-e.stopCh:
+			default:
+				e.resync()
+			}
+		})
+
+		return true
+	}
+
+	return time.Since(e.emptyEndpointsSince) < e.emptyEndpointsGracePeriod
+}
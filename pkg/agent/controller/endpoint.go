@@ -20,6 +20,8 @@ package controller
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/submariner-io/admiral/pkg/log"
@@ -44,7 +46,8 @@ import (
 
 func startEndpointController(localClient dynamic.Interface, restMapper meta.RESTMapper, scheme *runtime.Scheme,
 	serviceImport *mcsv1a1.ServiceImport, serviceImportNameSpace, serviceName, clusterID string,
-	globalIngressIPCache *globalIngressIPCache,
+	globalIngressIPCache *globalIngressIPCache, emptyEndpointsGracePeriod time.Duration, maxEndpoints int,
+	compactEndpoints bool,
 ) (*EndpointController, error) {
 	klog.V(log.DEBUG).Infof("Starting Endpoints controller for service %s/%s", serviceImportNameSpace, serviceName)
 
@@ -59,12 +62,20 @@ func startEndpointController(localClient dynamic.Interface, restMapper meta.REST
 		stopCh:                       make(chan struct{}),
 		isHeadless:                   serviceImport.Spec.Type == mcsv1a1.Headless,
 		globalIngressIPCache:         globalIngressIPCache,
+		clustersetGroup:              serviceImport.Labels[lhconstants.ClustersetGroupLabel],
 		localClient:                  localClient,
 		ingressIPClient:              localClient.Resource(*globalIngressIPGVR),
+		emptyEndpointsGracePeriod:    emptyEndpointsGracePeriod,
+		maxEndpoints:                 maxEndpoints,
+		compactEndpoints:             compactEndpoints,
 	}
 
+	controller.setEndpointExclusion(serviceImport.Annotations)
+
 	nameSelector := fields.OneTermEqualSelector("metadata.name", serviceName)
 
+	controller.federator = broker.NewFederator(localClient, restMapper, serviceImportNameSpace, "", "ownerReferences")
+
 	epsSyncer, err := syncer.NewResourceSyncer(&syncer.ResourceSyncerConfig{
 		Name:                "Endpoints -> EndpointSlice",
 		SourceClient:        localClient,
@@ -72,7 +83,7 @@ func startEndpointController(localClient dynamic.Interface, restMapper meta.REST
 		SourceFieldSelector: nameSelector.String(),
 		Direction:           syncer.LocalToRemote,
 		RestMapper:          restMapper,
-		Federator:           broker.NewFederator(localClient, restMapper, serviceImportNameSpace, "", "ownerReferences"),
+		Federator:           controller.federator,
 		ResourceType:        &corev1.Endpoints{},
 		Transform:           controller.endpointsToEndpointSlice,
 		Scheme:              scheme,
@@ -95,54 +106,118 @@ func (e *EndpointController) stop() {
 	})
 }
 
+// resync re-derives the EndpointSlice from the Service's current Endpoints, read directly from the API server rather than
+// the informer cache to avoid a window of stale IPs, eg after the Service's selector changed.
+func (e *EndpointController) resync() {
+	obj, err := e.localClient.Resource(corev1.SchemeGroupVersion.WithResource("endpoints")).
+		Namespace(e.serviceImportSourceNameSpace).Get(context.TODO(), e.serviceName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		e.onEndpointsMissing()
+		return
+	}
+
+	if err != nil {
+		klog.Errorf("Error retrieving Endpoints %s/%s: %v", e.serviceImportSourceNameSpace, e.serviceName, err)
+		return
+	}
+
+	endpoints := &corev1.Endpoints{}
+
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, endpoints)
+	if err != nil {
+		klog.Errorf("Error converting Endpoints %s/%s: %v", e.serviceImportSourceNameSpace, e.serviceName, err)
+		return
+	}
+
+	klog.V(log.DEBUG).Infof("Resyncing the EndpointSlice for Endpoints %s/%s", endpoints.Namespace, endpoints.Name)
+
+	endpointSlice, _ := e.endpointSliceFromEndpoints(endpoints, syncer.Update)
+	if endpointSlice == nil || e.deferEmptyEndpoints(endpointSlice.(*discovery.EndpointSlice)) {
+		return
+	}
+
+	err = e.federator.Distribute(endpointSlice)
+	if err != nil {
+		klog.Errorf("Error resyncing the EndpointSlice for Endpoints %s/%s: %v", endpoints.Namespace, endpoints.Name, err)
+	}
+}
+
+// onEndpointsMissing deletes the EndpointSlice, unless deferred by the empty endpoints grace period, as a deleted Endpoints
+// object has no endpoints. The EndpointSlice is re-populated when the Endpoints object is re-created.
+func (e *EndpointController) onEndpointsMissing() {
+	if e.deferEmptyEndpoints(&discovery.EndpointSlice{}) {
+		return
+	}
+
+	klog.V(log.DEBUG).Infof("Endpoints %s/%s not found - deleting the EndpointSlice", e.serviceImportSourceNameSpace, e.serviceName)
+
+	err := e.federator.Delete(e.emptyEndpointSlice(e.serviceImportSourceNameSpace, e.serviceName))
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Error deleting the EndpointSlice for Endpoints %s/%s: %v", e.serviceImportSourceNameSpace, e.serviceName, err)
+	}
+}
+
+func (e *EndpointController) emptyEndpointSlice(namespace, endpointsName string) *discovery.EndpointSlice {
+	return &discovery.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      endpointsName + "-" + e.clusterID,
+			Namespace: namespace,
+		},
+	}
+}
+
 func (e *EndpointController) cleanup() {
-	resourceClient := e.localClient.Resource(schema.GroupVersionResource{
+	deleteEndpointSlices(e.localClient, e.serviceImportSourceNameSpace, e.serviceName, e.clusterID)
+}
+
+// deleteEndpointSlices deletes the EndpointSlices in the given namespace for the service from the given source cluster.
+func deleteEndpointSlices(localClient dynamic.Interface, namespace, serviceName, clusterID string) {
+	resourceClient := localClient.Resource(schema.GroupVersionResource{
 		Group:    discovery.SchemeGroupVersion.Group,
 		Version:  discovery.SchemeGroupVersion.Version,
 		Resource: "endpointslices",
-	}).Namespace(e.serviceImportSourceNameSpace)
+	}).Namespace(namespace)
 
 	// MCS-compliant labels
 	err := resourceClient.DeleteCollection(context.TODO(), metav1.DeleteOptions{}, metav1.ListOptions{
 		LabelSelector: labels.SelectorFromSet(map[string]string{
-			lhconstants.LabelSourceNamespace:  e.serviceImportSourceNameSpace,
-			lhconstants.MCSLabelSourceCluster: e.clusterID,
-			lhconstants.MCSLabelServiceName:   e.serviceName,
+			lhconstants.LabelSourceNamespace:  namespace,
+			lhconstants.MCSLabelSourceCluster: clusterID,
+			lhconstants.MCSLabelServiceName:   serviceName,
 		}).String(),
 	})
 
 	if err != nil && !apierrors.IsNotFound(err) {
-		klog.Errorf("Error deleting the EndpointSlices associated with serviceImport %q: %v", e.serviceImportName, err)
+		klog.Errorf("Error deleting the EndpointSlices for service \"%s/%s\" from cluster %q: %v", namespace, serviceName, clusterID, err)
 	}
 
 	// Lighthouse-proprietary labels
 	err = resourceClient.DeleteCollection(context.TODO(), metav1.DeleteOptions{}, metav1.ListOptions{
 		LabelSelector: labels.SelectorFromSet(map[string]string{
-			lhconstants.LabelSourceNamespace:         e.serviceImportSourceNameSpace,
-			lhconstants.LighthouseLabelSourceCluster: e.clusterID,
-			lhconstants.LighthouseLabelSourceName:    e.serviceName,
+			lhconstants.LabelSourceNamespace:         namespace,
+			lhconstants.LighthouseLabelSourceCluster: clusterID,
+			lhconstants.LighthouseLabelSourceName:    serviceName,
 		}).String(),
 	})
 
 	if err != nil && !apierrors.IsNotFound(err) {
-		klog.Errorf("Error deleting the EndpointSlices associated with serviceImport %q: %v", e.serviceImportName, err)
+		klog.Errorf("Error deleting the EndpointSlices for service \"%s/%s\" from cluster %q: %v", namespace, serviceName, clusterID, err)
 	}
 }
 
 func (e *EndpointController) endpointsToEndpointSlice(obj runtime.Object, numRequeues int, op syncer.Operation) (runtime.Object, bool) {
 	endPoints := obj.(*corev1.Endpoints)
 
-	endpointSliceName := endPoints.Name + "-" + e.clusterID
-
 	if op == syncer.Delete {
 		klog.V(log.DEBUG).Infof("Endpoints %s/%s deleted", endPoints.Namespace, endPoints.Name)
 
-		return &discovery.EndpointSlice{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      endpointSliceName,
-				Namespace: endPoints.Namespace,
-			},
-		}, false
+		// The deleted Endpoints may be re-created shortly, eg when its backing controller is recreated, so the grace period
+		// for empty endpoints applies. If it elapses, the scheduled resync deletes the EndpointSlice.
+		if e.deferEmptyEndpoints(&discovery.EndpointSlice{}) {
+			return nil, false
+		}
+
+		return e.emptyEndpointSlice(endPoints.Namespace, endPoints.Name), false
 	}
 
 	if op == syncer.Create {
@@ -151,7 +226,12 @@ func (e *EndpointController) endpointsToEndpointSlice(obj runtime.Object, numReq
 		klog.V(log.TRACE).Infof("Endpoints %s/%s updated", endPoints.Namespace, endPoints.Name)
 	}
 
-	return e.endpointSliceFromEndpoints(endPoints, op)
+	endpointSlice, requeue := e.endpointSliceFromEndpoints(endPoints, op)
+	if op == syncer.Update && endpointSlice != nil && e.deferEmptyEndpoints(endpointSlice.(*discovery.EndpointSlice)) {
+		return nil, false
+	}
+
+	return endpointSlice, requeue
 }
 
 func (e *EndpointController) endpointSliceFromEndpoints(endpoints *corev1.Endpoints, op syncer.Operation) (
@@ -167,36 +247,70 @@ func (e *EndpointController) endpointSliceFromEndpoints(endpoints *corev1.Endpoi
 		lhconstants.MCSLabelServiceName:   e.serviceName,
 	}
 
+	if e.clustersetGroup != "" {
+		endpointSlice.Labels[lhconstants.ClustersetGroupLabel] = e.clustersetGroup
+	}
+
 	endpointSlice.AddressType = discovery.AddressTypeIPv4
 
 	if len(endpoints.Subsets) > 0 {
-		subset := endpoints.Subsets[0]
-		for i := range subset.Ports {
-			endpointSlice.Ports = append(endpointSlice.Ports, discovery.EndpointPort{
-				Port:     &subset.Ports[i].Port,
-				Name:     &subset.Ports[i].Name,
-				Protocol: &subset.Ports[i].Protocol,
-			})
+		var allAddresses []corev1.EndpointAddress
+		for i := range endpoints.Subsets {
+			allAddresses = append(allAddresses, endpoints.Subsets[i].Addresses...)
+			allAddresses = append(allAddresses, endpoints.Subsets[i].NotReadyAddresses...)
 		}
 
-		if allAddressesIPv6(append(subset.Addresses, subset.NotReadyAddresses...)) {
+		if allAddressesIPv6(allAddresses) {
 			endpointSlice.AddressType = discovery.AddressTypeIPv6
 		}
 
-		newEndpoints, retry := e.getEndpointsFromAddresses(subset.Addresses, endpointSlice.AddressType, true)
-		if retry {
-			return nil, true
+		// The subsets of an Endpoints group the addresses by the ports they expose so record the ports of each endpoint if
+		// they differ, eg for a multi-port headless Service whose pods don't all expose all ports.
+		ports := newEndpointPorts()
+
+		var notReadyEndpoints []discovery.Endpoint
+
+		var fqdns []string
+
+		for i := range endpoints.Subsets {
+			subset := &endpoints.Subsets[i]
+			subsetPorts := ports.addSubset(subset)
+
+			newEndpoints, retry := e.getEndpointsFromAddresses(subset.Addresses, endpointSlice.AddressType, true)
+			if retry {
+				return nil, true
+			}
+
+			ports.addEndpoints(newEndpoints, subsetPorts)
+			endpointSlice.Endpoints = append(endpointSlice.Endpoints, newEndpoints...)
+
+			newEndpoints, retry = e.getEndpointsFromAddresses(subset.NotReadyAddresses, endpointSlice.AddressType, false)
+			if retry {
+				// TODO: We may not want unready endpoints at all
+				return nil, true
+			}
+
+			ports.addEndpoints(newEndpoints, subsetPorts)
+			notReadyEndpoints = append(notReadyEndpoints, newEndpoints...)
+
+			subsetFQDNs := getFQDNs(subset.Addresses)
+			ports.addAddresses(subsetFQDNs, subsetPorts)
+			fqdns = append(fqdns, subsetFQDNs...)
 		}
 
-		endpointSlice.Endpoints = append(endpointSlice.Endpoints, newEndpoints...)
+		endpointSlice.Ports = ports.all
+		endpointSlice.Endpoints = append(endpointSlice.Endpoints, notReadyEndpoints...)
 
-		newEndpoints, retry = e.getEndpointsFromAddresses(subset.NotReadyAddresses, endpointSlice.AddressType, false)
-		if retry {
-			// TODO: We may not want unready endpoints at all
-			return nil, true
+		/* An EndpointSlice can only hold a single address type so the ready FQDN-only addresses are recorded as an
+		annotation, which the DNS plugin answers with CNAMEs.
+		*/
+		if len(fqdns) > 0 {
+			endpointSlice.Annotations = map[string]string{lhconstants.FQDNEndpointsAnnotation: strings.Join(fqdns, ",")}
 		}
 
-		endpointSlice.Endpoints = append(endpointSlice.Endpoints, newEndpoints...)
+		e.capEndpoints(endpointSlice)
+		ports.annotate(endpointSlice, fqdns)
+		e.compact(endpointSlice)
 	}
 
 	if op == syncer.Create {
@@ -216,6 +330,34 @@ func (e *EndpointController) getEndpointsFromAddresses(addresses []corev1.Endpoi
 
 	for i := range addresses {
 		address := &addresses[i]
+		if isFQDNAddress(address) {
+			continue
+		}
+
+		// Globalnet only allocates global IPs for pods so the manually managed addresses of a selectorless Service, which
+		// don't reference a pod, can't be exported.
+		if e.isHeadless && e.globalIngressIPCache != nil && address.TargetRef == nil {
+			klog.Warningf("EndpointAddress %q for service %s/%s doesn't reference a pod so it can't be assigned a global IP",
+				address.IP, e.serviceImportSourceNameSpace, e.serviceName)
+
+			continue
+		}
+
+		excluded, err := e.isExcluded(address)
+		if err != nil {
+			klog.Errorf("Error checking whether EndpointAddress %q for service %s/%s is excluded: %v", address.IP,
+				e.serviceImportSourceNameSpace, e.serviceName, err)
+
+			return nil, true
+		}
+
+		if excluded {
+			klog.V(log.DEBUG).Infof("Excluding EndpointAddress %q for service %s/%s from the export", address.IP,
+				e.serviceImportSourceNameSpace, e.serviceName)
+
+			continue
+		}
+
 		if utilnet.IsIPv6String(address.IP) == isIPv6AddressType {
 			endpoint, retry := e.endpointFromAddress(address, ready)
 			if retry {
@@ -260,17 +402,39 @@ func (e *EndpointController) endpointFromAddress(address *corev1.EndpointAddress
 }
 
 func allAddressesIPv6(addresses []corev1.EndpointAddress) bool {
-	if len(addresses) == 0 {
-		return false
-	}
+	found := false
 
-	for _, address := range addresses {
-		if !utilnet.IsIPv6String(address.IP) {
+	for i := range addresses {
+		if isFQDNAddress(&addresses[i]) {
+			continue
+		}
+
+		if !utilnet.IsIPv6String(addresses[i].IP) {
 			return false
 		}
+
+		found = true
+	}
+
+	return found
+}
+
+// isFQDNAddress returns true if the address refers to its backend by a fully qualified host name rather than an IP, eg an
+// external backend behind a headless Service.
+func isFQDNAddress(address *corev1.EndpointAddress) bool {
+	return address.IP == "" && address.Hostname != ""
+}
+
+func getFQDNs(addresses []corev1.EndpointAddress) []string {
+	var fqdns []string
+
+	for i := range addresses {
+		if isFQDNAddress(&addresses[i]) {
+			fqdns = append(fqdns, addresses[i].Hostname)
+		}
 	}
 
-	return true
+	return fqdns
 }
 
 func (e *EndpointController) getIP(address *corev1.EndpointAddress) string {
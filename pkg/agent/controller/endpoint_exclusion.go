@@ -0,0 +1,205 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const invalidEndpointExclusion = "InvalidEndpointExclusion"
+
+// endpointExclusion filters out the endpoints that shouldn't be exported, either by IP or by the labels of their pod.
+type endpointExclusion struct {
+	ips         string
+	podSelector string
+	ipNets      []*net.IPNet
+	selector    labels.Selector
+}
+
+// getServiceExportEndpointExclusion returns the endpoint IPs or CIDRs and the pod label selector to exclude from the export,
+// as requested via the ServiceExport annotations, if any, in normalized form. If either is invalid, a non-empty reason and
+// message are returned.
+func getServiceExportEndpointExclusion(svcExport *mcsv1a1.ServiceExport) (ips, podSelector, reason, msg string) {
+	annotations := svcExport.GetAnnotations()
+
+	if value, ok := annotations[lhconstants.ExcludedIPsAnnotation]; ok {
+		ipNets, err := parseExcludedIPs(value)
+		if err != nil {
+			return "", "", invalidEndpointExclusion, fmt.Sprintf("The excluded IPs %q are invalid: %v", value, err)
+		}
+
+		cidrs := make([]string, len(ipNets))
+		for i := range ipNets {
+			cidrs[i] = ipNets[i].String()
+		}
+
+		ips = strings.Join(cidrs, ",")
+	}
+
+	if value, ok := annotations[lhconstants.ExcludedPodSelectorAnnotation]; ok {
+		selector, err := parseExcludedPodSelector(value)
+		if err != nil {
+			return "", "", invalidEndpointExclusion, fmt.Sprintf("The excluded pod selector %q is invalid: %v", value, err)
+		}
+
+		podSelector = selector.String()
+	}
+
+	return ips, podSelector, "", ""
+}
+
+// parseExcludedIPs parses a comma-separated list of IPs and CIDRs, a single IP being converted to a host CIDR.
+func parseExcludedIPs(value string) ([]*net.IPNet, error) {
+	ipNets := []*net.IPNet{}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+
+			ipNets = append(ipNets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, errors.Errorf("%q is neither an IP nor a CIDR", entry)
+		}
+
+		ipNets = append(ipNets, ipNet)
+	}
+
+	return ipNets, nil
+}
+
+// parseExcludedPodSelector parses a pod label selector. An empty selector would exclude every pod so it's rejected.
+func parseExcludedPodSelector(value string) (labels.Selector, error) {
+	selector, err := labels.Parse(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing the label selector")
+	}
+
+	if selector.Empty() {
+		return nil, errors.New("the label selector is empty")
+	}
+
+	return selector, nil
+}
+
+// newEndpointExclusion returns the endpoint exclusion recorded on the given ServiceImport annotations, if any. The
+// annotations were validated when the ServiceImport was created so an invalid value is logged and ignored.
+func newEndpointExclusion(annotations map[string]string) *endpointExclusion {
+	exclusion := &endpointExclusion{
+		ips:         annotations[lhconstants.ExcludedIPsAnnotation],
+		podSelector: annotations[lhconstants.ExcludedPodSelectorAnnotation],
+	}
+
+	var err error
+
+	if exclusion.ips != "" {
+		exclusion.ipNets, err = parseExcludedIPs(exclusion.ips)
+		if err != nil {
+			klog.Errorf("Ignoring the invalid excluded IPs %q: %v", exclusion.ips, err)
+		}
+	}
+
+	if exclusion.podSelector != "" {
+		exclusion.selector, err = parseExcludedPodSelector(exclusion.podSelector)
+		if err != nil {
+			klog.Errorf("Ignoring the invalid excluded pod selector %q: %v", exclusion.podSelector, err)
+		}
+	}
+
+	return exclusion
+}
+
+func (x *endpointExclusion) equals(other *endpointExclusion) bool {
+	return x.ips == other.ips && x.podSelector == other.podSelector
+}
+
+// setEndpointExclusion updates the endpoint exclusion from the given ServiceImport annotations and returns true if it
+// changed, in which case the EndpointSlice needs to be re-derived.
+func (e *EndpointController) setEndpointExclusion(annotations map[string]string) bool {
+	exclusion := newEndpointExclusion(annotations)
+
+	e.exclusionMutex.Lock()
+	defer e.exclusionMutex.Unlock()
+
+	if e.exclusion != nil && e.exclusion.equals(exclusion) {
+		return false
+	}
+
+	e.exclusion = exclusion
+
+	return true
+}
+
+// isExcluded returns true if the given address matches an excluded IP or references a pod matching the excluded pod
+// selector.
+func (e *EndpointController) isExcluded(address *corev1.EndpointAddress) (bool, error) {
+	e.exclusionMutex.Lock()
+	exclusion := e.exclusion
+	e.exclusionMutex.Unlock()
+
+	if exclusion == nil {
+		return false, nil
+	}
+
+	if ip := net.ParseIP(address.IP); ip != nil {
+		for _, ipNet := range exclusion.ipNets {
+			if ipNet.Contains(ip) {
+				return true, nil
+			}
+		}
+	}
+
+	if exclusion.selector == nil || address.TargetRef == nil || (address.TargetRef.Kind != "" && address.TargetRef.Kind != "Pod") {
+		return false, nil
+	}
+
+	obj, err := e.localClient.Resource(corev1.SchemeGroupVersion.WithResource("pods")).Namespace(e.serviceImportSourceNameSpace).
+		Get(context.TODO(), address.TargetRef.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, errors.Wrapf(err, "error retrieving pod %s/%s", e.serviceImportSourceNameSpace, address.TargetRef.Name)
+	}
+
+	return exclusion.selector.Matches(labels.Set(obj.GetLabels())), nil
+}
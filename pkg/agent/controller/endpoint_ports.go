@@ -0,0 +1,130 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	"k8s.io/klog/v2"
+)
+
+// endpointPorts collects the ports of an EndpointSlice, ie the union of the ports of the Endpoints subsets, and the ports
+// exposed by each endpoint address.
+type endpointPorts struct {
+	all         []discovery.EndpointPort
+	byAddress   map[string][]discovery.EndpointPort
+	subsetSizes []int
+}
+
+func newEndpointPorts() *endpointPorts {
+	return &endpointPorts{byAddress: map[string][]discovery.EndpointPort{}}
+}
+
+// addSubset adds the ports of the subset to the union and returns them.
+func (p *endpointPorts) addSubset(subset *corev1.EndpointSubset) []discovery.EndpointPort {
+	subsetPorts := make([]discovery.EndpointPort, 0, len(subset.Ports))
+
+	for i := range subset.Ports {
+		port := discovery.EndpointPort{
+			Port:     &subset.Ports[i].Port,
+			Name:     &subset.Ports[i].Name,
+			Protocol: &subset.Ports[i].Protocol,
+		}
+
+		subsetPorts = append(subsetPorts, port)
+
+		if !containsEndpointPort(p.all, &port) {
+			p.all = append(p.all, port)
+		}
+	}
+
+	p.subsetSizes = append(p.subsetSizes, len(subsetPorts))
+
+	return subsetPorts
+}
+
+func (p *endpointPorts) addEndpoints(endpoints []discovery.Endpoint, ports []discovery.EndpointPort) {
+	for i := range endpoints {
+		p.addAddresses(endpoints[i].Addresses, ports)
+	}
+}
+
+func (p *endpointPorts) addAddresses(addresses []string, ports []discovery.EndpointPort) {
+	for _, address := range addresses {
+		p.byAddress[address] = ports
+	}
+}
+
+// annotate records the ports exposed by each of the EndpointSlice's endpoints, and FQDN-only addresses, in an annotation
+// if they don't all expose the same ports. The DNS plugin then only answers a query for a named port with the endpoints
+// that expose it.
+func (p *endpointPorts) annotate(endpointSlice *discovery.EndpointSlice, fqdns []string) {
+	if !p.isHeterogeneous() {
+		return
+	}
+
+	byAddress := map[string][]discovery.EndpointPort{}
+
+	for i := range endpointSlice.Endpoints {
+		for _, address := range endpointSlice.Endpoints[i].Addresses {
+			byAddress[address] = p.byAddress[address]
+		}
+	}
+
+	for _, fqdn := range fqdns {
+		byAddress[fqdn] = p.byAddress[fqdn]
+	}
+
+	data, err := json.Marshal(byAddress)
+	if err != nil {
+		klog.Errorf("Error marshalling the endpoint ports for EndpointSlice %q: %v", endpointSlice.Name, err)
+		return
+	}
+
+	if endpointSlice.Annotations == nil {
+		endpointSlice.Annotations = map[string]string{}
+	}
+
+	endpointSlice.Annotations[lhconstants.EndpointPortsAnnotation] = string(data)
+}
+
+// isHeterogeneous returns whether the subsets expose different ports. As the ports of each subset are a subset of the union,
+// they only differ if a subset has fewer ports than the union.
+func (p *endpointPorts) isHeterogeneous() bool {
+	for _, size := range p.subsetSizes {
+		if size != len(p.all) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsEndpointPort(ports []discovery.EndpointPort, port *discovery.EndpointPort) bool {
+	for i := range ports {
+		if *ports[i].Name == *port.Name && *ports[i].Protocol == *port.Protocol && *ports[i].Port == *port.Port {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,102 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+)
+
+var _ = Describe("Endpoint ports", func() {
+	var t *testDriver
+
+	BeforeEach(func() {
+		t = newTestDiver()
+
+		t.service.Spec.ClusterIP = corev1.ClusterIPNone
+
+		t.endpoints.Subsets[0].Ports = append(t.endpoints.Subsets[0].Ports, corev1.EndpointPort{
+			Name:     "port-2",
+			Protocol: corev1.ProtocolTCP,
+			Port:     5678,
+		})
+
+		t.endpoints.Subsets = append(t.endpoints.Subsets, corev1.EndpointSubset{
+			Addresses: []corev1.EndpointAddress{{IP: "192.168.5.3"}},
+			Ports:     []corev1.EndpointPort{t.endpoints.Subsets[0].Ports[0]},
+		})
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+		t.createService()
+		t.createEndpoints()
+		t.createServiceExport()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("the endpoints expose different ports", func() {
+		It("should publish the endpoints of all subsets and record the ports of each", func() {
+			t.awaitHeadlessServiceImport()
+
+			endpointSlice := awaitBrokerEndpointSliceWithCount(t, 4)
+			Expect(endpointSlice.Ports).To(HaveLen(2))
+
+			portsByAddress := map[string][]discovery.EndpointPort{}
+			Expect(json.Unmarshal([]byte(endpointSlice.Annotations[lhconstants.EndpointPortsAnnotation]), &portsByAddress)).
+				To(Succeed())
+
+			Expect(portNames(portsByAddress["192.168.5.1"])).To(Equal([]string{"port-1", "port-2"}))
+			Expect(portNames(portsByAddress["192.168.5.2"])).To(Equal([]string{"port-1", "port-2"}))
+			Expect(portNames(portsByAddress["10.253.6.1"])).To(Equal([]string{"port-1", "port-2"}))
+			Expect(portNames(portsByAddress["192.168.5.3"])).To(Equal([]string{"port-1"}))
+		})
+	})
+
+	When("the endpoints expose the same ports", func() {
+		BeforeEach(func() {
+			t.endpoints.Subsets[1].Ports = t.endpoints.Subsets[0].Ports
+		})
+
+		It("should publish the endpoints of all subsets without recording the ports of each", func() {
+			t.awaitHeadlessServiceImport()
+
+			endpointSlice := awaitBrokerEndpointSliceWithCount(t, 4)
+			Expect(endpointSlice.Ports).To(HaveLen(2))
+			Expect(endpointSlice.Annotations).ToNot(HaveKey(lhconstants.EndpointPortsAnnotation))
+		})
+	})
+})
+
+func portNames(ports []discovery.EndpointPort) []string {
+	names := make([]string, len(ports))
+	for i := range ports {
+		names[i] = *ports[i].Name
+	}
+
+	return names
+}
@@ -0,0 +1,99 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/log"
+	"github.com/submariner-io/admiral/pkg/resource"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+func (a *Controller) isExportAllNamespace(namespace string) bool {
+	return a.exportAllNamespaces[namespace]
+}
+
+// autoExportService creates a ServiceExport for the given Service if one doesn't already exist. Auto-created
+// ServiceExports are labeled so they can be distinguished from, and don't interfere with, user-created ones.
+func (a *Controller) autoExportService(svc *corev1.Service) error {
+	if _, ok := getServiceImportType(svc); !ok {
+		return nil
+	}
+
+	_, found, err := a.serviceExportSyncer.GetResource(svc.Name, svc.Namespace)
+	if err != nil || found {
+		return errors.Wrap(err, "error retrieving ServiceExport")
+	}
+
+	svcExport := &mcsv1a1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      svc.Name,
+			Namespace: svc.Namespace,
+			Labels: map[string]string{
+				lhconstants.AutoExportedLabel: "true",
+			},
+		},
+	}
+
+	raw, err := resource.ToUnstructured(svcExport)
+	if err != nil {
+		return errors.Wrap(err, "error converting resource")
+	}
+
+	_, err = a.serviceExportClient.Namespace(svc.Namespace).Create(context.TODO(), raw, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+
+	if err == nil {
+		klog.V(log.DEBUG).Infof("Auto-exported Service %s/%s", svc.Namespace, svc.Name)
+	}
+
+	return errors.Wrap(err, "error creating ServiceExport")
+}
+
+// deleteAutoExport deletes the ServiceExport for the given Service if it was auto-created.
+func (a *Controller) deleteAutoExport(svc *corev1.Service) error {
+	obj, found, err := a.serviceExportSyncer.GetResource(svc.Name, svc.Namespace)
+	if err != nil || !found {
+		return errors.Wrap(err, "error retrieving ServiceExport")
+	}
+
+	if obj.(*mcsv1a1.ServiceExport).Labels[lhconstants.AutoExportedLabel] != "true" {
+		return nil
+	}
+
+	err = a.serviceExportClient.Namespace(svc.Namespace).Delete(context.TODO(), svc.Name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	if err == nil {
+		klog.V(log.DEBUG).Infof("Deleted the auto-created ServiceExport for Service %s/%s", svc.Namespace, svc.Name)
+	}
+
+	return errors.Wrap(err, "error deleting ServiceExport")
+}
@@ -0,0 +1,169 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+// exportAnnotationsHash records, on the ServiceImport, a hash of the annotations of the ServiceExport it was derived from.
+const exportAnnotationsHash = "lighthouse.submariner.io/export-annotations-hash"
+
+// hashExportAnnotations returns a hash of the given ServiceExport's annotations, or an empty string if it has none. The last
+// error annotation is written by the agent itself so it's excluded.
+func hashExportAnnotations(svcExport *mcsv1a1.ServiceExport) string {
+	annotations := svcExport.GetAnnotations()
+
+	keys := make([]string, 0, len(annotations))
+
+	for k := range annotations {
+		if k != lhconstants.LastErrorAnnotation {
+			keys = append(keys, k)
+		}
+	}
+
+	if len(keys) == 0 {
+		return ""
+	}
+
+	sort.Strings(keys)
+
+	h := fnv.New64a()
+
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(h, "%s=%s\n", k, annotations[k])
+	}
+
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+// isExportAnnotationsChanged returns true if the annotations on the ServiceExport differ from the ones the local
+// ServiceImport was derived from, or there's no local ServiceImport, so the ServiceImport is re-derived. The annotations
+// configure the export, eg its weight or hostname, so a change on an already exported Service must take effect.
+func (a *Controller) isExportAnnotationsChanged(svcExport *mcsv1a1.ServiceExport) bool {
+	obj, found, err := a.serviceImportSyncer.GetLocalResource(a.getObjectNameWithClusterID(svcExport.Name, svcExport.Namespace),
+		a.namespace, &mcsv1a1.ServiceImport{})
+	if err != nil {
+		klog.Errorf("Error retrieving the local ServiceImport for Service (%s/%s): %v", svcExport.Namespace, svcExport.Name, err)
+		return true
+	}
+
+	if !found {
+		return true
+	}
+
+	return obj.(*mcsv1a1.ServiceImport).GetAnnotations()[exportAnnotationsHash] != hashExportAnnotations(svcExport)
+}
+
+// exportAnnotations holds what's derived from the annotations of a ServiceExport being exported.
+type exportAnnotations struct {
+	svcExport *mcsv1a1.ServiceExport
+	svc       *corev1.Service
+	svcType   mcsv1a1.ServiceImportType
+	// serviceImport holds the annotations to record on the ServiceImport.
+	serviceImport map[string]string
+	activation    time.Time
+}
+
+// exportAnnotationValidator validates one or more of the annotations of a ServiceExport and records what's derived from
+// them. If an annotation is invalid, a non-empty reason and message are returned.
+type exportAnnotationValidator func(a *Controller, e *exportAnnotations) (reason, msg string)
+
+// exportAnnotationValidators validates the ServiceExport annotations in order, the first invalid one preventing the export.
+var exportAnnotationValidators = []exportAnnotationValidator{
+	recordedAs(lhconstants.ClustersetHostnameAnnotation, func(a *Controller, e *exportAnnotations) (string, string, string) {
+		return a.getClustersetHostname(e.svcExport)
+	}),
+	recordedAs(lhconstants.WeightAnnotation, func(_ *Controller, e *exportAnnotations) (string, string, string) {
+		return getServiceExportWeight(e.svcExport)
+	}),
+	recordedAs(lhconstants.StaticClustersetIPAnnotation, func(a *Controller, e *exportAnnotations) (string, string, string) {
+		return a.getServiceExportStaticIP(e.svcExport, e.svc)
+	}),
+	recordedAs(lhconstants.MinEndpointsAnnotation, func(_ *Controller, e *exportAnnotations) (string, string, string) {
+		return getServiceExportMinEndpoints(e.svcExport, e.svcType)
+	}),
+	recordedAs(lhconstants.CNAMETargetAnnotation, func(_ *Controller, e *exportAnnotations) (string, string, string) {
+		return getServiceExportCNAMETarget(e.svcExport, e.svcType)
+	}),
+	recordedAs(lhconstants.AllowedClustersAnnotation, func(_ *Controller, e *exportAnnotations) (string, string, string) {
+		return getServiceExportAllowedClusters(e.svcExport)
+	}),
+	func(_ *Controller, e *exportAnnotations) (string, string) {
+		ips, podSelector, reason, msg := getServiceExportEndpointExclusion(e.svcExport)
+		e.record(lhconstants.ExcludedIPsAnnotation, ips)
+		e.record(lhconstants.ExcludedPodSelectorAnnotation, podSelector)
+
+		return reason, msg
+	},
+	recordedAs(lhconstants.HealthAnnotation, func(_ *Controller, e *exportAnnotations) (string, string, string) {
+		return getServiceExportHealth(e.svcExport)
+	}),
+	func(_ *Controller, e *exportAnnotations) (reason, msg string) {
+		e.activation, reason, msg = getServiceExportActivation(e.svcExport)
+		return reason, msg
+	},
+}
+
+// recordedAs returns a validator that records the value returned by the given function, if any, as the given ServiceImport
+// annotation.
+func recordedAs(annotation string, get func(a *Controller, e *exportAnnotations) (value, reason, msg string),
+) exportAnnotationValidator {
+	return func(a *Controller, e *exportAnnotations) (string, string) {
+		value, reason, msg := get(a, e)
+		e.record(annotation, value)
+
+		return reason, msg
+	}
+}
+
+func (e *exportAnnotations) record(annotation, value string) {
+	if value != "" {
+		e.serviceImport[annotation] = value
+	}
+}
+
+// validateExportAnnotations validates the annotations of the given ServiceExport via the exportAnnotationValidators. If
+// one is invalid, a non-empty reason and message are returned.
+func (a *Controller) validateExportAnnotations(svcExport *mcsv1a1.ServiceExport, svc *corev1.Service,
+	svcType mcsv1a1.ServiceImportType,
+) (e *exportAnnotations, reason, msg string) {
+	e = &exportAnnotations{
+		svcExport:     svcExport,
+		svc:           svc,
+		svcType:       svcType,
+		serviceImport: map[string]string{},
+	}
+
+	for _, validate := range exportAnnotationValidators {
+		if reason, msg = validate(a, e); reason != "" {
+			return e, reason, msg
+		}
+	}
+
+	return e, "", ""
+}
@@ -0,0 +1,435 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+type exportAnnotationValue struct {
+	value    string
+	recorded string
+}
+
+type exportAnnotationEntry struct {
+	annotation string
+	headless   bool
+	// values are set on the ServiceExport in turn, the first when it's created, and each is expected to be recorded on the
+	// ServiceImport. An empty value removes the annotation.
+	values        []exportAnnotationValue
+	invalidValues []string
+	invalidReason string
+	// rejectedForOtherType is true if the annotation is rejected for the Service type other than the entry's.
+	rejectedForOtherType bool
+}
+
+var exportAnnotationEntries = []exportAnnotationEntry{
+	{
+		annotation:    lhconstants.ClustersetHostnameAnnotation,
+		values:        []exportAnnotationValue{{"payments", "payments"}, {"billing", "billing"}},
+		invalidValues: []string{"Not_Valid", "svc"},
+		invalidReason: "InvalidClustersetHostname",
+	},
+	{
+		annotation:    lhconstants.WeightAnnotation,
+		values:        []exportAnnotationValue{{"70", "70"}, {"0", "0"}, {"30", "30"}, {"", ""}},
+		invalidValues: []string{"101", "heavy"},
+		invalidReason: "InvalidWeight",
+	},
+	{
+		annotation:           lhconstants.StaticClustersetIPAnnotation,
+		values:               []exportAnnotationValue{{"243.1.1.1", "243.1.1.1"}, {"243.1.1.2", "243.1.1.2"}, {"fd00::1", "fd00::1"}},
+		invalidValues:        []string{"243.1.1"},
+		invalidReason:        "InvalidStaticIP",
+		rejectedForOtherType: true,
+	},
+	{
+		annotation: lhconstants.AllowedClustersAnnotation,
+		values: []exportAnnotationValue{
+			{" cluster2,cluster1 ,cluster2", "cluster1,cluster2"}, {"cluster3", "cluster3"}, {"", ""},
+		},
+		invalidValues: []string{"cluster1,Cluster_2", ""},
+		invalidReason: "InvalidAllowedClusters",
+	},
+	{
+		annotation: lhconstants.CNAMETargetAnnotation,
+		values: []exportAnnotationValue{
+			{" Registry.Example.com.", "registry.example.com"}, {"mirror.example.com", "mirror.example.com"}, {"", ""},
+		},
+		invalidValues:        []string{"registry_1.example.com", "nginx." + serviceNamespace + ".svc.clusterset.local"},
+		invalidReason:        "InvalidCNAMETarget",
+		rejectedForOtherType: true,
+	},
+	{
+		annotation:    lhconstants.HealthAnnotation,
+		values:        []exportAnnotationValue{{"Unhealthy", lhconstants.UnhealthyValue}, {lhconstants.HealthyValue, lhconstants.HealthyValue}},
+		invalidValues: []string{"degraded"},
+		invalidReason: "InvalidHealth",
+	},
+	{
+		annotation:           lhconstants.MinEndpointsAnnotation,
+		headless:             true,
+		values:               []exportAnnotationValue{{"3", "3"}, {"2", "2"}},
+		invalidValues:        []string{"0"},
+		invalidReason:        "InvalidMinEndpoints",
+		rejectedForOtherType: true,
+	},
+	{
+		annotation:    lhconstants.ExcludedIPsAnnotation,
+		headless:      true,
+		values:        []exportAnnotationValue{{" 192.168.5.2", "192.168.5.2/32"}, {"10.253.0.0/16", "10.253.0.0/16"}, {"", ""}},
+		invalidValues: []string{"192.168.5.1,not-an-ip"},
+		invalidReason: "InvalidEndpointExclusion",
+	},
+	{
+		annotation:    lhconstants.ExcludedPodSelectorAnnotation,
+		headless:      true,
+		values:        []exportAnnotationValue{{"role=maintenance", "role=maintenance"}, {"", ""}},
+		invalidValues: []string{""},
+		invalidReason: "InvalidEndpointExclusion",
+	},
+	{
+		annotation:    lhconstants.ActivationTimeAnnotation,
+		invalidValues: []string{"tomorrow"},
+		invalidReason: "InvalidActivationTime",
+	},
+}
+
+var _ = Describe("ServiceExport annotations", func() {
+	var t *testDriver
+
+	BeforeEach(func() {
+		t = newTestDiver()
+
+		t.serviceExport.Annotations = map[string]string{}
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+		t.createService()
+
+		if t.service.Spec.ClusterIP == corev1.ClusterIPNone {
+			t.createEndpoints()
+		}
+
+		t.createServiceExport()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	awaitExported := func() {
+		if t.service.Spec.ClusterIP == corev1.ClusterIPNone {
+			t.awaitHeadlessServiceImport()
+		} else {
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+		}
+	}
+
+	for i := range exportAnnotationEntries {
+		entry := &exportAnnotationEntries[i]
+
+		Context(entry.annotation, func() {
+			BeforeEach(func() {
+				if entry.headless {
+					t.service.Spec.ClusterIP = corev1.ClusterIPNone
+				}
+			})
+
+			if len(entry.values) > 0 {
+				When("a ServiceExport declares a valid value which is subsequently updated", func() {
+					BeforeEach(func() {
+						t.serviceExport.Annotations[entry.annotation] = entry.values[0].value
+					})
+
+					It("should record each value on the ServiceImport", func() {
+						awaitExported()
+
+						for i, v := range entry.values {
+							if i > 0 {
+								t.setServiceExportAnnotation(entry.annotation, v.value)
+							}
+
+							t.awaitServiceImportAnnotation(entry.annotation, v.recorded)
+						}
+					})
+				})
+			}
+
+			for _, value := range entry.invalidValues {
+				value := value
+
+				When(fmt.Sprintf("a ServiceExport declares the invalid value %q", value), func() {
+					BeforeEach(func() {
+						t.serviceExport.Annotations[entry.annotation] = value
+					})
+
+					It("should update the ServiceExport status and export it once fixed", func() {
+						t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, entry.invalidReason))
+						t.awaitNoServiceImport(t.brokerServiceImportClient)
+						Expect(t.awaitLastExportError(Not(BeNil())).Code).To(Equal(entry.invalidReason))
+
+						fixed := ""
+						if len(entry.values) > 0 {
+							fixed = entry.values[0].value
+						}
+
+						t.setServiceExportAnnotation(entry.annotation, fixed)
+						awaitExported()
+					})
+				})
+			}
+
+			if entry.rejectedForOtherType {
+				When("the Service type doesn't support it", func() {
+					BeforeEach(func() {
+						if entry.headless {
+							t.service.Spec.ClusterIP = "10.253.9.1"
+						} else {
+							t.service.Spec.ClusterIP = corev1.ClusterIPNone
+						}
+
+						t.serviceExport.Annotations[entry.annotation] = entry.values[0].value
+					})
+
+					It("should update the ServiceExport status and not export it", func() {
+						t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, entry.invalidReason))
+						t.awaitNoServiceImport(t.brokerServiceImportClient)
+					})
+				})
+			}
+		})
+	}
+
+	When("the clusterset hostname is already in use by another exported Service", func() {
+		BeforeEach(func() {
+			t.serviceExport.Annotations[lhconstants.ClustersetHostnameAnnotation] = "payments"
+		})
+
+		It("should reject the export until the other Service is unexported", func() {
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			conflicting := t.newRemoteServiceImport("billing", "other-ns", lhconstants.ClustersetHostnameAnnotation, "billing")
+			test.CreateResource(t.cluster1.localServiceImportClient, conflicting)
+
+			t.setServiceExportAnnotation(lhconstants.ClustersetHostnameAnnotation, "billing")
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "ClustersetHostnameConflict"))
+
+			Expect(t.cluster1.localServiceImportClient.Delete(context.TODO(), conflicting.Name,
+				metav1.DeleteOptions{})).To(Succeed())
+
+			t.awaitServiceImportAnnotation(lhconstants.ClustersetHostnameAnnotation, "billing")
+		})
+	})
+
+	When("another cluster declares a different static IP for the same service", func() {
+		BeforeEach(func() {
+			t.serviceExport.Annotations[lhconstants.StaticClustersetIPAnnotation] = "243.1.1.1"
+		})
+
+		It("should reject the export until the other cluster's export is removed", func() {
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			conflicting := t.newRemoteServiceImport(t.service.Name, t.service.Namespace, lhconstants.StaticClustersetIPAnnotation,
+				"243.1.1.9")
+			test.CreateResource(t.cluster1.localServiceImportClient, conflicting)
+
+			t.setServiceExportAnnotation(lhconstants.StaticClustersetIPAnnotation, "243.1.1.2")
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "StaticIPConflict"))
+
+			Expect(t.cluster1.localServiceImportClient.Delete(context.TODO(), conflicting.Name,
+				metav1.DeleteOptions{})).To(Succeed())
+
+			t.awaitServiceImportAnnotation(lhconstants.StaticClustersetIPAnnotation, "243.1.1.2")
+		})
+	})
+
+	When("a ServiceExport declares both a CNAME target and a static clusterset IP", func() {
+		BeforeEach(func() {
+			t.serviceExport.Annotations[lhconstants.CNAMETargetAnnotation] = "registry.example.com"
+			t.serviceExport.Annotations[lhconstants.StaticClustersetIPAnnotation] = "243.1.0.1"
+		})
+
+		It("should update the ServiceExport status and not export it", func() {
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "InvalidCNAMETarget"))
+			t.awaitNoServiceImport(t.brokerServiceImportClient)
+		})
+	})
+
+	When("the number of ready endpoints crosses the minimum endpoints", func() {
+		BeforeEach(func() {
+			t.service.Spec.ClusterIP = corev1.ClusterIPNone
+			t.serviceExport.Annotations[lhconstants.MinEndpointsAnnotation] = "3"
+		})
+
+		It("should update the ServiceExport status accordingly", func() {
+			t.awaitHeadlessServiceImport()
+			t.awaitEndpointSlice()
+
+			By("Awaiting the InsufficientEndpoints status with 2 ready endpoints")
+
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "InsufficientEndpoints"))
+
+			By("Adding a ready endpoint")
+
+			t.endpoints.Subsets[0].Addresses = append(t.endpoints.Subsets[0].Addresses, corev1.EndpointAddress{IP: "192.168.5.4"})
+			t.updateEndpoints()
+
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionTrue, ""))
+
+			By("Removing the ready endpoint")
+
+			t.endpoints.Subsets[0].Addresses = t.endpoints.Subsets[0].Addresses[:2]
+			t.updateEndpoints()
+
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "InsufficientEndpoints"))
+
+			By("Lowering the minimum endpoints")
+
+			t.setServiceExportAnnotation(lhconstants.MinEndpointsAnnotation, "2")
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionTrue, ""))
+		})
+	})
+
+	When("a ServiceExport excludes endpoints", func() {
+		BeforeEach(func() {
+			t.service.Spec.ClusterIP = corev1.ClusterIPNone
+		})
+
+		It("should not sync the excluded endpoints", func() {
+			t.awaitHeadlessServiceImport()
+			t.awaitEndpointSlice()
+
+			By("Excluding an endpoint IP")
+
+			t.setServiceExportAnnotation(lhconstants.ExcludedIPsAnnotation, "192.168.5.1")
+			t.awaitUpdatedEndpointSlice([]string{"192.168.5.2", "10.253.6.1"})
+
+			By("Excluding a CIDR")
+
+			t.setServiceExportAnnotation(lhconstants.ExcludedIPsAnnotation, "10.253.0.0/16")
+			t.awaitUpdatedEndpointSlice([]string{"192.168.5.1", "192.168.5.2"})
+
+			By("Removing the exclusion")
+
+			t.setServiceExportAnnotation(lhconstants.ExcludedIPsAnnotation, "")
+			t.awaitUpdatedEndpointSlice([]string{"192.168.5.1", "192.168.5.2", "10.253.6.1"})
+		})
+	})
+
+	When("a ServiceExport excludes pods by label", func() {
+		BeforeEach(func() {
+			t.service.Spec.ClusterIP = corev1.ClusterIPNone
+			t.serviceExport.Annotations[lhconstants.ExcludedPodSelectorAnnotation] = "role=maintenance"
+
+			test.CreateResource(t.cluster1.localDynClient.Resource(corev1.SchemeGroupVersion.WithResource("pods")).
+				Namespace(t.service.Namespace), &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "one",
+					Namespace: t.service.Namespace,
+					Labels:    map[string]string{"role": "maintenance"},
+				},
+			})
+		})
+
+		It("should not sync the endpoints of the matching pods", func() {
+			t.awaitHeadlessServiceImport()
+			t.awaitUpdatedEndpointSlice([]string{"192.168.5.2", "10.253.6.1"})
+		})
+	})
+
+	When("a ServiceExport declares a near-future activation time", func() {
+		var activation time.Time
+
+		BeforeEach(func() {
+			// RFC 3339 has a granularity of a second so the activation is between 2 and 3 seconds from now.
+			activation = time.Now().Add(3 * time.Second).Truncate(time.Second)
+			t.serviceExport.Annotations[lhconstants.ActivationTimeAnnotation] = activation.Format(time.RFC3339)
+		})
+
+		It("should only export the Service once the time has passed", func() {
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "ScheduledActivation"))
+
+			Consistently(func() error {
+				_, err := t.brokerServiceImportClient.Get(context.TODO(), t.service.Name+"-"+t.service.Namespace+"-"+clusterID1,
+					metav1.GetOptions{})
+				return err
+			}, time.Until(activation)-200*time.Millisecond).ShouldNot(Succeed())
+
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+			Expect(time.Now()).ToNot(BeTemporally("<", activation))
+		})
+	})
+
+	When("a ServiceExport's activation time has already passed", func() {
+		BeforeEach(func() {
+			t.serviceExport.Annotations[lhconstants.ActivationTimeAnnotation] = time.Now().Add(-time.Hour).Format(time.RFC3339)
+		})
+
+		It("should export the Service", func() {
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+		})
+	})
+
+	When("the activation time of a scheduled ServiceExport is removed", func() {
+		BeforeEach(func() {
+			t.serviceExport.Annotations[lhconstants.ActivationTimeAnnotation] = time.Now().Add(time.Hour).Format(time.RFC3339)
+		})
+
+		It("should export the Service", func() {
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "ScheduledActivation"))
+
+			t.setServiceExportAnnotation(lhconstants.ActivationTimeAnnotation, "")
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+		})
+	})
+})
+
+// newRemoteServiceImport returns a local ServiceImport of the given service as if imported from cluster2 with the given
+// annotation.
+func (t *testDriver) newRemoteServiceImport(name, namespace, annotation, value string) *mcsv1a1.ServiceImport {
+	return &mcsv1a1.ServiceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name + "-" + namespace + "-" + clusterID2,
+			Annotations: map[string]string{
+				lhconstants.OriginName:      name,
+				lhconstants.OriginNamespace: namespace,
+				annotation:                  value,
+			},
+			Labels: map[string]string{
+				lhconstants.LighthouseLabelSourceCluster: clusterID2,
+			},
+		},
+		Spec: mcsv1a1.ServiceImportSpec{
+			Type: mcsv1a1.ClusterSetIP,
+			IPs:  []string{"10.253.1.1"},
+		},
+	}
+}
@@ -0,0 +1,117 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/submariner-io/admiral/pkg/log"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+// getServiceExportExpiry returns the time at which the given ServiceExport expires, based on its creation time plus the
+// TTL requested via the ServiceExport annotation. The zero time is returned if no valid TTL is set.
+func getServiceExportExpiry(svcExport *mcsv1a1.ServiceExport) time.Time {
+	ttl, ok := svcExport.GetAnnotations()[lhconstants.ExportTTLAnnotation]
+	if !ok {
+		return time.Time{}
+	}
+
+	d, err := time.ParseDuration(ttl)
+	if err != nil || d <= 0 {
+		klog.Warningf("Ignoring invalid TTL %q for ServiceExport %s/%s: it must be a positive duration", ttl,
+			svcExport.Namespace, svcExport.Name)
+		return time.Time{}
+	}
+
+	return svcExport.CreationTimestamp.Add(d)
+}
+
+// scheduledExpiry identifies the ServiceExport, and its expiry time, for which a timer was scheduled.
+type scheduledExpiry struct {
+	uid    types.UID
+	expiry time.Time
+}
+
+func (s scheduledExpiry) equals(other scheduledExpiry) bool {
+	return s.uid == other.uid && s.expiry.Equal(other.expiry)
+}
+
+// checkServiceExportExpiry returns true if the given ServiceExport's TTL has elapsed, in which case it's deleted, along with
+// its ServiceImport via the regular unexport path. If the TTL hasn't elapsed yet, a timer is scheduled to delete it on expiry.
+// If the TTL is changed or removed, a previously scheduled timer no longer deletes it.
+func (a *Controller) checkServiceExportExpiry(svcExport *mcsv1a1.ServiceExport) bool {
+	key := svcExport.Namespace + "/" + svcExport.Name
+
+	expiry := getServiceExportExpiry(svcExport)
+	if expiry.IsZero() {
+		a.exportExpiryScheduled.Delete(key)
+		return false
+	}
+
+	remaining := time.Until(expiry)
+	if remaining <= 0 {
+		a.expireServiceExport(svcExport.Name, svcExport.Namespace, svcExport.UID)
+		return true
+	}
+
+	scheduled := scheduledExpiry{uid: svcExport.UID, expiry: expiry}
+
+	if existing, ok := a.exportExpiryScheduled.Load(key); ok && existing.(scheduledExpiry).equals(scheduled) {
+		return false
+	}
+
+	a.exportExpiryScheduled.Store(key, scheduled)
+
+	klog.V(log.DEBUG).Infof("ServiceExport %s/%s expires in %v", svcExport.Namespace, svcExport.Name, remaining)
+
+	time.AfterFunc(remaining, func() {
+		select {
+		case <-a.stopCh:
+		default:
+			if current, ok := a.exportExpiryScheduled.Load(key); ok && current.(scheduledExpiry).equals(scheduled) {
+				a.expireServiceExport(svcExport.Name, svcExport.Namespace, svcExport.UID)
+			}
+		}
+	})
+
+	return false
+}
+
+// expireServiceExport deletes the ServiceExport with the given UID. The UID precondition ensures a ServiceExport that was
+// re-created with the same name in the meantime isn't deleted.
+func (a *Controller) expireServiceExport(name, namespace string, uid types.UID) {
+	err := a.serviceExportClient.Namespace(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &uid},
+	})
+
+	switch {
+	case err == nil:
+		klog.Infof("The TTL for ServiceExport %s/%s has elapsed - deleted it", namespace, name)
+	case apierrors.IsNotFound(err) || apierrors.IsConflict(err):
+	default:
+		klog.Errorf("Error deleting expired ServiceExport %s/%s: %v", namespace, name, err)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("ServiceExport TTL", func() {
+	var (
+		t   *testDriver
+		age time.Duration
+	)
+
+	BeforeEach(func() {
+		t = newTestDiver()
+		age = 0
+
+		t.serviceExport.Annotations = map[string]string{lhconstants.ExportTTLAnnotation: "3s"}
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+		t.createService()
+
+		t.serviceExport.CreationTimestamp = metav1.NewTime(time.Now().Add(-age))
+		t.createServiceExport()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("a ServiceExport declares a TTL", func() {
+		It("should export the Service and automatically unexport it when the TTL elapses", func() {
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+			t.awaitServiceUnexported()
+			test.AwaitNoResource(t.cluster1.localServiceExportClient, t.serviceExport.Name)
+		})
+	})
+
+	When("the TTL of an exported ServiceExport is shortened", func() {
+		BeforeEach(func() {
+			t.serviceExport.Annotations[lhconstants.ExportTTLAnnotation] = "1h"
+		})
+
+		It("should unexport the Service when the new TTL elapses", func() {
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			t.setServiceExportAnnotation(lhconstants.ExportTTLAnnotation, "3s")
+			t.awaitServiceUnexported()
+			test.AwaitNoResource(t.cluster1.localServiceExportClient, t.serviceExport.Name)
+		})
+	})
+
+	When("the TTL of an exported ServiceExport is removed", func() {
+		It("should not unexport the Service", func() {
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			t.setServiceExportAnnotation(lhconstants.ExportTTLAnnotation, "")
+
+			Consistently(func() error {
+				_, err := t.cluster1.localServiceExportClient.Get(context.TODO(), t.serviceExport.Name, metav1.GetOptions{})
+				return err
+			}, 4*time.Second).Should(Succeed())
+		})
+	})
+
+	When("a ServiceExport's TTL has already elapsed", func() {
+		BeforeEach(func() {
+			age = time.Hour
+		})
+
+		It("should delete the ServiceExport without exporting the Service", func() {
+			test.AwaitNoResource(t.cluster1.localServiceExportClient, t.serviceExport.Name)
+			t.awaitNoServiceImport(t.brokerServiceImportClient)
+		})
+	})
+
+	When("a ServiceExport declares an invalid TTL", func() {
+		BeforeEach(func() {
+			t.serviceExport.Annotations[lhconstants.ExportTTLAnnotation] = "bogus"
+		})
+
+		It("should export the Service and not unexport it", func() {
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+			Consistently(func() error {
+				_, err := t.cluster1.localServiceExportClient.Get(context.TODO(), t.serviceExport.Name, metav1.GetOptions{})
+				return err
+			}, 500*time.Millisecond).Should(Succeed())
+		})
+	})
+})
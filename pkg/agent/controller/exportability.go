@@ -0,0 +1,79 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strconv"
+
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+// ValidateExportability returns whether the given Service would be exported by the controller with its default
+// configuration, ie with all port protocols allowed. If not, the returned reason is the one the controller reports in
+// the ServiceExport's Valid condition.
+func ValidateExportability(service *corev1.Service) (bool, string) {
+	return validateExportability(service, nil)
+}
+
+func validateExportability(service *corev1.Service, allowedProtocols map[corev1.Protocol]bool) (bool, string) {
+	if isOptedOutOfExport(service) {
+		return false, serviceNotExportable
+	}
+
+	svcType, ok := getServiceImportType(service)
+	if !ok {
+		return false, invalidServiceType
+	}
+
+	// A Service with no ports is valid but, if it has ports, at least one must be exportable.
+	if svcType != mcsv1a1.ClusterSetIP || len(service.Spec.Ports) == 0 {
+		return true, ""
+	}
+
+	for i := range service.Spec.Ports {
+		if isProtocolAllowed(allowedProtocols, service.Spec.Ports[i].Protocol) {
+			return true, ""
+		}
+	}
+
+	return false, noExportablePorts
+}
+
+// isOptedOutOfExport returns true if the Service's exportable annotation is set to false, allowing admins to prevent
+// sensitive Services from being exported.
+func isOptedOutOfExport(service *corev1.Service) bool {
+	exportable, err := strconv.ParseBool(service.GetAnnotations()[lhconstants.ExportableAnnotation])
+	return err == nil && !exportable
+}
+
+func (a *Controller) exportabilityMessage(service *corev1.Service, reason string) string {
+	switch reason {
+	case invalidServiceType:
+		return fmt.Sprintf("Service of type %v not supported", service.Spec.Type)
+	case serviceNotExportable:
+		return fmt.Sprintf("The Service is annotated with %s=false", lhconstants.ExportableAnnotation)
+	case noExportablePorts:
+		return fmt.Sprintf("None of the Service's port protocols are allowed to be exported %v", a.allowedProtocolList())
+	}
+
+	return reason
+}
@@ -0,0 +1,126 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/lighthouse/pkg/agent/controller"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("ValidateExportability", func() {
+	var service *corev1.Service
+
+	BeforeEach(func() {
+		service = &corev1.Service{
+			Spec: corev1.ServiceSpec{
+				Type:      corev1.ServiceTypeClusterIP,
+				ClusterIP: "10.253.9.1",
+				Ports:     []corev1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80}},
+			},
+		}
+	})
+
+	verifyExportable := func() {
+		ok, reason := controller.ValidateExportability(service)
+		Expect(ok).To(BeTrue())
+		Expect(reason).To(BeEmpty())
+	}
+
+	verifyNotExportable := func(expReason string) {
+		ok, reason := controller.ValidateExportability(service)
+		Expect(ok).To(BeFalse())
+		Expect(reason).To(Equal(expReason))
+	}
+
+	When("the Service is of type ClusterIP", func() {
+		It("should be exportable", func() {
+			verifyExportable()
+		})
+	})
+
+	When("the Service type is not specified", func() {
+		It("should be exportable", func() {
+			service.Spec.Type = ""
+			verifyExportable()
+		})
+	})
+
+	When("the Service is headless", func() {
+		It("should be exportable", func() {
+			service.Spec.ClusterIP = corev1.ClusterIPNone
+			verifyExportable()
+		})
+	})
+
+	When("the Service is of type ExternalName", func() {
+		It("should be exportable", func() {
+			service.Spec.Type = corev1.ServiceTypeExternalName
+			service.Spec.ClusterIP = ""
+			service.Spec.ExternalName = "db.example.com"
+			verifyExportable()
+		})
+	})
+
+	When("the Service has no ports", func() {
+		It("should be exportable", func() {
+			service.Spec.Ports = nil
+			verifyExportable()
+		})
+	})
+
+	When("the Service has ports with mixed protocols", func() {
+		It("should be exportable", func() {
+			service.Spec.Ports = append(service.Spec.Ports,
+				corev1.ServicePort{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53},
+				corev1.ServicePort{Name: "sctp", Protocol: corev1.ProtocolSCTP, Port: 9999})
+			verifyExportable()
+		})
+	})
+
+	When("the Service is of type NodePort", func() {
+		It("should not be exportable", func() {
+			service.Spec.Type = corev1.ServiceTypeNodePort
+			verifyNotExportable("UnsupportedServiceType")
+		})
+	})
+
+	When("the Service is annotated as not exportable", func() {
+		It("should not be exportable", func() {
+			service.Annotations = map[string]string{lhconstants.ExportableAnnotation: "false"}
+			verifyNotExportable("ServiceNotExportable")
+		})
+	})
+
+	When("the Service is annotated as exportable", func() {
+		It("should be exportable", func() {
+			service.Annotations = map[string]string{lhconstants.ExportableAnnotation: "true"}
+			verifyExportable()
+		})
+	})
+
+	When("the Service is of type LoadBalancer", func() {
+		It("should not be exportable", func() {
+			service.Spec.Type = corev1.ServiceTypeLoadBalancer
+			verifyNotExportable("UnsupportedServiceType")
+		})
+	})
+})
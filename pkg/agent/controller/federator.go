@@ -0,0 +1,77 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/submariner-io/admiral/pkg/federate"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+// localServiceImportFederator wraps the local ServiceImport Federator to report a failure to write the local
+// ServiceImport in the status of the originating ServiceExport. Updates to an existing ServiceImport are logged as a diff.
+type localServiceImportFederator struct {
+	federate.Federator
+	controller *Controller
+}
+
+func (f *localServiceImportFederator) Distribute(obj runtime.Object) error {
+	objMeta, _ := meta.Accessor(obj)
+
+	_, span := f.controller.startSpan(context.Background(), "write local ServiceImport",
+		objMeta.GetAnnotations()[lhconstants.OriginName], objMeta.GetAnnotations()[lhconstants.OriginNamespace])
+
+	existing := f.getExistingServiceImport(obj)
+
+	err := f.Federator.Distribute(obj)
+	endSpan(span, err)
+
+	if err == nil && existing != nil {
+		logServiceImportDiff(existing, obj.(*mcsv1a1.ServiceImport))
+	}
+
+	if err != nil {
+		f.controller.updateExportedServiceStatus(objMeta.GetAnnotations()[lhconstants.OriginName],
+			objMeta.GetAnnotations()[lhconstants.OriginNamespace], corev1.ConditionFalse, localSyncFailed,
+			fmt.Sprintf("Failed to sync the local ServiceImport: %v", err))
+	}
+
+	return err // nolint:wrapcheck // Let the caller wrap it.
+}
+
+func (f *localServiceImportFederator) getExistingServiceImport(obj runtime.Object) *mcsv1a1.ServiceImport {
+	serviceImport, ok := obj.(*mcsv1a1.ServiceImport)
+	if !ok {
+		return nil
+	}
+
+	existing, found, err := f.controller.serviceImportSyncer.GetLocalResource(serviceImport.Name, f.controller.namespace,
+		&mcsv1a1.ServiceImport{})
+	if err != nil || !found {
+		return nil
+	}
+
+	return existing.(*mcsv1a1.ServiceImport)
+}
@@ -0,0 +1,102 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const (
+	awaitingEndpoints = "AwaitingEndpoints"
+	noReadyEndpoints  = "NoReadyEndpoints"
+)
+
+// isHeadlessForced returns true if the ServiceExport's force-headless annotation requests that the Service be exported
+// as headless regardless of its ClusterIP.
+func isHeadlessForced(svcExport *mcsv1a1.ServiceExport) bool {
+	forced, err := strconv.ParseBool(svcExport.GetAnnotations()[lhconstants.ForceHeadlessAnnotation])
+	return err == nil && forced
+}
+
+// getExportedServiceImportType returns the ServiceImport type for the exported Service, taking into account whether a
+// headless import is forced. An ExternalName Service has no endpoints so it can't be forced headless.
+func getExportedServiceImportType(svcExport *mcsv1a1.ServiceExport, svc *corev1.Service) (mcsv1a1.ServiceImportType, bool) {
+	svcType, ok := getServiceImportType(svc)
+	if ok && svcType == mcsv1a1.ClusterSetIP && svc.Spec.Type != corev1.ServiceTypeExternalName && isHeadlessForced(svcExport) {
+		return mcsv1a1.Headless, true
+	}
+
+	return svcType, ok
+}
+
+// checkServiceEndpoints verifies that a Service forced to be exported as headless has endpoints, as its pod IPs are
+// exported rather than its ClusterIP. If not, a non-empty reason and message are returned. Endpoints that exist but
+// aren't ready, and aren't published regardless via PublishNotReadyAddresses, are reported with a distinct reason to
+// distinguish a readiness problem from a startup race.
+func (a *Controller) checkServiceEndpoints(svc *corev1.Service) (reason, msg string, err error) {
+	obj, err := a.serviceImportSyncer.GetLocalClient().Resource(corev1.SchemeGroupVersion.WithResource("endpoints")).
+		Namespace(svc.Namespace).Get(context.TODO(), svc.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return awaitingEndpoints, fmt.Sprintf("The Service %s/%s is forced to be exported as headless but has no Endpoints",
+			svc.Namespace, svc.Name), nil
+	}
+
+	if err != nil {
+		return "", "", errors.Wrapf(err, "error retrieving Endpoints %s/%s", svc.Namespace, svc.Name)
+	}
+
+	endpoints := &corev1.Endpoints{}
+
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, endpoints)
+	if err != nil {
+		return "", "", errors.Wrap(err, "error converting Endpoints")
+	}
+
+	hasNotReady := false
+
+	for i := range endpoints.Subsets {
+		if len(endpoints.Subsets[i].Addresses) > 0 {
+			return "", "", nil
+		}
+
+		hasNotReady = hasNotReady || len(endpoints.Subsets[i].NotReadyAddresses) > 0
+	}
+
+	if hasNotReady {
+		if svc.Spec.PublishNotReadyAddresses {
+			return "", "", nil
+		}
+
+		return noReadyEndpoints, fmt.Sprintf("The Service %s/%s is forced to be exported as headless but none of its endpoints "+
+			"are ready", svc.Namespace, svc.Name), nil
+	}
+
+	return awaitingEndpoints, fmt.Sprintf("The Service %s/%s is forced to be exported as headless but its Endpoints have no addresses",
+		svc.Namespace, svc.Name), nil
+}
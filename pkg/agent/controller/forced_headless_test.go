@@ -0,0 +1,135 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+var _ = Describe("Forced headless export", func() {
+	var t *testDriver
+
+	BeforeEach(func() {
+		t = newTestDiver()
+		t.serviceExport.Annotations = map[string]string{lhconstants.ForceHeadlessAnnotation: "true"}
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+		t.createService()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("a ServiceExport forces a ClusterIP Service to be headless", func() {
+		It("should sync a headless ServiceImport and an EndpointSlice with the pod IPs", func() {
+			t.createEndpoints()
+			t.createServiceExport()
+
+			t.awaitHeadlessServiceImport()
+			t.awaitEndpointSlice()
+		})
+	})
+
+	When("the forced headless Service has no Endpoints", func() {
+		It("should not export it until the Endpoints are created", func() {
+			t.createServiceExport()
+
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "AwaitingEndpoints"))
+			t.awaitNoServiceImport(t.brokerServiceImportClient)
+
+			t.createEndpoints()
+			t.awaitHeadlessServiceImport()
+			t.awaitEndpointSlice()
+		})
+	})
+
+	When("the forced headless Service's endpoints are all not ready", func() {
+		BeforeEach(func() {
+			t.endpoints.Subsets[0].NotReadyAddresses = append(t.endpoints.Subsets[0].NotReadyAddresses,
+				t.endpoints.Subsets[0].Addresses...)
+			t.endpoints.Subsets[0].Addresses = nil
+		})
+
+		It("should not export it until an endpoint is ready", func() {
+			t.createEndpoints()
+			t.createServiceExport()
+
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "NoReadyEndpoints"))
+			t.awaitNoServiceImport(t.brokerServiceImportClient)
+
+			t.endpoints.Subsets[0].Addresses = t.endpoints.Subsets[0].NotReadyAddresses[1:]
+			t.endpoints.Subsets[0].NotReadyAddresses = t.endpoints.Subsets[0].NotReadyAddresses[:1]
+			t.updateEndpoints()
+
+			t.awaitHeadlessServiceImport()
+		})
+	})
+
+	When("the forced headless Service's endpoints are all not ready and PublishNotReadyAddresses is set", func() {
+		BeforeEach(func() {
+			t.service.Spec.PublishNotReadyAddresses = true
+			t.endpoints.Subsets[0].NotReadyAddresses = append(t.endpoints.Subsets[0].NotReadyAddresses,
+				t.endpoints.Subsets[0].Addresses...)
+			t.endpoints.Subsets[0].Addresses = nil
+		})
+
+		It("should sync a headless ServiceImport", func() {
+			t.createEndpoints()
+			t.createServiceExport()
+
+			t.awaitHeadlessServiceImport()
+		})
+	})
+
+	When("the force-headless annotation is false", func() {
+		BeforeEach(func() {
+			t.serviceExport.Annotations[lhconstants.ForceHeadlessAnnotation] = "false"
+		})
+
+		It("should sync a ClusterSetIP ServiceImport", func() {
+			t.createEndpoints()
+			t.createServiceExport()
+
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+		})
+
+		Context("and is subsequently updated to true", func() {
+			It("should sync a headless ServiceImport and an EndpointSlice with the pod IPs", func() {
+				t.createEndpoints()
+				t.createServiceExport()
+				t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+				t.setServiceExportAnnotation(lhconstants.ForceHeadlessAnnotation, "true")
+				t.awaitServiceImportType(mcsv1a1.Headless)
+				t.awaitEndpointSlice()
+
+				By("Updating the annotation back to false")
+
+				t.setServiceExportAnnotation(lhconstants.ForceHeadlessAnnotation, "false")
+				t.awaitServiceImportType(mcsv1a1.ClusterSetIP)
+			})
+		})
+	})
+})
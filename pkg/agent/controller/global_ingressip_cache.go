@@ -66,9 +66,23 @@ func (c *globalIngressIPCache) start(stopCh <-chan struct{}) error {
 }
 
 func (c *globalIngressIPCache) onCreateOrUpdate(obj *unstructured.Unstructured) {
+	var prevIP string
+
 	c.applyToCache(obj, func(to *sync.Map, key string, obj *unstructured.Unstructured) {
+		if prev, found := to.Load(key); found {
+			prevIP, _, _ = unstructured.NestedString(prev.(*unstructured.Unstructured).Object, "status", "allocatedIP")
+		}
+
 		to.Store(key, obj)
 	})
+
+	target, _, _ := unstructured.NestedString(obj.Object, "spec", "target")
+	newIP, _, _ := unstructured.NestedString(obj.Object, "status", "allocatedIP")
+
+	if target == ClusterIPService && prevIP != "" && newIP != "" && prevIP != newIP && c.onServiceIPChanged != nil {
+		name, _, _ := unstructured.NestedString(obj.Object, "spec", "serviceRef", "name")
+		c.onServiceIPChanged(name, obj.GetNamespace(), prevIP, newIP)
+	}
 }
 
 func (c *globalIngressIPCache) onDelete(obj *unstructured.Unstructured) {
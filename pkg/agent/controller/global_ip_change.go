@@ -0,0 +1,82 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/submariner-io/admiral/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const globalIPChanged = "GlobalIPChanged"
+
+// onServiceGlobalIPChanged handles the re-allocation of an exported Service's global IP. Nothing else triggers a
+// re-sync of the ServiceImport in that case so its ServiceExport is queued for resync, otherwise clients would be routed
+// to the stale IP until the next resync.
+func (a *Controller) onServiceGlobalIPChanged(name, namespace, oldIP, newIP string) {
+	klog.Warningf("The global IP for Service (%s/%s) changed from %q to %q", namespace, name, oldIP, newIP)
+
+	svcExport, err := a.getServiceExport(name, namespace)
+	if apierrors.IsNotFound(err) {
+		klog.V(log.DEBUG).Infof("Service (%s/%s) is not exported - ignoring the global IP change", namespace, name)
+		return
+	} else if err != nil {
+		klog.Errorf("Error retrieving ServiceExport (%s/%s): %v", namespace, name, err)
+		return
+	}
+
+	a.recordServiceExportEvent(svcExport, corev1.EventTypeWarning, globalIPChanged,
+		fmt.Sprintf("The global IP for the exported Service changed from %q to %q", oldIP, newIP))
+
+	a.queueServiceExportForResync(svcExport)
+}
+
+func (a *Controller) recordServiceExportEvent(svcExport *mcsv1a1.ServiceExport, eventType, reason, msg string) {
+	now := metav1.Now()
+
+	_, err := a.kubeClientSet.CoreV1().Events(svcExport.Namespace).Create(context.TODO(), &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%v.%x", svcExport.Name, now.UnixNano()),
+			Namespace: svcExport.Namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:       "ServiceExport",
+			APIVersion: mcsv1a1.GroupVersion.String(),
+			Name:       svcExport.Name,
+			Namespace:  svcExport.Namespace,
+			UID:        svcExport.UID,
+		},
+		Reason:         reason,
+		Message:        msg,
+		Type:           eventType,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+		Source:         corev1.EventSource{Component: "lighthouse-agent"},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		klog.Errorf("Error recording %q event for ServiceExport (%s/%s): %v", reason, svcExport.Namespace, svcExport.Name, err)
+	}
+}
@@ -26,6 +26,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
 )
 
 var _ = Describe("Globalnet enabled", func() {
@@ -63,6 +64,39 @@ var _ = Describe("Globalnet enabled", func() {
 				It("should sync a ServiceImport with the global IP", func() {
 					t.awaitServiceExported(globalIP1)
 				})
+
+				Context("and the global IP subsequently changes", func() {
+					It("should update the ServiceImport and record a GlobalIPChanged event", func() {
+						t.awaitServiceExported(globalIP1)
+
+						setIngressAllocatedIP(ingressIP, globalIP2)
+						test.UpdateResource(t.cluster1.localIngressIPClient, ingressIP)
+
+						t.cluster1.awaitUpdatedServiceImport(t.service, globalIP2)
+						t.awaitUpdatedServiceImport(globalIP2)
+						t.awaitGlobalIPChangedEvent()
+					})
+				})
+
+				Context("and the Service subsequently becomes headless", func() {
+					BeforeEach(func() {
+						t.createEndpointIngressIPs()
+					})
+
+					JustBeforeEach(func() {
+						t.createEndpoints()
+					})
+
+					It("should recreate the ServiceImport as headless and sync an EndpointSlice with the endpoint global IPs", func() {
+						t.awaitServiceExported(globalIP1)
+
+						t.service.Spec.ClusterIP = corev1.ClusterIPNone
+						t.updateService()
+						t.awaitServiceImportType(mcsv1a1.Headless)
+						t.awaitHeadlessServiceImport()
+						t.awaitEndpointSlice()
+					})
+				})
 			})
 		})
 
@@ -135,6 +169,22 @@ var _ = Describe("Globalnet enabled", func() {
 			})
 		})
 
+		Context("and it's selectorless with manually managed Endpoints", func() {
+			BeforeEach(func() {
+				t.service.Spec.Selector = nil
+				t.endpoints.Subsets[0].Addresses[1].TargetRef = nil
+				t.endpoints.Subsets[0].NotReadyAddresses = nil
+
+				t.createGlobalIngressIP(t.newHeadlessGlobalIngressIP("one", globalIP1))
+			})
+
+			It("should only export the addresses that reference a pod", func() {
+				t.awaitHeadlessServiceImport()
+				test.AwaitResource(t.cluster2.localEndpointSliceClient, t.endpoints.Name+"-"+clusterID1)
+				t.awaitUpdatedEndpointSlice([]string{globalIP1})
+			})
+		})
+
 		Context("and it initially does not have a global IP for all endpoint addresses", func() {
 			It("should eventually sync a ServiceImport and EndpointSlice with the global IPs", func() {
 				time.Sleep(time.Millisecond * 300)
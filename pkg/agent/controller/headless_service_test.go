@@ -19,8 +19,18 @@ limitations under the License.
 package controller_test
 
 import (
+	"context"
+	"time"
+
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
 	corev1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
 )
 
 var _ = Describe("Headless service syncing", func() {
@@ -63,6 +73,78 @@ var _ = Describe("Headless service syncing", func() {
 		})
 	})
 
+	When("a ServiceExport is created for a Service that sets both ClusterIP and ClusterIPs to None", func() {
+		BeforeEach(func() {
+			t.service.Spec.ClusterIPs = []string{corev1.ClusterIPNone}
+		})
+
+		It("should sync a headless ServiceImport", func() {
+			t.createEndpoints()
+			t.createServiceExport()
+			t.awaitHeadlessServiceImport()
+		})
+	})
+
+	When("a ServiceExport is created for a Service with an empty ClusterIP and ClusterIPs set to None", func() {
+		BeforeEach(func() {
+			t.service.Spec.ClusterIP = ""
+			t.service.Spec.ClusterIPs = []string{corev1.ClusterIPNone}
+		})
+
+		It("should sync a headless ServiceImport", func() {
+			t.createEndpoints()
+			t.createServiceExport()
+			t.awaitHeadlessServiceImport()
+		})
+	})
+
+	When("the Endpoints have FQDN-only addresses", func() {
+		BeforeEach(func() {
+			t.endpoints.Subsets[0].Addresses = append(t.endpoints.Subsets[0].Addresses,
+				corev1.EndpointAddress{Hostname: "ext1.example.com"}, corev1.EndpointAddress{Hostname: "ext2.example.com"})
+			t.endpoints.Subsets[0].NotReadyAddresses = append(t.endpoints.Subsets[0].NotReadyAddresses,
+				corev1.EndpointAddress{Hostname: "ext3.example.com"})
+		})
+
+		It("should record the ready FQDNs on the EndpointSlice alongside the IP endpoints", func() {
+			t.createEndpoints()
+			t.createServiceExport()
+
+			t.awaitHeadlessServiceImport()
+			t.awaitEndpointSlice()
+
+			Expect(t.awaitBrokerEndpointSlice().Annotations).To(HaveKeyWithValue(lhconstants.FQDNEndpointsAnnotation,
+				"ext1.example.com,ext2.example.com"))
+			Expect(t.cluster2.awaitEndpointSlice(t).Annotations).To(HaveKeyWithValue(lhconstants.FQDNEndpointsAnnotation,
+				"ext1.example.com,ext2.example.com"))
+		})
+	})
+
+	When("the Service is selectorless with manually managed Endpoints", func() {
+		BeforeEach(func() {
+			t.service.Spec.Selector = nil
+			t.endpoints.Labels = nil
+			t.endpoints.Subsets[0].Addresses = []corev1.EndpointAddress{{IP: "10.20.0.1"}, {IP: "10.20.0.2"}}
+			t.endpoints.Subsets[0].NotReadyAddresses = nil
+		})
+
+		It("should sync a ServiceImport and EndpointSlice with the Endpoints IPs", func() {
+			t.createEndpoints()
+			t.createServiceExport()
+
+			t.awaitHeadlessServiceImport()
+			test.AwaitResource(t.cluster2.localEndpointSliceClient, t.endpoints.Name+"-"+clusterID1)
+			t.awaitUpdatedEndpointSlice([]string{"10.20.0.1", "10.20.0.2"})
+
+			endpointSlice := &discovery.EndpointSlice{}
+			Expect(scheme.Scheme.Convert(test.AwaitResource(t.brokerEndpointSliceClient, t.endpoints.Name+"-"+clusterID1),
+				endpointSlice, nil)).To(Succeed())
+			Expect(endpointSlice.Endpoints[0].Hostname).To(BeNil())
+			Expect(endpointSlice.Endpoints[0].NodeName).To(BeNil())
+			Expect(*endpointSlice.Endpoints[0].Conditions.Ready).To(BeTrue())
+		})
+	})
+
 	When("the Endpoints for a service are updated", func() {
 		It("should update the ServiceImport and EndpointSlice", func() {
 			t.createEndpoints()
@@ -78,6 +160,171 @@ var _ = Describe("Headless service syncing", func() {
 		})
 	})
 
+	When("the Endpoints for a service are deleted", func() {
+		It("should delete the EndpointSlice and re-create it when the Endpoints are re-created", func() {
+			t.createEndpoints()
+			t.createServiceExport()
+
+			t.awaitHeadlessServiceImport()
+			t.awaitEndpointSlice()
+
+			t.deleteEndpoints()
+			t.awaitNoEndpointSlice(t.brokerEndpointSliceClient)
+			t.awaitNoEndpointSlice(t.cluster1.localEndpointSliceClient)
+			t.awaitNoEndpointSlice(t.cluster2.localEndpointSliceClient)
+
+			t.createEndpoints()
+			t.awaitEndpointSlice()
+		})
+	})
+
+	When("the Endpoints for a service are deleted and the Service's selector subsequently changes", func() {
+		It("should not re-create the EndpointSlice", func() {
+			t.createEndpoints()
+			t.createServiceExport()
+
+			t.awaitHeadlessServiceImport()
+			t.awaitEndpointSlice()
+
+			t.deleteEndpoints()
+			t.awaitNoEndpointSlice(t.brokerEndpointSliceClient)
+
+			t.service.Spec.Selector = map[string]string{"app": "other"}
+			t.updateService()
+
+			Consistently(func() bool {
+				_, err := t.brokerEndpointSliceClient.Get(context.TODO(), t.endpoints.Name+"-"+clusterID1, metav1.GetOptions{})
+				return apierrors.IsNotFound(err)
+			}, 300*time.Millisecond).Should(BeTrue())
+		})
+	})
+
+	When("an empty endpoints grace period is configured and the Endpoints are deleted", func() {
+		BeforeEach(func() {
+			t.cluster1.agentSpec.EmptyEndpointsGracePeriod = 500 * time.Millisecond
+		})
+
+		JustBeforeEach(func() {
+			t.createEndpoints()
+			t.createServiceExport()
+
+			t.awaitHeadlessServiceImport()
+			t.awaitEndpointSlice()
+
+			t.deleteEndpoints()
+		})
+
+		brokerEndpointSliceExists := func() bool {
+			_, err := t.brokerEndpointSliceClient.Get(context.TODO(), t.endpoints.Name+"-"+clusterID1, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				return false
+			}
+
+			Expect(err).To(Succeed())
+
+			return true
+		}
+
+		Context("and the Endpoints are re-created within it", func() {
+			It("should not delete the EndpointSlice", func() {
+				Consistently(brokerEndpointSliceExists, 300*time.Millisecond).Should(BeTrue())
+
+				t.createEndpoints()
+
+				Consistently(brokerEndpointSliceExists, 700*time.Millisecond).Should(BeTrue())
+				t.awaitEndpointSlice()
+			})
+		})
+
+		Context("and the Endpoints aren't re-created within it", func() {
+			It("should eventually delete the EndpointSlice", func() {
+				Consistently(brokerEndpointSliceExists, 300*time.Millisecond).Should(BeTrue())
+				t.awaitNoEndpointSlice(t.brokerEndpointSliceClient)
+				t.awaitNoEndpointSlice(t.cluster2.localEndpointSliceClient)
+			})
+		})
+	})
+
+	When("an empty endpoints grace period is configured", func() {
+		var endpoints *corev1.Endpoints
+
+		BeforeEach(func() {
+			t.cluster1.agentSpec.EmptyEndpointsGracePeriod = 500 * time.Millisecond
+		})
+
+		JustBeforeEach(func() {
+			t.createEndpoints()
+			t.createServiceExport()
+
+			t.awaitHeadlessServiceImport()
+			t.awaitEndpointSlice()
+
+			endpoints = t.endpoints.DeepCopy()
+
+			t.endpoints.Subsets = nil
+			t.updateEndpoints()
+		})
+
+		brokerEndpointCount := func() int {
+			obj, err := t.brokerEndpointSliceClient.Get(context.TODO(), t.endpoints.Name+"-"+clusterID1, metav1.GetOptions{})
+			Expect(err).To(Succeed())
+
+			endpointSlice := &discovery.EndpointSlice{}
+			Expect(scheme.Scheme.Convert(obj, endpointSlice, nil)).To(Succeed())
+
+			return len(endpointSlice.Endpoints)
+		}
+
+		Context("and the endpoints are restored within it", func() {
+			It("should not publish the empty endpoints", func() {
+				Consistently(brokerEndpointCount, 300*time.Millisecond).Should(Equal(3))
+
+				t.endpoints = endpoints
+				t.updateEndpoints()
+
+				Consistently(brokerEndpointCount, 700*time.Millisecond).Should(Equal(3))
+			})
+		})
+
+		Context("and the endpoints aren't restored within it", func() {
+			It("should eventually publish the empty endpoints", func() {
+				Consistently(brokerEndpointCount, 300*time.Millisecond).Should(Equal(3))
+				Eventually(brokerEndpointCount, 2*time.Second).Should(Equal(0))
+			})
+		})
+	})
+
+	When("the selector of an exported headless Service changes", func() {
+		It("should update the EndpointSlice with the new endpoint set", func() {
+			t.createEndpoints()
+			t.createServiceExport()
+
+			t.awaitHeadlessServiceImport()
+			t.awaitEndpointSlice()
+
+			t.endpoints.Subsets[0].Addresses = []corev1.EndpointAddress{{IP: "192.168.5.3"}}
+			t.updateEndpoints()
+
+			t.service.Spec.Selector = map[string]string{"app": "other"}
+			t.updateService()
+
+			t.awaitUpdatedEndpointSlice(append(t.endpointIPs(), "10.253.6.1"))
+		})
+	})
+
+	When("a remote headless ServiceImport is deleted from the importing cluster", func() {
+		It("should delete the mirrored EndpointSlice", func() {
+			t.createEndpoints()
+			t.createServiceExport()
+			t.awaitHeadlessServiceImport()
+			t.awaitEndpointSlice()
+
+			Expect(t.cluster2.localServiceImportClient.Delete(context.TODO(), t.service.Name+"-"+t.service.Namespace+"-"+clusterID1,
+				metav1.DeleteOptions{})).To(Succeed())
+			t.awaitNoEndpointSlice(t.cluster2.localEndpointSliceClient)
+		})
+	})
+
 	When("a ServiceExport is deleted", func() {
 		It("should delete the ServiceImport and EndpointSlice", func() {
 			t.createEndpoints()
@@ -0,0 +1,47 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const invalidHealth = "InvalidHealth"
+
+// getServiceExportHealth returns the health of the service in this cluster as published via the ServiceExport annotation
+// by an external health check, if any, normalized to lower case. If it's neither healthy nor unhealthy, a non-empty reason
+// and message are returned.
+func getServiceExportHealth(svcExport *mcsv1a1.ServiceExport) (health, reason, msg string) {
+	value, ok := svcExport.GetAnnotations()[lhconstants.HealthAnnotation]
+	if !ok {
+		return "", "", ""
+	}
+
+	health = strings.ToLower(strings.TrimSpace(value))
+	if health != lhconstants.HealthyValue && health != lhconstants.UnhealthyValue {
+		return "", invalidHealth, fmt.Sprintf("The health %q is invalid: it must be %q or %q", value, lhconstants.HealthyValue,
+			lhconstants.UnhealthyValue)
+	}
+
+	return health, "", ""
+}
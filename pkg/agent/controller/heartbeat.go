@@ -0,0 +1,119 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+// runHeartbeat periodically stamps the current time on the ServiceImports exported by this cluster, if enabled, so
+// consumers in other clusters, eg the DNS plugin's TTL decay, can tell how recently this cluster refreshed them. The
+// ServiceImport of a stable service doesn't otherwise change.
+func (a *Controller) runHeartbeat(stopCh <-chan struct{}) {
+	if a.heartbeatPeriod <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(a.heartbeatPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			a.publishHeartbeat()
+		}
+	}
+}
+
+func (a *Controller) publishHeartbeat() {
+	list, err := a.serviceImportSyncer.ListLocalResources(&mcsv1a1.ServiceImport{})
+	if err != nil {
+		klog.Errorf("Error listing ServiceImports: %v", err)
+		return
+	}
+
+	heartbeat := time.Now().UTC().Format(time.RFC3339)
+
+	for _, obj := range list {
+		serviceImport := obj.(*mcsv1a1.ServiceImport)
+		if serviceImport.Namespace != a.namespace ||
+			serviceImport.Labels[lhconstants.LighthouseLabelSourceCluster] != a.clusterID {
+			continue
+		}
+
+		if err := a.updateHeartbeat(serviceImport.Name, heartbeat); err != nil {
+			klog.Errorf("Error publishing the heartbeat for ServiceImport %q: %v", serviceImport.Name, err)
+		}
+	}
+}
+
+func (a *Controller) updateHeartbeat(name, heartbeat string) error {
+	client := a.serviceImportSyncer.GetLocalClient().Resource(serviceImportGVR).Namespace(a.namespace)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error { // nolint:wrapcheck // Errors are wrapped below.
+		obj, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "error retrieving the ServiceImport")
+		}
+
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+
+		annotations[lhconstants.HeartbeatAnnotation] = heartbeat
+		obj.SetAnnotations(annotations)
+
+		_, err = client.Update(context.TODO(), obj, metav1.UpdateOptions{})
+
+		return errors.Wrap(err, "error updating the ServiceImport")
+	})
+}
+
+// stampHeartbeat retains the heartbeat of the existing ServiceImport, if any, on the given re-derived ServiceImport so
+// it isn't lost until the next heartbeat. A newly exported service's ServiceImport is stamped with the current time.
+func (a *Controller) stampHeartbeat(serviceImport *mcsv1a1.ServiceImport) {
+	if a.heartbeatPeriod <= 0 {
+		return
+	}
+
+	heartbeat := time.Now().UTC().Format(time.RFC3339)
+
+	existing, found, err := a.serviceImportSyncer.GetLocalResource(serviceImport.Name, a.namespace, &mcsv1a1.ServiceImport{})
+	if err == nil && found {
+		if h, ok := existing.(*mcsv1a1.ServiceImport).Annotations[lhconstants.HeartbeatAnnotation]; ok {
+			heartbeat = h
+		}
+	}
+
+	serviceImport.Annotations[lhconstants.HeartbeatAnnotation] = heartbeat
+}
@@ -0,0 +1,79 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+var _ = Describe("ServiceImport heartbeat", func() {
+	var t *testDriver
+
+	BeforeEach(func() {
+		t = newTestDiver()
+		t.cluster1.agentSpec.HeartbeatPeriod = 500 * time.Millisecond
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+		t.createService()
+		t.createServiceExport()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	getHeartbeat := func() time.Time {
+		serviceImport := t.cluster2.awaitServiceImport(t.service, mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP)
+
+		heartbeat, err := time.Parse(time.RFC3339, serviceImport.Annotations[lhconstants.HeartbeatAnnotation])
+		Expect(err).To(Succeed())
+
+		return heartbeat
+	}
+
+	When("enabled and a stable Service is exported", func() {
+		It("should periodically refresh the heartbeat on the ServiceImport in the other clusters", func() {
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			first := getHeartbeat()
+			Expect(first).To(BeTemporally("~", time.Now(), 10*time.Second))
+
+			Eventually(getHeartbeat, 5*time.Second).Should(BeTemporally(">", first))
+		})
+	})
+
+	When("not enabled and a Service is exported", func() {
+		BeforeEach(func() {
+			t.cluster1.agentSpec.HeartbeatPeriod = 0
+		})
+
+		It("should not stamp the heartbeat on the ServiceImport", func() {
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+			Expect(t.awaitBrokerServiceImport(mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP).Annotations).ToNot(
+				HaveKey(lhconstants.HeartbeatAnnotation))
+		})
+	})
+})
@@ -0,0 +1,64 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	"github.com/submariner-io/admiral/pkg/log"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+// logServiceImportDiff logs the IPs and ports added to and removed from a local ServiceImport, and a change of its type,
+// at debug level. Nothing is logged if none of them changed.
+func logServiceImportDiff(from, to *mcsv1a1.ServiceImport) {
+	addedIPs, removedIPs := diffStrings(from.Spec.IPs, to.Spec.IPs)
+	addedPorts, removedPorts := diffStrings(portStrings(from.Spec.Ports), portStrings(to.Spec.Ports))
+
+	if len(addedIPs) == 0 && len(removedIPs) == 0 && len(addedPorts) == 0 && len(removedPorts) == 0 &&
+		from.Spec.Type == to.Spec.Type {
+		return
+	}
+
+	typeChange := ""
+	if from.Spec.Type != to.Spec.Type {
+		typeChange = fmt.Sprintf(", type changed from %q to %q", from.Spec.Type, to.Spec.Type)
+	}
+
+	klog.V(log.DEBUG).Infof("ServiceImport %s/%s updated - added IPs: %v, removed IPs: %v, added ports: %v, removed ports: %v%s",
+		to.Namespace, to.Name, addedIPs, removedIPs, addedPorts, removedPorts, typeChange)
+}
+
+func diffStrings(from, to []string) (added, removed []string) {
+	fromSet := sets.NewString(from...)
+	toSet := sets.NewString(to...)
+
+	return toSet.Difference(fromSet).List(), fromSet.Difference(toSet).List()
+}
+
+func portStrings(ports []mcsv1a1.ServicePort) []string {
+	s := make([]string, len(ports))
+	for i := range ports {
+		s[i] = fmt.Sprintf("%s:%d/%s", ports[i].Name, ports[i].Port, ports[i].Protocol)
+	}
+
+	return s
+}
@@ -0,0 +1,69 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	validations "k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// LegacyImportNameScheme names a ServiceImport <name>-<namespace>-<cluster ID>. This is the default.
+	LegacyImportNameScheme = "legacy"
+
+	// HashedImportNameScheme names a ServiceImport <name>-<hash>, where the hash is derived from the name, namespace and
+	// cluster ID. This avoids overly long names while keeping a human-readable prefix.
+	HashedImportNameScheme = "hashed"
+
+	maxHashedNamePrefixLen = 40
+	hashedNameSuffixLen    = 16
+)
+
+func isValidImportNameScheme(scheme string) bool {
+	return scheme == "" || scheme == LegacyImportNameScheme || scheme == HashedImportNameScheme
+}
+
+// getObjectNameWithClusterID returns the name of the ServiceImport for the given Service according to the configured
+// naming scheme. If the legacy name would exceed the maximum length of a resource name, the hashed name is used instead.
+// Consumers must rely on the origin annotations and labels rather than the name to identify the Service.
+func (a *Controller) getObjectNameWithClusterID(name, namespace string) string {
+	if a.importNameScheme == HashedImportNameScheme {
+		return hashedObjectName(name, namespace, a.clusterID)
+	}
+
+	legacyName := name + "-" + namespace + "-" + a.clusterID
+	if len(legacyName) > validations.DNS1123SubdomainMaxLength {
+		return hashedObjectName(name, namespace, a.clusterID)
+	}
+
+	return legacyName
+}
+
+func hashedObjectName(name, namespace, clusterID string) string {
+	hash := sha256.Sum256([]byte(name + "/" + namespace + "/" + clusterID))
+
+	prefix := name
+	if len(prefix) > maxHashedNamePrefixLen {
+		prefix = prefix[:maxHashedNamePrefixLen]
+	}
+
+	return prefix + "-" + hex.EncodeToString(hash[:])[:hashedNameSuffixLen]
+}
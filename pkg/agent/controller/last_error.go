@@ -0,0 +1,147 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/log"
+	"github.com/submariner-io/admiral/pkg/resource"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+// ExportError is the machine-readable form of the last error encountered exporting a Service. The ServiceExportStatus
+// has no field for it so it's recorded as JSON in the LastErrorAnnotation on the ServiceExport.
+type ExportError struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Timestamp metav1.Time `json:"timestamp"`
+}
+
+// The condition reasons that represent export failures, as opposed to transient states such as awaiting sync.
+var exportErrorReasons = map[string]bool{
+	serviceRetrievalFailed:     true,
+	invalidServiceType:         true,
+	localSyncFailed:            true,
+	brokerSyncFailed:           true,
+	brokerPermissionDenied:     true,
+	noExportablePorts:          true,
+	serviceNotExportable:       true,
+	invalidWeight:              true,
+	invalidStaticIP:            true,
+	staticIPConflict:           true,
+	duplicateExport:            true,
+	exportQuotaExceeded:        true,
+	invalidActivationTime:      true,
+	invalidAllowedClusters:     true,
+	invalidCNAMETarget:         true,
+	invalidClustersetHostname:  true,
+	clustersetHostnameConflict: true,
+	invalidEndpointExclusion:   true,
+	invalidHealth:              true,
+	invalidMinEndpoints:        true,
+}
+
+// GetLastExportError returns the last error recorded on the given ServiceExport or nil if there is none.
+func GetLastExportError(svcExport *mcsv1a1.ServiceExport) (*ExportError, error) {
+	value, ok := svcExport.GetAnnotations()[lhconstants.LastErrorAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	lastError := &ExportError{}
+
+	err := json.Unmarshal([]byte(value), lastError)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing the %q annotation", lhconstants.LastErrorAnnotation)
+	}
+
+	return lastError, nil
+}
+
+// updateLastExportError records the given condition as the last error on the ServiceExport if it represents a failure or
+// clears it on success. Other conditions leave the last error as is.
+func (a *Controller) updateLastExportError(name, namespace string, status corev1.ConditionStatus, reason, msg string) {
+	isError := status != corev1.ConditionTrue && exportErrorReasons[reason]
+	if status != corev1.ConditionTrue && !isError {
+		return
+	}
+
+	retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		toUpdate, err := a.getServiceExport(name, namespace)
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		existing, _ := GetLastExportError(toUpdate)
+
+		annotations := toUpdate.GetAnnotations()
+
+		if isError {
+			if existing != nil && existing.Code == reason && existing.Message == msg {
+				return nil
+			}
+
+			value, err := json.Marshal(&ExportError{Code: reason, Message: msg, Timestamp: metav1.Now()})
+			if err != nil {
+				return errors.Wrap(err, "error marshalling the last error")
+			}
+
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+
+			annotations[lhconstants.LastErrorAnnotation] = string(value)
+		} else {
+			if _, ok := annotations[lhconstants.LastErrorAnnotation]; !ok {
+				return nil
+			}
+
+			delete(annotations, lhconstants.LastErrorAnnotation)
+		}
+
+		klog.V(log.DEBUG).Infof("Updating the last error annotation for ServiceExport (%s/%s): %q", namespace, name,
+			annotations[lhconstants.LastErrorAnnotation])
+
+		toUpdate.SetAnnotations(annotations)
+
+		raw, err := resource.ToUnstructured(toUpdate)
+		if err != nil {
+			return errors.Wrap(err, "error converting resource")
+		}
+
+		_, err = a.serviceExportClient.Namespace(toUpdate.Namespace).Update(context.TODO(), raw, metav1.UpdateOptions{})
+
+		return errors.Wrap(err, "error updating ServiceExport")
+	})
+
+	if retryErr != nil {
+		klog.Errorf("Error updating the last error for ServiceExport (%s/%s): %+v", namespace, name, retryErr)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	discovery "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+const endpointsTruncated = "EndpointsTruncated"
+
+// capEndpoints caps the endpoints of the EndpointSlice at the configured maximum, if any, so a very large headless Service
+// doesn't bloat etcd and DNS answers. The ready endpoints come first so they're preferred in the published sample. The
+// total number of endpoints is recorded in an annotation so the truncation can be reported in the ServiceExport status.
+func (e *EndpointController) capEndpoints(endpointSlice *discovery.EndpointSlice) {
+	if e.maxEndpoints <= 0 || len(endpointSlice.Endpoints) <= e.maxEndpoints {
+		return
+	}
+
+	klog.Warningf("Service %s/%s has %d endpoints - only publishing the maximum of %d", e.serviceImportSourceNameSpace,
+		e.serviceName, len(endpointSlice.Endpoints), e.maxEndpoints)
+
+	if endpointSlice.Annotations == nil {
+		endpointSlice.Annotations = map[string]string{}
+	}
+
+	endpointSlice.Annotations[lhconstants.EndpointsTruncatedAnnotation] = strconv.Itoa(len(endpointSlice.Endpoints))
+	endpointSlice.Endpoints = endpointSlice.Endpoints[:e.maxEndpoints]
+}
+
+// getTruncatedEndpoints returns the total number of endpoints of this cluster's EndpointSlice for the service if they were
+// capped, otherwise 0.
+func (a *Controller) getTruncatedEndpoints(name, namespace string) (int, error) {
+	if a.maxEndpointsPerImport <= 0 {
+		return 0, nil
+	}
+
+	obj, err := a.serviceImportSyncer.GetLocalClient().Resource(a.endpointSliceGVR).Namespace(namespace).Get(context.TODO(),
+		name+"-"+a.clusterID, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, errors.Wrap(err, "error retrieving EndpointSlice")
+	}
+
+	total, _ := strconv.Atoi(obj.GetAnnotations()[lhconstants.EndpointsTruncatedAnnotation])
+
+	return total, nil
+}
+
+func (a *Controller) endpointsTruncatedMessage(total int) string {
+	return fmt.Sprintf("Service was successfully synced to the broker but only %d of its %d endpoints are published as "+
+		"the maximum per service is %d", a.maxEndpointsPerImport, total, a.maxEndpointsPerImport)
+}
+
+// isTruncationRelevant returns whether a change to the EndpointSlice may change the truncation reported in the status of
+// its ServiceExport, ie whether it's this cluster's and endpoints are capped.
+func (a *Controller) isTruncationRelevant(endpointSlice *discovery.EndpointSlice) bool {
+	return a.maxEndpointsPerImport > 0 && endpointSlice.Labels[lhconstants.MCSLabelSourceCluster] == a.clusterID
+}
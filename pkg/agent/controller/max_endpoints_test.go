@@ -0,0 +1,125 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var _ = Describe("Maximum endpoints per import", func() {
+	var t *testDriver
+
+	BeforeEach(func() {
+		t = newTestDiver()
+
+		t.service.Spec.ClusterIP = corev1.ClusterIPNone
+		t.cluster1.agentSpec.MaxEndpointsPerImport = 3
+
+		// 5 ready and 1 not ready endpoints.
+		t.endpoints.Subsets[0].Addresses = append(t.endpoints.Subsets[0].Addresses,
+			corev1.EndpointAddress{IP: "192.168.5.3"}, corev1.EndpointAddress{IP: "192.168.5.4"},
+			corev1.EndpointAddress{IP: "192.168.5.5"})
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+		t.createService()
+		t.createEndpoints()
+		t.createServiceExport()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("the endpoints exceed the maximum", func() {
+		It("should publish a capped sample of ready endpoints and report the truncation in the ServiceExport status", func() {
+			t.awaitHeadlessServiceImport()
+
+			endpointSlice := awaitBrokerEndpointSliceWithCount(t, 3)
+			Expect(endpointSlice.Annotations).To(HaveKeyWithValue(lhconstants.EndpointsTruncatedAnnotation, "6"))
+
+			for i := range endpointSlice.Endpoints {
+				Expect(*endpointSlice.Endpoints[i].Conditions.Ready).To(BeTrue())
+			}
+
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionTrue, "EndpointsTruncated"))
+
+			By("Reducing the endpoints to the maximum")
+
+			t.endpoints.Subsets[0].Addresses = t.endpoints.Subsets[0].Addresses[:2]
+			t.updateEndpoints()
+
+			Eventually(func() map[string]string {
+				return getBrokerEndpointSlice(t).Annotations
+			}, 5).ShouldNot(HaveKey(lhconstants.EndpointsTruncatedAnnotation))
+
+			Expect(getBrokerEndpointSlice(t).Endpoints).To(HaveLen(3))
+
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionTrue, ""))
+		})
+	})
+
+	When("no maximum is configured", func() {
+		BeforeEach(func() {
+			t.cluster1.agentSpec.MaxEndpointsPerImport = 0
+		})
+
+		It("should publish all the endpoints", func() {
+			t.awaitHeadlessServiceImport()
+
+			endpointSlice := awaitBrokerEndpointSliceWithCount(t, 6)
+			Expect(endpointSlice.Annotations).ToNot(HaveKey(lhconstants.EndpointsTruncatedAnnotation))
+
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionTrue, ""))
+		})
+	})
+})
+
+func awaitBrokerEndpointSliceWithCount(t *testDriver, count int) *discovery.EndpointSlice {
+	var endpointSlice *discovery.EndpointSlice
+
+	Eventually(func() int {
+		endpointSlice = getBrokerEndpointSlice(t)
+		return len(endpointSlice.Endpoints)
+	}, 5).Should(Equal(count))
+
+	return endpointSlice
+}
+
+func getBrokerEndpointSlice(t *testDriver) *discovery.EndpointSlice {
+	endpointSlice := &discovery.EndpointSlice{}
+
+	obj, err := t.brokerEndpointSliceClient.Get(context.TODO(), t.endpoints.Name+"-"+clusterID1, metav1.GetOptions{})
+	if err != nil {
+		return endpointSlice
+	}
+
+	Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, endpointSlice)).To(Succeed())
+
+	return endpointSlice
+}
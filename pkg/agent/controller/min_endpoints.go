@@ -0,0 +1,198 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/federate"
+	"github.com/submariner-io/admiral/pkg/syncer"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"github.com/submariner-io/lighthouse/pkg/endpointslice"
+	corev1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const (
+	invalidMinEndpoints   = "InvalidMinEndpoints"
+	insufficientEndpoints = "InsufficientEndpoints"
+)
+
+// getServiceExportMinEndpoints returns the minimum number of ready endpoints clusterset-wide requested via the ServiceExport
+// annotation, if any. Only headless services export their endpoints so, if the service isn't headless or the minimum
+// isn't a positive integer, a non-empty reason and message are returned.
+func getServiceExportMinEndpoints(svcExport *mcsv1a1.ServiceExport, svcType mcsv1a1.ServiceImportType,
+) (minEndpoints, reason, msg string) {
+	value, ok := svcExport.GetAnnotations()[lhconstants.MinEndpointsAnnotation]
+	if !ok {
+		return "", "", ""
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return "", invalidMinEndpoints, fmt.Sprintf("The minimum endpoints %q is invalid: it must be a positive integer", value)
+	}
+
+	if svcType != mcsv1a1.Headless {
+		return "", invalidMinEndpoints, "A minimum number of endpoints can only be required for a headless Service"
+	}
+
+	return strconv.Itoa(n), "", ""
+}
+
+func getMinEndpoints(annotations map[string]string) int {
+	n, err := strconv.Atoi(annotations[lhconstants.MinEndpointsAnnotation])
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
+
+// updateSyncedExportStatus updates the status of a ServiceExport whose ServiceImport was successfully synced, taking into
+// account the minimum number of ready endpoints clusterset-wide it requires, if any, and whether its published endpoints
+// were truncated.
+func (a *Controller) updateSyncedExportStatus(name, namespace string, minEndpoints int) {
+	if minEndpoints > 0 {
+		ready, err := a.countReadyEndpoints(name, namespace)
+		if err != nil {
+			klog.Errorf("Error counting the ready endpoints of service %s/%s: %v", namespace, name, err)
+		} else if ready < minEndpoints {
+			a.updateExportedServiceStatus(name, namespace, corev1.ConditionFalse, insufficientEndpoints,
+				fmt.Sprintf("Only %d of the minimum %d endpoints are ready clusterset-wide", ready, minEndpoints))
+			return
+		}
+	}
+
+	total, err := a.getTruncatedEndpoints(name, namespace)
+	if err != nil {
+		klog.Errorf("Error checking whether the endpoints of service %s/%s are truncated: %v", namespace, name, err)
+	} else if total > 0 {
+		a.updateExportedServiceStatus(name, namespace, corev1.ConditionTrue, endpointsTruncated, a.endpointsTruncatedMessage(total))
+		return
+	}
+
+	a.updateExportedServiceStatus(name, namespace, corev1.ConditionTrue, "", "Service was successfully synced to the broker")
+}
+
+// countReadyEndpoints returns the number of ready endpoints of the service in all clusters, from the EndpointSlices synced
+// to the local cluster.
+func (a *Controller) countReadyEndpoints(name, namespace string) (int, error) {
+	list, err := a.serviceImportSyncer.GetLocalClient().Resource(a.endpointSliceGVR).Namespace(namespace).List(context.TODO(),
+		metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(map[string]string{
+				discovery.LabelManagedBy:        lhconstants.LabelValueManagedBy,
+				lhconstants.MCSLabelServiceName: name,
+			}).String(),
+		})
+	if err != nil {
+		return 0, errors.Wrap(err, "error listing EndpointSlices")
+	}
+
+	ready := 0
+
+	for i := range list.Items {
+		endpointSlice := &discovery.EndpointSlice{}
+
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].Object, endpointSlice)
+		if err != nil {
+			return 0, errors.Wrap(err, "error converting EndpointSlice")
+		}
+
+		endpoints, err := endpointslice.GetEndpoints(endpointSlice)
+		if err != nil {
+			return 0, err // nolint:wrapcheck // Already wrapped with the EndpointSlice name.
+		}
+
+		for j := range endpoints {
+			if endpoints[j].Conditions.Ready == nil || *endpoints[j].Conditions.Ready {
+				ready++
+			}
+		}
+	}
+
+	return ready, nil
+}
+
+// newMinEndpointsWatcher creates a syncer that watches the local EndpointSlices, including those synced from other clusters,
+// to re-evaluate the status of synced exports that require a minimum number of endpoints, or whose endpoints may be
+// truncated, as their endpoints change.
+func (a *Controller) newMinEndpointsWatcher(restMapper meta.RESTMapper, scheme *runtime.Scheme) (syncer.Interface, error) {
+	watcher, err := syncer.NewResourceSyncer(&syncer.ResourceSyncerConfig{
+		Name:            "EndpointSlice minimum endpoints watcher",
+		SourceClient:    a.serviceImportSyncer.GetLocalClient(),
+		SourceNamespace: metav1.NamespaceAll,
+		Direction:       syncer.None,
+		RestMapper:      restMapper,
+		Federator:       federate.NewNoopFederator(),
+		ResourceType:    &discovery.EndpointSlice{},
+		Transform:       a.onEndpointSliceChanged,
+		ShouldProcess: func(obj *unstructured.Unstructured, op syncer.Operation) bool {
+			return obj.GetLabels()[discovery.LabelManagedBy] == lhconstants.LabelValueManagedBy
+		},
+		Scheme: scheme,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating EndpointSlice minimum endpoints watcher")
+	}
+
+	return watcher, nil
+}
+
+func (a *Controller) onEndpointSliceChanged(obj runtime.Object, _ int, _ syncer.Operation) (runtime.Object, bool) {
+	endpointSlice := obj.(*discovery.EndpointSlice)
+
+	svcExport, err := a.getServiceExport(endpointSlice.Labels[lhconstants.MCSLabelServiceName], endpointSlice.Namespace)
+	if apierrors.IsNotFound(err) {
+		return nil, false
+	}
+
+	if err != nil {
+		klog.Errorf("Error retrieving the ServiceExport for EndpointSlice %s/%s: %v", endpointSlice.Namespace,
+			endpointSlice.Name, err)
+		return nil, true
+	}
+
+	minEndpoints := getMinEndpoints(svcExport.GetAnnotations())
+	if minEndpoints == 0 && !a.isTruncationRelevant(endpointSlice) {
+		return nil, false
+	}
+
+	// The status is only re-evaluated once the export was synced, which sets it initially.
+	numCond := len(svcExport.Status.Conditions)
+	if numCond == 0 || (svcExport.Status.Conditions[numCond-1].Status != corev1.ConditionTrue &&
+		getLastExportConditionReason(svcExport) != insufficientEndpoints) {
+		return nil, false
+	}
+
+	a.updateSyncedExportStatus(svcExport.Name, svcExport.Namespace, minEndpoints)
+
+	return nil, false
+}
@@ -0,0 +1,370 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/federate"
+	"github.com/submariner-io/admiral/pkg/log"
+	"github.com/submariner-io/admiral/pkg/syncer"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	validations "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+// namespaceMapping maps local namespaces to clusterset namespaces. The mapping is one-to-one so it can be reversed on
+// import. Namespaces that aren't mapped are the same locally and in the clusterset.
+type namespaceMapping struct {
+	mutex        sync.RWMutex
+	toClusterset map[string]string
+	toLocal      map[string]string
+}
+
+func newNamespaceMapping() *namespaceMapping {
+	return &namespaceMapping{
+		toClusterset: map[string]string{},
+		toLocal:      map[string]string{},
+	}
+}
+
+func (m *namespaceMapping) clustersetNamespace(local string) string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if ns, ok := m.toClusterset[local]; ok {
+		return ns
+	}
+
+	return local
+}
+
+func (m *namespaceMapping) localNamespace(clusterset string) string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return reverseNamespace(m.toLocal, clusterset)
+}
+
+// set replaces the mapping with the given ConfigMap data, keyed by local namespace, and returns the previous local
+// namespace for each clusterset namespace, or nil if the mapping didn't change. If the data is invalid, the mapping is
+// left unchanged.
+func (m *namespaceMapping) set(data map[string]string) (map[string]string, error) {
+	toClusterset := make(map[string]string, len(data))
+	toLocal := make(map[string]string, len(data))
+
+	locals := make([]string, 0, len(data))
+	for local := range data {
+		locals = append(locals, local)
+	}
+
+	sort.Strings(locals)
+
+	for _, local := range locals {
+		clusterset := data[local]
+
+		for _, ns := range []string{local, clusterset} {
+			if errs := validations.IsDNS1123Label(ns); len(errs) > 0 {
+				return nil, errors.Errorf("%q is not a valid namespace %v", ns, errs)
+			}
+		}
+
+		if other, ok := toLocal[clusterset]; ok {
+			return nil, errors.Errorf("namespaces %q and %q are both mapped to clusterset namespace %q", other, local, clusterset)
+		}
+
+		toClusterset[local] = clusterset
+		toLocal[clusterset] = local
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if reflect.DeepEqual(toClusterset, m.toClusterset) {
+		return nil, nil
+	}
+
+	previous := m.toLocal
+	m.toClusterset = toClusterset
+	m.toLocal = toLocal
+
+	return previous, nil
+}
+
+func reverseNamespace(toLocal map[string]string, clusterset string) string {
+	if ns, ok := toLocal[clusterset]; ok {
+		return ns
+	}
+
+	return clusterset
+}
+
+// newNamespaceMappingWatcher creates a syncer that watches the namespace mapping ConfigMap in the agent namespace so
+// changes to the mapping are applied without restarting the agent.
+func (a *Controller) newNamespaceMappingWatcher(configMapName string, restMapper meta.RESTMapper,
+	localClient dynamic.Interface, scheme *runtime.Scheme,
+) (syncer.Interface, error) {
+	watcher, err := syncer.NewResourceSyncer(&syncer.ResourceSyncerConfig{
+		Name:            "Namespace mapping watcher",
+		SourceClient:    localClient,
+		SourceNamespace: a.namespace,
+		Direction:       syncer.None,
+		RestMapper:      restMapper,
+		Federator:       federate.NewNoopFederator(),
+		ResourceType:    &corev1.ConfigMap{},
+		Transform:       a.onNamespaceMappingChanged,
+		ShouldProcess: func(obj *unstructured.Unstructured, op syncer.Operation) bool {
+			return obj.GetName() == configMapName
+		},
+		Scheme: scheme,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating namespace mapping watcher")
+	}
+
+	return watcher, nil
+}
+
+// loadNamespaceMapping synchronously loads the namespace mapping from the ConfigMap, if it exists, so it's applied
+// before any Services are exported or imported.
+func (a *Controller) loadNamespaceMapping() {
+	obj, found, err := a.namespaceMappingWatcher.GetResource(a.namespaceMappingConfigMap, a.namespace)
+	if err != nil {
+		klog.Errorf("Error retrieving the namespace mapping ConfigMap: %v", err)
+		return
+	}
+
+	if found {
+		a.applyNamespaceMapping(obj.(*corev1.ConfigMap).Data)
+	}
+}
+
+func (a *Controller) onNamespaceMappingChanged(obj runtime.Object, _ int, op syncer.Operation) (runtime.Object, bool) {
+	// The re-sync writes to the broker so requeue until resumed.
+	if a.IsPaused() {
+		return nil, true
+	}
+
+	var data map[string]string
+	if op != syncer.Delete {
+		data = obj.(*corev1.ConfigMap).Data
+	}
+
+	if previous := a.applyNamespaceMapping(data); previous != nil {
+		a.resyncNamespaceMapping(previous)
+	}
+
+	return nil, false
+}
+
+func (a *Controller) applyNamespaceMapping(data map[string]string) map[string]string {
+	previous, err := a.namespaceMapping.set(data)
+	if err != nil {
+		klog.Errorf("Ignoring invalid namespace mapping in ConfigMap %q: %v", a.namespaceMappingConfigMap, err)
+		return nil
+	}
+
+	if previous != nil {
+		klog.Infof("Loaded the namespace mapping from ConfigMap %q: %v", a.namespaceMappingConfigMap, data)
+	}
+
+	return previous
+}
+
+// resyncNamespaceMapping re-applies the namespace mapping to the ServiceImports and EndpointSlices that were already
+// synced, ie this cluster's on the broker and those of the other clusters imported locally. An imported EndpointSlice
+// whose local namespace changed is moved to the new namespace.
+func (a *Controller) resyncNamespaceMapping(previousToLocal map[string]string) {
+	a.resyncExportedServiceImports()
+	a.resyncExportedEndpointSlices()
+	a.resyncImportedServiceImports()
+	a.resyncImportedEndpointSlices(previousToLocal)
+}
+
+func (a *Controller) resyncExportedServiceImports() {
+	list, err := a.serviceImportSyncer.ListLocalResources(&mcsv1a1.ServiceImport{})
+	if err != nil {
+		klog.Errorf("Error listing the local ServiceImports: %v", err)
+		return
+	}
+
+	for _, obj := range list {
+		si := obj.(*mcsv1a1.ServiceImport)
+		if si.Labels[lhconstants.LighthouseLabelSourceCluster] != a.clusterID {
+			continue
+		}
+
+		if err := a.distributeToBroker(si); err != nil {
+			klog.Errorf("Error syncing ServiceImport %q to the broker: %v", si.Name, err)
+		}
+	}
+}
+
+func (a *Controller) resyncExportedEndpointSlices() {
+	list, err := a.endpointSliceSyncer.ListLocalResources(&discovery.EndpointSlice{})
+	if err != nil {
+		klog.Errorf("Error listing the local EndpointSlices: %v", err)
+		return
+	}
+
+	for _, obj := range list {
+		eps := obj.(*discovery.EndpointSlice)
+		if eps.Labels[discovery.LabelManagedBy] != lhconstants.LabelValueManagedBy ||
+			eps.Labels[lhconstants.MCSLabelSourceCluster] != a.clusterID {
+			continue
+		}
+
+		toDistribute := a.toBrokerEndpointSlice(eps)
+		toDistribute.Labels[syncer.OrigNamespaceLabelKey] = eps.Namespace
+
+		if err := a.endpointSliceSyncer.GetBrokerFederator().Distribute(toDistribute); err != nil {
+			klog.Errorf("Error syncing EndpointSlice %s/%s to the broker: %v", eps.Namespace, eps.Name, err)
+		}
+	}
+}
+
+func (a *Controller) resyncImportedServiceImports() {
+	list, err := a.brokerImportClient.List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		klog.Errorf("Error listing the broker ServiceImports: %v", err)
+		return
+	}
+
+	for i := range list.Items {
+		if list.Items[i].GetLabels()[lhconstants.LighthouseLabelSourceCluster] == a.clusterID {
+			continue
+		}
+
+		si := &mcsv1a1.ServiceImport{}
+		if err := a.serviceImportController.scheme.Convert(&list.Items[i], si, nil); err != nil {
+			klog.Errorf("Error converting ServiceImport %q: %v", list.Items[i].GetName(), err)
+			continue
+		}
+
+		toDistribute, _ := a.remoteServiceImportToLocal(si, 0, syncer.Update)
+
+		if err := a.serviceImportSyncer.GetLocalFederator().Distribute(toDistribute); err != nil {
+			klog.Errorf("Error syncing the imported ServiceImport %q: %v", si.Name, err)
+		}
+	}
+}
+
+func (a *Controller) resyncImportedEndpointSlices(previousToLocal map[string]string) {
+	list, err := a.endpointSliceSyncer.GetBrokerClient().Resource(a.endpointSliceGVR).Namespace(
+		a.endpointSliceSyncer.GetBrokerNamespace()).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		klog.Errorf("Error listing the broker EndpointSlices: %v", err)
+		return
+	}
+
+	for i := range list.Items {
+		if list.Items[i].GetLabels()[lhconstants.MCSLabelSourceCluster] == a.clusterID {
+			continue
+		}
+
+		eps := &discovery.EndpointSlice{}
+		if err := a.serviceImportController.scheme.Convert(&list.Items[i], eps, nil); err != nil {
+			klog.Errorf("Error converting EndpointSlice %q: %v", list.Items[i].GetName(), err)
+			continue
+		}
+
+		previousNamespace := reverseNamespace(previousToLocal, eps.Labels[lhconstants.LabelSourceNamespace])
+
+		toDistribute, _ := a.remoteEndpointSliceToLocal(eps.DeepCopy(), 0, syncer.Update)
+
+		if err := a.endpointSliceSyncer.GetLocalFederator().Distribute(toDistribute); err != nil {
+			klog.Errorf("Error syncing the imported EndpointSlice %q: %v", eps.Name, err)
+			continue
+		}
+
+		if previousNamespace == toDistribute.(*discovery.EndpointSlice).Namespace {
+			continue
+		}
+
+		klog.V(log.DEBUG).Infof("Moved the imported EndpointSlice %q from namespace %q to %q", eps.Name, previousNamespace,
+			toDistribute.(*discovery.EndpointSlice).Namespace)
+
+		err := a.endpointSliceSyncer.GetLocalClient().Resource(a.endpointSliceGVR).Namespace(previousNamespace).Delete(
+			context.TODO(), eps.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("Error deleting the imported EndpointSlice %s/%s: %v", previousNamespace, eps.Name, err)
+		}
+	}
+}
+
+// remoteServiceImportToLocal maps the clusterset namespace of a ServiceImport imported from the broker to the local
+// namespace and, when it's first imported, records its propagation latency. ServiceImports of other clusterset groups
+// sharing the broker aren't imported.
+func (a *Controller) remoteServiceImportToLocal(obj runtime.Object, _ int, op syncer.Operation) (runtime.Object, bool) {
+	serviceImport := obj.(*mcsv1a1.ServiceImport)
+
+	if !a.isInClustersetGroup(serviceImport.Labels) {
+		return nil, false
+	}
+
+	if op == syncer.Create {
+		a.observePropagationLatency(serviceImport)
+	}
+
+	if clusterset, ok := serviceImport.Labels[lhconstants.LabelSourceNamespace]; ok {
+		if serviceImport.Annotations == nil {
+			serviceImport.Annotations = map[string]string{}
+		}
+
+		serviceImport.Annotations[lhconstants.OriginNamespace] = a.namespaceMapping.localNamespace(clusterset)
+	}
+
+	return serviceImport, false
+}
+
+// toBrokerServiceImport returns a copy of the given local ServiceImport labeled with the clusterset namespace. The
+// origin annotations still refer to the local Service.
+func (a *Controller) toBrokerServiceImport(serviceImport *mcsv1a1.ServiceImport) *mcsv1a1.ServiceImport {
+	serviceImport = serviceImport.DeepCopy()
+
+	if ns, ok := serviceImport.Labels[lhconstants.LabelSourceNamespace]; ok {
+		serviceImport.Labels[lhconstants.LabelSourceNamespace] = a.namespaceMapping.clustersetNamespace(ns)
+	}
+
+	return serviceImport
+}
+
+// toBrokerEndpointSlice returns a copy of the given local EndpointSlice labeled with the clusterset namespace.
+func (a *Controller) toBrokerEndpointSlice(endpointSlice *discovery.EndpointSlice) *discovery.EndpointSlice {
+	endpointSlice = endpointSlice.DeepCopy()
+
+	if ns, ok := endpointSlice.Labels[lhconstants.LabelSourceNamespace]; ok {
+		endpointSlice.Labels[lhconstants.LabelSourceNamespace] = a.namespaceMapping.clustersetNamespace(ns)
+	}
+
+	return endpointSlice
+}
@@ -0,0 +1,175 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+var _ = Describe("Namespace mapping", func() {
+	const (
+		configMapName       = "lighthouse-namespace-mapping"
+		clustersetNamespace = "shared-ns"
+		importNamespace     = "imported-ns"
+	)
+
+	var t *testDriver
+
+	configMapClient := func(c *cluster) dynamic.ResourceInterface {
+		return c.localDynClient.Resource(*test.GetGroupVersionResourceFor(t.syncerConfig.RestMapper,
+			&corev1.ConfigMap{})).Namespace(test.LocalNamespace)
+	}
+
+	newConfigMap := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      configMapName,
+				Namespace: test.LocalNamespace,
+			},
+			Data: data,
+		}
+	}
+
+	endpointSliceClient := func(c *cluster, namespace string) dynamic.ResourceInterface {
+		return c.localDynClient.Resource(*test.GetGroupVersionResourceFor(t.syncerConfig.RestMapper,
+			&discovery.EndpointSlice{})).Namespace(namespace)
+	}
+
+	serviceImportName := func() string {
+		return t.service.Name + "-" + t.service.Namespace + "-" + clusterID1
+	}
+
+	endpointSliceName := func() string {
+		return t.endpoints.Name + "-" + clusterID1
+	}
+
+	awaitLabel := func(client dynamic.ResourceInterface, name, namespace string) {
+		test.AwaitAndVerifyResource(client, name, func(obj *unstructured.Unstructured) bool {
+			return obj.GetLabels()[lhconstants.LabelSourceNamespace] == namespace
+		})
+	}
+
+	awaitImportedNamespace := func(namespace string) {
+		test.AwaitAndVerifyResource(t.cluster2.localServiceImportClient, serviceImportName(), func(obj *unstructured.Unstructured) bool {
+			return obj.GetAnnotations()[lhconstants.OriginNamespace] == namespace
+		})
+
+		awaitLabel(endpointSliceClient(&t.cluster2, namespace), endpointSliceName(), namespace)
+	}
+
+	BeforeEach(func() {
+		t = newTestDiver()
+
+		t.cluster1.agentSpec.NamespaceMappingConfigMap = configMapName
+		t.cluster2.agentSpec.NamespaceMappingConfigMap = configMapName
+
+		test.CreateResource(configMapClient(&t.cluster1), newConfigMap(map[string]string{serviceNamespace: clustersetNamespace}))
+		test.CreateResource(configMapClient(&t.cluster2), newConfigMap(map[string]string{importNamespace: clustersetNamespace}))
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+		t.createEndpoints()
+		t.createService()
+		t.createServiceExport()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("a Service is exported from a mapped namespace", func() {
+		It("should sync it to the broker with the clusterset namespace and import it into the mapped local namespace", func() {
+			t.cluster1.awaitServiceImport(t.service, mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP)
+
+			awaitLabel(t.brokerServiceImportClient, serviceImportName(), clustersetNamespace)
+			awaitLabel(t.brokerEndpointSliceClient, endpointSliceName(), clustersetNamespace)
+
+			awaitImportedNamespace(importNamespace)
+
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionTrue, ""))
+		})
+	})
+
+	When("the importing cluster's mapping is reloaded", func() {
+		It("should move the imported Service to the newly mapped local namespace", func() {
+			awaitImportedNamespace(importNamespace)
+
+			test.UpdateResource(configMapClient(&t.cluster2), newConfigMap(map[string]string{"other-ns": clustersetNamespace}))
+
+			awaitImportedNamespace("other-ns")
+			test.AwaitNoResource(endpointSliceClient(&t.cluster2, importNamespace), endpointSliceName())
+		})
+	})
+
+	When("the exporting cluster's mapping is reloaded", func() {
+		It("should re-sync the exported Service with the new clusterset namespace", func() {
+			awaitImportedNamespace(importNamespace)
+
+			test.UpdateResource(configMapClient(&t.cluster1), newConfigMap(map[string]string{serviceNamespace: "other-shared-ns"}))
+
+			awaitLabel(t.brokerServiceImportClient, serviceImportName(), "other-shared-ns")
+			awaitLabel(t.brokerEndpointSliceClient, endpointSliceName(), "other-shared-ns")
+			awaitImportedNamespace("other-shared-ns")
+		})
+
+		It("should not re-sync the exported Service to the broker while syncing is paused", func() {
+			awaitImportedNamespace(importNamespace)
+
+			t.cluster1.agentController.Pause()
+
+			test.UpdateResource(configMapClient(&t.cluster1), newConfigMap(map[string]string{serviceNamespace: "other-shared-ns"}))
+
+			Consistently(func() string {
+				return test.GetResource(t.brokerServiceImportClient, &mcsv1a1.ServiceImport{
+					ObjectMeta: metav1.ObjectMeta{Name: serviceImportName()},
+				}).GetLabels()[lhconstants.LabelSourceNamespace]
+			}, 500*time.Millisecond).Should(Equal(clustersetNamespace))
+
+			t.cluster1.agentController.Resume()
+
+			awaitLabel(t.brokerServiceImportClient, serviceImportName(), "other-shared-ns")
+			awaitLabel(t.brokerEndpointSliceClient, endpointSliceName(), "other-shared-ns")
+		})
+	})
+
+	When("the mapping ConfigMap is invalid", func() {
+		BeforeEach(func() {
+			test.UpdateResource(configMapClient(&t.cluster2), newConfigMap(map[string]string{
+				importNamespace: clustersetNamespace,
+				"other-ns":      clustersetNamespace,
+			}))
+		})
+
+		It("should ignore it", func() {
+			awaitImportedNamespace(clustersetNamespace)
+		})
+	})
+})
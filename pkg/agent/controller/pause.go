@@ -0,0 +1,58 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"k8s.io/klog/v2"
+)
+
+// Pause suspends the processing of local ServiceExports, Services and EndpointSlices so that no changes are made to the
+// broker. Writes to the broker made outside of the syncers, eg restoring a ServiceImport deleted from the broker or
+// re-applying the namespace mapping, are also held. The syncers requeue the resources they're informed of while paused,
+// rather than block their workers, so they're processed on Resume.
+func (a *Controller) Pause() {
+	a.pauseMutex.Lock()
+	defer a.pauseMutex.Unlock()
+
+	if !a.paused {
+		a.paused = true
+
+		klog.Info("Agent syncing paused")
+	}
+}
+
+// Resume resumes processing previously suspended by Pause.
+func (a *Controller) Resume() {
+	a.pauseMutex.Lock()
+	defer a.pauseMutex.Unlock()
+
+	if a.paused {
+		a.paused = false
+
+		klog.Info("Agent syncing resumed")
+	}
+}
+
+// IsPaused returns true if syncing is currently paused.
+func (a *Controller) IsPaused() bool {
+	a.pauseMutex.Lock()
+	defer a.pauseMutex.Unlock()
+
+	return a.paused
+}
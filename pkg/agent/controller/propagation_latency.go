@@ -0,0 +1,101 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/submariner-io/admiral/pkg/log"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const (
+	srcClusterLabel = "source_cluster"
+	dstClusterLabel = "destination_cluster"
+
+	ExportPropagationLatencyName = "submariner_service_export_propagation_seconds"
+)
+
+var exportPropagationLatency = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    ExportPropagationLatencyName,
+		Help:    "Time from a service being exported in the source cluster to its ServiceImport being observed in the destination cluster",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 12),
+	},
+	[]string{srcClusterLabel, dstClusterLabel},
+)
+
+func init() {
+	prometheus.MustRegister(exportPropagationLatency)
+}
+
+// stampExportTimestamp records the time the service was exported on the given ServiceImport, if enabled. The timestamp of
+// the existing ServiceImport, if any, is retained so it reflects the initial export and the ServiceImport isn't updated
+// needlessly.
+func (a *Controller) stampExportTimestamp(serviceImport *mcsv1a1.ServiceImport) {
+	if !a.propagationLatencyEnabled {
+		return
+	}
+
+	stamp := time.Now().UTC().Format(time.RFC3339Nano)
+
+	existing, found, err := a.serviceImportSyncer.GetLocalResource(serviceImport.Name, a.namespace, &mcsv1a1.ServiceImport{})
+	if err == nil && found {
+		if s, ok := existing.(*mcsv1a1.ServiceImport).Annotations[lhconstants.ExportTimestampAnnotation]; ok {
+			stamp = s
+		}
+	}
+
+	serviceImport.Annotations[lhconstants.ExportTimestampAnnotation] = stamp
+}
+
+// observePropagationLatency records the time taken for a remote ServiceImport to be propagated to this cluster, based on its
+// export timestamp. It's only recorded when the ServiceImport is first observed, ie not when it's re-listed on restart.
+func (a *Controller) observePropagationLatency(serviceImport *mcsv1a1.ServiceImport) {
+	stamp, ok := serviceImport.Annotations[lhconstants.ExportTimestampAnnotation]
+	if !ok {
+		return
+	}
+
+	exportedAt, err := time.Parse(time.RFC3339Nano, stamp)
+	if err != nil {
+		klog.Warningf("ServiceImport %q has an invalid export timestamp %q: %v", serviceImport.Name, stamp, err)
+		return
+	}
+
+	_, found, err := a.serviceImportSyncer.GetLocalResource(serviceImport.Name, a.namespace, &mcsv1a1.ServiceImport{})
+	if err != nil || found {
+		return
+	}
+
+	// Clock skew between the clusters may make the latency appear negative.
+	latency := time.Since(exportedAt)
+	if latency < 0 {
+		latency = 0
+	}
+
+	sourceCluster := serviceImport.Labels[lhconstants.LighthouseLabelSourceCluster]
+
+	klog.V(log.DEBUG).Infof("ServiceImport %q from cluster %q propagated in %v", serviceImport.Name, sourceCluster, latency)
+
+	exportPropagationLatency.WithLabelValues(sourceCluster, a.clusterID).Observe(latency.Seconds())
+}
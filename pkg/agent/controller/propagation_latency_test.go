@@ -0,0 +1,125 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	"github.com/submariner-io/lighthouse/pkg/agent/controller"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+var _ = Describe("Export propagation latency", func() {
+	var (
+		t            *testDriver
+		initialCount uint64
+	)
+
+	BeforeEach(func() {
+		t = newTestDiver()
+		t.cluster1.agentSpec.PropagationLatencyEnabled = true
+		initialCount = getPropagationLatencySampleCount(clusterID1, clusterID2)
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+		t.createService()
+		t.createServiceExport()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("enabled and a Service is exported", func() {
+		It("should stamp the export timestamp on the ServiceImport", func() {
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			stamp := t.awaitBrokerServiceImport(mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP).
+				Annotations[lhconstants.ExportTimestampAnnotation]
+
+			exportedAt, err := time.Parse(time.RFC3339Nano, stamp)
+			Expect(err).To(Succeed())
+			Expect(exportedAt).To(BeTemporally("~", time.Now(), 10*time.Second))
+			Expect(t.cluster2.awaitServiceImport(t.service, mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP).
+				Annotations).To(HaveKeyWithValue(lhconstants.ExportTimestampAnnotation, stamp))
+		})
+
+		It("should record the propagation latency in the importing cluster", func() {
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			Eventually(func() uint64 {
+				return getPropagationLatencySampleCount(clusterID1, clusterID2)
+			}).Should(Equal(initialCount + 1))
+		})
+
+		It("should retain the export timestamp when the ServiceImport is rewritten", func() {
+			stamp := t.awaitBrokerServiceImport(mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP).
+				Annotations[lhconstants.ExportTimestampAnnotation]
+
+			t.serviceExport.Annotations = map[string]string{lhconstants.ForceResyncAnnotation: "1"}
+			test.UpdateResource(t.cluster1.localServiceExportClient, t.serviceExport)
+			t.awaitForceResyncHandled("1")
+
+			Expect(t.awaitBrokerServiceImport(mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP).Annotations).To(
+				HaveKeyWithValue(lhconstants.ExportTimestampAnnotation, stamp))
+		})
+	})
+
+	When("not enabled and a Service is exported", func() {
+		BeforeEach(func() {
+			t.cluster1.agentSpec.PropagationLatencyEnabled = false
+		})
+
+		It("should not stamp the export timestamp on the ServiceImport", func() {
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+			Expect(t.awaitBrokerServiceImport(mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP).Annotations).ToNot(
+				HaveKey(lhconstants.ExportTimestampAnnotation))
+		})
+	})
+})
+
+func getPropagationLatencySampleCount(sourceCluster, destinationCluster string) uint64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	Expect(err).To(Succeed())
+
+	for _, family := range families {
+		if family.GetName() != controller.ExportPropagationLatencyName {
+			continue
+		}
+
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+
+			if labels["source_cluster"] == sourceCluster && labels["destination_cluster"] == destinationCluster {
+				return m.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,74 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// parseAllowedProtocols converts the configured protocol allowlist to a set. An empty allowlist allows all protocols
+// and is represented by an empty set.
+func parseAllowedProtocols(protocols []string) (map[corev1.Protocol]bool, error) {
+	allowed := make(map[corev1.Protocol]bool, len(protocols))
+
+	for _, p := range protocols {
+		protocol := corev1.Protocol(strings.ToUpper(strings.TrimSpace(p)))
+
+		switch protocol {
+		case corev1.ProtocolTCP, corev1.ProtocolUDP, corev1.ProtocolSCTP:
+			allowed[protocol] = true
+		default:
+			return nil, errors.Errorf("%q is not a valid allowed protocol", p)
+		}
+	}
+
+	return allowed, nil
+}
+
+func (a *Controller) isProtocolAllowed(protocol corev1.Protocol) bool {
+	return isProtocolAllowed(a.allowedProtocols, protocol)
+}
+
+func isProtocolAllowed(allowedProtocols map[corev1.Protocol]bool, protocol corev1.Protocol) bool {
+	if len(allowedProtocols) == 0 {
+		return true
+	}
+
+	// The protocol defaults to TCP if not specified.
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+
+	return allowedProtocols[protocol]
+}
+
+func (a *Controller) allowedProtocolList() []string {
+	list := make([]string, 0, len(a.allowedProtocols))
+	for protocol := range a.allowedProtocols {
+		list = append(list, string(protocol))
+	}
+
+	sort.Strings(list)
+
+	return list
+}
@@ -0,0 +1,94 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const exportQuotaExceeded = "ExportQuotaExceeded"
+
+// checkExportQuota returns a non-empty reason and message if exporting the given Service would exceed the maximum number
+// of Services this cluster may export. It's the last check before a ServiceImport is returned so only exports that are
+// otherwise ready count. Services that are already exported are always allowed so they continue to be updated. The
+// exported Services are counted from the local ServiceImport cache once and then maintained under a lock, so that
+// concurrent checks, eg from different workers, count each other's admissions, until their ServiceExport is deleted.
+func (a *Controller) checkExportQuota(name, namespace string) (reason, msg string) {
+	if a.maxExportedServices <= 0 {
+		return "", ""
+	}
+
+	a.exportQuotaMutex.Lock()
+	defer a.exportQuotaMutex.Unlock()
+
+	if a.exportQuotaAdmitted == nil {
+		admitted, err := a.listExportedServices()
+		if err != nil {
+			klog.Errorf("Error listing ServiceImports: %v", err)
+			return "", ""
+		}
+
+		a.exportQuotaAdmitted = admitted
+	}
+
+	key := namespace + "/" + name
+	if a.exportQuotaAdmitted[key] {
+		return "", ""
+	}
+
+	if len(a.exportQuotaAdmitted) < a.maxExportedServices {
+		a.exportQuotaAdmitted[key] = true
+		return "", ""
+	}
+
+	return exportQuotaExceeded, fmt.Sprintf("The maximum number of exported Services (%d) for this cluster has been reached",
+		a.maxExportedServices)
+}
+
+// listExportedServices returns the keys of the Services exported by this cluster according to the local ServiceImport
+// cache.
+func (a *Controller) listExportedServices() (map[string]bool, error) {
+	list, err := a.serviceImportSyncer.ListLocalResources(&mcsv1a1.ServiceImport{})
+	if err != nil {
+		return nil, err // nolint:wrapcheck // Let the caller wrap it.
+	}
+
+	exported := map[string]bool{}
+
+	for _, obj := range list {
+		serviceImport := obj.(*mcsv1a1.ServiceImport)
+		if serviceImport.Labels[lhconstants.LighthouseLabelSourceCluster] == a.clusterID {
+			exported[serviceImport.Annotations[lhconstants.OriginNamespace]+"/"+serviceImport.Annotations[lhconstants.OriginName]] = true
+		}
+	}
+
+	return exported, nil
+}
+
+// releaseExportQuota releases the quota admitted for the given Service.
+func (a *Controller) releaseExportQuota(name, namespace string) {
+	a.exportQuotaMutex.Lock()
+	defer a.exportQuotaMutex.Unlock()
+
+	delete(a.exportQuotaAdmitted, namespace+"/"+name)
+}
@@ -0,0 +1,180 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	"github.com/submariner-io/lighthouse/pkg/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+var _ = Describe("Exported Services quota", func() {
+	var (
+		t     *testDriver
+		other *corev1.Service
+	)
+
+	BeforeEach(func() {
+		t = newTestDiver()
+		t.cluster1.agentSpec.MaxExportedServices = 1
+
+		other = t.service.DeepCopy()
+		other.Name = "other"
+		other.Spec.ClusterIP = "10.253.20.1"
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+
+		test.CreateResource(t.cluster1.dynamicServiceClient().Namespace(other.Namespace), other)
+		test.CreateResource(t.cluster1.localServiceExportClient, &mcsv1a1.ServiceExport{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      other.Name,
+				Namespace: other.Namespace,
+			},
+		})
+
+		Expect(testutil.WaitForExported(t.cluster1.localDynClient, other.Namespace, other.Name, 5*time.Second)).To(Succeed())
+
+		t.createService()
+		t.createServiceExport()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("a ServiceExport exceeds the quota", func() {
+		It("should not be exported until an exported Service is unexported", func() {
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "ExportQuotaExceeded"))
+			t.awaitNoServiceImport(t.brokerServiceImportClient)
+
+			Expect(t.cluster1.localServiceExportClient.Delete(context.TODO(), other.Name, metav1.DeleteOptions{})).To(Succeed())
+
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+		})
+
+		It("should record the last error and clear it once exported", func() {
+			Expect(t.awaitLastExportError(Not(BeNil())).Code).To(Equal("ExportQuotaExceeded"))
+
+			Expect(t.cluster1.localServiceExportClient.Delete(context.TODO(), other.Name, metav1.DeleteOptions{})).To(Succeed())
+
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+			t.awaitLastExportError(BeNil())
+		})
+	})
+})
+
+var _ = Describe("Exported Services quota with a pending export", func() {
+	var t *testDriver
+
+	BeforeEach(func() {
+		t = newTestDiver()
+		t.cluster1.agentSpec.MaxExportedServices = 1
+		t.service.Spec.ClusterIP = ""
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+		t.createService()
+		t.createServiceExport()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("a ServiceExport awaits its Service's ClusterIP", func() {
+		It("should not count towards the quota", func() {
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "ServiceIPPending"))
+
+			other := t.service.DeepCopy()
+			other.Name = "other"
+			other.Spec.ClusterIP = "10.253.20.1"
+
+			test.CreateResource(t.cluster1.dynamicServiceClient().Namespace(other.Namespace), other)
+			test.CreateResource(t.cluster1.localServiceExportClient, &mcsv1a1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      other.Name,
+					Namespace: other.Namespace,
+				},
+			})
+
+			Expect(testutil.WaitForExported(t.cluster1.localDynClient, other.Namespace, other.Name, 5*time.Second)).To(Succeed())
+		})
+	})
+})
+
+var _ = Describe("Exported Services quota with concurrent exports", func() {
+	const numServices = 8
+
+	var t *testDriver
+
+	BeforeEach(func() {
+		t = newTestDiver()
+		t.cluster1.agentSpec.MaxExportedServices = 1
+		t.cluster1.agentSpec.Workers = 4
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+
+		for i := 0; i < numServices; i++ {
+			service := t.service.DeepCopy()
+			service.Name = fmt.Sprintf("concurrent-%d", i)
+			service.Spec.ClusterIP = fmt.Sprintf("10.253.30.%d", i+1)
+			test.CreateResource(t.cluster1.dynamicServiceClient().Namespace(service.Namespace), service)
+		}
+
+		for i := 0; i < numServices; i++ {
+			test.CreateResource(t.cluster1.localServiceExportClient, &mcsv1a1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("concurrent-%d", i),
+					Namespace: t.service.Namespace,
+				},
+			})
+		}
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("several ServiceExports are created at once", func() {
+		It("should not export more Services than the quota", func() {
+			countServiceImports := func() int {
+				list, err := t.cluster1.localServiceImportClient.List(context.TODO(), metav1.ListOptions{})
+				Expect(err).To(Succeed())
+
+				return len(list.Items)
+			}
+
+			Eventually(countServiceImports, 5).Should(Equal(1))
+			Consistently(countServiceImports, time.Second).Should(Equal(1))
+		})
+	})
+})
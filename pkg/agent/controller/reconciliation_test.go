@@ -20,8 +20,12 @@ package controller_test
 
 import (
 	. "github.com/onsi/ginkgo"
+	"github.com/submariner-io/admiral/pkg/fake"
 	"github.com/submariner-io/admiral/pkg/syncer/test"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/testing"
 	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
 )
 
@@ -83,6 +87,38 @@ var _ = Describe("Reconciliation", func() {
 		})
 	})
 
+	When("a ServiceExport is missed by the informer on startup", func() {
+		It("should process it on reconciliation", func() {
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			t.afterEach()
+			t = newTestDiver()
+
+			// Simulate the ServiceExport being created after the informer's initial list.
+			listed := false
+			t.cluster1.localDynClient.(*fake.DynamicClient).PrependReactor("list", "serviceexports",
+				func(action testing.Action) (bool, runtime.Object, error) {
+					if listed {
+						return false, nil, nil
+					}
+
+					listed = true
+
+					return true, &unstructured.UnstructuredList{Object: map[string]interface{}{
+						"apiVersion": mcsv1a1.SchemeGroupVersion.String(),
+						"kind":       "ServiceExportList",
+					}}, nil
+				})
+
+			t.createService()
+			t.createServiceExport()
+			t.cluster1.start(t, *t.syncerConfig)
+
+			t.cluster1.awaitServiceImport(t.service, mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP)
+			t.awaitBrokerServiceImport(mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP)
+		})
+	})
+
 	When("a synced local ServiceImport is stale in the broker datastore on startup", func() {
 		It("should delete it from the broker datastore on reconciliation", func() {
 			serviceImport := t.awaitBrokerServiceImport(mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP)
@@ -0,0 +1,66 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+// recreateLocalServiceImport recreates a deleted local ServiceImport for this cluster if its ServiceExport and Service
+// still exist, eg if it was deleted out-of-band. When the agent deletes the ServiceImport on unexport, the ServiceExport
+// or Service no longer exists so nothing is recreated.
+func (a *Controller) recreateLocalServiceImport(serviceImport *mcsv1a1.ServiceImport) {
+	if serviceImport.Labels[lhconstants.LighthouseLabelSourceCluster] != a.clusterID {
+		return
+	}
+
+	name := serviceImport.Annotations[lhconstants.OriginName]
+	namespace := serviceImport.Annotations[lhconstants.OriginNamespace]
+
+	obj, found, err := a.serviceExportSyncer.GetResource(name, namespace)
+	if err != nil || !found {
+		return
+	}
+
+	svcExport := obj.(*mcsv1a1.ServiceExport)
+
+	obj, found, err = a.getService(name, namespace)
+	if err != nil || !found {
+		return
+	}
+
+	toCreate, _ := a.serviceToServiceImport(svcExport, obj.(*corev1.Service))
+	if toCreate == nil {
+		return
+	}
+
+	klog.Infof("The local ServiceImport for exported Service (%s/%s) was deleted - recreating it", namespace, name)
+
+	federator := &localServiceImportFederator{
+		Federator:  a.serviceImportSyncer.GetLocalFederator(),
+		controller: a,
+	}
+
+	if err := federator.Distribute(toCreate); err != nil {
+		klog.Errorf("Error recreating the ServiceImport for Service (%s/%s): %v", namespace, name, err)
+	}
+}
@@ -0,0 +1,43 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+)
+
+// ResyncAll triggers a resync of every ServiceExport, eg for support or after an upgrade, by populating a work queue with
+// all their keys. Each ServiceExport is re-derived and its ServiceImport rewritten as if it was just created. The number
+// of ServiceExports queued is returned.
+func (a *Controller) ResyncAll() (int, error) {
+	// ListResources waits for the informer cache to sync.
+	serviceExports, err := a.serviceExportSyncer.ListResources()
+	if err != nil {
+		return 0, errors.Wrap(err, "error listing ServiceExports")
+	}
+
+	for _, obj := range serviceExports {
+		a.queueServiceExportForResync(obj)
+	}
+
+	klog.Infof("Queued %d ServiceExports for resync", len(serviceExports))
+
+	return len(serviceExports), nil
+}
@@ -0,0 +1,108 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+var _ = Describe("Global resync", func() {
+	var (
+		t        *testDriver
+		services []*corev1.Service
+	)
+
+	BeforeEach(func() {
+		t = newTestDiver()
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+
+		second := t.service.DeepCopy()
+		second.Name = "nginx2"
+		second.Spec.ClusterIP = "10.253.9.2"
+
+		services = []*corev1.Service{t.service, second}
+
+		for _, service := range services {
+			t.service = service
+			t.serviceExport = &mcsv1a1.ServiceExport{ObjectMeta: metav1.ObjectMeta{Name: service.Name, Namespace: service.Namespace}}
+
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(service.Spec.ClusterIP)
+		}
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("a resync of all ServiceExports is triggered", func() {
+		It("should re-derive and rewrite the ServiceImports of all of them", func() {
+			// Out-of-band changes to the local ServiceImports aren't otherwise repaired as the ServiceExports don't change.
+			for _, service := range services {
+				obj := test.AwaitResource(t.cluster1.localServiceImportClient, localServiceImportName(service))
+				Expect(unstructured.SetNestedSlice(obj.Object, []interface{}{
+					map[string]interface{}{"port": int64(9999), "protocol": "TCP"},
+				}, "spec", "ports")).To(Succeed())
+
+				_, err := t.cluster1.localServiceImportClient.Update(context.TODO(), obj, metav1.UpdateOptions{})
+				Expect(err).To(Succeed())
+			}
+
+			for _, service := range services {
+				Consistently(func() int {
+					return localServiceImportPortCount(t, service)
+				}, 300*time.Millisecond).Should(Equal(1))
+			}
+
+			Expect(t.cluster1.agentController.ResyncAll()).To(Equal(len(services)))
+
+			for _, service := range services {
+				Eventually(func() int {
+					return localServiceImportPortCount(t, service)
+				}, 5).Should(Equal(len(service.Spec.Ports)))
+			}
+		})
+	})
+})
+
+func localServiceImportName(service *corev1.Service) string {
+	return service.Name + "-" + service.Namespace + "-" + clusterID1
+}
+
+func localServiceImportPortCount(t *testDriver, service *corev1.Service) int {
+	obj, err := t.cluster1.localServiceImportClient.Get(context.TODO(), localServiceImportName(service), metav1.GetOptions{})
+	Expect(err).To(Succeed())
+
+	ports, _, _ := unstructured.NestedSlice(obj.Object, "spec", "ports")
+
+	return len(ports)
+}
@@ -0,0 +1,357 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/fake"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+var _ = Describe("Broker ServiceImport server-side apply", func() {
+	var (
+		t      *testDriver
+		broker *serverSideApplyBroker
+	)
+
+	BeforeEach(func() {
+		t = newTestDiver()
+
+		broker = newServerSideApplyBroker(t.syncerConfig.BrokerClient.(*fake.DynamicClient))
+		t.cluster1.brokerClient = broker.clientFor()
+		t.cluster2.brokerClient = broker.clientFor()
+
+		t.service.Spec.Ports = []corev1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80}}
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	It("should apply the ServiceImport with the agent's field manager", func() {
+		t.createService()
+		t.createServiceExport()
+		t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+		Expect(broker.fieldManagers()).To(ContainElement("lighthouse-agent-" + clusterID1))
+		Expect(broker.clientSideWriteCount()).To(BeZero())
+	})
+
+	It("should allow another agent to update disjoint fields without conflict", func() {
+		t.createService()
+		t.createServiceExport()
+		t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+		name := t.service.Name + "-" + t.service.Namespace + "-" + clusterID1
+
+		By("Applying an annotation as another agent")
+
+		other := &unstructured.Unstructured{}
+		other.SetAPIVersion(mcsv1a1.GroupVersion.String())
+		other.SetKind("ServiceImport")
+		other.SetName(name)
+		other.SetNamespace(test.RemoteNamespace)
+		other.SetAnnotations(map[string]string{"other-agent": "true"})
+
+		data, err := json.Marshal(other)
+		Expect(err).To(Succeed())
+
+		_, err = broker.clientFor().Resource(serviceImportGVR).Namespace(test.RemoteNamespace).Patch(context.TODO(), name,
+			types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: "lighthouse-agent-" + clusterID2})
+		Expect(err).To(Succeed())
+
+		By("Renaming a Service port")
+
+		t.service.Spec.Ports[0].Name = "renamed"
+		t.updateService()
+
+		Eventually(func() []mcsv1a1.ServicePort {
+			return getServiceImport(t.brokerServiceImportClient, t.service).Spec.Ports
+		}).Should(ContainElement(HaveField("Name", "renamed")))
+
+		serviceImport := getServiceImport(t.brokerServiceImportClient, t.service)
+		Expect(serviceImport.Annotations).To(HaveKeyWithValue("other-agent", "true"))
+		Expect(serviceImport.Status.Clusters).To(HaveLen(1))
+		Expect(broker.clientSideWriteCount()).To(BeZero())
+	})
+})
+
+var _ = Describe("Broker ServiceImport without server-side apply support", func() {
+	var t *testDriver
+
+	BeforeEach(func() {
+		t = newTestDiver()
+		t.service.Spec.Ports = []corev1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80}}
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	It("should fall back to client-side writes", func() {
+		t.createService()
+		t.createServiceExport()
+		t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+		By("Renaming a Service port")
+
+		t.service.Spec.Ports[0].Name = "renamed"
+		t.updateService()
+
+		Eventually(func() []mcsv1a1.ServicePort {
+			return getServiceImport(t.brokerServiceImportClient, t.service).Spec.Ports
+		}).Should(ContainElement(HaveField("Name", "renamed")))
+	})
+})
+
+var serviceImportGVR = schema.GroupVersionResource{Group: mcsv1a1.GroupVersion.Group, Version: mcsv1a1.GroupVersion.Version,
+	Resource: "serviceimports"}
+
+// serverSideApplyBroker emulates the API server's handling of server-side apply for ServiceImports on top of the fake
+// client's tracker, which doesn't support it. Ownership is tracked per label, per annotation and for the spec and status as
+// a whole: a manager's apply sets the fields it applies, takes ownership of them and removes the fields it previously
+// applied but no longer does. Fields owned by other managers are left intact.
+type serverSideApplyBroker struct {
+	sync.Mutex
+	client           *fake.DynamicClient
+	owners           map[string]map[string]ownedField
+	managers         map[string]bool
+	clientSideWrites int
+}
+
+type ownedField struct {
+	fields  []string
+	manager string
+}
+
+func newServerSideApplyBroker(client *fake.DynamicClient) *serverSideApplyBroker {
+	return &serverSideApplyBroker{
+		client:   client,
+		owners:   map[string]map[string]ownedField{},
+		managers: map[string]bool{},
+	}
+}
+
+func (b *serverSideApplyBroker) clientFor() dynamic.Interface {
+	return &serverSideApplyBrokerClient{Interface: b.client, broker: b}
+}
+
+func (b *serverSideApplyBroker) fieldManagers() []string {
+	b.Lock()
+	defer b.Unlock()
+
+	managers := []string{}
+	for m := range b.managers {
+		managers = append(managers, m)
+	}
+
+	return managers
+}
+
+func (b *serverSideApplyBroker) clientSideWriteCount() int {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.clientSideWrites
+}
+
+func (b *serverSideApplyBroker) clientSideWrite() {
+	b.Lock()
+	defer b.Unlock()
+
+	b.clientSideWrites++
+}
+
+func (b *serverSideApplyBroker) apply(gvr schema.GroupVersionResource, namespace, name string, data []byte, manager string,
+	subresources ...string,
+) (*unstructured.Unstructured, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	applied := &unstructured.Unstructured{}
+	if err := json.Unmarshal(data, &applied.Object); err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	b.managers[manager] = true
+
+	isStatus := len(subresources) > 0 && subresources[0] == "status"
+
+	appliedFields := map[string]ownedField{}
+
+	if isStatus {
+		appliedFields["status"] = ownedField{fields: []string{"status"}, manager: manager}
+	} else {
+		for _, field := range []string{"labels", "annotations"} {
+			values, _, _ := unstructured.NestedStringMap(applied.Object, "metadata", field)
+			for k := range values {
+				f := []string{"metadata", field, k}
+				appliedFields[strings.Join(f, "|")] = ownedField{fields: f, manager: manager}
+			}
+		}
+
+		if _, found := applied.Object["spec"]; found {
+			appliedFields["spec"] = ownedField{fields: []string{"spec"}, manager: manager}
+		}
+	}
+
+	obj := &unstructured.Unstructured{}
+	create := false
+
+	existing, err := b.client.Tracker().Get(gvr, namespace, name)
+	if apierrors.IsNotFound(err) {
+		create = true
+
+		obj.SetAPIVersion(applied.GetAPIVersion())
+		obj.SetKind(applied.GetKind())
+		obj.SetName(name)
+		obj.SetNamespace(namespace)
+	} else if err != nil {
+		return nil, err
+	} else {
+		obj = existing.(*unstructured.Unstructured).DeepCopy()
+	}
+
+	key := namespace + "/" + name
+
+	owners := b.owners[key]
+	if owners == nil {
+		owners = map[string]ownedField{}
+		b.owners[key] = owners
+	}
+
+	for path, owned := range owners {
+		if owned.manager != manager || (path == "status") != isStatus {
+			continue
+		}
+
+		if _, stillApplied := appliedFields[path]; !stillApplied {
+			unstructured.RemoveNestedField(obj.Object, owned.fields...)
+			delete(owners, path)
+		}
+	}
+
+	for path, owned := range appliedFields {
+		value, _, _ := unstructured.NestedFieldCopy(applied.Object, owned.fields...)
+		Expect(unstructured.SetNestedField(obj.Object, value, owned.fields...)).To(Succeed())
+
+		owners[path] = owned
+	}
+
+	if create {
+		err = b.client.Tracker().Create(gvr, obj, namespace)
+	} else {
+		err = b.client.Tracker().Update(gvr, obj, namespace)
+	}
+
+	return obj, err
+}
+
+type serverSideApplyBrokerClient struct {
+	dynamic.Interface
+	broker *serverSideApplyBroker
+}
+
+type serverSideApplyBrokerNamespaceableClient struct {
+	dynamic.NamespaceableResourceInterface
+	broker *serverSideApplyBroker
+	gvr    schema.GroupVersionResource
+}
+
+type serverSideApplyBrokerResourceClient struct {
+	dynamic.ResourceInterface
+	broker    *serverSideApplyBroker
+	gvr       schema.GroupVersionResource
+	namespace string
+}
+
+func (c *serverSideApplyBrokerClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	resourceClient := c.Interface.Resource(gvr)
+	if gvr != serviceImportGVR {
+		return resourceClient
+	}
+
+	return &serverSideApplyBrokerNamespaceableClient{NamespaceableResourceInterface: resourceClient, broker: c.broker, gvr: gvr}
+}
+
+func (c *serverSideApplyBrokerNamespaceableClient) Namespace(namespace string) dynamic.ResourceInterface {
+	return &serverSideApplyBrokerResourceClient{
+		ResourceInterface: c.NamespaceableResourceInterface.Namespace(namespace),
+		broker:            c.broker,
+		gvr:               c.gvr,
+		namespace:         namespace,
+	}
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *serverSideApplyBrokerResourceClient) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions,
+	subresources ...string,
+) (*unstructured.Unstructured, error) {
+	c.broker.clientSideWrite()
+	return c.ResourceInterface.Create(ctx, obj, options, subresources...)
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *serverSideApplyBrokerResourceClient) Update(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions,
+	subresources ...string,
+) (*unstructured.Unstructured, error) {
+	c.broker.clientSideWrite()
+	return c.ResourceInterface.Update(ctx, obj, options, subresources...)
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *serverSideApplyBrokerResourceClient) UpdateStatus(ctx context.Context, obj *unstructured.Unstructured,
+	options metav1.UpdateOptions,
+) (*unstructured.Unstructured, error) {
+	c.broker.clientSideWrite()
+	return c.ResourceInterface.UpdateStatus(ctx, obj, options)
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *serverSideApplyBrokerResourceClient) Patch(ctx context.Context, name string, pt types.PatchType, data []byte,
+	options metav1.PatchOptions, subresources ...string,
+) (*unstructured.Unstructured, error) {
+	if pt != types.ApplyPatchType {
+		return c.ResourceInterface.Patch(ctx, name, pt, data, options, subresources...)
+	}
+
+	return c.broker.apply(c.gvr, c.namespace, name, data, options.FieldManager, subresources...)
+}
@@ -0,0 +1,414 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/federate"
+	"github.com/submariner-io/admiral/pkg/log"
+	"github.com/submariner-io/admiral/pkg/syncer"
+	"github.com/submariner-io/admiral/pkg/workqueue"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const (
+	serviceAliasAliasIndex  = "alias"
+	serviceAliasTargetIndex = "target"
+
+	// ServiceAliasResolvedCondition is the type of the ServiceAlias status condition reporting whether its alias resolves
+	// to the target clusterset service.
+	ServiceAliasResolvedCondition = "Resolved"
+)
+
+// ServiceAliasGVR is the resource of the cluster-scoped ServiceAlias CR. Its spec maps an alias service name and namespace
+// to a target clusterset service whose records the Lighthouse DNS server serves for the alias.
+var ServiceAliasGVR = schema.GroupVersionResource{
+	Group:    "lighthouse.submariner.io",
+	Version:  "v1alpha1",
+	Resource: "servicealiases",
+}
+
+type serviceAliasStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// serviceAliasController reports in the status of each ServiceAlias whether its alias resolves to the target service, ie
+// whether the alias is unambiguous, isn't itself an imported service, which takes precedence, and the target is imported
+// from at least one cluster. The ServiceAlias CRD may be installed after the agent starts so its discovery is retried.
+type serviceAliasController struct {
+	client        dynamic.ResourceInterface
+	queue         workqueue.Interface
+	importWatcher syncer.Interface
+	backoff       wait.Backoff
+	storeMutex    sync.RWMutex
+	store         cache.Indexer
+	importsMutex  sync.Mutex
+	imports       map[string]map[string]bool
+}
+
+// newServiceAliasController creates a serviceAliasController that tracks the local ServiceImports, which include those
+// imported from other clusters, to determine which clusterset services exist.
+func (a *Controller) newServiceAliasController(client dynamic.Interface, restMapper meta.RESTMapper, scheme *runtime.Scheme,
+) (*serviceAliasController, error) {
+	c := &serviceAliasController{
+		client: client.Resource(ServiceAliasGVR),
+		queue:  workqueue.New("ServiceAlias"),
+		backoff: wait.Backoff{
+			Duration: time.Second,
+			Factor:   2,
+			Steps:    math.MaxInt32,
+			Cap:      5 * time.Minute,
+		},
+		imports: map[string]map[string]bool{},
+	}
+
+	var err error
+
+	c.importWatcher, err = syncer.NewResourceSyncer(&syncer.ResourceSyncerConfig{
+		Name:            "ServiceImport alias target watcher",
+		SourceClient:    a.serviceImportSyncer.GetLocalClient(),
+		SourceNamespace: a.namespace,
+		Direction:       syncer.None,
+		RestMapper:      restMapper,
+		Federator:       federate.NewNoopFederator(),
+		ResourceType:    &mcsv1a1.ServiceImport{},
+		Transform:       c.onServiceImport,
+		Scheme:          scheme,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating ServiceImport alias target watcher")
+	}
+
+	return c, nil
+}
+
+// start starts the controller once the ServiceAlias resource is found. It doesn't block waiting for it.
+func (c *serviceAliasController) start(stopCh <-chan struct{}) error {
+	err := c.checkResource()
+	if apierrors.IsNotFound(err) {
+		klog.Infof("ServiceAlias resource not found - the ServiceAlias controller will start once it's installed")
+
+		go c.awaitResource(stopCh)
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return c.run(stopCh)
+}
+
+func (c *serviceAliasController) awaitResource(stopCh <-chan struct{}) {
+	backoff := c.backoff
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(backoff.Step()):
+		}
+
+		err := c.checkResource()
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+
+		if err == nil {
+			err = c.run(stopCh)
+		}
+
+		if err != nil {
+			klog.Errorf("Error starting the ServiceAlias controller: %v", err)
+			continue
+		}
+
+		return
+	}
+}
+
+func (c *serviceAliasController) checkResource() error {
+	_, err := c.client.List(context.TODO(), metav1.ListOptions{Limit: 1})
+
+	return errors.Wrap(err, "error listing ServiceAliases")
+}
+
+func (c *serviceAliasController) run(stopCh <-chan struct{}) error {
+	klog.Infof("Starting ServiceAlias controller")
+
+	if err := c.importWatcher.Start(stopCh); err != nil {
+		return errors.Wrap(err, "error starting ServiceImport alias target watcher")
+	}
+
+	store, informer := cache.NewIndexerInformer(&cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return c.client.List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return c.client.Watch(context.TODO(), options)
+		},
+	}, &unstructured.Unstructured{}, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc: c.onServiceAlias,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			c.onServiceAlias(oldObj)
+			c.onServiceAlias(newObj)
+		},
+		DeleteFunc: c.onServiceAlias,
+	}, cache.Indexers{
+		serviceAliasAliasIndex:  func(obj interface{}) ([]string, error) { return indexServiceAlias(obj, "alias"), nil },
+		serviceAliasTargetIndex: func(obj interface{}) ([]string, error) { return indexServiceAlias(obj, "target"), nil },
+	})
+
+	c.storeMutex.Lock()
+	c.store = store
+	c.storeMutex.Unlock()
+
+	go informer.Run(stopCh)
+
+	if ok := cache.WaitForCacheSync(stopCh, informer.HasSynced); !ok {
+		return fmt.Errorf("failed to wait for the ServiceAlias informer cache to sync")
+	}
+
+	c.queue.Run(stopCh, c.processServiceAlias)
+
+	go func() {
+		<-stopCh
+		c.queue.ShutDown()
+	}()
+
+	return nil
+}
+
+// onServiceAlias queues the given ServiceAlias and the others declaring the same alias, whose ambiguity may have changed.
+func (c *serviceAliasController) onServiceAlias(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	alias, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+
+	c.queue.Enqueue(alias)
+
+	name, namespace, ok := getServiceAliasRef(alias, "alias")
+	if ok {
+		c.enqueueReferencing(serviceAliasAliasIndex, namespace+"/"+name)
+	}
+}
+
+func (c *serviceAliasController) onServiceImport(obj runtime.Object, _ int, op syncer.Operation) (runtime.Object, bool) {
+	serviceImport := obj.(*mcsv1a1.ServiceImport)
+
+	cluster := serviceImport.GetLabels()[lhconstants.LighthouseLabelSourceCluster]
+	name := serviceImport.GetAnnotations()[lhconstants.OriginName]
+	namespace := serviceImport.GetAnnotations()[lhconstants.OriginNamespace]
+
+	if cluster == "" || name == "" {
+		return nil, false
+	}
+
+	key := namespace + "/" + name
+
+	c.importsMutex.Lock()
+
+	clusters := c.imports[key]
+
+	if op == syncer.Delete {
+		delete(clusters, cluster)
+
+		if len(clusters) == 0 {
+			delete(c.imports, key)
+		}
+	} else {
+		if clusters == nil {
+			clusters = map[string]bool{}
+			c.imports[key] = clusters
+		}
+
+		clusters[cluster] = true
+	}
+
+	c.importsMutex.Unlock()
+
+	c.enqueueReferencing(serviceAliasAliasIndex, key)
+	c.enqueueReferencing(serviceAliasTargetIndex, key)
+
+	return nil, false
+}
+
+func (c *serviceAliasController) enqueueReferencing(index, key string) {
+	store := c.getStore()
+	if store == nil {
+		return
+	}
+
+	objs, err := store.ByIndex(index, key)
+	if err != nil {
+		klog.Errorf("Error retrieving the ServiceAliases by %s %q: %v", index, key, err)
+		return
+	}
+
+	for _, obj := range objs {
+		c.queue.Enqueue(obj)
+	}
+}
+
+func (c *serviceAliasController) getStore() cache.Indexer {
+	c.storeMutex.RLock()
+	defer c.storeMutex.RUnlock()
+
+	return c.store
+}
+
+func (c *serviceAliasController) isImported(name, namespace string) bool {
+	c.importsMutex.Lock()
+	defer c.importsMutex.Unlock()
+
+	return len(c.imports[namespace+"/"+name]) > 0
+}
+
+func (c *serviceAliasController) processServiceAlias(key, _, _ string) (bool, error) {
+	obj, found, err := c.getStore().GetByKey(key)
+	if err != nil {
+		return true, errors.Wrapf(err, "error retrieving ServiceAlias %q", key)
+	}
+
+	if !found {
+		return false, nil
+	}
+
+	alias := obj.(*unstructured.Unstructured).DeepCopy()
+
+	status := &serviceAliasStatus{}
+
+	statusObj, _, _ := unstructured.NestedMap(alias.Object, "status")
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(statusObj, status); err != nil {
+		klog.Warningf("Ignoring the invalid status of ServiceAlias %q: %v", key, err)
+	}
+
+	condition := c.resolve(alias)
+
+	existing := meta.FindStatusCondition(status.Conditions, condition.Type)
+	if existing != nil && existing.Status == condition.Status && existing.Reason == condition.Reason &&
+		existing.Message == condition.Message {
+		return false, nil
+	}
+
+	klog.V(log.DEBUG).Infof("ServiceAlias %q %s: %s", key, condition.Reason, condition.Message)
+
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	statusObj, err = runtime.DefaultUnstructuredConverter.ToUnstructured(status)
+	if err != nil {
+		return false, errors.Wrapf(err, "error converting the status of ServiceAlias %q", key)
+	}
+
+	if err := unstructured.SetNestedMap(alias.Object, statusObj, "status"); err != nil {
+		return false, errors.Wrapf(err, "error setting the status of ServiceAlias %q", key)
+	}
+
+	_, err = c.client.UpdateStatus(context.TODO(), alias, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+
+	return err != nil, errors.Wrapf(err, "error updating the status of ServiceAlias %q", key)
+}
+
+func (c *serviceAliasController) resolve(alias *unstructured.Unstructured) metav1.Condition {
+	condition := metav1.Condition{
+		Type:   ServiceAliasResolvedCondition,
+		Status: metav1.ConditionFalse,
+	}
+
+	aliasName, aliasNamespace, aliasOK := getServiceAliasRef(alias, "alias")
+	targetName, targetNamespace, targetOK := getServiceAliasRef(alias, "target")
+
+	switch {
+	case !aliasOK || !targetOK:
+		condition.Reason = "InvalidServiceAlias"
+		condition.Message = "The alias and the target must both specify a name and namespace"
+	case c.countDeclaring(aliasName, aliasNamespace) > 1:
+		condition.Reason = "AmbiguousAlias"
+		condition.Message = fmt.Sprintf("The alias %s/%s is declared by more than one ServiceAlias", aliasNamespace, aliasName)
+	case c.isImported(aliasName, aliasNamespace):
+		condition.Reason = "AliasShadowed"
+		condition.Message = fmt.Sprintf("The alias %s/%s is an exported service, which takes precedence", aliasNamespace, aliasName)
+	case !c.isImported(targetName, targetNamespace):
+		condition.Reason = "TargetNotFound"
+		condition.Message = fmt.Sprintf("The target service %s/%s isn't exported by any cluster", targetNamespace, targetName)
+	default:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "TargetImported"
+		condition.Message = fmt.Sprintf("The alias resolves to the target service %s/%s", targetNamespace, targetName)
+	}
+
+	return condition
+}
+
+func (c *serviceAliasController) countDeclaring(name, namespace string) int {
+	objs, err := c.getStore().ByIndex(serviceAliasAliasIndex, namespace+"/"+name)
+	if err != nil {
+		return 0
+	}
+
+	return len(objs)
+}
+
+func indexServiceAlias(obj interface{}, field string) []string {
+	alias, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+
+	name, namespace, ok := getServiceAliasRef(alias, field)
+	if !ok {
+		return nil
+	}
+
+	return []string{namespace + "/" + name}
+}
+
+func getServiceAliasRef(alias *unstructured.Unstructured, field string) (name, namespace string, ok bool) {
+	name, _, _ = unstructured.NestedString(alias.Object, "spec", field, "name")
+	namespace, _, _ = unstructured.NestedString(alias.Object, "spec", field, "namespace")
+
+	return name, namespace, name != "" && namespace != ""
+}
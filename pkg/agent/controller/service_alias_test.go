@@ -0,0 +1,191 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/fake"
+	"github.com/submariner-io/lighthouse/pkg/agent/controller"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var _ = Describe("ServiceAlias", func() {
+	var (
+		t           *testDriver
+		aliasClient dynamic.ResourceInterface
+	)
+
+	BeforeEach(func() {
+		t = newTestDiver()
+		aliasClient = t.cluster1.localDynClient.Resource(controller.ServiceAliasGVR)
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("a ServiceAlias targets an exported service", func() {
+		JustBeforeEach(func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+		})
+
+		It("should report the alias as resolved", func() {
+			createServiceAlias(aliasClient, "alias1", "db", "legacy", t.service.Name, t.service.Namespace)
+			awaitServiceAliasResolved(aliasClient, "alias1", metav1.ConditionTrue, "TargetImported")
+		})
+
+		Context("and the service is subsequently unexported", func() {
+			It("should report the target as not found", func() {
+				createServiceAlias(aliasClient, "alias1", "db", "legacy", t.service.Name, t.service.Namespace)
+				awaitServiceAliasResolved(aliasClient, "alias1", metav1.ConditionTrue, "TargetImported")
+
+				t.deleteServiceExport()
+				awaitServiceAliasResolved(aliasClient, "alias1", metav1.ConditionFalse, "TargetNotFound")
+			})
+		})
+	})
+
+	When("a ServiceAlias targets a service that doesn't exist", func() {
+		It("should report the target as not found", func() {
+			createServiceAlias(aliasClient, "alias1", "db", "legacy", "nonexistent", serviceNamespace)
+			awaitServiceAliasResolved(aliasClient, "alias1", metav1.ConditionFalse, "TargetNotFound")
+		})
+
+		Context("and the service is subsequently exported", func() {
+			It("should report the alias as resolved", func() {
+				createServiceAlias(aliasClient, "alias1", "db", "legacy", t.service.Name, t.service.Namespace)
+				awaitServiceAliasResolved(aliasClient, "alias1", metav1.ConditionFalse, "TargetNotFound")
+
+				t.createService()
+				t.createServiceExport()
+				awaitServiceAliasResolved(aliasClient, "alias1", metav1.ConditionTrue, "TargetImported")
+			})
+		})
+	})
+
+	When("a ServiceAlias's alias is itself an exported service", func() {
+		JustBeforeEach(func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+		})
+
+		It("should report the alias as shadowed", func() {
+			createServiceAlias(aliasClient, "alias1", t.service.Name, t.service.Namespace, "postgres", "data")
+			awaitServiceAliasResolved(aliasClient, "alias1", metav1.ConditionFalse, "AliasShadowed")
+		})
+	})
+
+	When("the same alias is declared by two ServiceAliases", func() {
+		It("should report both as ambiguous until one is deleted", func() {
+			createServiceAlias(aliasClient, "alias1", "db", "legacy", "postgres", "data")
+			awaitServiceAliasResolved(aliasClient, "alias1", metav1.ConditionFalse, "TargetNotFound")
+
+			createServiceAlias(aliasClient, "alias2", "db", "legacy", "mysql", "data")
+			awaitServiceAliasResolved(aliasClient, "alias1", metav1.ConditionFalse, "AmbiguousAlias")
+			awaitServiceAliasResolved(aliasClient, "alias2", metav1.ConditionFalse, "AmbiguousAlias")
+
+			Expect(aliasClient.Delete(context.TODO(), "alias2", metav1.DeleteOptions{})).To(Succeed())
+			awaitServiceAliasResolved(aliasClient, "alias1", metav1.ConditionFalse, "TargetNotFound")
+		})
+	})
+
+	When("a ServiceAlias doesn't specify the target namespace", func() {
+		It("should report it as invalid", func() {
+			createServiceAlias(aliasClient, "alias1", "db", "legacy", "postgres", "")
+			awaitServiceAliasResolved(aliasClient, "alias1", metav1.ConditionFalse, "InvalidServiceAlias")
+		})
+	})
+
+	When("the ServiceAlias resource is installed after the agent starts", func() {
+		var aliasReactor *fake.FailingReactor
+
+		BeforeEach(func() {
+			aliasReactor = fake.NewFailingReactorForResource(&t.cluster1.localDynClient.(*fake.DynamicClient).Fake,
+				controller.ServiceAliasGVR.Resource)
+			aliasReactor.SetFailOnList(apierrors.NewNotFound(schema.GroupResource{}, ""))
+		})
+
+		It("should start reporting the ServiceAlias status", func() {
+			createServiceAlias(aliasClient, "alias1", "db", "legacy", "nonexistent", serviceNamespace)
+			Consistently(func() *metav1.Condition {
+				return getServiceAliasResolved(aliasClient, "alias1")
+			}, 300*time.Millisecond).Should(BeNil())
+
+			aliasReactor.SetFailOnList(nil)
+			awaitServiceAliasResolved(aliasClient, "alias1", metav1.ConditionFalse, "TargetNotFound")
+		})
+	})
+})
+
+func createServiceAlias(client dynamic.ResourceInterface, name, alias, namespace, target, targetNs string) {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(controller.ServiceAliasGVR.GroupVersion().String())
+	obj.SetKind("ServiceAlias")
+	obj.SetName(name)
+
+	Expect(unstructured.SetNestedStringMap(obj.Object, map[string]string{"name": alias, "namespace": namespace},
+		"spec", "alias")).To(Succeed())
+	Expect(unstructured.SetNestedStringMap(obj.Object, map[string]string{"name": target, "namespace": targetNs},
+		"spec", "target")).To(Succeed())
+
+	_, err := client.Create(context.TODO(), obj, metav1.CreateOptions{})
+	Expect(err).To(Succeed())
+}
+
+func getServiceAliasResolved(client dynamic.ResourceInterface, name string) *metav1.Condition {
+	obj, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+	Expect(err).To(Succeed())
+
+	status := &struct {
+		Conditions []metav1.Condition `json:"conditions"`
+	}{}
+
+	statusObj, _, _ := unstructured.NestedMap(obj.Object, "status")
+	Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(statusObj, status)).To(Succeed())
+
+	return meta.FindStatusCondition(status.Conditions, controller.ServiceAliasResolvedCondition)
+}
+
+func awaitServiceAliasResolved(client dynamic.ResourceInterface, name string, status metav1.ConditionStatus, reason string) {
+	Eventually(func() []string {
+		condition := getServiceAliasResolved(client, name)
+		if condition == nil {
+			return nil
+		}
+
+		return []string{string(condition.Status), condition.Reason}
+	}, 5).Should(Equal([]string{string(status), reason}))
+}
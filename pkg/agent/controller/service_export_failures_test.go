@@ -20,10 +20,20 @@ package controller_test
 
 import (
 	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/fake"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/testing"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
 )
 
 var _ = Describe("Service export failures", func() {
@@ -54,4 +64,140 @@ var _ = Describe("Service export failures", func() {
 			t.awaitServiceExported(t.service.Spec.ClusterIP)
 		})
 	})
+
+	When("writing the local ServiceImport initially fails", func() {
+		BeforeEach(func() {
+			t.cluster1.localServiceImportClient.PersistentFailOnCreate.Store("mock local create error")
+		})
+
+		It("should report LocalSyncFailed and eventually update the ServiceExport status", func() {
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "LocalSyncFailed"))
+			t.awaitNoServiceImport(t.brokerServiceImportClient)
+
+			t.cluster1.localServiceImportClient.PersistentFailOnCreate.Store("")
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+		})
+	})
+
+	When("writing the broker ServiceImport initially fails", func() {
+		BeforeEach(func() {
+			t.brokerServiceImportClient.PersistentFailOnCreate.Store("mock broker create error")
+		})
+
+		It("should report BrokerSyncFailed and eventually update the ServiceExport status", func() {
+			t.cluster1.awaitServiceImport(t.service, mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP)
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "BrokerSyncFailed"))
+
+			t.brokerServiceImportClient.PersistentFailOnCreate.Store("")
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+		})
+
+		Context("and the ServiceExport status updates are recorded", func() {
+			var syncedReported int32
+
+			BeforeEach(func() {
+				atomic.StoreInt32(&syncedReported, 0)
+
+				t.cluster1.localDynClient.(*fake.DynamicClient).PrependReactor("update", "serviceexports",
+					func(action testing.Action) (bool, runtime.Object, error) {
+						obj := action.(testing.UpdateAction).GetObject().(*unstructured.Unstructured)
+						conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+
+						for i := range conditions {
+							status, _, _ := unstructured.NestedString(conditions[i].(map[string]interface{}), "status")
+							if status == string(corev1.ConditionTrue) {
+								atomic.StoreInt32(&syncedReported, 1)
+							}
+						}
+
+						return false, nil, nil
+					})
+			})
+
+			It("should not report it as synced while only the local ServiceImport is written", func() {
+				t.cluster1.awaitServiceImport(t.service, mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP)
+				t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "BrokerSyncFailed"))
+				Expect(atomic.LoadInt32(&syncedReported)).To(BeZero())
+
+				t.brokerServiceImportClient.PersistentFailOnCreate.Store("")
+				t.awaitServiceExported(t.service.Spec.ClusterIP)
+				Expect(atomic.LoadInt32(&syncedReported)).To(Equal(int32(1)))
+			})
+		})
+
+		It("should record the structured last error and clear it on success", func() {
+			lastError := t.awaitLastExportError(Not(BeNil()))
+			Expect(lastError.Code).To(Equal("BrokerSyncFailed"))
+			Expect(lastError.Message).ToNot(BeEmpty())
+			Expect(lastError.Timestamp.IsZero()).To(BeFalse())
+
+			t.brokerServiceImportClient.PersistentFailOnCreate.Store("")
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+			t.awaitLastExportError(BeNil())
+		})
+	})
+
+	When("the agent initially isn't permitted to write the ServiceImport to the broker", func() {
+		var forbidden int32
+
+		BeforeEach(func() {
+			atomic.StoreInt32(&forbidden, 1)
+
+			t.syncerConfig.BrokerClient.(*fake.DynamicClient).PrependReactor("create", "serviceimports",
+				func(action testing.Action) (bool, runtime.Object, error) {
+					if atomic.LoadInt32(&forbidden) == 1 {
+						return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: mcsv1a1.GroupName, Resource: "serviceimports"},
+							t.service.Name, errors.New("fake RBAC denial"))
+					}
+
+					return false, nil, nil
+				})
+		})
+
+		It("should report BrokerPermissionDenied and eventually update the ServiceExport status", func() {
+			t.cluster1.awaitServiceImport(t.service, mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP)
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "BrokerPermissionDenied"))
+			t.awaitNoServiceImport(t.brokerServiceImportClient)
+
+			Expect(t.awaitLastExportError(Not(BeNil())).Code).To(Equal("BrokerPermissionDenied"))
+
+			atomic.StoreInt32(&forbidden, 0)
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+		})
+	})
+
+	When("the broker initially throttles writing the ServiceImport", func() {
+		var (
+			mutex    sync.Mutex
+			attempts []time.Time
+		)
+
+		BeforeEach(func() {
+			attempts = nil
+
+			t.syncerConfig.BrokerClient.(*fake.DynamicClient).PrependReactor("create", "serviceimports",
+				func(action testing.Action) (bool, runtime.Object, error) {
+					mutex.Lock()
+					defer mutex.Unlock()
+
+					attempts = append(attempts, time.Now())
+					if len(attempts) <= 2 {
+						return true, nil, apierrors.NewTooManyRequests("fake throttling", 1)
+					}
+
+					return false, nil, nil
+				})
+		})
+
+		It("should retry after the suggested delay and eventually update the ServiceExport status", func() {
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "BrokerSyncFailed"))
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			Expect(len(attempts)).To(BeNumerically(">=", 3))
+			Expect(attempts[2].Sub(attempts[1])).To(BeNumerically(">=", 900*time.Millisecond))
+		})
+	})
 })
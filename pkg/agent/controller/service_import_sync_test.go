@@ -19,8 +19,23 @@ limitations under the License.
 package controller_test
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	"github.com/submariner-io/lighthouse/pkg/agent/controller"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
 	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
 )
 
@@ -48,6 +63,18 @@ var _ = Describe("ServiceImport syncing", func() {
 			})
 		})
 
+		When("the Service has no ports", func() {
+			It("should sync a ServiceImport with an empty port list", func() {
+				t.service.Spec.Ports = nil
+				t.createService()
+				t.createServiceExport()
+				t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+				serviceImport := t.awaitBrokerServiceImport(mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP)
+				Expect(serviceImport.Spec.Ports).To(BeEmpty())
+			})
+		})
+
 		When("the Service doesn't initially exist", func() {
 			It("should initially update the ServiceExport status to Initialized and eventually sync a ServiceImport", func() {
 				t.createServiceExport()
@@ -57,6 +84,96 @@ var _ = Describe("ServiceImport syncing", func() {
 				t.awaitServiceExported(t.service.Spec.ClusterIP)
 			})
 		})
+
+		When("a Service with the same name only exists in another namespace", func() {
+			It("should include a hint with the namespace in the ServiceExport status", func() {
+				other := t.service.DeepCopy()
+				other.Namespace = "other-ns"
+				test.CreateResource(t.cluster1.dynamicServiceClient().Namespace(other.Namespace), other)
+
+				t.createServiceExport()
+
+				message := fmt.Sprintf("Service to be exported doesn't exist - a Service %q exists in namespace %s",
+					t.service.Name, other.Namespace)
+
+				cond := newServiceExportCondition(corev1.ConditionFalse, "ServiceUnavailable")
+				cond.Message = &message
+				t.awaitServiceExportStatus(cond)
+			})
+		})
+
+		When("a Service with the same name in another namespace was deleted", func() {
+			It("should not include a hint in the ServiceExport status", func() {
+				other := t.service.DeepCopy()
+				other.Namespace = "other-ns"
+				otherClient := t.cluster1.dynamicServiceClient().Namespace(other.Namespace)
+				test.CreateResource(otherClient, other)
+				Expect(otherClient.Delete(context.TODO(), other.Name, metav1.DeleteOptions{})).To(Succeed())
+
+				t.createServiceExport()
+
+				message := "Service to be exported doesn't exist"
+
+				cond := newServiceExportCondition(corev1.ConditionFalse, "ServiceUnavailable")
+				cond.Message = &message
+				t.awaitServiceExportStatus(cond)
+			})
+		})
+	})
+
+	When("syncing is paused", func() {
+		It("should not sync a ServiceImport until resumed", func() {
+			t.cluster1.agentController.Pause()
+			Expect(t.cluster1.agentController.IsPaused()).To(BeTrue())
+
+			t.createService()
+			t.createServiceExport()
+
+			t.ensureNoServiceImport(t.brokerServiceImportClient)
+			t.ensureNoServiceImport(t.cluster1.localServiceImportClient)
+
+			t.cluster1.agentController.Resume()
+			Expect(t.cluster1.agentController.IsPaused()).To(BeFalse())
+
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+		})
+
+		It("should not delete the broker ServiceImport of a deleted ServiceExport until resumed", func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			t.cluster1.agentController.Pause()
+
+			t.deleteServiceExport()
+
+			Consistently(func() error {
+				_, err := t.brokerServiceImportClient.Get(context.TODO(), t.service.Name+"-"+t.service.Namespace+"-"+clusterID1,
+					metav1.GetOptions{})
+				return err
+			}, 500*time.Millisecond).Should(Succeed())
+
+			t.cluster1.agentController.Resume()
+
+			t.awaitServiceUnexported()
+		})
+
+		It("should not restore a broker ServiceImport deleted out-of-band until resumed", func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			t.cluster1.agentController.Pause()
+
+			Expect(t.brokerServiceImportClient.Delete(context.TODO(),
+				t.service.Name+"-"+t.service.Namespace+"-"+clusterID1, metav1.DeleteOptions{})).To(Succeed())
+
+			t.ensureNoServiceImport(t.brokerServiceImportClient)
+
+			t.cluster1.agentController.Resume()
+
+			t.awaitBrokerServiceImport(mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP)
+		})
 	})
 
 	When("a ServiceExport is deleted after a ServiceImport is synced", func() {
@@ -70,6 +187,82 @@ var _ = Describe("ServiceImport syncing", func() {
 		})
 	})
 
+	When("the ServiceExports of two contributing clusters are deleted", func() {
+		It("should not leave any ServiceImport on the broker", func() {
+			t.createService()
+			t.createServiceExport()
+
+			_, err := t.cluster2.localKubeClient.CoreV1().Services(t.service.Namespace).Create(context.TODO(), t.service,
+				metav1.CreateOptions{})
+			Expect(err).To(Succeed())
+
+			test.CreateResource(t.cluster2.dynamicServiceClient().Namespace(t.service.Namespace), t.service)
+			test.CreateResource(t.cluster2.localServiceExportClient, t.serviceExport)
+
+			brokerImportName := func(clusterID string) string {
+				return t.service.Name + "-" + t.service.Namespace + "-" + clusterID
+			}
+
+			test.AwaitResource(t.brokerServiceImportClient, brokerImportName(clusterID1))
+			test.AwaitResource(t.brokerServiceImportClient, brokerImportName(clusterID2))
+
+			By("Deleting the first cluster's ServiceExport")
+
+			t.deleteServiceExport()
+			test.AwaitNoResource(t.brokerServiceImportClient, brokerImportName(clusterID1))
+
+			Consistently(func() error {
+				_, err := t.brokerServiceImportClient.Get(context.TODO(), brokerImportName(clusterID2), metav1.GetOptions{})
+				return err
+			}, 300*time.Millisecond).Should(Succeed())
+
+			By("Deleting the second cluster's ServiceExport")
+
+			Expect(t.cluster2.localServiceExportClient.Delete(context.TODO(), t.serviceExport.Name, metav1.DeleteOptions{})).To(Succeed())
+			test.AwaitNoResource(t.brokerServiceImportClient, brokerImportName(clusterID2))
+
+			Consistently(func() int {
+				list, err := t.brokerServiceImportClient.List(context.TODO(), metav1.ListOptions{})
+				Expect(err).To(Succeed())
+				return len(list.Items)
+			}, 300*time.Millisecond).Should(BeZero())
+		})
+	})
+
+	When("the local ServiceImport is deleted out-of-band", func() {
+		It("should recreate it", func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			Expect(t.cluster1.localServiceImportClient.Delete(context.TODO(),
+				t.service.Name+"-"+t.service.Namespace+"-"+clusterID1, metav1.DeleteOptions{})).To(Succeed())
+
+			t.cluster1.awaitServiceImport(t.service, mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP)
+
+			// Ensure the broker ServiceImport isn't left deleted.
+			t.ensureServiceImport(t.brokerServiceImportClient)
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+		})
+	})
+
+	When("the broker ServiceImport is deleted out-of-band", func() {
+		It("should restore it", func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			Expect(t.brokerServiceImportClient.Delete(context.TODO(),
+				t.service.Name+"-"+t.service.Namespace+"-"+clusterID1, metav1.DeleteOptions{})).To(Succeed())
+
+			t.awaitBrokerServiceImport(mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP)
+
+			// Ensure the other cluster's ServiceImport isn't left deleted.
+			t.ensureServiceImport(t.brokerServiceImportClient)
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+		})
+	})
+
 	When("an exported Service is deleted and recreated while the ServiceExport still exists", func() {
 		It("should delete and recreate the ServiceImport", func() {
 			t.createService()
@@ -85,6 +278,258 @@ var _ = Describe("ServiceImport syncing", func() {
 		})
 	})
 
+	When("a clusterset group is configured", func() {
+		BeforeEach(func() {
+			t.cluster1.agentSpec.ClustersetGroup = "blue"
+		})
+
+		It("should label the ServiceImport and EndpointSlice with the clusterset group", func() {
+			t.createService()
+			t.createEndpoints()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			Expect(t.awaitBrokerServiceImport(mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP).Labels).To(
+				HaveKeyWithValue(lhconstants.ClustersetGroupLabel, "blue"))
+			Expect(t.awaitBrokerEndpointSlice().Labels).To(HaveKeyWithValue(lhconstants.ClustersetGroupLabel, "blue"))
+		})
+	})
+
+	When("two clusterset groups share the broker", func() {
+		BeforeEach(func() {
+			t.cluster1.agentSpec.ClustersetGroup = "blue"
+			t.cluster2.agentSpec.ClustersetGroup = "green"
+		})
+
+		It("should not import the ServiceImport and EndpointSlice of the other group", func() {
+			t.createService()
+			t.createEndpoints()
+			t.createServiceExport()
+
+			t.cluster1.awaitServiceImport(t.service, mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP)
+			t.awaitBrokerServiceImport(mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP)
+			t.awaitBrokerEndpointSlice()
+
+			Consistently(func() bool {
+				_, err := t.cluster2.localServiceImportClient.Get(context.TODO(),
+					t.service.Name+"-"+t.service.Namespace+"-"+clusterID1, metav1.GetOptions{})
+				return apierrors.IsNotFound(err)
+			}, 300*time.Millisecond).Should(BeTrue())
+
+			Consistently(func() bool {
+				_, err := t.cluster2.localEndpointSliceClient.Get(context.TODO(), t.endpoints.Name+"-"+clusterID1, metav1.GetOptions{})
+				return apierrors.IsNotFound(err)
+			}, 300*time.Millisecond).Should(BeTrue())
+		})
+	})
+
+	When("both clusters are in the same clusterset group", func() {
+		BeforeEach(func() {
+			t.cluster1.agentSpec.ClustersetGroup = "blue"
+			t.cluster2.agentSpec.ClustersetGroup = "blue"
+		})
+
+		It("should import the ServiceImport and EndpointSlice", func() {
+			t.createService()
+			t.createEndpoints()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+			t.awaitEndpointSlice()
+		})
+	})
+
+	When("the hashed import name scheme is configured", func() {
+		BeforeEach(func() {
+			t.cluster1.agentSpec.ImportNameScheme = controller.HashedImportNameScheme
+		})
+
+		It("should sync a ServiceImport with a hashed name and the origin annotations", func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionTrue, ""))
+
+			var serviceImports []unstructured.Unstructured
+
+			Eventually(func() []unstructured.Unstructured {
+				list, err := t.brokerServiceImportClient.List(context.TODO(), metav1.ListOptions{})
+				Expect(err).To(Succeed())
+				serviceImports = list.Items
+
+				return serviceImports
+			}, 5).Should(HaveLen(1))
+
+			Expect(serviceImports[0].GetName()).To(MatchRegexp("^%s-[0-9a-f]{16}$", t.service.Name))
+			Expect(serviceImports[0].GetAnnotations()).To(HaveKeyWithValue(lhconstants.OriginName, t.service.Name))
+			Expect(serviceImports[0].GetAnnotations()).To(HaveKeyWithValue(lhconstants.OriginNamespace, t.service.Namespace))
+
+			t.deleteServiceExport()
+
+			Eventually(func() []unstructured.Unstructured {
+				list, err := t.brokerServiceImportClient.List(context.TODO(), metav1.ListOptions{})
+				Expect(err).To(Succeed())
+
+				return list.Items
+			}, 5).Should(BeEmpty())
+		})
+	})
+
+	When("the legacy import name would exceed the maximum resource name length", func() {
+		It("should sync ServiceImports with unique hashed names and the origin annotations", func() {
+			longName := strings.Repeat("a", 250)
+			names := []string{longName + "-x", longName + "-y"}
+
+			for _, name := range names {
+				t.service.Name = name
+				t.serviceExport.Name = name
+				t.createService()
+				t.createServiceExport()
+			}
+
+			var serviceImports []unstructured.Unstructured
+
+			Eventually(func() []unstructured.Unstructured {
+				list, err := t.brokerServiceImportClient.List(context.TODO(), metav1.ListOptions{})
+				Expect(err).To(Succeed())
+				serviceImports = list.Items
+
+				return serviceImports
+			}, 5).Should(HaveLen(2))
+
+			Expect(serviceImports[0].GetName()).ToNot(Equal(serviceImports[1].GetName()))
+
+			var origins []string
+
+			for i := range serviceImports {
+				Expect(validation.IsDNS1123Subdomain(serviceImports[i].GetName())).To(BeEmpty())
+				Expect(serviceImports[i].GetName()).To(MatchRegexp("^%s-[0-9a-f]{16}$", longName[:40]))
+				Expect(serviceImports[i].GetAnnotations()).To(HaveKeyWithValue(lhconstants.OriginNamespace, t.service.Namespace))
+
+				origins = append(origins, serviceImports[i].GetAnnotations()[lhconstants.OriginName])
+			}
+
+			Expect(origins).To(ConsistOf(names))
+		})
+	})
+
+	When("a protocol allowlist is configured", func() {
+		BeforeEach(func() {
+			t.cluster1.agentSpec.AllowedProtocols = []string{"tcp"}
+		})
+
+		Context("and the Service has ports with mixed protocols", func() {
+			BeforeEach(func() {
+				t.service.Spec.Ports = []corev1.ServicePort{
+					{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+					{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53},
+					{Name: "sctp", Protocol: corev1.ProtocolSCTP, Port: 9999},
+				}
+			})
+
+			It("should only sync the allowed ports", func() {
+				t.createService()
+				t.createServiceExport()
+				t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionTrue, ""))
+
+				for _, client := range []dynamic.ResourceInterface{t.brokerServiceImportClient, t.cluster2.localServiceImportClient} {
+					obj := test.AwaitResource(client, t.service.Name+"-"+t.service.Namespace+"-"+clusterID1)
+
+					serviceImport := &mcsv1a1.ServiceImport{}
+					Expect(scheme.Scheme.Convert(obj, serviceImport, nil)).To(Succeed())
+					Expect(serviceImport.Spec.Ports).To(Equal([]mcsv1a1.ServicePort{
+						{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+					}))
+				}
+			})
+		})
+
+		Context("and none of the Service's port protocols are allowed", func() {
+			BeforeEach(func() {
+				t.service.Spec.Ports = []corev1.ServicePort{{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53}}
+			})
+
+			It("should update the ServiceExport status to NoExportablePorts and not sync a ServiceImport", func() {
+				t.createService()
+				t.createServiceExport()
+				t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "NoExportablePorts"))
+				t.awaitNoServiceImport(t.brokerServiceImportClient)
+			})
+		})
+	})
+
+	When("the type of an exported Service flips between ClusterIP and headless", func() {
+		It("should recreate the ServiceImport with the new type and source the IPs accordingly", func() {
+			t.createService()
+			t.createEndpoints()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			serviceIP := t.service.Spec.ClusterIP
+
+			t.service.Spec.ClusterIP = corev1.ClusterIPNone
+			t.updateService()
+			t.awaitServiceImportType(mcsv1a1.Headless)
+			t.awaitHeadlessServiceImport()
+			t.awaitEndpointSlice()
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionTrue, ""))
+
+			t.service.Spec.ClusterIP = serviceIP
+			t.updateService()
+			t.awaitServiceImportType(mcsv1a1.ClusterSetIP)
+			t.awaitServiceExported(serviceIP)
+			t.awaitEndpointSlice()
+		})
+	})
+
+	When("a port of an exported Service is renamed", func() {
+		BeforeEach(func() {
+			t.service.Spec.Ports = []corev1.ServicePort{
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+				{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53},
+			}
+		})
+
+		It("should replace the port names in the ServiceImport", func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			t.service.Spec.Ports[0].Name = "web"
+			t.updateService()
+
+			for _, client := range []dynamic.ResourceInterface{t.brokerServiceImportClient, t.cluster2.localServiceImportClient} {
+				Eventually(func() []mcsv1a1.ServicePort {
+					obj := test.AwaitResource(client, t.service.Name+"-"+t.service.Namespace+"-"+clusterID1)
+
+					serviceImport := &mcsv1a1.ServiceImport{}
+					Expect(scheme.Scheme.Convert(obj, serviceImport, nil)).To(Succeed())
+
+					return serviceImport.Spec.Ports
+				}).Should(Equal([]mcsv1a1.ServicePort{
+					{Name: "web", Protocol: corev1.ProtocolTCP, Port: 80},
+					{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53},
+				}))
+			}
+
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionTrue, ""))
+		})
+	})
+
+	When("the force-resync annotation on a ServiceExport is bumped", func() {
+		It("should rewrite the ServiceImport", func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			t.serviceExport.Annotations = map[string]string{lhconstants.ForceResyncAnnotation: "1"}
+			test.UpdateResource(t.cluster1.localServiceExportClient, t.serviceExport)
+			t.awaitForceResyncHandled("1")
+
+			t.serviceExport.Annotations[lhconstants.ForceResyncAnnotation] = "2"
+			test.UpdateResource(t.cluster1.localServiceExportClient, t.serviceExport)
+			t.awaitForceResyncHandled("2")
+		})
+	})
+
 	When("the ServiceImport sync initially fails", func() {
 		BeforeEach(func() {
 			t.cluster1.localServiceImportClient.PersistentFailOnCreate.Store("mock create error")
@@ -94,7 +539,7 @@ var _ = Describe("ServiceImport syncing", func() {
 			t.createService()
 			t.createServiceExport()
 
-			message := "AwaitingSync"
+			message := "LocalSyncFailed"
 			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, message))
 
 			t.awaitNotServiceExportStatus(&mcsv1a1.ServiceExportCondition{
@@ -122,6 +567,204 @@ var _ = Describe("ServiceImport syncing", func() {
 		})
 	})
 
+	When("a ServiceExport is created for a Service annotated as not exportable", func() {
+		BeforeEach(func() {
+			t.service.Annotations = map[string]string{lhconstants.ExportableAnnotation: "false"}
+		})
+
+		It("should update the ServiceExport status and not sync a ServiceImport", func() {
+			t.createService()
+			t.createServiceExport()
+
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "ServiceNotExportable"))
+			t.awaitNoServiceImport(t.brokerServiceImportClient)
+			t.awaitNoServiceImport(t.cluster1.localServiceImportClient)
+		})
+	})
+
+	When("a ServiceExport is created for an ExternalName Service", func() {
+		BeforeEach(func() {
+			t.service.Spec.Type = corev1.ServiceTypeExternalName
+			t.service.Spec.ExternalName = "db.example.com"
+			t.service.Spec.ClusterIP = ""
+		})
+
+		It("should sync a ClusterSetIP ServiceImport with the external name", func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionTrue, ""))
+
+			for _, client := range []dynamic.ResourceInterface{t.brokerServiceImportClient, t.cluster2.localServiceImportClient} {
+				obj := test.AwaitResource(client, t.service.Name+"-"+t.service.Namespace+"-"+clusterID1)
+
+				serviceImport := &mcsv1a1.ServiceImport{}
+				Expect(scheme.Scheme.Convert(obj, serviceImport, nil)).To(Succeed())
+				Expect(serviceImport.Spec.Type).To(Equal(mcsv1a1.ClusterSetIP))
+				Expect(serviceImport.Spec.IPs).To(BeEmpty())
+				Expect(serviceImport.Annotations).To(HaveKeyWithValue(lhconstants.ExternalNameAnnotation, "db.example.com"))
+			}
+		})
+	})
+
+	When("a ServiceExport is created for a Service with an empty ClusterIP and ClusterIPs set", func() {
+		BeforeEach(func() {
+			t.service.Spec.ClusterIPs = []string{t.service.Spec.ClusterIP}
+			t.service.Spec.ClusterIP = ""
+		})
+
+		It("should sync a ClusterSetIP ServiceImport with the IP from ClusterIPs", func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIPs[0])
+		})
+	})
+
+	When("a ServiceExport is created for a Service that doesn't have a ClusterIP assigned yet", func() {
+		var clusterIP string
+
+		BeforeEach(func() {
+			clusterIP = t.service.Spec.ClusterIP
+			t.service.Spec.ClusterIP = ""
+		})
+
+		It("should not sync a ServiceImport until a ClusterIP is assigned", func() {
+			t.createService()
+			t.createServiceExport()
+
+			t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "ServiceIPPending"))
+			t.awaitNoServiceImport(t.brokerServiceImportClient)
+
+			t.service.Spec.ClusterIP = clusterIP
+			t.updateService()
+
+			t.awaitServiceExported(clusterIP)
+		})
+	})
+
+	When("the Service informer is restricted by a label selector", func() {
+		BeforeEach(func() {
+			t.cluster1.agentSpec.ServiceLabelSelector = "export=true"
+		})
+
+		When("the exported Service doesn't match", func() {
+			BeforeEach(func() {
+				t.createService()
+			})
+
+			It("should not sync a ServiceImport", func() {
+				t.createServiceExport()
+				t.awaitServiceUnavailableStatus()
+				t.awaitNoServiceImport(t.brokerServiceImportClient)
+			})
+
+			Context("and its labels subsequently change to match", func() {
+				It("should sync a ServiceImport", func() {
+					t.createServiceExport()
+					t.awaitServiceUnavailableStatus()
+
+					t.service.Labels = map[string]string{"export": "true"}
+					t.updateService()
+
+					t.awaitServiceExported(t.service.Spec.ClusterIP)
+				})
+			})
+		})
+
+		When("the exported Service matches", func() {
+			BeforeEach(func() {
+				t.service.Labels = map[string]string{"export": "true"}
+				t.createService()
+			})
+
+			It("should sync a ServiceImport", func() {
+				t.createServiceExport()
+				t.awaitServiceExported(t.service.Spec.ClusterIP)
+			})
+
+			Context("and its labels subsequently change to no longer match", func() {
+				It("should delete the ServiceImport", func() {
+					t.createServiceExport()
+					t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+					t.service.Labels = map[string]string{"export": "false"}
+					t.updateService()
+
+					t.awaitServiceUnexported()
+					t.awaitServiceUnavailableStatus()
+				})
+			})
+		})
+	})
+
+	When("an export label selector is configured", func() {
+		BeforeEach(func() {
+			t.cluster1.agentSpec.ExportLabelSelector = "lighthouse.submariner.io/export=true"
+		})
+
+		When("the exported Service matches", func() {
+			BeforeEach(func() {
+				t.service.Labels = map[string]string{"lighthouse.submariner.io/export": "true"}
+				t.createService()
+				t.createServiceExport()
+			})
+
+			It("should sync a ServiceImport", func() {
+				t.awaitServiceExported(t.service.Spec.ClusterIP)
+			})
+
+			Context("and the label is subsequently removed", func() {
+				It("should delete the ServiceImport and set the ServiceNotSelected condition", func() {
+					t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+					t.service.Labels = nil
+					t.updateService()
+
+					t.awaitServiceUnexported()
+					t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "ServiceNotSelected"))
+				})
+			})
+		})
+
+		When("the exported Service doesn't match", func() {
+			BeforeEach(func() {
+				t.createService()
+				t.createServiceExport()
+			})
+
+			It("should not sync a ServiceImport and set the ServiceNotSelected condition", func() {
+				t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "ServiceNotSelected"))
+				t.awaitNoServiceImport(t.brokerServiceImportClient)
+			})
+
+			Context("and the label is subsequently added", func() {
+				It("should sync a ServiceImport", func() {
+					t.awaitServiceExportStatus(newServiceExportCondition(corev1.ConditionFalse, "ServiceNotSelected"))
+
+					t.service.Labels = map[string]string{"lighthouse.submariner.io/export": "true"}
+					t.updateService()
+
+					t.awaitServiceExported(t.service.Spec.ClusterIP)
+				})
+			})
+		})
+	})
+
+	When("a Service specifies an external traffic policy", func() {
+		BeforeEach(func() {
+			t.service.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeLocal
+		})
+
+		It("should record the policy on the ServiceImport", func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			serviceImport := t.awaitBrokerServiceImport(mcsv1a1.ClusterSetIP, t.service.Spec.ClusterIP)
+			Expect(serviceImport.Annotations).To(HaveKeyWithValue(lhconstants.ExternalTrafficPolicyAnnotation,
+				string(corev1.ServiceExternalTrafficPolicyTypeLocal)))
+		})
+	})
+
 	When("a Service has port information", func() {
 		BeforeEach(func() {
 			t.service.Spec.Ports = []corev1.ServicePort{
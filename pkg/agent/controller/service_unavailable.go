@@ -0,0 +1,161 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/submariner-io/admiral/pkg/log"
+	"github.com/submariner-io/admiral/pkg/syncer"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const serviceUnavailableMsg = "Service to be exported doesn't exist"
+
+// serviceUnavailableMessage returns the ServiceUnavailable condition message for an exported Service that doesn't exist.
+// If a Service with the same name exists in other namespaces, they're included as a hint as the ServiceExport was likely
+// created in the wrong namespace. Each namespace tracked for the name is confirmed by a lookup in the Service informer's
+// cache rather than listing every Service.
+func (a *Controller) serviceUnavailableMessage(name, namespace string) string {
+	namespaces := []string{}
+
+	for _, ns := range a.getServiceNamespaces(name) {
+		if ns == namespace {
+			continue
+		}
+
+		_, found, err := a.getService(name, ns)
+		if err != nil {
+			klog.Errorf("Error retrieving Service (%s/%s): %v", ns, name, err)
+			continue
+		}
+
+		if found {
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	if len(namespaces) == 0 {
+		return serviceUnavailableMsg
+	}
+
+	sort.Strings(namespaces)
+
+	return fmt.Sprintf("%s - a Service %q exists in namespace %s", serviceUnavailableMsg, name,
+		strings.Join(namespaces, ", "))
+}
+
+// trackServiceNamespace records the namespace of a Service by name, or removes it when the Service is deleted.
+func (a *Controller) trackServiceNamespace(svc *corev1.Service, op syncer.Operation) {
+	a.serviceNamespacesMutex.Lock()
+	defer a.serviceNamespacesMutex.Unlock()
+
+	namespaces := a.serviceNamespaces[svc.Name]
+
+	if op == syncer.Delete {
+		delete(namespaces, svc.Namespace)
+
+		if len(namespaces) == 0 {
+			delete(a.serviceNamespaces, svc.Name)
+		}
+
+		return
+	}
+
+	if namespaces == nil {
+		namespaces = map[string]bool{}
+		a.serviceNamespaces[svc.Name] = namespaces
+	}
+
+	namespaces[svc.Namespace] = true
+}
+
+// getServiceNamespaces returns the namespaces in which a Service with the given name was observed.
+func (a *Controller) getServiceNamespaces(name string) []string {
+	a.serviceNamespacesMutex.Lock()
+	defer a.serviceNamespacesMutex.Unlock()
+
+	namespaces := make([]string, 0, len(a.serviceNamespaces[name]))
+	for ns := range a.serviceNamespaces[name] {
+		namespaces = append(namespaces, ns)
+	}
+
+	return namespaces
+}
+
+// getService returns the Service from the Service informer's cache. A Service that doesn't match the Service label selector
+// is treated as non-existent, even if the cache hasn't observed that it no longer matches yet.
+func (a *Controller) getService(name, namespace string) (runtime.Object, bool, error) {
+	obj, found, err := a.serviceSyncer.GetResource(name, namespace)
+	if err != nil || !found {
+		return nil, false, err // nolint:wrapcheck // Let the caller wrap it.
+	}
+
+	if !a.serviceSelector.Matches(labels.Set(obj.(*corev1.Service).Labels)) {
+		return nil, false, nil
+	}
+
+	return obj, true, nil
+}
+
+// onServiceCreated queues the ServiceExport of a Service that appeared for resync if it was reported as unavailable, so
+// it's exported right away rather than on the next requeue with backoff. Besides a newly created Service, this is the
+// case for a Service whose labels changed to match the Service label selector that restricts the Service informer.
+func (a *Controller) onServiceCreated(svc *corev1.Service) {
+	obj, found, err := a.serviceExportSyncer.GetResource(svc.Name, svc.Namespace)
+	if err != nil {
+		klog.Errorf("Error retrieving ServiceExport for Service (%s/%s): %v", svc.Namespace, svc.Name, err)
+		return
+	}
+
+	if !found || getLastExportConditionReason(obj.(*mcsv1a1.ServiceExport)) != serviceUnavailable {
+		return
+	}
+
+	klog.V(log.DEBUG).Infof("Unavailable exported Service %s/%s now exists - queueing its ServiceExport for resync",
+		svc.Namespace, svc.Name)
+
+	a.queueServiceExportForResync(obj)
+}
+
+// onServiceNoLongerMatched handles an exported Service whose labels no longer match the Service label selector by deleting
+// its ServiceImport as the Service is treated as non-existent. The API server reports such a Service as deleted to the
+// restricted Service informer but this handles an update, eg from a watch that doesn't filter by label.
+func (a *Controller) onServiceNoLongerMatched(svcExport *mcsv1a1.ServiceExport, svc *corev1.Service) (runtime.Object, bool) {
+	klog.Infof("Exported Service %s/%s no longer matches the Service label selector - deleting the ServiceImport", svc.Namespace,
+		svc.Name)
+
+	err := a.serviceImportSyncer.GetLocalFederator().Delete(a.newServiceImport(svcExport.Name, svcExport.Namespace))
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Error deleting ServiceImport for Service (%s/%s): %v", svc.Namespace, svc.Name, err)
+		return nil, true
+	}
+
+	a.updateExportedServiceStatus(svcExport.Name, svcExport.Namespace, corev1.ConditionFalse, serviceUnavailable,
+		a.serviceUnavailableMessage(svcExport.Name, svcExport.Namespace))
+
+	return nil, false
+}
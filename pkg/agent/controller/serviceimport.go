@@ -37,11 +37,14 @@ func newServiceImportController(spec *AgentSpecification, serviceSyncer syncer.I
 	localClient dynamic.Interface, scheme *runtime.Scheme,
 ) (*ServiceImportController, error) {
 	controller := &ServiceImportController{
-		serviceSyncer: serviceSyncer,
-		localClient:   localClient,
-		restMapper:    restMapper,
-		clusterID:     spec.ClusterID,
-		scheme:        scheme,
+		serviceSyncer:    serviceSyncer,
+		localClient:      localClient,
+		restMapper:       restMapper,
+		clusterID:        spec.ClusterID,
+		scheme:           scheme,
+		emptyGracePeriod: spec.EmptyEndpointsGracePeriod,
+		maxEndpoints:     spec.MaxEndpointsPerImport,
+		compactEndpoints: spec.CompactEndpoints,
 	}
 
 	var err error
@@ -50,7 +53,7 @@ func newServiceImportController(spec *AgentSpecification, serviceSyncer syncer.I
 		Name:            "ServiceImport watcher",
 		SourceClient:    localClient,
 		SourceNamespace: spec.Namespace,
-		Direction:       syncer.LocalToRemote,
+		Direction:       syncer.None,
 		RestMapper:      restMapper,
 		Federator:       federate.NewNoopFederator(),
 		ResourceType:    &mcsv1a1.ServiceImport{},
@@ -98,9 +101,25 @@ func (c *ServiceImportController) start(stopCh <-chan struct{}) error {
 }
 
 func (c *ServiceImportController) serviceImportCreatedOrUpdated(serviceImport *mcsv1a1.ServiceImport, key string) bool {
-	if _, found := c.endpointControllers.Load(key); found {
-		klog.V(log.DEBUG).Infof("The endpoint controller is already running for %q", key)
-		return false
+	if obj, found := c.endpointControllers.Load(key); found {
+		endpointController := obj.(*EndpointController)
+
+		// The ServiceImport may have been recreated with a different type, eg if the exported Service became headless, and
+		// the deletion coalesced with the re-creation so the endpoint controller is restarted to source the IPs accordingly.
+		if endpointController.isHeadless == (serviceImport.Spec.Type == mcsv1a1.Headless) {
+			klog.V(log.DEBUG).Infof("The endpoint controller is already running for %q", key)
+
+			if endpointController.setEndpointExclusion(serviceImport.Annotations) {
+				endpointController.resync()
+			}
+
+			return false
+		}
+
+		klog.Infof("The type of ServiceImport %q changed to %q - restarting the endpoint controller", key, serviceImport.Spec.Type)
+
+		c.endpointControllers.Delete(key)
+		endpointController.stop()
 	}
 
 	if serviceImport.GetLabels()[lhconstants.LighthouseLabelSourceCluster] != c.clusterID {
@@ -112,7 +131,8 @@ func (c *ServiceImportController) serviceImportCreatedOrUpdated(serviceImport *m
 	serviceName := annotations[lhconstants.OriginName]
 
 	endpointController, err := startEndpointController(c.localClient, c.restMapper, c.scheme,
-		serviceImport, serviceNameSpace, serviceName, c.clusterID, c.globalIngressIPCache)
+		serviceImport, serviceNameSpace, serviceName, c.clusterID, c.globalIngressIPCache, c.emptyGracePeriod,
+		c.maxEndpoints, c.compactEndpoints)
 	if err != nil {
 		klog.Errorf(err.Error())
 		return true
@@ -123,8 +143,17 @@ func (c *ServiceImportController) serviceImportCreatedOrUpdated(serviceImport *m
 	return false
 }
 
+// resyncEndpoints re-derives the EndpointSlice for the local ServiceImport with the given key, if any.
+func (c *ServiceImportController) resyncEndpoints(key string) {
+	if obj, found := c.endpointControllers.Load(key); found {
+		obj.(*EndpointController).resync()
+	}
+}
+
 func (c *ServiceImportController) serviceImportDeleted(serviceImport *mcsv1a1.ServiceImport, key string) {
-	if serviceImport.GetLabels()[lhconstants.LighthouseLabelSourceCluster] != c.clusterID {
+	sourceCluster := serviceImport.GetLabels()[lhconstants.LighthouseLabelSourceCluster]
+	if sourceCluster != c.clusterID {
+		c.remoteServiceImportDeleted(serviceImport, sourceCluster)
 		return
 	}
 
@@ -134,6 +163,22 @@ func (c *ServiceImportController) serviceImportDeleted(serviceImport *mcsv1a1.Se
 	}
 }
 
+// remoteServiceImportDeleted deletes the local EndpointSlices mirrored from the source cluster of a remote headless
+// ServiceImport. These are normally removed when the EndpointSlice is deleted from the broker but this ensures they don't
+// linger if that event was missed.
+func (c *ServiceImportController) remoteServiceImportDeleted(serviceImport *mcsv1a1.ServiceImport, sourceCluster string) {
+	if sourceCluster == "" || serviceImport.Spec.Type != mcsv1a1.Headless {
+		return
+	}
+
+	annotations := serviceImport.GetAnnotations()
+
+	klog.V(log.DEBUG).Infof("Remote headless ServiceImport %q from cluster %q deleted - deleting mirrored EndpointSlices",
+		serviceImport.Name, sourceCluster)
+
+	deleteEndpointSlices(c.localClient, annotations[lhconstants.OriginNamespace], annotations[lhconstants.OriginName], sourceCluster)
+}
+
 func (c *ServiceImportController) serviceImportToEndpointController(obj runtime.Object, numRequeues int,
 	op syncer.Operation,
 ) (runtime.Object, bool) {
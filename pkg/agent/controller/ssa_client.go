@@ -0,0 +1,159 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+const brokerFieldManagerPrefix = "lighthouse-agent-"
+
+// serverSideApplyClient wraps the broker client so that resources of the given type are created and updated via
+// server-side apply with a stable field manager per cluster. The API server then merges the writes of the agents by field
+// ownership so concurrent writes don't fail with conflicts or clobber fields owned by other agents. A broker that doesn't
+// support server-side apply rejects the patch type, after which the client-side writes are used.
+type serverSideApplyClient struct {
+	dynamic.Interface
+	gvr          schema.GroupVersionResource
+	fieldManager string
+	// unsupported is set to 1 once the broker rejected server-side apply.
+	unsupported *int32
+}
+
+type serverSideApplyNamespaceableClient struct {
+	dynamic.NamespaceableResourceInterface
+	fieldManager string
+	unsupported  *int32
+}
+
+type serverSideApplyResourceClient struct {
+	dynamic.ResourceInterface
+	fieldManager string
+	unsupported  *int32
+}
+
+func newServerSideApplyClient(client dynamic.Interface, gvr schema.GroupVersionResource, clusterID string) dynamic.Interface {
+	return &serverSideApplyClient{
+		Interface:    client,
+		gvr:          gvr,
+		fieldManager: brokerFieldManagerPrefix + clusterID,
+		unsupported:  new(int32),
+	}
+}
+
+func (c *serverSideApplyClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	resourceClient := c.Interface.Resource(gvr)
+	if gvr != c.gvr {
+		return resourceClient
+	}
+
+	return &serverSideApplyNamespaceableClient{
+		NamespaceableResourceInterface: resourceClient,
+		fieldManager:                   c.fieldManager,
+		unsupported:                    c.unsupported,
+	}
+}
+
+func (c *serverSideApplyNamespaceableClient) Namespace(namespace string) dynamic.ResourceInterface {
+	return &serverSideApplyResourceClient{
+		ResourceInterface: c.NamespaceableResourceInterface.Namespace(namespace),
+		fieldManager:      c.fieldManager,
+		unsupported:       c.unsupported,
+	}
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *serverSideApplyResourceClient) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions,
+	subresources ...string,
+) (*unstructured.Unstructured, error) {
+	if applied, err, ok := c.apply(ctx, obj, subresources...); ok {
+		return applied, err
+	}
+
+	return c.ResourceInterface.Create(ctx, obj, options, subresources...) // nolint:wrapcheck // Let the caller wrap it.
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *serverSideApplyResourceClient) Update(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions,
+	subresources ...string,
+) (*unstructured.Unstructured, error) {
+	if applied, err, ok := c.apply(ctx, obj, subresources...); ok {
+		return applied, err
+	}
+
+	return c.ResourceInterface.Update(ctx, obj, options, subresources...) // nolint:wrapcheck // Let the caller wrap it.
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *serverSideApplyResourceClient) UpdateStatus(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions,
+) (*unstructured.Unstructured, error) {
+	if applied, err, ok := c.apply(ctx, obj, "status"); ok {
+		return applied, err
+	}
+
+	return c.ResourceInterface.UpdateStatus(ctx, obj, options) // nolint:wrapcheck // Let the caller wrap it.
+}
+
+// apply writes the object via server-side apply. It returns false if the broker doesn't support it, in which case the
+// caller falls back to the client-side write.
+// nolint:revive // error-return - the bool indicates whether the result applies.
+func (c *serverSideApplyResourceClient) apply(ctx context.Context, obj *unstructured.Unstructured, subresources ...string,
+) (*unstructured.Unstructured, error, bool) {
+	if atomic.LoadInt32(c.unsupported) == 1 {
+		return nil, nil, false
+	}
+
+	// The server-maintained metadata would otherwise be applied as preconditions, eg the resource version, or rejected.
+	toApply := obj.DeepCopy()
+	toApply.SetResourceVersion("")
+	toApply.SetUID("")
+	toApply.SetCreationTimestamp(metav1.Time{})
+	toApply.SetManagedFields(nil)
+	toApply.SetGeneration(0)
+
+	data, err := json.Marshal(toApply)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error marshalling %q", obj.GetName()), true
+	}
+
+	force := true
+
+	applied, err := c.ResourceInterface.Patch(ctx, obj.GetName(), types.ApplyPatchType, data,
+		metav1.PatchOptions{FieldManager: c.fieldManager, Force: &force}, subresources...)
+	if apierrors.IsUnsupportedMediaType(err) {
+		if atomic.CompareAndSwapInt32(c.unsupported, 0, 1) {
+			klog.Warningf("The broker doesn't support server-side apply - falling back to client-side writes: %v", err)
+		}
+
+		return nil, nil, false
+	}
+
+	return applied, err, true // nolint:wrapcheck // Let the caller wrap it.
+}
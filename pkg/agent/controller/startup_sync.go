@@ -0,0 +1,83 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/submariner-io/admiral/pkg/syncer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+// syncUnprocessedServiceExports lists the ServiceExports from the API server, once the informer caches have synced, and
+// syncs any that haven't been processed. On a fresh install, a ServiceExport created the instant its CRD is registered may
+// be missed by the informer so this ensures pre-existing ServiceExports are exported rather than relying solely on watch
+// events. A ServiceExport is deemed unprocessed if it has no status conditions and no local ServiceImport.
+func (a *Controller) syncUnprocessedServiceExports() {
+	// ListResources waits for the informer cache to sync.
+	if _, err := a.serviceExportSyncer.ListResources(); err != nil {
+		klog.Errorf("Unable to sync unprocessed ServiceExports: %v", err)
+		return
+	}
+
+	list, err := a.serviceExportClient.Namespace(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		klog.Errorf("Error listing ServiceExports: %v", err)
+		return
+	}
+
+	federator := &localServiceImportFederator{
+		Federator:  a.serviceImportSyncer.GetLocalFederator(),
+		controller: a,
+	}
+
+	for i := range list.Items {
+		svcExport := &mcsv1a1.ServiceExport{}
+
+		err := a.serviceImportController.scheme.Convert(&list.Items[i], svcExport, nil)
+		if err != nil {
+			klog.Errorf("Error converting ServiceExport %q: %v", list.Items[i].GetName(), err)
+			continue
+		}
+
+		if len(svcExport.Status.Conditions) > 0 {
+			continue
+		}
+
+		_, found, err := a.serviceImportSyncer.GetLocalResource(a.getObjectNameWithClusterID(svcExport.Name, svcExport.Namespace),
+			a.namespace, &mcsv1a1.ServiceImport{})
+		if err != nil || found {
+			continue
+		}
+
+		klog.Infof("ServiceExport (%s/%s) hasn't been processed - syncing it", svcExport.Namespace, svcExport.Name)
+
+		serviceImport, _ := a.serviceExportToServiceImport(svcExport, 0, syncer.Create)
+		if serviceImport == nil {
+			continue
+		}
+
+		if err := federator.Distribute(serviceImport); err != nil {
+			klog.Errorf("Error syncing the ServiceImport for ServiceExport (%s/%s): %v", svcExport.Namespace, svcExport.Name, err)
+			continue
+		}
+	}
+}
@@ -0,0 +1,89 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"net"
+
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const (
+	invalidStaticIP  = "InvalidStaticIP"
+	staticIPConflict = "StaticIPConflict"
+)
+
+// getServiceExportStaticIP returns the static clusterset IP requested via the ServiceExport annotation, if any, which is
+// resolved regardless of the cluster backing the service. If it's not a valid IPv4 or IPv6 address, the Service isn't
+// exported with a ClusterSetIP or another cluster already declared a different static IP for the service, a non-empty
+// reason and message are returned.
+func (a *Controller) getServiceExportStaticIP(svcExport *mcsv1a1.ServiceExport, svc *corev1.Service) (staticIP, reason, msg string) {
+	staticIP, ok := svcExport.GetAnnotations()[lhconstants.StaticClustersetIPAnnotation]
+	if !ok {
+		return "", "", ""
+	}
+
+	ip := net.ParseIP(staticIP)
+	if ip == nil {
+		return "", invalidStaticIP, fmt.Sprintf("The static clusterset IP %q is invalid: it must be an IPv4 or IPv6 address", staticIP)
+	}
+
+	if svcType, _ := getExportedServiceImportType(svcExport, svc); svcType != mcsv1a1.ClusterSetIP ||
+		svc.Spec.Type == corev1.ServiceTypeExternalName {
+		return "", invalidStaticIP, "A static clusterset IP is only supported for a Service exported with a ClusterSetIP"
+	}
+
+	if cluster, otherIP := a.getConflictingStaticIP(svcExport.Name, svcExport.Namespace, ip); cluster != "" {
+		return "", staticIPConflict, fmt.Sprintf("The static clusterset IP %q conflicts with the static clusterset IP %q "+
+			"declared by cluster %q for the same service", ip.String(), otherIP, cluster)
+	}
+
+	return ip.String(), "", ""
+}
+
+// getConflictingStaticIP returns the cluster and static IP of another cluster's export of the given service that declares
+// a static clusterset IP different from the given one, if any.
+func (a *Controller) getConflictingStaticIP(name, namespace string, ip net.IP) (cluster, otherIP string) {
+	// The local ServiceImports include those synced from the broker so this covers the whole clusterset.
+	list, err := a.serviceImportSyncer.ListLocalResources(&mcsv1a1.ServiceImport{})
+	if err != nil {
+		klog.Errorf("Error listing ServiceImports: %v", err)
+		return "", ""
+	}
+
+	for _, obj := range list {
+		si := obj.(*mcsv1a1.ServiceImport)
+
+		if si.Labels[lhconstants.LighthouseLabelSourceCluster] == a.clusterID ||
+			si.Annotations[lhconstants.OriginName] != name || si.Annotations[lhconstants.OriginNamespace] != namespace {
+			continue
+		}
+
+		otherIP, ok := si.Annotations[lhconstants.StaticClustersetIPAnnotation]
+		if ok && !ip.Equal(net.ParseIP(otherIP)) {
+			return si.Labels[lhconstants.LighthouseLabelSourceCluster], otherIP
+		}
+	}
+
+	return "", ""
+}
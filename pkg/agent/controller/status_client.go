@@ -0,0 +1,112 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/util"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// statusSubresourceClient wraps a dynamic client so that, after a resource of the given type is created or updated, its
+// status is written via the status subresource. If the CRD defines a status subresource, the API server ignores the
+// status on writes to the main resource and the Federators write whole objects, so the status would otherwise be dropped.
+// Writing the status separately also means a spec update doesn't clobber the status and vice versa.
+type statusSubresourceClient struct {
+	dynamic.Interface
+	gvr schema.GroupVersionResource
+}
+
+type statusSubresourceNamespaceableClient struct {
+	dynamic.NamespaceableResourceInterface
+}
+
+type statusSubresourceResourceClient struct {
+	dynamic.ResourceInterface
+}
+
+func newStatusSubresourceClient(client dynamic.Interface, gvr schema.GroupVersionResource) dynamic.Interface {
+	return &statusSubresourceClient{Interface: client, gvr: gvr}
+}
+
+func (c *statusSubresourceClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	resourceClient := c.Interface.Resource(gvr)
+	if gvr != c.gvr {
+		return resourceClient
+	}
+
+	return &statusSubresourceNamespaceableClient{NamespaceableResourceInterface: resourceClient}
+}
+
+func (c *statusSubresourceNamespaceableClient) Namespace(namespace string) dynamic.ResourceInterface {
+	return &statusSubresourceResourceClient{ResourceInterface: c.NamespaceableResourceInterface.Namespace(namespace)}
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *statusSubresourceResourceClient) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions,
+	subresources ...string,
+) (*unstructured.Unstructured, error) {
+	status, found, _ := unstructured.NestedFieldCopy(obj.Object, util.StatusField)
+
+	created, err := c.ResourceInterface.Create(ctx, obj, options, subresources...)
+	if err != nil || !found || len(subresources) > 0 {
+		return created, err // nolint:wrapcheck // Let the caller wrap it.
+	}
+
+	return c.writeStatus(ctx, created, status)
+}
+
+// nolint:gocritic // hugeParam - we're matching K8s API
+func (c *statusSubresourceResourceClient) Update(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions,
+	subresources ...string,
+) (*unstructured.Unstructured, error) {
+	status, found, _ := unstructured.NestedFieldCopy(obj.Object, util.StatusField)
+
+	updated, err := c.ResourceInterface.Update(ctx, obj, options, subresources...)
+	if err != nil || !found || len(subresources) > 0 {
+		return updated, err // nolint:wrapcheck // Let the caller wrap it.
+	}
+
+	return c.writeStatus(ctx, updated, status)
+}
+
+func (c *statusSubresourceResourceClient) writeStatus(ctx context.Context, written *unstructured.Unstructured, status interface{},
+) (*unstructured.Unstructured, error) {
+	current, _, _ := unstructured.NestedFieldNoCopy(written.Object, util.StatusField)
+	if equality.Semantic.DeepEqual(current, status) {
+		return written, nil
+	}
+
+	toUpdate := written.DeepCopy()
+
+	err := unstructured.SetNestedField(toUpdate.Object, status, util.StatusField)
+	if err != nil {
+		return nil, errors.Wrap(err, "error setting the status field")
+	}
+
+	updated, err := c.ResourceInterface.UpdateStatus(ctx, toUpdate, metav1.UpdateOptions{})
+
+	return updated, errors.Wrapf(err, "error updating the status of %q", written.GetName())
+}
@@ -0,0 +1,84 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+
+	"github.com/submariner-io/admiral/pkg/syncer"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// serviceExportsEquivalent returns true if a ServiceExport update only changed its status or its last error annotation,
+// which the controller itself manages, so that its own writes don't trigger further reconciles. A periodic resync, which
+// redelivers the same object, is always processed. The one status change that is processed is the transition to
+// ServiceUnavailable as the resulting reconcile polls for the Service to be recreated.
+func serviceExportsEquivalent(oldObj, newObj *unstructured.Unstructured) bool {
+	if newObj.GetResourceVersion() == oldObj.GetResourceVersion() && equality.Semantic.DeepEqual(oldObj, newObj) {
+		return false
+	}
+
+	if oldObj.GetGeneration() != newObj.GetGeneration() ||
+		!equality.Semantic.DeepEqual(oldObj.GetDeletionTimestamp(), newObj.GetDeletionTimestamp()) ||
+		!reflect.DeepEqual(oldObj.GetFinalizers(), newObj.GetFinalizers()) ||
+		!syncer.DefaultResourcesEquivalent(withoutLastError(oldObj), withoutLastError(newObj)) {
+		return false
+	}
+
+	newReason := getLastExportConditionReasonFrom(newObj)
+
+	return newReason != serviceUnavailable || getLastExportConditionReasonFrom(oldObj) == serviceUnavailable
+}
+
+func withoutLastError(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	annotations := obj.GetAnnotations()
+	if _, ok := annotations[lhconstants.LastErrorAnnotation]; !ok {
+		return obj
+	}
+
+	obj = obj.DeepCopy()
+
+	// An empty map isn't equivalent to no annotations.
+	delete(annotations, lhconstants.LastErrorAnnotation)
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+
+	obj.SetAnnotations(annotations)
+
+	return obj
+}
+
+func getLastExportConditionReasonFrom(obj *unstructured.Unstructured) string {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	cond, ok := conditions[len(conditions)-1].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	reason, _, _ := unstructured.NestedString(cond, "reason")
+
+	return reason
+}
@@ -0,0 +1,121 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/fake"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/testing"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+var _ = Describe("ServiceExport status updates", func() {
+	var (
+		t            *testDriver
+		exporter     *tracetest.InMemoryExporter
+		brokerWrites int32
+	)
+
+	BeforeEach(func() {
+		t = newTestDiver()
+		t.cluster1.agentSpec.TracingEnabled = true
+
+		exporter = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+		atomic.StoreInt32(&brokerWrites, 0)
+
+		countWrites := func(action testing.Action) (bool, runtime.Object, error) {
+			atomic.AddInt32(&brokerWrites, 1)
+			return false, nil, nil
+		}
+
+		brokerClient := t.syncerConfig.BrokerClient.(*fake.DynamicClient)
+		brokerClient.PrependReactor("create", "serviceimports", countWrites)
+		brokerClient.PrependReactor("update", "serviceimports", countWrites)
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+		t.createService()
+		t.createServiceExport()
+		t.awaitServiceExported(t.service.Spec.ClusterIP)
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+
+	updateReconciles := func() int {
+		count := 0
+
+		for _, span := range exporter.GetSpans() {
+			if span.Name == "ServiceExport reconcile" && hasAttribute(span.Attributes, attribute.String("operation", "update")) {
+				count++
+			}
+		}
+
+		return count
+	}
+
+	It("should not reconcile the ServiceExport on the controller's own status updates", func() {
+		Consistently(updateReconciles).Should(BeZero())
+	})
+
+	When("only the ServiceExport status is updated", func() {
+		It("should not cause additional broker writes", func() {
+			writes := atomic.LoadInt32(&brokerWrites)
+
+			serviceExport := &mcsv1a1.ServiceExport{}
+			Expect(scheme.Scheme.Convert(test.GetResource(t.cluster1.localServiceExportClient, t.serviceExport),
+				serviceExport, nil)).To(Succeed())
+			Expect(serviceExport.Status.Conditions).ToNot(BeEmpty())
+
+			msg := "modified"
+			serviceExport.Status.Conditions[0].Message = &msg
+			test.UpdateResource(t.cluster1.localServiceExportClient, serviceExport)
+
+			Consistently(func() int32 {
+				return atomic.LoadInt32(&brokerWrites)
+			}).Should(Equal(writes))
+			Expect(updateReconciles()).To(BeZero())
+		})
+	})
+
+	When("only the ServiceExport last error annotation is updated", func() {
+		It("should not reconcile the ServiceExport", func() {
+			t.setServiceExportAnnotation(lhconstants.LastErrorAnnotation, `{"code":"BrokerSyncFailed","message":"failed"}`)
+
+			Consistently(updateReconciles).Should(BeZero())
+		})
+	})
+})
@@ -0,0 +1,146 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/fake"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/testing"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+var _ = Describe("ServiceImport status subresource", func() {
+	var t *testDriver
+
+	BeforeEach(func() {
+		t = newTestDiver()
+
+		emulateStatusSubresource(t.syncerConfig.BrokerClient)
+		emulateStatusSubresource(t.cluster1.localDynClient)
+		emulateStatusSubresource(t.cluster2.localDynClient)
+
+		t.service.Spec.Ports = []corev1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80}}
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	It("should write the status and spec without clobbering each other", func() {
+		t.createService()
+		t.createServiceExport()
+
+		for _, client := range []dynamic.ResourceInterface{
+			t.cluster1.localServiceImportClient, t.brokerServiceImportClient,
+			t.cluster2.localServiceImportClient,
+		} {
+			serviceImport := awaitServiceImportStatus(client, t.service)
+			Expect(serviceImport.Spec.IPs).To(Equal([]string{t.service.Spec.ClusterIP}))
+		}
+
+		By("Renaming a Service port")
+
+		t.service.Spec.Ports[0].Name = "renamed"
+		t.updateService()
+
+		for _, client := range []dynamic.ResourceInterface{
+			t.cluster1.localServiceImportClient, t.brokerServiceImportClient,
+			t.cluster2.localServiceImportClient,
+		} {
+			Eventually(func() []mcsv1a1.ServicePort {
+				return getServiceImport(client, t.service).Spec.Ports
+			}).Should(ContainElement(HaveField("Name", "renamed")))
+
+			Expect(getServiceImport(client, t.service).Status.Clusters).To(HaveLen(1))
+		}
+	})
+})
+
+// emulateStatusSubresource emulates the API server's handling of a CRD that defines a status subresource whereby the
+// status is ignored on writes to the main resource and only the status is written via the status subresource.
+func emulateStatusSubresource(client dynamic.Interface) {
+	f := client.(*fake.DynamicClient)
+
+	f.PrependReactor("create", "serviceimports", func(action testing.Action) (bool, runtime.Object, error) {
+		create := action.(testing.CreateAction)
+		if create.GetSubresource() == "" {
+			unstructured.RemoveNestedField(create.GetObject().(*unstructured.Unstructured).Object, "status")
+		}
+
+		return false, nil, nil
+	})
+
+	f.PrependReactor("update", "serviceimports", func(action testing.Action) (bool, runtime.Object, error) {
+		update := action.(testing.UpdateAction)
+		obj := update.GetObject().(*unstructured.Unstructured)
+
+		existing, err := f.Tracker().Get(update.GetResource(), update.GetNamespace(), obj.GetName())
+		if err != nil {
+			return false, nil, nil
+		}
+
+		existingObj := existing.(*unstructured.Unstructured).DeepCopy()
+
+		if update.GetSubresource() == "status" {
+			existingObj.Object["status"] = obj.Object["status"]
+			obj.Object = existingObj.Object
+		} else {
+			obj.Object["status"] = existingObj.Object["status"]
+		}
+
+		return false, nil, nil
+	})
+}
+
+func getServiceImport(client dynamic.ResourceInterface, service *corev1.Service) *mcsv1a1.ServiceImport {
+	obj, err := client.Get(context.TODO(), service.Name+"-"+service.Namespace+"-"+clusterID1, metav1.GetOptions{})
+	Expect(err).To(Succeed())
+
+	serviceImport := &mcsv1a1.ServiceImport{}
+	Expect(scheme.Scheme.Convert(obj, serviceImport, nil)).To(Succeed())
+
+	return serviceImport
+}
+
+func awaitServiceImportStatus(client dynamic.ResourceInterface, service *corev1.Service) *mcsv1a1.ServiceImport {
+	test.AwaitResource(client, service.Name+"-"+service.Namespace+"-"+clusterID1)
+
+	var serviceImport *mcsv1a1.ServiceImport
+
+	Eventually(func() []mcsv1a1.ClusterStatus {
+		serviceImport = getServiceImport(client, service)
+		return serviceImport.Status.Clusters
+	}).Should(Equal([]mcsv1a1.ClusterStatus{{Cluster: clusterID1}}))
+
+	return serviceImport
+}
@@ -0,0 +1,148 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const (
+	// SummaryConfigMapName is the name of the ConfigMap, in the agent's namespace, that summarizes the state of the
+	// exported Services for dashboards.
+	SummaryConfigMapName = "lighthouse-agent-summary"
+
+	SummaryExportedServices = "exportedServices"
+	SummaryFailedExports    = "failedExports"
+	SummaryPendingExports   = "pendingExports"
+	SummaryBrokerConnected  = "brokerConnected"
+	SummaryLastUpdated      = "lastUpdated"
+
+	defaultSummaryUpdatePeriod = time.Minute
+)
+
+// runSummaryUpdater maintains the summary ConfigMap. It's updated periodically and whenever a ServiceExport's status
+// changes or a ServiceExport is deleted.
+func (a *Controller) runSummaryUpdater(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(a.summaryUpdatePeriod)
+	defer ticker.Stop()
+
+	for {
+		if err := a.updateSummary(); err != nil {
+			klog.Errorf("Error updating the summary ConfigMap: %v", err)
+		}
+
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		case <-a.summaryTrigger:
+		}
+	}
+}
+
+func (a *Controller) triggerSummaryUpdate() {
+	select {
+	case a.summaryTrigger <- struct{}{}:
+	default:
+	}
+}
+
+func (a *Controller) buildSummary() (map[string]string, error) {
+	// List from the API server rather than the informer cache, which may not yet reflect the status update that
+	// triggered this.
+	serviceExports, err := a.serviceExportClient.Namespace(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing ServiceExports")
+	}
+
+	exported, failed, pending := 0, 0, 0
+
+	for i := range serviceExports.Items {
+		svcExport := &mcsv1a1.ServiceExport{}
+		if err := a.serviceImportController.scheme.Convert(&serviceExports.Items[i], svcExport, nil); err != nil {
+			return nil, errors.Wrapf(err, "error converting %#v to ServiceExport", serviceExports.Items[i])
+		}
+
+		numCond := len(svcExport.Status.Conditions)
+		switch {
+		case numCond == 0 || getLastExportConditionReason(svcExport) == "AwaitingSync":
+			pending++
+		case svcExport.Status.Conditions[numCond-1].Status == corev1.ConditionTrue:
+			exported++
+		default:
+			failed++
+		}
+	}
+
+	_, err = a.brokerImportClient.List(context.TODO(), metav1.ListOptions{Limit: 1})
+	if err != nil {
+		klog.V(log.DEBUG).Infof("Unable to list ServiceImports on the broker: %v", err)
+	}
+
+	return map[string]string{
+		SummaryExportedServices: strconv.Itoa(exported),
+		SummaryFailedExports:    strconv.Itoa(failed),
+		SummaryPendingExports:   strconv.Itoa(pending),
+		SummaryBrokerConnected:  strconv.FormatBool(err == nil),
+	}, nil
+}
+
+func (a *Controller) updateSummary() error {
+	data, err := a.buildSummary()
+	if err != nil {
+		return err
+	}
+
+	data[SummaryLastUpdated] = time.Now().UTC().Format(time.RFC3339)
+
+	configMaps := a.kubeClientSet.CoreV1().ConfigMaps(a.namespace)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error { // nolint:wrapcheck // Errors are wrapped below.
+		existing, err := configMaps.Get(context.TODO(), SummaryConfigMapName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			_, err = configMaps.Create(context.TODO(), &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      SummaryConfigMapName,
+					Namespace: a.namespace,
+				},
+				Data: data,
+			}, metav1.CreateOptions{})
+
+			return errors.Wrap(err, "error creating the summary ConfigMap")
+		} else if err != nil {
+			return errors.Wrap(err, "error retrieving the summary ConfigMap")
+		}
+
+		existing.Data = data
+		_, err = configMaps.Update(context.TODO(), existing, metav1.UpdateOptions{})
+
+		return errors.Wrap(err, "error updating the summary ConfigMap")
+	})
+}
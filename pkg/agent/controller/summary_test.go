@@ -0,0 +1,94 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	"github.com/submariner-io/lighthouse/pkg/agent/controller"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Summary ConfigMap", func() {
+	var t *testDriver
+
+	BeforeEach(func() {
+		t = newTestDiver()
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	awaitSummary := func(exported, failed string) {
+		Eventually(func() map[string]string {
+			cm, err := t.cluster1.localKubeClient.CoreV1().ConfigMaps(test.LocalNamespace).Get(context.TODO(),
+				controller.SummaryConfigMapName, metav1.GetOptions{})
+			if err != nil {
+				return nil
+			}
+
+			return cm.Data
+		}, 5).Should(And(
+			HaveKeyWithValue(controller.SummaryExportedServices, exported),
+			HaveKeyWithValue(controller.SummaryFailedExports, failed),
+			HaveKeyWithValue(controller.SummaryPendingExports, "0"),
+			HaveKeyWithValue(controller.SummaryBrokerConnected, "true"),
+			HaveKey(controller.SummaryLastUpdated)))
+	}
+
+	When("the agent starts with no ServiceExports", func() {
+		It("should create the summary with zero counts", func() {
+			awaitSummary("0", "0")
+		})
+	})
+
+	When("a Service is exported and subsequently unexported", func() {
+		It("should reflect the current export set in the summary", func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+			awaitSummary("1", "0")
+
+			t.deleteServiceExport()
+			t.awaitServiceUnexported()
+			awaitSummary("0", "0")
+		})
+	})
+
+	When("a ServiceExport fails", func() {
+		BeforeEach(func() {
+			t.service.Spec.Type = corev1.ServiceTypeNodePort
+		})
+
+		It("should count it as failed in the summary", func() {
+			t.createService()
+			t.createServiceExport()
+			awaitSummary("0", "1")
+		})
+	})
+})
@@ -0,0 +1,67 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/submariner-io/lighthouse/pkg/agent/controller"
+
+	serviceNameAttr      = attribute.Key("service.name")
+	serviceNamespaceAttr = attribute.Key("service.namespace")
+	operationAttr        = attribute.Key("operation")
+)
+
+// newTracer returns the Tracer used to emit reconcile spans. Tracing is a no-op unless enabled, in which case the given
+// TracerProvider is used or, if nil, the global one.
+func newTracer(enabled bool, provider trace.TracerProvider) trace.Tracer {
+	if !enabled {
+		return trace.NewNoopTracerProvider().Tracer(tracerName)
+	}
+
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return provider.Tracer(tracerName)
+}
+
+func (a *Controller) startSpan(ctx context.Context, spanName, name, namespace string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	attrs = append([]attribute.KeyValue{serviceNameAttr.String(name), serviceNamespaceAttr.String(namespace)}, attrs...)
+
+	return a.tracer.Start(ctx, spanName, trace.WithAttributes(attrs...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
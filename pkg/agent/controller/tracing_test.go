@@ -0,0 +1,130 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ = Describe("Reconcile tracing", func() {
+	var (
+		t        *testDriver
+		exporter *tracetest.InMemoryExporter
+	)
+
+	BeforeEach(func() {
+		t = newTestDiver()
+
+		exporter = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+
+	// Filter on the operation as a reconcile may also be triggered by an update, eg on transitioning to ServiceUnavailable.
+	reconcileSpans := func(op string) func() []tracetest.SpanStub {
+		return func() []tracetest.SpanStub {
+			var spans []tracetest.SpanStub
+
+			for _, span := range exporter.GetSpans() {
+				if span.Name == "ServiceExport reconcile" && hasAttribute(span.Attributes, attribute.String("operation", op)) {
+					spans = append(spans, span)
+				}
+			}
+
+			return spans
+		}
+	}
+
+	When("tracing is enabled", func() {
+		BeforeEach(func() {
+			t.cluster1.agentSpec.TracingEnabled = true
+		})
+
+		It("should record a span per ServiceExport reconcile with the service key", func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			Eventually(reconcileSpans("create")).Should(HaveLen(1))
+			Expect(reconcileSpans("create")()[0].Attributes).To(ContainElements(
+				attribute.String("service.name", t.service.Name),
+				attribute.String("service.namespace", t.service.Namespace)))
+
+			t.deleteServiceExport()
+			t.awaitNoServiceImport(t.brokerServiceImportClient)
+
+			Eventually(reconcileSpans("delete")).Should(HaveLen(1))
+			Consistently(reconcileSpans("create")).Should(HaveLen(1))
+		})
+
+		It("should record child spans for the reconcile stages", func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			spanNames := func() []string {
+				var names []string
+				for _, span := range exporter.GetSpans() {
+					names = append(names, span.Name)
+				}
+
+				return names
+			}
+
+			Eventually(spanNames).Should(ContainElements("get Service", "write local ServiceImport",
+				"ServiceImport broker sync", "update ServiceExport status"))
+		})
+	})
+
+	When("tracing is disabled", func() {
+		It("should not record any spans", func() {
+			t.createService()
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			Consistently(func() int {
+				return len(exporter.GetSpans())
+			}).Should(BeZero())
+		})
+	})
+})
+
+func hasAttribute(attrs []attribute.KeyValue, attr attribute.KeyValue) bool {
+	for i := range attrs {
+		if attrs[i] == attr {
+			return true
+		}
+	}
+
+	return false
+}
@@ -20,35 +20,151 @@ package controller
 
 import (
 	"sync"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/submariner-io/admiral/pkg/federate"
 	"github.com/submariner-io/admiral/pkg/syncer"
 	"github.com/submariner-io/admiral/pkg/syncer/broker"
 	"github.com/submariner-io/admiral/pkg/watcher"
+	"github.com/submariner-io/admiral/pkg/workqueue"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
 type Controller struct {
-	clusterID               string
-	globalnetEnabled        bool
-	namespace               string
-	kubeClientSet           kubernetes.Interface
-	serviceExportClient     dynamic.NamespaceableResourceInterface
-	serviceExportSyncer     syncer.Interface
-	serviceImportSyncer     *broker.Syncer
-	endpointSliceSyncer     *broker.Syncer
-	serviceSyncer           syncer.Interface
-	serviceImportController *ServiceImportController
+	clusterID                  string
+	globalnetEnabled           bool
+	namespace                  string
+	kubeClientSet              kubernetes.Interface
+	serviceExportClient        dynamic.NamespaceableResourceInterface
+	serviceImportSyncer        *broker.Syncer
+	endpointSliceSyncer        *broker.Syncer
+	serviceSyncer              syncer.Interface
+	serviceImportController    *ServiceImportController
+	serviceAliasController     *serviceAliasController
+	stopCh                     <-chan struct{}
+	clustersetGroup            string
+	importNameScheme           string
+	brokerImportClient         dynamic.ResourceInterface
+	brokerServiceImportWatcher syncer.Interface
+	minEndpointsWatcher        syncer.Interface
+	maxEndpointsPerImport      int
+	tracer                     trace.Tracer
+	brokerThrottle             *brokerThrottle
+	endpointSliceGVR           schema.GroupVersionResource
+	propagationLatencyEnabled  bool
+	heartbeatPeriod            time.Duration
+	serviceExportWorkers
+	exportSelection
+	serviceTracking
+	exportQuota
+	exportSchedules
+	pauseState
+	availabilityState
+	summaryState
+	namespaceMappingState
+}
+
+// serviceExportWorkers holds the state of the workers processing the ServiceExports from the work queue.
+type serviceExportWorkers struct {
+	serviceExportSyncer      syncer.Interface
+	serviceExportSyncerName  string
+	serviceExportSyncCounter *prometheus.GaugeVec
+	serviceExportQueue       workqueue.Interface
+	createdServiceExports    sync.Map
+	serviceImportLocks       keyedMutex
+	workers                  int
+}
+
+// exportSelection holds the configuration restricting which Services are exported.
+type exportSelection struct {
+	exportAllNamespaces map[string]bool
+	allowedProtocols    map[corev1.Protocol]bool
+	exportSelector      labels.Selector
+	serviceSelector     labels.Selector
+}
+
+// serviceTracking holds what's observed of the Services to detect changes that affect their exports.
+type serviceTracking struct {
+	serviceNamespacesMutex sync.Mutex
+	serviceNamespaces      map[string]map[string]bool
+	serviceSelectors       sync.Map
+}
+
+// exportQuota holds the ServiceExports admitted within the maximum number of exported Services.
+type exportQuota struct {
+	maxExportedServices int
+	exportQuotaMutex    sync.Mutex
+	exportQuotaAdmitted map[string]bool
+}
+
+// exportSchedules holds the ServiceExports whose expiry or activation is scheduled.
+type exportSchedules struct {
+	exportExpiryScheduled     sync.Map
+	exportActivationScheduled sync.Map
+}
+
+// pauseState holds whether the syncing to the broker is paused.
+type pauseState struct {
+	pauseMutex sync.Mutex
+	paused     bool
+}
+
+// availabilityState holds the tracking of the availability of the imported clusterset services.
+type availabilityState struct {
+	availabilityWatcher syncer.Interface
+	availabilityTracker *availabilityTracker
+}
+
+// summaryState holds the updating of the summary ConfigMap.
+type summaryState struct {
+	summaryUpdatePeriod time.Duration
+	summaryTrigger      chan struct{}
+}
+
+// namespaceMappingState holds the mapping of local namespaces to clusterset namespaces and its source ConfigMap.
+type namespaceMappingState struct {
+	namespaceMapping          *namespaceMapping
+	namespaceMappingConfigMap string
+	namespaceMappingWatcher   syncer.Interface
 }
 
 type AgentSpecification struct {
-	ClusterID        string
-	Namespace        string
-	GlobalnetEnabled bool `split_words:"true"`
-	Uninstall        bool
+	ClusterID                  string
+	Namespace                  string
+	GlobalnetEnabled           bool `split_words:"true"`
+	Uninstall                  bool
+	ResyncPeriod               time.Duration `split_words:"true"`
+	ServiceLabelSelector       string        `split_words:"true"`
+	ServiceFieldSelector       string        `split_words:"true"`
+	DebugBindAddress           string        `split_words:"true"`
+	ExportAllNamespaces        []string      `split_words:"true"`
+	AllowedProtocols           []string      `split_words:"true"`
+	ClustersetGroup            string        `split_words:"true"`
+	ImportNameScheme           string        `split_words:"true"`
+	SummaryUpdatePeriod        time.Duration `split_words:"true"`
+	Workers                    int
+	MaxExportedServices        int           `split_words:"true"`
+	EmptyEndpointsGracePeriod  time.Duration `split_words:"true"`
+	TracingEnabled             bool          `split_words:"true"`
+	BrokerThrottleDelay        time.Duration `split_words:"true"`
+	ExportLabelSelector        string        `split_words:"true"`
+	NamespaceMappingConfigMap  string        `split_words:"true"`
+	PropagationLatencyEnabled  bool          `split_words:"true"`
+	WatchIdleTimeout           time.Duration `split_words:"true"`
+	AvailabilityWebhookURL     string        `split_words:"true"`
+	BrokerAuthFailureThreshold int           `split_words:"true"`
+	MaxEndpointsPerImport      int           `split_words:"true"`
+	CompactEndpoints           bool          `split_words:"true"`
+	HeartbeatPeriod            time.Duration `split_words:"true"`
 }
 
 // The ServiceImportController listens for ServiceImport resources created in the target namespace
@@ -63,6 +179,9 @@ type ServiceImportController struct {
 	clusterID            string
 	scheme               *runtime.Scheme
 	globalIngressIPCache *globalIngressIPCache
+	emptyGracePeriod     time.Duration
+	maxEndpoints         int
+	compactEndpoints     bool
 }
 
 // Each EndpointController listens for the endpoints that backs a service and have a ServiceImport
@@ -80,6 +199,15 @@ type EndpointController struct {
 	localClient                  dynamic.Interface
 	ingressIPClient              dynamic.NamespaceableResourceInterface
 	globalIngressIPCache         *globalIngressIPCache
+	federator                    federate.Federator
+	clustersetGroup              string
+	emptyEndpointsGracePeriod    time.Duration
+	emptyEndpointsMutex          sync.Mutex
+	emptyEndpointsSince          time.Time
+	exclusionMutex               sync.Mutex
+	exclusion                    *endpointExclusion
+	maxEndpoints                 int
+	compactEndpoints             bool
 }
 
 type globalIngressIPCache struct {
@@ -87,4 +215,6 @@ type globalIngressIPCache struct {
 	byService sync.Map
 	byPod     sync.Map
 	watcher   watcher.Interface
+	// onServiceIPChanged is invoked when the allocated global IP for a Service changes.
+	onServiceIPChanged func(name, namespace, oldIP, newIP string)
 }
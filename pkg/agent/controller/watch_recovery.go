@@ -0,0 +1,216 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+const (
+	resourceLabel = "resource"
+
+	WatchRestartsName = "submariner_agent_watch_restarts"
+)
+
+var watchRestarts = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: WatchRestartsName,
+		Help: "Number of watches on the local cluster that failed or stalled and were re-established with a relist",
+	},
+	[]string{resourceLabel},
+)
+
+func init() {
+	prometheus.MustRegister(watchRestarts)
+}
+
+// watchRecoveringClient wraps a dynamic client so that watches that fail, or stall without delivering any event for the
+// idle timeout, are terminated with an error. The informer then relists and re-establishes the watch rather than silently
+// missing events. Each restart is counted.
+type watchRecoveringClient struct {
+	dynamic.Interface
+	idleTimeout time.Duration
+}
+
+type watchRecoveringNamespaceableClient struct {
+	dynamic.NamespaceableResourceInterface
+	resource    string
+	idleTimeout time.Duration
+}
+
+type watchRecoveringResourceClient struct {
+	dynamic.ResourceInterface
+	resource    string
+	idleTimeout time.Duration
+}
+
+func newWatchRecoveringClient(client dynamic.Interface, idleTimeout time.Duration) dynamic.Interface {
+	return &watchRecoveringClient{Interface: client, idleTimeout: idleTimeout}
+}
+
+func (c *watchRecoveringClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &watchRecoveringNamespaceableClient{
+		NamespaceableResourceInterface: c.Interface.Resource(gvr),
+		resource:                       gvr.Resource,
+		idleTimeout:                    c.idleTimeout,
+	}
+}
+
+func (c *watchRecoveringNamespaceableClient) Namespace(namespace string) dynamic.ResourceInterface {
+	return &watchRecoveringResourceClient{
+		ResourceInterface: c.NamespaceableResourceInterface.Namespace(namespace),
+		resource:          c.resource,
+		idleTimeout:       c.idleTimeout,
+	}
+}
+
+func (c *watchRecoveringNamespaceableClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	w, err := c.NamespaceableResourceInterface.Watch(ctx, opts)
+	if err != nil {
+		return nil, err // nolint:wrapcheck // Let the caller wrap it.
+	}
+
+	return newRecoveringWatcher(w, c.resource, c.idleTimeout), nil
+}
+
+func (c *watchRecoveringResourceClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	w, err := c.ResourceInterface.Watch(ctx, opts)
+	if err != nil {
+		return nil, err // nolint:wrapcheck // Let the caller wrap it.
+	}
+
+	return newRecoveringWatcher(w, c.resource, c.idleTimeout), nil
+}
+
+// recoveringWatcher relays the events of a watch, counting watch errors and terminating the watch with an error if it
+// stalls.
+type recoveringWatcher struct {
+	delegate    watch.Interface
+	resource    string
+	idleTimeout time.Duration
+	result      chan watch.Event
+	stopCh      chan struct{}
+	stopOnce    sync.Once
+}
+
+func newRecoveringWatcher(delegate watch.Interface, resource string, idleTimeout time.Duration) *recoveringWatcher {
+	w := &recoveringWatcher{
+		delegate:    delegate,
+		resource:    resource,
+		idleTimeout: idleTimeout,
+		result:      make(chan watch.Event),
+		stopCh:      make(chan struct{}),
+	}
+
+	go w.run()
+
+	return w
+}
+
+func (w *recoveringWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+func (w *recoveringWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopCh)
+		w.delegate.Stop()
+	})
+}
+
+func (w *recoveringWatcher) run() {
+	defer close(w.result)
+
+	// A nil channel never fires so stalls aren't detected if there's no idle timeout.
+	var (
+		timer *time.Timer
+		idle  <-chan time.Time
+	)
+
+	if w.idleTimeout > 0 {
+		timer = time.NewTimer(w.idleTimeout)
+		defer timer.Stop()
+
+		idle = timer.C
+	}
+
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case event, ok := <-w.delegate.ResultChan():
+			if !ok {
+				return
+			}
+
+			// The informer relists on an error event.
+			if event.Type == watch.Error {
+				klog.Warningf("The watch for %q failed - relisting: %v", w.resource, apierrors.FromObject(event.Object))
+				watchRestarts.WithLabelValues(w.resource).Inc()
+			}
+
+			if timer != nil {
+				if !timer.Stop() {
+					<-timer.C
+				}
+
+				timer.Reset(w.idleTimeout)
+			}
+
+			if !w.send(event) {
+				return
+			}
+		case <-idle:
+			klog.Warningf("The watch for %q hasn't delivered any event for %v - relisting", w.resource, w.idleTimeout)
+			watchRestarts.WithLabelValues(w.resource).Inc()
+
+			w.delegate.Stop()
+
+			w.send(watch.Event{Type: watch.Error, Object: &metav1.Status{
+				Status:  metav1.StatusFailure,
+				Code:    http.StatusGatewayTimeout,
+				Reason:  metav1.StatusReasonTimeout,
+				Message: fmt.Sprintf("no events received for %v", w.idleTimeout),
+			}})
+
+			return
+		}
+	}
+}
+
+func (w *recoveringWatcher) send(event watch.Event) bool {
+	select {
+	case w.result <- event:
+		return true
+	case <-w.stopCh:
+		return false
+	}
+}
@@ -0,0 +1,136 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/submariner-io/admiral/pkg/fake"
+	"github.com/submariner-io/lighthouse/pkg/agent/controller"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/testing"
+)
+
+var _ = Describe("Watch recovery", func() {
+	var (
+		t              *testDriver
+		initialCount   float64
+		listCount      int32
+		injectedWatch  *watch.RaceFreeFakeWatcher
+		watchInjected  int32
+		injectWatchErr bool
+	)
+
+	BeforeEach(func() {
+		t = newTestDiver()
+		initialCount = getWatchRestarts("serviceexports")
+		atomic.StoreInt32(&listCount, 0)
+		atomic.StoreInt32(&watchInjected, 0)
+		injectWatchErr = true
+
+		injectedWatch = watch.NewRaceFreeFake()
+
+		localFake := &t.cluster1.localDynClient.(*fake.DynamicClient).Fake
+
+		localFake.PrependReactor("list", "serviceexports", func(action testing.Action) (bool, runtime.Object, error) {
+			atomic.AddInt32(&listCount, 1)
+			return false, nil, nil
+		})
+
+		localFake.PrependWatchReactor("serviceexports", func(action testing.Action) (bool, watch.Interface, error) {
+			if !atomic.CompareAndSwapInt32(&watchInjected, 0, 1) {
+				return false, nil, nil
+			}
+
+			if injectWatchErr {
+				injectedWatch.Error(&metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonInternalError,
+					Message: "fake watch error"})
+			}
+
+			return true, injectedWatch, nil
+		})
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+		t.createService()
+		t.createEndpoints()
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("the watch for ServiceExports fails", func() {
+		It("should relist and continue processing subsequent events", func() {
+			Eventually(func() float64 {
+				return getWatchRestarts("serviceexports")
+			}, 5).Should(Equal(initialCount + 1))
+
+			Eventually(func() int32 {
+				return atomic.LoadInt32(&listCount)
+			}, 5).Should(BeNumerically(">=", 2))
+
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+		})
+	})
+
+	When("the watch for ServiceExports stalls for longer than the idle timeout", func() {
+		BeforeEach(func() {
+			injectWatchErr = false
+			t.cluster1.agentSpec.WatchIdleTimeout = 500 * time.Millisecond
+		})
+
+		It("should relist and process the missed events", func() {
+			t.createServiceExport()
+			t.awaitServiceExported(t.service.Spec.ClusterIP)
+
+			Expect(getWatchRestarts("serviceexports")).To(BeNumerically(">", initialCount))
+			Expect(atomic.LoadInt32(&listCount)).To(BeNumerically(">=", 2))
+		})
+	})
+})
+
+func getWatchRestarts(resource string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	Expect(err).To(Succeed())
+
+	for _, family := range families {
+		if family.GetName() != controller.WatchRestartsName {
+			continue
+		}
+
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "resource" && l.GetValue() == resource {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,49 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strconv"
+
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const (
+	invalidWeight = "InvalidWeight"
+	maxWeight     = 100
+)
+
+// getServiceExportWeight returns the load balancing weight requested via the ServiceExport annotation, if any. If it's
+// not an integer between 0 and 100, a non-empty reason and message are returned.
+func getServiceExportWeight(svcExport *mcsv1a1.ServiceExport) (weight, reason, msg string) {
+	weight, ok := svcExport.GetAnnotations()[lhconstants.WeightAnnotation]
+	if !ok {
+		return "", "", ""
+	}
+
+	w, err := strconv.Atoi(weight)
+	if err != nil || w < 0 || w > maxWeight {
+		return "", invalidWeight, fmt.Sprintf("The weight %q is invalid: it must be an integer between 0 and %d", weight,
+			maxWeight)
+	}
+
+	return strconv.Itoa(w), "", ""
+}
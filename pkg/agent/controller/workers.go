@@ -0,0 +1,255 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/submariner-io/admiral/pkg/federate"
+	"github.com/submariner-io/admiral/pkg/syncer"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const defaultWorkers = 1
+
+// newServiceExportSyncer creates the ServiceExport syncer. A syncer processes its work queue with a single worker so, to
+// reconcile ServiceExports in parallel, its transform only queues the ServiceExport on the ServiceExport work queue, which
+// is processed by the configured number of workers. The work queue guarantees that a ServiceExport is only processed by
+// one worker at a time and the workers share the syncer's informer.
+func (a *Controller) newServiceExportSyncer(config *syncer.ResourceSyncerConfig, counterOpts *prometheus.GaugeOpts) error {
+	// The syncer doesn't write the ServiceImports so maintain its counter here.
+	a.serviceExportSyncCounter = prometheus.NewGaugeVec(*counterOpts, []string{
+		syncer.DirectionLabel,
+		syncer.OperationLabel,
+		syncer.SyncerNameLabel,
+	})
+
+	prometheus.MustRegister(a.serviceExportSyncCounter)
+
+	a.serviceExportSyncerName = config.Name
+	config.Transform = a.queueServiceExport
+	config.Federator = federate.NewNoopFederator()
+
+	var err error
+
+	a.serviceExportSyncer, err = syncer.NewResourceSyncer(config)
+
+	return errors.Wrap(err, "error creating ServiceExport syncer")
+}
+
+func (a *Controller) queueServiceExport(obj runtime.Object, _ int, op syncer.Operation) (runtime.Object, bool) {
+	if op == syncer.Create {
+		a.markServiceExportCreated(obj)
+	}
+
+	a.serviceExportQueue.Enqueue(obj)
+
+	return nil, false
+}
+
+// queueServiceExportForResync queues the given ServiceExport to be re-derived, and its ServiceImport rewritten, as if it was
+// just created.
+func (a *Controller) queueServiceExportForResync(obj runtime.Object) {
+	a.markServiceExportCreated(obj)
+	a.serviceExportQueue.Enqueue(obj)
+}
+
+func (a *Controller) markServiceExportCreated(obj runtime.Object) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err == nil {
+		a.createdServiceExports.Store(key, true)
+	}
+}
+
+// processServiceExport derives and writes, or deletes, the ServiceImport of a queued ServiceExport. A ServiceExport that's
+// no longer in the syncer's cache was deleted. It holds the lock of the ServiceImport for the duration of the call so it's
+// serialized with ServiceExports that map to the same ServiceImport and with the Service syncer.
+func (a *Controller) processServiceExport(key, name, namespace string) (bool, error) {
+	unlock := a.serviceImportLocks.lock(a.getObjectNameWithClusterID(name, namespace))
+	defer unlock()
+
+	obj, found, err := a.serviceExportSyncer.GetResource(name, namespace)
+	if err != nil {
+		return true, errors.Wrapf(err, "error retrieving ServiceExport %q", key)
+	}
+
+	op := syncer.Update
+
+	if !found {
+		op = syncer.Delete
+		obj = &mcsv1a1.ServiceExport{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+
+		a.createdServiceExports.Delete(key)
+	} else if _, created := a.createdServiceExports.LoadAndDelete(key); created {
+		op = syncer.Create
+	}
+
+	serviceImport, requeue := a.serviceExportToServiceImport(obj, a.serviceExportQueue.NumRequeues(key), op)
+
+	// A requeued ServiceExport is retried as it was processed.
+	if requeue && op == syncer.Create {
+		a.createdServiceExports.Store(key, true)
+	}
+
+	if serviceImport == nil {
+		return requeue, nil
+	}
+
+	federator := &localServiceImportFederator{
+		Federator:  a.serviceImportSyncer.GetLocalFederator(),
+		controller: a,
+	}
+
+	if op == syncer.Delete {
+		err = federator.Delete(serviceImport)
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+	} else {
+		err = federator.Distribute(serviceImport)
+	}
+
+	if err != nil {
+		if op == syncer.Create {
+			a.createdServiceExports.Store(key, true)
+		}
+
+		return true, errors.Wrapf(err, "error syncing the ServiceImport for ServiceExport %q", key)
+	}
+
+	a.serviceExportSyncCounter.With(prometheus.Labels{
+		syncer.DirectionLabel:  syncer.None.String(),
+		syncer.OperationLabel:  op.String(),
+		syncer.SyncerNameLabel: a.serviceExportSyncerName,
+	}).Inc()
+
+	return requeue, nil
+}
+
+// syncServiceToServiceImport re-derives the ServiceImport of an exported Service when the Service changes. It writes the
+// result itself, rather than leave it to the syncer, so it's serialized with the ServiceExport workers by the lock of the
+// ServiceImport for the duration of the call.
+func (a *Controller) syncServiceToServiceImport(obj runtime.Object, numRequeues int, op syncer.Operation) (runtime.Object, bool) {
+	objMeta := obj.(*corev1.Service).ObjectMeta
+
+	unlock := a.serviceImportLocks.lock(a.getObjectNameWithClusterID(objMeta.Name, objMeta.Namespace))
+	defer unlock()
+
+	serviceImport, requeue := a.serviceToRemoteServiceImport(obj, numRequeues, op)
+	if serviceImport == nil {
+		return nil, requeue
+	}
+
+	federator := a.serviceImportSyncer.GetLocalFederator()
+
+	var err error
+
+	if op == syncer.Delete {
+		err = federator.Delete(serviceImport)
+		if apierrors.IsNotFound(err) {
+			err = nil
+		}
+	} else {
+		err = federator.Distribute(serviceImport)
+	}
+
+	if err != nil {
+		klog.Errorf("Error syncing the ServiceImport for Service (%s/%s): %v", objMeta.Namespace, objMeta.Name, err)
+		return nil, true
+	}
+
+	return nil, requeue
+}
+
+func (a *Controller) startServiceExportWorkers(stopCh <-chan struct{}) {
+	for i := 0; i < a.workers; i++ {
+		a.serviceExportQueue.Run(stopCh, a.processServiceExport)
+	}
+
+	go func() {
+		<-stopCh
+		a.serviceExportQueue.ShutDown()
+	}()
+}
+
+func (a *Controller) reconcileServiceExports() {
+	a.serviceExportSyncer.Reconcile(func() []runtime.Object {
+		return a.serviceImportLister(func(si *mcsv1a1.ServiceImport) runtime.Object {
+			return &mcsv1a1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      si.GetAnnotations()[lhconstants.OriginName],
+					Namespace: si.GetAnnotations()[lhconstants.OriginNamespace],
+				},
+			}
+		})
+	})
+}
+
+// keyedMutex provides a mutex per key that's only kept while it's held or awaited.
+type keyedMutex struct {
+	mutex sync.Mutex
+	locks map[string]*refCountedMutex
+}
+
+type refCountedMutex struct {
+	sync.Mutex
+	refs int
+}
+
+// lock acquires the mutex of the given key and returns the function that releases it.
+func (k *keyedMutex) lock(key string) func() {
+	k.mutex.Lock()
+
+	if k.locks == nil {
+		k.locks = map[string]*refCountedMutex{}
+	}
+
+	m, ok := k.locks[key]
+	if !ok {
+		m = &refCountedMutex{}
+		k.locks[key] = m
+	}
+
+	m.refs++
+
+	k.mutex.Unlock()
+
+	m.Lock()
+
+	return func() {
+		m.Unlock()
+
+		k.mutex.Lock()
+		defer k.mutex.Unlock()
+
+		m.refs--
+		if m.refs == 0 {
+			delete(k.locks, key)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/fake"
+	"github.com/submariner-io/admiral/pkg/syncer/test"
+	"github.com/submariner-io/lighthouse/pkg/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/testing"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+var _ = Describe("Parallel ServiceExport workers", func() {
+	const numServices = 20
+
+	var (
+		t        *testDriver
+		services []*corev1.Service
+	)
+
+	BeforeEach(func() {
+		t = newTestDiver()
+		t.cluster1.agentSpec.Workers = 4
+
+		services = make([]*corev1.Service, numServices)
+		for i := range services {
+			services[i] = t.service.DeepCopy()
+			services[i].Name = fmt.Sprintf("burst-%d", i)
+			services[i].Spec.ClusterIP = fmt.Sprintf("10.253.10.%d", i+1)
+		}
+	})
+
+	JustBeforeEach(func() {
+		t.justBeforeEach()
+
+		for _, service := range services {
+			test.CreateResource(t.cluster1.dynamicServiceClient().Namespace(service.Namespace), service)
+			test.CreateResource(t.cluster1.localServiceExportClient, &mcsv1a1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      service.Name,
+					Namespace: service.Namespace,
+				},
+			})
+		}
+	})
+
+	AfterEach(func() {
+		t.afterEach()
+	})
+
+	When("a burst of ServiceExports is created", func() {
+		It("should export all the Services", func() {
+			for _, service := range services {
+				Expect(testutil.WaitForExported(t.cluster1.localDynClient, service.Namespace, service.Name, 5*time.Second)).To(Succeed())
+
+				awaitServiceImport(t.brokerServiceImportClient, service, mcsv1a1.ClusterSetIP, service.Spec.ClusterIP)
+				t.cluster2.awaitServiceImport(service, mcsv1a1.ClusterSetIP, service.Spec.ClusterIP)
+			}
+		})
+	})
+
+	When("the local ServiceImport of one of a burst of ServiceExports fails to be written", func() {
+		BeforeEach(func() {
+			t.cluster1.localDynClient.(*fake.DynamicClient).PrependReactor("create", "serviceimports",
+				func(action testing.Action) (bool, runtime.Object, error) {
+					obj := action.(testing.CreateAction).GetObject().(*unstructured.Unstructured)
+					if obj.GetName() == services[0].Name+"-"+services[0].Namespace+"-"+clusterID1 {
+						return true, nil, errors.New("fake create error")
+					}
+
+					return false, nil, nil
+				})
+		})
+
+		It("should export the other Services", func() {
+			for _, service := range services[1:] {
+				Expect(testutil.WaitForExported(t.cluster1.localDynClient, service.Namespace, service.Name, 5*time.Second)).To(Succeed())
+			}
+
+			test.AwaitNoResource(t.brokerServiceImportClient, services[0].Name+"-"+services[0].Namespace+"-"+clusterID1)
+		})
+	})
+
+	When("a burst of ServiceExports is deleted", func() {
+		It("should unexport all the Services", func() {
+			for _, service := range services {
+				Expect(testutil.WaitForExported(t.cluster1.localDynClient, service.Namespace, service.Name, 5*time.Second)).To(Succeed())
+			}
+
+			for _, service := range services {
+				Expect(t.cluster1.localServiceExportClient.Delete(context.TODO(), service.Name, metav1.DeleteOptions{})).To(Succeed())
+			}
+
+			for _, service := range services {
+				test.AwaitNoResource(t.brokerServiceImportClient, service.Name+"-"+service.Namespace+"-"+clusterID1)
+				test.AwaitNoResource(t.cluster2.localServiceImportClient, service.Name+"-"+service.Namespace+"-"+clusterID1)
+			}
+		})
+	})
+})
@@ -32,6 +32,9 @@ import (
 	"github.com/submariner-io/admiral/pkg/syncer/broker"
 	"github.com/submariner-io/admiral/pkg/util"
 	"github.com/submariner-io/lighthouse/pkg/agent/controller"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -102,6 +105,11 @@ func main() {
 	// set up signals so we handle the first shutdown signal gracefully
 	ctx := signals.SetupSignalHandler()
 
+	var tracerProvider *sdktrace.TracerProvider
+	if agentSpec.TracingEnabled {
+		tracerProvider = startTracing(ctx)
+	}
+
 	lightHouseAgent, err := controller.New(&agentSpec, broker.SyncerConfig{
 		LocalRestConfig: cfg,
 		LocalClient:     localClient,
@@ -133,6 +141,11 @@ func main() {
 
 	httpServer := startHTTPServer()
 
+	debugServer, err := controller.StartDebugServer(agentSpec.DebugBindAddress, lightHouseAgent)
+	if err != nil {
+		klog.Fatalf("Failed to start the debug server: %v", err)
+	}
+
 	<-ctx.Done()
 
 	klog.Info("All controllers stopped or exited. Stopping main loop")
@@ -140,6 +153,18 @@ func main() {
 	if err := httpServer.Shutdown(context.TODO()); err != nil {
 		klog.Errorf("Error shutting down metrics HTTP server: %v", err)
 	}
+
+	if debugServer != nil {
+		if err := debugServer.Shutdown(context.TODO()); err != nil {
+			klog.Errorf("Error shutting down debug HTTP server: %v", err)
+		}
+	}
+
+	if tracerProvider != nil {
+		if err := tracerProvider.Shutdown(context.TODO()); err != nil {
+			klog.Errorf("Error shutting down the tracer provider: %v", err)
+		}
+	}
 }
 
 func init() {
@@ -148,6 +173,20 @@ func init() {
 		"The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
 }
 
+// startTracing registers a global TracerProvider that exports spans via OTLP over HTTP. The exporter is configured
+// using the standard OTEL_EXPORTER_OTLP_* environment variables.
+func startTracing(ctx context.Context) *sdktrace.TracerProvider {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		klog.Fatalf("Error creating the OTLP trace exporter: %v", err)
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(tracerProvider)
+
+	return tracerProvider
+}
+
 func startHTTPServer() *http.Server {
 	srv := &http.Server{Addr: ":8082", ReadHeaderTimeout: 60 * time.Second}
 
@@ -29,4 +29,31 @@ const (
 	MCSLabelServiceName                = "multicluster.kubernetes.io/service-name"
 	MCSLabelSourceCluster              = "multicluster.kubernetes.io/source-cluster"
 	KubernetesServiceName              = "kubernetes.io/service-name"
+	ForceResyncAnnotation              = "lighthouse.submariner.io/force-resync"
+	ExternalTrafficPolicyAnnotation    = "lighthouse.submariner.io/external-traffic-policy"
+	LastErrorAnnotation                = "lighthouse.submariner.io/last-error"
+	AutoExportedLabel                  = "lighthouse.submariner.io/auto-exported"
+	ClustersetGroupLabel               = "lighthouse.submariner.io/clusterset-group"
+	ExternalNameAnnotation             = "lighthouse.submariner.io/external-name"
+	ClustersetHostnameAnnotation       = "lighthouse.submariner.io/clusterset-hostname"
+	FQDNEndpointsAnnotation            = "lighthouse.submariner.io/fqdn-endpoints"
+	WeightAnnotation                   = "lighthouse.submariner.io/weight"
+	ExportableAnnotation               = "lighthouse.submariner.io/exportable"
+	ExportTTLAnnotation                = "lighthouse.submariner.io/export-ttl"
+	ExportTimestampAnnotation          = "lighthouse.submariner.io/export-timestamp"
+	ActivationTimeAnnotation           = "lighthouse.submariner.io/activation-time"
+	ForceHeadlessAnnotation            = "lighthouse.submariner.io/force-headless"
+	StaticClustersetIPAnnotation       = "lighthouse.submariner.io/static-clusterset-ip"
+	AllowedClustersAnnotation          = "lighthouse.submariner.io/allowed-clusters"
+	MinEndpointsAnnotation             = "lighthouse.submariner.io/min-endpoints"
+	ExcludedIPsAnnotation              = "lighthouse.submariner.io/excluded-ips"
+	ExcludedPodSelectorAnnotation      = "lighthouse.submariner.io/excluded-pod-selector"
+	CNAMETargetAnnotation              = "lighthouse.submariner.io/cname-target"
+	EndpointsTruncatedAnnotation       = "lighthouse.submariner.io/endpoints-truncated"
+	EndpointPortsAnnotation            = "lighthouse.submariner.io/endpoint-ports"
+	CompactedEndpointsAnnotation       = "lighthouse.submariner.io/compacted-endpoints"
+	HealthAnnotation                   = "lighthouse.submariner.io/health"
+	HeartbeatAnnotation                = "lighthouse.submariner.io/heartbeat"
+	HealthyValue                       = "healthy"
+	UnhealthyValue                     = "unhealthy"
 )
@@ -0,0 +1,230 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package endpointslice provides helpers for consumers of the EndpointSlices synced via the broker.
+package endpointslice
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	discovery "k8s.io/api/discovery/v1"
+)
+
+// Compact moves the ready endpoints of the EndpointSlice into an annotation holding their IPs compacted by CompactIPs, which
+// for a very large headless Service with mostly contiguous IPs is a fraction of the size of the endpoints. Endpoints with a
+// hostname are retained as is since their hostname records are resolved from it. The node name of the compacted endpoints
+// isn't retained. Consumers retrieve the endpoints via GetEndpoints.
+func Compact(endpointSlice *discovery.EndpointSlice) error {
+	var ips []string
+
+	remaining := make([]discovery.Endpoint, 0, len(endpointSlice.Endpoints))
+
+	for i := range endpointSlice.Endpoints {
+		if isReady(&endpointSlice.Endpoints[i]) && endpointSlice.Endpoints[i].Hostname == nil {
+			ips = append(ips, endpointSlice.Endpoints[i].Addresses...)
+		} else {
+			remaining = append(remaining, endpointSlice.Endpoints[i])
+		}
+	}
+
+	if len(ips) == 0 {
+		return nil
+	}
+
+	compacted, err := CompactIPs(ips)
+	if err != nil {
+		return err
+	}
+
+	if endpointSlice.Annotations == nil {
+		endpointSlice.Annotations = map[string]string{}
+	}
+
+	endpointSlice.Annotations[lhconstants.CompactedEndpointsAnnotation] = compacted
+	endpointSlice.Endpoints = remaining
+
+	return nil
+}
+
+// GetEndpoints returns the endpoints of the EndpointSlice, including the ready endpoints compacted by Compact, if any. The
+// EndpointSlice isn't modified.
+func GetEndpoints(endpointSlice *discovery.EndpointSlice) ([]discovery.Endpoint, error) {
+	compacted, ok := endpointSlice.Annotations[lhconstants.CompactedEndpointsAnnotation]
+	if !ok {
+		return endpointSlice.Endpoints, nil
+	}
+
+	ips, err := ExpandIPs(compacted)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error expanding the compacted endpoints of EndpointSlice %q", endpointSlice.Name)
+	}
+
+	endpoints := make([]discovery.Endpoint, 0, len(ips)+len(endpointSlice.Endpoints))
+	ready := true
+
+	for _, ip := range ips {
+		endpoints = append(endpoints, discovery.Endpoint{
+			Addresses:  []string{ip},
+			Conditions: discovery.EndpointConditions{Ready: &ready},
+		})
+	}
+
+	return append(endpoints, endpointSlice.Endpoints...), nil
+}
+
+// HasEndpoints returns whether the EndpointSlice has any endpoints, including compacted ones.
+func HasEndpoints(endpointSlice *discovery.EndpointSlice) bool {
+	return len(endpointSlice.Endpoints) > 0 || endpointSlice.Annotations[lhconstants.CompactedEndpointsAnnotation] != ""
+}
+
+// CompactIPs encodes the given IPv4 and/or IPv6 addresses as a comma-separated list of the CIDR blocks that exactly cover
+// them, with an IP that isn't part of a larger block as is, eg 10.0.0.1 to 10.0.0.6 is encoded as
+// "10.0.0.1,10.0.0.2/31,10.0.0.4/31,10.0.0.6". The IPs are sorted and de-duplicated, so ExpandIPs returns them in order
+// and in canonical form.
+func CompactIPs(ips []string) (string, error) {
+	addrs := make([]netip.Addr, 0, len(ips))
+
+	for _, ip := range ips {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return "", errors.Wrapf(err, "error parsing IP %q", ip)
+		}
+
+		if addr.Zone() != "" {
+			return "", errors.Errorf("IP %q has a zone", ip)
+		}
+
+		addrs = append(addrs, addr)
+	}
+
+	sort.Slice(addrs, func(i, j int) bool {
+		return addrs[i].Less(addrs[j])
+	})
+
+	var blocks []string
+
+	for i := 0; i < len(addrs); {
+		// Find the end of the run of contiguous IPs, skipping duplicates.
+		j := i
+		for j+1 < len(addrs) && (addrs[j+1] == addrs[j] || addrs[j+1] == addrs[j].Next()) {
+			j++
+		}
+
+		blocks = appendBlocks(blocks, addrs[i], addrs[j])
+		i = j + 1
+	}
+
+	return strings.Join(blocks, ","), nil
+}
+
+// ExpandIPs decodes the IPs encoded by CompactIPs.
+func ExpandIPs(compacted string) ([]string, error) {
+	if compacted == "" {
+		return nil, nil
+	}
+
+	var ips []string
+
+	for _, block := range strings.Split(compacted, ",") {
+		if !strings.Contains(block, "/") {
+			addr, err := netip.ParseAddr(block)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error parsing IP %q", block)
+			}
+
+			ips = append(ips, addr.String())
+
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(block)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing CIDR %q", block)
+		}
+
+		if prefix.Masked() != prefix {
+			return nil, errors.Errorf("CIDR %q isn't aligned to its prefix length", block)
+		}
+
+		last := lastAddr(prefix)
+
+		for addr := prefix.Addr(); ; addr = addr.Next() {
+			ips = append(ips, addr.String())
+
+			if addr == last {
+				break
+			}
+		}
+	}
+
+	return ips, nil
+}
+
+// appendBlocks appends the largest CIDR blocks that exactly cover the range of IPs from start to end, inclusive.
+func appendBlocks(blocks []string, start, end netip.Addr) []string {
+	for {
+		bits := start.BitLen()
+
+		// Widen the block while it starts at start and doesn't extend past end.
+		prefixLen := bits
+		for prefixLen > 0 {
+			wider := netip.PrefixFrom(start, prefixLen-1).Masked()
+			if wider.Addr() != start || end.Less(lastAddr(wider)) {
+				break
+			}
+
+			prefixLen--
+		}
+
+		block := netip.PrefixFrom(start, prefixLen)
+		if prefixLen == bits {
+			blocks = append(blocks, start.String())
+		} else {
+			blocks = append(blocks, block.String())
+		}
+
+		last := lastAddr(block)
+		if last == end {
+			return blocks
+		}
+
+		start = last.Next()
+	}
+}
+
+// lastAddr returns the last IP of the CIDR block.
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	bytes := prefix.Addr().AsSlice()
+
+	for bit := prefix.Bits(); bit < len(bytes)*8; bit++ {
+		bytes[bit/8] |= 0x80 >> (bit % 8)
+	}
+
+	addr, _ := netip.AddrFromSlice(bytes)
+
+	return addr
+}
+
+// isReady returns whether the endpoint is ready, where a nil Ready condition is interpreted as ready.
+func isReady(endpoint *discovery.Endpoint) bool {
+	return endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready
+}
@@ -0,0 +1,231 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpointslice_test
+
+import (
+	"math/rand"
+	"net/netip"
+	"sort"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"github.com/submariner-io/lighthouse/pkg/endpointslice"
+	discovery "k8s.io/api/discovery/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("CompactIPs", func() {
+	When("the IPs form an aligned block", func() {
+		It("should encode them as a CIDR", func() {
+			Expect(endpointslice.CompactIPs([]string{"10.0.0.3", "10.0.0.1", "10.0.0.0", "10.0.0.2"})).To(Equal("10.0.0.0/30"))
+		})
+	})
+
+	When("the IPs form an unaligned run", func() {
+		It("should encode them as the largest covering blocks", func() {
+			Expect(endpointslice.CompactIPs([]string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6"})).To(
+				Equal("10.0.0.1,10.0.0.2/31,10.0.0.4/31,10.0.0.6"))
+		})
+	})
+
+	When("the IPs aren't contiguous", func() {
+		It("should encode them as is", func() {
+			Expect(endpointslice.CompactIPs([]string{"10.0.0.9", "10.0.0.1", "fd00::5"})).To(Equal("10.0.0.1,10.0.0.9,fd00::5"))
+		})
+	})
+
+	When("the IPs contain duplicates", func() {
+		It("should encode them once", func() {
+			Expect(endpointslice.CompactIPs([]string{"10.0.0.1", "10.0.0.0", "10.0.0.1"})).To(Equal("10.0.0.0/31"))
+		})
+	})
+
+	When("an IP is invalid", func() {
+		It("should return an error", func() {
+			_, err := endpointslice.CompactIPs([]string{"10.0.0.1", "10.0.0"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("random sets of IPs are compacted", func() {
+		It("should expand to the same IPs", func() {
+			r := rand.New(rand.NewSource(GinkgoRandomSeed())) //nolint:gosec // Not used for security.
+
+			for i := 0; i < 200; i++ {
+				ips := randomIPs(r)
+
+				compacted, err := endpointslice.CompactIPs(ips)
+				Expect(err).To(Succeed())
+
+				expanded, err := endpointslice.ExpandIPs(compacted)
+				Expect(err).To(Succeed())
+				Expect(expanded).To(Equal(sortedUnique(ips)), "Compacted: %s", compacted)
+
+				Expect(len(strings.Split(compacted, ","))).To(BeNumerically("<=", len(expanded)))
+			}
+		})
+	})
+})
+
+var _ = Describe("ExpandIPs", func() {
+	When("the input is empty", func() {
+		It("should return no IPs", func() {
+			Expect(endpointslice.ExpandIPs("")).To(BeEmpty())
+		})
+	})
+
+	When("a CIDR isn't aligned to its prefix length", func() {
+		It("should return an error", func() {
+			_, err := endpointslice.ExpandIPs("10.0.0.1/30")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("a block is invalid", func() {
+		It("should return an error", func() {
+			_, err := endpointslice.ExpandIPs("10.0.0.1,bogus")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Compact", func() {
+	var endpointSlice *discovery.EndpointSlice
+
+	BeforeEach(func() {
+		endpointSlice = &discovery.EndpointSlice{
+			Endpoints: []discovery.Endpoint{
+				newEndpoint("10.0.0.1", nil),
+				newEndpoint("10.0.0.2", pointer.Bool(true)),
+				newEndpoint("10.0.0.3", pointer.Bool(false)),
+				newEndpoint("10.0.0.0", pointer.Bool(true)),
+			},
+		}
+	})
+
+	It("should compact the ready endpoints and retain the others", func() {
+		Expect(endpointslice.Compact(endpointSlice)).To(Succeed())
+		Expect(endpointSlice.Annotations).To(HaveKeyWithValue(lhconstants.CompactedEndpointsAnnotation, "10.0.0.0/31,10.0.0.2"))
+		Expect(endpointSlice.Endpoints).To(Equal([]discovery.Endpoint{newEndpoint("10.0.0.3", pointer.Bool(false))}))
+		Expect(endpointslice.HasEndpoints(endpointSlice)).To(BeTrue())
+
+		endpoints, err := endpointslice.GetEndpoints(endpointSlice)
+		Expect(err).To(Succeed())
+		Expect(endpoints).To(Equal([]discovery.Endpoint{
+			newEndpoint("10.0.0.0", pointer.Bool(true)),
+			newEndpoint("10.0.0.1", pointer.Bool(true)),
+			newEndpoint("10.0.0.2", pointer.Bool(true)),
+			newEndpoint("10.0.0.3", pointer.Bool(false)),
+		}))
+	})
+
+	When("there are no ready endpoints", func() {
+		BeforeEach(func() {
+			endpointSlice.Endpoints = endpointSlice.Endpoints[2:3]
+		})
+
+		It("should not add the annotation", func() {
+			Expect(endpointslice.Compact(endpointSlice)).To(Succeed())
+			Expect(endpointSlice.Annotations).ToNot(HaveKey(lhconstants.CompactedEndpointsAnnotation))
+			Expect(endpointSlice.Endpoints).To(HaveLen(1))
+		})
+	})
+
+	When("ready endpoints have a hostname", func() {
+		BeforeEach(func() {
+			endpointSlice.Endpoints[1].Hostname = pointer.String("web-1")
+		})
+
+		It("should retain them as is", func() {
+			withHostname := endpointSlice.Endpoints[1]
+
+			Expect(endpointslice.Compact(endpointSlice)).To(Succeed())
+			Expect(endpointSlice.Annotations).To(HaveKeyWithValue(lhconstants.CompactedEndpointsAnnotation, "10.0.0.0/31"))
+			Expect(endpointSlice.Endpoints).To(Equal([]discovery.Endpoint{withHostname, newEndpoint("10.0.0.3", pointer.Bool(false))}))
+
+			endpoints, err := endpointslice.GetEndpoints(endpointSlice)
+			Expect(err).To(Succeed())
+			Expect(endpoints).To(ContainElement(withHostname))
+		})
+	})
+
+	When("the EndpointSlice isn't compacted", func() {
+		It("should return its endpoints as is", func() {
+			Expect(endpointslice.GetEndpoints(endpointSlice)).To(Equal(endpointSlice.Endpoints))
+		})
+	})
+})
+
+func newEndpoint(ip string, ready *bool) discovery.Endpoint {
+	return discovery.Endpoint{
+		Addresses:  []string{ip},
+		Conditions: discovery.EndpointConditions{Ready: ready},
+	}
+}
+
+// randomIPs returns a mix of random runs of contiguous IPv4 and IPv6 addresses, which may overlap, and scattered addresses.
+func randomIPs(r *rand.Rand) []string {
+	var ips []string
+
+	for runs := r.Intn(8); runs >= 0; runs-- {
+		var addr netip.Addr
+
+		if r.Intn(2) == 0 {
+			addr = netip.AddrFrom4([4]byte{10, byte(r.Intn(2)), byte(r.Intn(256)), byte(r.Intn(256))})
+		} else {
+			addr = netip.AddrFrom16([16]byte{0xfd, 15: byte(r.Intn(256)), 14: byte(r.Intn(2))})
+		}
+
+		for n := r.Intn(300); n >= 0 && addr.IsValid(); n-- {
+			if r.Intn(10) > 0 {
+				ips = append(ips, addr.String())
+			}
+
+			addr = addr.Next()
+		}
+	}
+
+	return ips
+}
+
+func sortedUnique(ips []string) []string {
+	addrs := make([]netip.Addr, 0, len(ips))
+	seen := map[string]bool{}
+
+	for _, ip := range ips {
+		if !seen[ip] {
+			seen[ip] = true
+
+			addrs = append(addrs, netip.MustParseAddr(ip))
+		}
+	}
+
+	sort.Slice(addrs, func(i, j int) bool {
+		return addrs[i].Less(addrs[j])
+	})
+
+	result := make([]string, len(addrs))
+	for i := range addrs {
+		result[i] = addrs[i].String()
+	}
+
+	return result
+}
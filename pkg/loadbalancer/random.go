@@ -0,0 +1,113 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadbalancer
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// Random load balancer implementation which selects uniformly among the items, ignoring the weights. An item that's
+// skipped isn't selected again until every item has been skipped, ie for a full round.
+type random struct {
+	mutex   sync.Mutex
+	items   []interface{}
+	skipped map[interface{}]bool
+}
+
+// NewRandom returns a Random load balancer.
+func NewRandom() Interface {
+	return &random{
+		items:   make([]interface{}, 0),
+		skipped: make(map[interface{}]bool),
+	}
+}
+
+func (lb *random) Skip(item interface{}) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	lb.skipped[item] = true
+
+	if len(lb.skipped) >= len(lb.items) {
+		lb.skipped = make(map[interface{}]bool)
+	}
+}
+
+// Number of Items added.
+func (lb *random) ItemCount() int {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	return len(lb.items)
+}
+
+// Add - adds a new unique item to the list. The weight is ignored.
+func (lb *random) Add(item interface{}, weight int64) (err error) {
+	if item == nil {
+		return fmt.Errorf("item cannot be nil")
+	}
+
+	if weight < 0 {
+		return fmt.Errorf("item weight %v cannot be negative", weight)
+	}
+
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	for _, i := range lb.items {
+		if i == item {
+			return fmt.Errorf("item %v already present", item)
+		}
+	}
+
+	lb.items = append(lb.items, item)
+
+	return nil
+}
+
+// RemoveAll - removes all items and reset state.
+func (lb *random) RemoveAll() {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	lb.items = lb.items[:0]
+	lb.skipped = make(map[interface{}]bool)
+}
+
+// Next - fetches a random item that hasn't been skipped in the current round.
+func (lb *random) Next() interface{} {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	candidates := make([]interface{}, 0, len(lb.items))
+
+	for _, item := range lb.items {
+		if !lb.skipped[item] {
+			candidates = append(candidates, item)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	return candidates[rand.Intn(len(candidates))] // nolint:gosec // A cryptographically secure generator isn't needed
+}
@@ -0,0 +1,83 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadbalancer_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/lighthouse/pkg/loadbalancer"
+)
+
+var _ = Describe("Random", func() {
+	var lb loadbalancer.Interface
+
+	BeforeEach(func() {
+		lb = loadbalancer.NewRandom()
+
+		for _, item := range []string{"east", "west", "north"} {
+			Expect(lb.Add(item, 1)).To(Succeed())
+		}
+	})
+
+	It("should eventually select every item", func() {
+		selected := map[interface{}]bool{}
+
+		for i := 0; i < 100; i++ {
+			selected[lb.Next()] = true
+		}
+
+		Expect(selected).To(HaveLen(3))
+		Expect(lb.ItemCount()).To(Equal(3))
+	})
+
+	When("adding an item that is already present", func() {
+		It("should return an error", func() {
+			Expect(lb.Add("east", 1)).ToNot(Succeed())
+		})
+	})
+
+	When("items are skipped", func() {
+		It("should omit them until a full round is done", func() {
+			lb.Skip("east")
+			lb.Skip("west")
+
+			for i := 0; i < 20; i++ {
+				Expect(lb.Next()).To(Equal("north"))
+			}
+
+			lb.Skip("north")
+
+			selected := map[interface{}]bool{}
+
+			for i := 0; i < 100; i++ {
+				selected[lb.Next()] = true
+			}
+
+			Expect(selected).To(HaveLen(3))
+		})
+	})
+
+	When("all items are removed", func() {
+		It("should return nil", func() {
+			lb.RemoveAll()
+			Expect(lb.Next()).To(BeNil())
+			Expect(lb.ItemCount()).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,272 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package serviceimport provides helpers for consumers of the per-cluster ServiceImports synced via the broker.
+package serviceimport
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+type ConflictField string
+
+const (
+	TypeConflict            ConflictField = "Type"
+	PortConflict            ConflictField = "Port"
+	SessionAffinityConflict ConflictField = "SessionAffinity"
+)
+
+// Conflict describes a property on which the per-cluster ServiceImports disagree. As per the MCS conflict resolution
+// rules, the value from the oldest ServiceImport wins, with ties broken by cluster name.
+type Conflict struct {
+	Field ConflictField
+	// WinningCluster is the cluster whose value was used.
+	WinningCluster string
+	// Clusters are the clusters whose value differs from the winning one.
+	Clusters []string
+	Message  string
+}
+
+// AggregatedServiceImport is the merged view of the per-cluster ServiceImports of an exported service.
+type AggregatedServiceImport struct {
+	Name                  string
+	Namespace             string
+	Type                  mcsv1a1.ServiceImportType
+	IPs                   []string
+	Ports                 []mcsv1a1.ServicePort
+	SessionAffinity       corev1.ServiceAffinity
+	SessionAffinityConfig *corev1.SessionAffinityConfig
+	Clusters              []mcsv1a1.ClusterStatus
+	Conflicts             []Conflict
+}
+
+// Aggregate merges the given per-cluster ServiceImports, which are expected to be for the same service, into a single
+// view. IPs, ports and clusters are unioned while conflicting properties are resolved in favor of the oldest
+// ServiceImport and reported in Conflicts. The result is deterministic regardless of the order of the input.
+func Aggregate(imports []*mcsv1a1.ServiceImport) AggregatedServiceImport {
+	sorted := make([]*mcsv1a1.ServiceImport, 0, len(imports))
+
+	for _, si := range imports {
+		if si != nil {
+			sorted = append(sorted, si)
+		}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ti, tj := sorted[i].CreationTimestamp, sorted[j].CreationTimestamp
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+
+		return clusterOf(sorted[i]) < clusterOf(sorted[j])
+	})
+
+	aggregated := AggregatedServiceImport{
+		IPs:      []string{},
+		Ports:    []mcsv1a1.ServicePort{},
+		Clusters: []mcsv1a1.ClusterStatus{},
+	}
+
+	if len(sorted) == 0 {
+		return aggregated
+	}
+
+	oldest := sorted[0]
+	aggregated.Name = oldest.Annotations[lhconstants.OriginName]
+	aggregated.Namespace = oldest.Annotations[lhconstants.OriginNamespace]
+	aggregated.Type = oldest.Spec.Type
+	aggregated.SessionAffinity = oldest.Spec.SessionAffinity
+	aggregated.SessionAffinityConfig = oldest.Spec.SessionAffinityConfig
+
+	typeConflicts := []string{}
+	affinityConflicts := []string{}
+	ips := map[string]bool{}
+	clusters := map[string]bool{}
+	ports := newPortMerger()
+
+	for _, si := range sorted {
+		cluster := clusterOf(si)
+
+		if si.Spec.Type != aggregated.Type {
+			typeConflicts = append(typeConflicts, cluster)
+		}
+
+		if si.Spec.SessionAffinity != aggregated.SessionAffinity {
+			affinityConflicts = append(affinityConflicts, cluster)
+		}
+
+		for _, ip := range si.Spec.IPs {
+			ips[ip] = true
+		}
+
+		if cluster != "" {
+			clusters[cluster] = true
+		}
+
+		ports.add(cluster, si.Spec.Ports)
+	}
+
+	for ip := range ips {
+		aggregated.IPs = append(aggregated.IPs, ip)
+	}
+
+	sort.Strings(aggregated.IPs)
+
+	for cluster := range clusters {
+		aggregated.Clusters = append(aggregated.Clusters, mcsv1a1.ClusterStatus{Cluster: cluster})
+	}
+
+	sort.Slice(aggregated.Clusters, func(i, j int) bool {
+		return aggregated.Clusters[i].Cluster < aggregated.Clusters[j].Cluster
+	})
+
+	aggregated.Ports = ports.merged()
+
+	winner := clusterOf(oldest)
+
+	if len(typeConflicts) > 0 {
+		aggregated.Conflicts = append(aggregated.Conflicts, Conflict{
+			Field:          TypeConflict,
+			WinningCluster: winner,
+			Clusters:       typeConflicts,
+			Message:        fmt.Sprintf("The service type differs between clusters - using %q", aggregated.Type),
+		})
+	}
+
+	if len(affinityConflicts) > 0 {
+		aggregated.Conflicts = append(aggregated.Conflicts, Conflict{
+			Field:          SessionAffinityConflict,
+			WinningCluster: winner,
+			Clusters:       affinityConflicts,
+			Message:        fmt.Sprintf("The session affinity differs between clusters - using %q", aggregated.SessionAffinity),
+		})
+	}
+
+	aggregated.Conflicts = append(aggregated.Conflicts, ports.conflicts...)
+
+	return aggregated
+}
+
+func clusterOf(si *mcsv1a1.ServiceImport) string {
+	if cluster := si.GetLabels()[lhconstants.LighthouseLabelSourceCluster]; cluster != "" {
+		return cluster
+	}
+
+	if len(si.Status.Clusters) > 0 {
+		return si.Status.Clusters[0].Cluster
+	}
+
+	return ""
+}
+
+// portMerger unions ports by name, or by number and protocol for an unnamed port. Ports must be added in order of
+// precedence so a port that conflicts with one already added is dropped and reported.
+type portMerger struct {
+	ports      map[string]mcsv1a1.ServicePort
+	owners     map[string]string
+	conflicts  []Conflict
+	conflicted map[string]int
+}
+
+func newPortMerger() *portMerger {
+	return &portMerger{
+		ports:      map[string]mcsv1a1.ServicePort{},
+		owners:     map[string]string{},
+		conflicted: map[string]int{},
+	}
+}
+
+func portKey(port *mcsv1a1.ServicePort) string {
+	if port.Name != "" {
+		return port.Name
+	}
+
+	return strconv.Itoa(int(port.Port)) + "/" + string(protocolOf(port))
+}
+
+func protocolOf(port *mcsv1a1.ServicePort) corev1.Protocol {
+	if port.Protocol == "" {
+		return corev1.ProtocolTCP
+	}
+
+	return port.Protocol
+}
+
+func portsEqual(p1, p2 *mcsv1a1.ServicePort) bool {
+	appProtocol := func(p *mcsv1a1.ServicePort) string {
+		if p.AppProtocol == nil {
+			return ""
+		}
+
+		return *p.AppProtocol
+	}
+
+	return p1.Port == p2.Port && protocolOf(p1) == protocolOf(p2) && appProtocol(p1) == appProtocol(p2)
+}
+
+func (m *portMerger) add(cluster string, ports []mcsv1a1.ServicePort) {
+	for i := range ports {
+		port := &ports[i]
+		key := portKey(port)
+
+		existing, found := m.ports[key]
+		if !found {
+			m.ports[key] = *port
+			m.owners[key] = cluster
+
+			continue
+		}
+
+		if portsEqual(&existing, port) {
+			continue
+		}
+
+		index, reported := m.conflicted[key]
+		if !reported {
+			index = len(m.conflicts)
+			m.conflicted[key] = index
+			m.conflicts = append(m.conflicts, Conflict{
+				Field:          PortConflict,
+				WinningCluster: m.owners[key],
+				Message: fmt.Sprintf("The definition of port %q differs between clusters - using %d/%s", key, existing.Port,
+					protocolOf(&existing)),
+			})
+		}
+
+		m.conflicts[index].Clusters = append(m.conflicts[index].Clusters, cluster)
+	}
+}
+
+func (m *portMerger) merged() []mcsv1a1.ServicePort {
+	ports := make([]mcsv1a1.ServicePort, 0, len(m.ports))
+	for _, port := range m.ports {
+		ports = append(ports, port)
+	}
+
+	sort.Slice(ports, func(i, j int) bool {
+		return portKey(&ports[i]) < portKey(&ports[j])
+	})
+
+	return ports
+}
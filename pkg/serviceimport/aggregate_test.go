@@ -0,0 +1,253 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serviceimport_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	lhconstants "github.com/submariner-io/lighthouse/pkg/constants"
+	"github.com/submariner-io/lighthouse/pkg/serviceimport"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const (
+	serviceName      = "nginx"
+	serviceNamespace = "test-ns"
+	cluster1         = "east"
+	cluster2         = "west"
+	cluster3         = "south"
+)
+
+var now = time.Now()
+
+var _ = Describe("Aggregate", func() {
+	When("no ServiceImports are given", func() {
+		It("should return an empty aggregate", func() {
+			aggregated := serviceimport.Aggregate(nil)
+			Expect(aggregated.Name).To(BeEmpty())
+			Expect(aggregated.IPs).To(BeEmpty())
+			Expect(aggregated.Ports).To(BeEmpty())
+			Expect(aggregated.Clusters).To(BeEmpty())
+			Expect(aggregated.Conflicts).To(BeEmpty())
+		})
+	})
+
+	When("a single ServiceImport is given", func() {
+		It("should return its properties", func() {
+			si := newServiceImport(cluster1, 0, mcsv1a1.ClusterSetIP, "10.253.1.1", httpPort(80))
+			si.Spec.SessionAffinity = corev1.ServiceAffinityClientIP
+
+			aggregated := serviceimport.Aggregate([]*mcsv1a1.ServiceImport{si})
+			Expect(aggregated.Name).To(Equal(serviceName))
+			Expect(aggregated.Namespace).To(Equal(serviceNamespace))
+			Expect(aggregated.Type).To(Equal(mcsv1a1.ClusterSetIP))
+			Expect(aggregated.IPs).To(Equal([]string{"10.253.1.1"}))
+			Expect(aggregated.Ports).To(Equal([]mcsv1a1.ServicePort{httpPort(80)}))
+			Expect(aggregated.SessionAffinity).To(Equal(corev1.ServiceAffinityClientIP))
+			Expect(aggregated.Clusters).To(Equal([]mcsv1a1.ClusterStatus{{Cluster: cluster1}}))
+			Expect(aggregated.Conflicts).To(BeEmpty())
+		})
+	})
+
+	When("compatible ServiceImports from multiple clusters are given", func() {
+		var aggregated serviceimport.AggregatedServiceImport
+
+		BeforeEach(func() {
+			aggregated = serviceimport.Aggregate([]*mcsv1a1.ServiceImport{
+				newServiceImport(cluster2, 1, mcsv1a1.ClusterSetIP, "10.253.2.1", httpPort(80), dnsPort()),
+				newServiceImport(cluster1, 0, mcsv1a1.ClusterSetIP, "10.253.1.1", httpPort(80)),
+				newServiceImport(cluster3, 2, mcsv1a1.ClusterSetIP, "10.253.1.1", dnsPort()),
+			})
+		})
+
+		It("should union the IPs", func() {
+			Expect(aggregated.IPs).To(Equal([]string{"10.253.1.1", "10.253.2.1"}))
+		})
+
+		It("should union the ports", func() {
+			Expect(aggregated.Ports).To(Equal([]mcsv1a1.ServicePort{dnsPort(), httpPort(80)}))
+		})
+
+		It("should list all the clusters sorted by name", func() {
+			Expect(aggregated.Clusters).To(Equal([]mcsv1a1.ClusterStatus{{Cluster: cluster1}, {Cluster: cluster3}, {Cluster: cluster2}}))
+		})
+
+		It("should not report any conflicts", func() {
+			Expect(aggregated.Conflicts).To(BeEmpty())
+		})
+	})
+
+	When("the ServiceImports conflict on type", func() {
+		It("should use the type of the oldest and report the conflict", func() {
+			aggregated := serviceimport.Aggregate([]*mcsv1a1.ServiceImport{
+				newServiceImport(cluster1, 1, mcsv1a1.ClusterSetIP, "10.253.1.1"),
+				newServiceImport(cluster2, 0, mcsv1a1.Headless, ""),
+				newServiceImport(cluster3, 2, mcsv1a1.ClusterSetIP, "10.253.3.1"),
+			})
+
+			Expect(aggregated.Type).To(Equal(mcsv1a1.Headless))
+			Expect(aggregated.Conflicts).To(HaveLen(1))
+			Expect(aggregated.Conflicts[0].Field).To(Equal(serviceimport.TypeConflict))
+			Expect(aggregated.Conflicts[0].WinningCluster).To(Equal(cluster2))
+			Expect(aggregated.Conflicts[0].Clusters).To(Equal([]string{cluster1, cluster3}))
+			Expect(aggregated.Conflicts[0].Message).To(ContainSubstring(string(mcsv1a1.Headless)))
+		})
+	})
+
+	When("the ServiceImports conflict on a port definition", func() {
+		It("should use the port of the oldest and report the conflict", func() {
+			aggregated := serviceimport.Aggregate([]*mcsv1a1.ServiceImport{
+				newServiceImport(cluster1, 0, mcsv1a1.ClusterSetIP, "10.253.1.1", httpPort(80)),
+				newServiceImport(cluster2, 1, mcsv1a1.ClusterSetIP, "10.253.2.1", httpPort(8080), dnsPort()),
+			})
+
+			Expect(aggregated.Ports).To(Equal([]mcsv1a1.ServicePort{dnsPort(), httpPort(80)}))
+			Expect(aggregated.Conflicts).To(HaveLen(1))
+			Expect(aggregated.Conflicts[0].Field).To(Equal(serviceimport.PortConflict))
+			Expect(aggregated.Conflicts[0].WinningCluster).To(Equal(cluster1))
+			Expect(aggregated.Conflicts[0].Clusters).To(Equal([]string{cluster2}))
+			Expect(aggregated.Conflicts[0].Message).To(ContainSubstring(`"http"`))
+		})
+
+		It("should report a conflict on the app protocol", func() {
+			port := httpPort(80)
+			port.AppProtocol = pointer.String("h2c")
+
+			aggregated := serviceimport.Aggregate([]*mcsv1a1.ServiceImport{
+				newServiceImport(cluster1, 0, mcsv1a1.ClusterSetIP, "10.253.1.1", httpPort(80)),
+				newServiceImport(cluster2, 1, mcsv1a1.ClusterSetIP, "10.253.2.1", port),
+			})
+
+			Expect(aggregated.Ports).To(Equal([]mcsv1a1.ServicePort{httpPort(80)}))
+			Expect(aggregated.Conflicts).To(HaveLen(1))
+			Expect(aggregated.Conflicts[0].Field).To(Equal(serviceimport.PortConflict))
+		})
+	})
+
+	When("ports differ only in an unset versus TCP protocol", func() {
+		It("should not report a conflict", func() {
+			port := httpPort(80)
+			port.Protocol = ""
+
+			aggregated := serviceimport.Aggregate([]*mcsv1a1.ServiceImport{
+				newServiceImport(cluster1, 0, mcsv1a1.ClusterSetIP, "10.253.1.1", httpPort(80)),
+				newServiceImport(cluster2, 1, mcsv1a1.ClusterSetIP, "10.253.2.1", port),
+			})
+
+			Expect(aggregated.Ports).To(HaveLen(1))
+			Expect(aggregated.Conflicts).To(BeEmpty())
+		})
+	})
+
+	When("the ServiceImports conflict on session affinity", func() {
+		It("should use the session affinity of the oldest and report the conflict", func() {
+			si := newServiceImport(cluster2, 1, mcsv1a1.ClusterSetIP, "10.253.2.1")
+			si.Spec.SessionAffinity = corev1.ServiceAffinityClientIP
+
+			aggregated := serviceimport.Aggregate([]*mcsv1a1.ServiceImport{
+				newServiceImport(cluster1, 0, mcsv1a1.ClusterSetIP, "10.253.1.1"),
+				si,
+			})
+
+			Expect(aggregated.SessionAffinity).To(Equal(corev1.ServiceAffinityNone))
+			Expect(aggregated.Conflicts).To(HaveLen(1))
+			Expect(aggregated.Conflicts[0].Field).To(Equal(serviceimport.SessionAffinityConflict))
+			Expect(aggregated.Conflicts[0].Clusters).To(Equal([]string{cluster2}))
+		})
+	})
+
+	When("the conflicting ServiceImports have the same creation time", func() {
+		It("should break the tie by cluster name", func() {
+			aggregated := serviceimport.Aggregate([]*mcsv1a1.ServiceImport{
+				newServiceImport(cluster2, 0, mcsv1a1.Headless, ""),
+				newServiceImport(cluster1, 0, mcsv1a1.ClusterSetIP, "10.253.1.1"),
+			})
+
+			Expect(aggregated.Type).To(Equal(mcsv1a1.ClusterSetIP))
+			Expect(aggregated.Conflicts).To(HaveLen(1))
+			Expect(aggregated.Conflicts[0].WinningCluster).To(Equal(cluster1))
+		})
+	})
+
+	When("the input order differs", func() {
+		It("should return the same aggregate", func() {
+			si1 := newServiceImport(cluster1, 0, mcsv1a1.ClusterSetIP, "10.253.1.1", httpPort(80))
+			si2 := newServiceImport(cluster2, 1, mcsv1a1.Headless, "", httpPort(8080))
+			si3 := newServiceImport(cluster3, 2, mcsv1a1.ClusterSetIP, "10.253.3.1", dnsPort())
+
+			Expect(serviceimport.Aggregate([]*mcsv1a1.ServiceImport{si3, si2, si1})).To(Equal(
+				serviceimport.Aggregate([]*mcsv1a1.ServiceImport{si1, si2, si3})))
+		})
+	})
+
+	When("a ServiceImport has no source cluster label", func() {
+		It("should use the cluster from its status", func() {
+			si := newServiceImport(cluster1, 0, mcsv1a1.ClusterSetIP, "10.253.1.1")
+			delete(si.Labels, lhconstants.LighthouseLabelSourceCluster)
+
+			aggregated := serviceimport.Aggregate([]*mcsv1a1.ServiceImport{si})
+			Expect(aggregated.Clusters).To(Equal([]mcsv1a1.ClusterStatus{{Cluster: cluster1}}))
+		})
+	})
+})
+
+func newServiceImport(cluster string, age int, siType mcsv1a1.ServiceImportType, ip string,
+	ports ...mcsv1a1.ServicePort,
+) *mcsv1a1.ServiceImport {
+	si := &mcsv1a1.ServiceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              serviceName + "-" + serviceNamespace + "-" + cluster,
+			CreationTimestamp: metav1.NewTime(now.Add(time.Duration(age) * time.Minute)),
+			Annotations: map[string]string{
+				lhconstants.OriginName:      serviceName,
+				lhconstants.OriginNamespace: serviceNamespace,
+			},
+			Labels: map[string]string{
+				lhconstants.LighthouseLabelSourceCluster: cluster,
+			},
+		},
+		Spec: mcsv1a1.ServiceImportSpec{
+			Type:            siType,
+			Ports:           ports,
+			SessionAffinity: corev1.ServiceAffinityNone,
+		},
+		Status: mcsv1a1.ServiceImportStatus{
+			Clusters: []mcsv1a1.ClusterStatus{{Cluster: cluster}},
+		},
+	}
+
+	if ip != "" {
+		si.Spec.IPs = []string{ip}
+	}
+
+	return si
+}
+
+func httpPort(port int32) mcsv1a1.ServicePort {
+	return mcsv1a1.ServicePort{Name: "http", Protocol: corev1.ProtocolTCP, Port: port}
+}
+
+func dnsPort() mcsv1a1.ServicePort {
+	return mcsv1a1.ServicePort{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53}
+}
@@ -0,0 +1,103 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testutil provides helpers for tests that export Services.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+const pollInterval = 50 * time.Millisecond
+
+// WaitForExported blocks until the given ServiceExport's latest Valid condition is True, ie its ServiceImport was
+// synced to the broker, or the timeout expires. On timeout, the returned error describes the last observed state.
+func WaitForExported(client dynamic.Interface, namespace, name string, timeout time.Duration) error {
+	resourceClient := client.Resource(mcsv1a1.SchemeGroupVersion.WithResource("serviceexports")).Namespace(namespace)
+
+	lastState := "not found"
+
+	err := wait.PollImmediate(pollInterval, timeout, func() (bool, error) {
+		obj, err := resourceClient.Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			lastState = "not found"
+			return false, nil
+		} else if err != nil {
+			return false, errors.Wrapf(err, "error retrieving ServiceExport %s/%s", namespace, name)
+		}
+
+		se := &mcsv1a1.ServiceExport{}
+
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, se)
+		if err != nil {
+			return false, errors.Wrapf(err, "error converting ServiceExport %s/%s", namespace, name)
+		}
+
+		cond := latestValidCondition(se)
+		if cond == nil {
+			lastState = "no Valid condition"
+			return false, nil
+		}
+
+		lastState = describeCondition(cond)
+
+		return cond.Status == corev1.ConditionTrue, nil
+	})
+
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		return errors.Errorf("timed out after %v waiting for ServiceExport %s/%s to be exported - last state: %s",
+			timeout, namespace, name, lastState)
+	}
+
+	return err // nolint:wrapcheck // Already wrapped above.
+}
+
+func latestValidCondition(se *mcsv1a1.ServiceExport) *mcsv1a1.ServiceExportCondition {
+	for i := len(se.Status.Conditions) - 1; i >= 0; i-- {
+		if se.Status.Conditions[i].Type == mcsv1a1.ServiceExportValid {
+			return &se.Status.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
+func describeCondition(cond *mcsv1a1.ServiceExportCondition) string {
+	reason, msg := "", ""
+
+	if cond.Reason != nil {
+		reason = *cond.Reason
+	}
+
+	if cond.Message != nil {
+		msg = *cond.Message
+	}
+
+	return fmt.Sprintf("Valid=%s, reason %q, message %q", cond.Status, reason, msg)
+}
@@ -0,0 +1,142 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+
+Copyright Contributors to the Submariner project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutil_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/submariner-io/admiral/pkg/resource"
+	"github.com/submariner-io/lighthouse/pkg/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+	mcsv1a1 "sigs.k8s.io/mcs-api/pkg/apis/v1alpha1"
+)
+
+var _ = Describe("WaitForExported", func() {
+	const (
+		namespace = "test-ns"
+		name      = "nginx"
+	)
+
+	var (
+		client        dynamic.Interface
+		serviceExport *mcsv1a1.ServiceExport
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(mcsv1a1.AddToScheme(scheme)).To(Succeed())
+
+		client = fake.NewSimpleDynamicClient(scheme)
+
+		serviceExport = &mcsv1a1.ServiceExport{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+		}
+	})
+
+	setCondition := func(status corev1.ConditionStatus, reason string) {
+		msg := "some message"
+		serviceExport.Status.Conditions = []mcsv1a1.ServiceExportCondition{
+			{
+				Type:    mcsv1a1.ServiceExportValid,
+				Status:  status,
+				Reason:  &reason,
+				Message: &msg,
+			},
+		}
+	}
+
+	createServiceExport := func() {
+		raw, err := resource.ToUnstructured(serviceExport)
+		Expect(err).To(Succeed())
+
+		_, err = client.Resource(mcsv1a1.SchemeGroupVersion.WithResource("serviceexports")).Namespace(namespace).Create(
+			context.TODO(), raw, metav1.CreateOptions{})
+		Expect(err).To(Succeed())
+	}
+
+	When("the ServiceExport is exported", func() {
+		It("should return success", func() {
+			setCondition(corev1.ConditionTrue, "")
+			createServiceExport()
+
+			Expect(testutil.WaitForExported(client, namespace, name, time.Second)).To(Succeed())
+		})
+	})
+
+	When("the ServiceExport is subsequently exported", func() {
+		It("should return success", func() {
+			setCondition(corev1.ConditionFalse, "AwaitingSync")
+			createServiceExport()
+
+			go func() {
+				defer GinkgoRecover()
+
+				time.Sleep(200 * time.Millisecond)
+
+				setCondition(corev1.ConditionTrue, "")
+				raw, err := resource.ToUnstructured(serviceExport)
+				Expect(err).To(Succeed())
+
+				_, err = client.Resource(mcsv1a1.SchemeGroupVersion.WithResource("serviceexports")).Namespace(namespace).Update(
+					context.TODO(), raw, metav1.UpdateOptions{})
+				Expect(err).To(Succeed())
+			}()
+
+			Expect(testutil.WaitForExported(client, namespace, name, 3*time.Second)).To(Succeed())
+		})
+	})
+
+	When("the ServiceExport isn't exported", func() {
+		It("should return a descriptive error", func() {
+			setCondition(corev1.ConditionFalse, "UnsupportedServiceType")
+			createServiceExport()
+
+			err := testutil.WaitForExported(client, namespace, name, 300*time.Millisecond)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("timed out"))
+			Expect(err.Error()).To(ContainSubstring("UnsupportedServiceType"))
+		})
+	})
+
+	When("the ServiceExport has no status", func() {
+		It("should return a descriptive error", func() {
+			createServiceExport()
+
+			err := testutil.WaitForExported(client, namespace, name, 300*time.Millisecond)
+			Expect(err).To(MatchError(ContainSubstring("no Valid condition")))
+		})
+	})
+
+	When("the ServiceExport doesn't exist", func() {
+		It("should return a descriptive error", func() {
+			err := testutil.WaitForExported(client, namespace, name, 300*time.Millisecond)
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+		})
+	})
+})